@@ -0,0 +1,133 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// alertPattern matches the line that turns a blockquote into a GitHub alert,
+// such as "> [!NOTE]".
+var alertPattern = regexp.MustCompile(`(?i)^(\s*>\s*)\[!(note|tip|important|warning|caution)\]\s*$`)
+
+// Alerts are marked with a word between these private use characters while
+// they're rendered, so they can be found in the output.
+const (
+	alertOpen  = "\ue000"
+	alertClose = "\ue001"
+)
+
+// alertColors are the colors of alerts by kind, as on GitHub, with ones
+// that still tell them apart on terminals with fewer colors.
+var alertColors = map[string]lipgloss.CompleteAdaptiveColor{
+	"NOTE":      alertColor("#0969DA", "26", "4", "#4493F8", "75", "12"),
+	"TIP":       alertColor("#1A7F37", "28", "2", "#3FB950", "71", "10"),
+	"IMPORTANT": alertColor("#8250DF", "98", "5", "#AB7DF8", "141", "13"),
+	"WARNING":   alertColor("#9A6700", "136", "3", "#D29922", "178", "11"),
+	"CAUTION":   alertColor("#D1242F", "160", "1", "#F85149", "203", "9"),
+}
+
+func alertColor(light, light256, light16, dark, dark256, dark16 string) lipgloss.CompleteAdaptiveColor {
+	return lipgloss.CompleteAdaptiveColor{
+		Light: lipgloss.CompleteColor{TrueColor: light, ANSI256: light256, ANSI: light16},
+		Dark:  lipgloss.CompleteColor{TrueColor: dark, ANSI256: dark256, ANSI: dark16},
+	}
+}
+
+var alertMarker = regexp.MustCompile(alertOpen + `([A-Z]+)` + alertClose)
+
+// markAlerts marks the blockquotes of a markdown document that are GitHub
+// alerts, those starting with a line like "> [!NOTE]", in any case.
+func markAlerts(md string) string {
+	var (
+		fence utils.CodeFence
+		quote bool
+	)
+	lines := strings.Split(md, "\n")
+	for i, l := range lines {
+		if fence.Scan(l) {
+			quote = false
+			continue
+		}
+		if m := alertPattern.FindStringSubmatch(l); m != nil && !quote {
+			lines[i] = m[1] + alertOpen + strings.ToUpper(m[2]) + alertClose
+		}
+		quote = strings.HasPrefix(strings.TrimSpace(l), ">")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderAlerts finishes rendering the alerts marked by markAlerts: their
+// labels are set in bold, and their blockquote gutters tinted, in the color
+// of their kind.
+func renderAlerts(out, gutter string) string {
+	if !strings.Contains(out, alertOpen) {
+		return out
+	}
+	lines := strings.Split(out, "\n")
+	for i := 0; i < len(lines); i++ {
+		plain := ansi.Strip(lines[i])
+		m := alertMarker.FindStringSubmatchIndex(plain)
+		if m == nil {
+			continue
+		}
+		kind := plain[m[2]:m[3]]
+		color := lipgloss.NewStyle().Foreground(alertColors[kind])
+
+		marker := plain[m[0]:m[1]]
+		label := strings.ToUpper(kind[:1]) + strings.ToLower(kind[1:])
+		label = color.Bold(true).Render(label) + strings.Repeat(" ", max(0, ansi.StringWidth(marker)-len(label)))
+		lines[i] = strings.Replace(lines[i], marker, label, 1)
+
+		// the gutter of the alert is the last one before its label, and it
+		// goes on for as long as the lines below have one in the same column
+		if strings.TrimSpace(gutter) == "" {
+			continue
+		}
+		g := strings.LastIndex(plain[:m[0]], gutter)
+		if g < 0 {
+			continue
+		}
+		col := ansi.StringWidth(plain[:g])
+		tinted := color.Render(gutter)
+		for j := i; j < len(lines); j++ {
+			l, ok := replaceAtColumn(lines[j], col, gutter, tinted)
+			if !ok {
+				break
+			}
+			lines[j] = l
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// replaceAtColumn replaces old with repl in s, if that's what's at the given
+// column of its printable text. Escape sequences in s don't take up columns.
+func replaceAtColumn(s string, col int, old, repl string) (string, bool) {
+	locs := escapePattern.FindAllStringIndex(s, -1)
+	w := 0
+	for i := 0; i < len(s); {
+		if len(locs) > 0 && locs[0][0] == i {
+			i = locs[0][1]
+			locs = locs[1:]
+			continue
+		}
+		if w == col {
+			if !strings.HasPrefix(s[i:], old) {
+				return s, false
+			}
+			return s[:i] + repl + s[i+len(old):], true
+		}
+		if w > col {
+			return s, false
+		}
+		_, size := utf8.DecodeRuneInString(s[i:])
+		w += ansi.StringWidth(s[i : i+size])
+		i += size
+	}
+	return s, false
+}
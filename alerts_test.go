@@ -0,0 +1,37 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestMarkAlerts(t *testing.T) {
+	md := "> [!note]\n> text\n\n> quote\n> [!TIP]\n\n```\n> [!WARNING]\n```\n"
+	want := "> " + alertOpen + "NOTE" + alertClose + "\n> text\n\n> quote\n> [!TIP]\n\n```\n> [!WARNING]\n```\n"
+	if got := markAlerts(md); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestRenderAlerts(t *testing.T) {
+	out := "  \x1b[37m│ \x1b[0m" + alertOpen + "TIP" + alertClose + "\n" +
+		"  \x1b[37m│ \x1b[0mtext\n" +
+		"  after\n"
+	got := strings.Split(ansi.Strip(renderAlerts(out, "│ ")), "\n")
+	want := []string{"  │ Tip  ", "  │ text", "  after", ""}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestReplaceAtColumn(t *testing.T) {
+	s := "\x1b[1mab\x1b[0m│ c"
+	if got, ok := replaceAtColumn(s, 2, "│ ", "| "); !ok || got != "\x1b[1mab\x1b[0m| c" {
+		t.Errorf("got %q, %v", got, ok)
+	}
+	if _, ok := replaceAtColumn(s, 1, "│ ", "| "); ok {
+		t.Error("replaced at the wrong column")
+	}
+}
@@ -0,0 +1,351 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/glamour/styles"
+	"github.com/charmbracelet/glow/v2/source"
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// Error codes of JSON-RPC 2.0.
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcServerError    = -32000
+)
+
+var (
+	// apiMode is set while serving glow api, which maps the lines of
+	// everything it renders.
+	apiMode bool
+
+	apiCmd = &cobra.Command{
+		Use:   "api",
+		Short: "Answer JSON-RPC requests on stdin, for editor plugins",
+		Long: paragraph(fmt.Sprintf("\n%s JSON-RPC 2.0 requests on stdin, one per line, and writes a response line for each to stdout, so editor plugins can keep a single glow running rather than spawning one per request. The methods are render, lineMap and outline, which take a path or text, and list, which takes a dir. Documents are rendered with the style and width of the config unless a request gives its own.",
+			keyword("Read"))),
+		Example: paragraph(`echo '{"jsonrpc":"2.0","id":1,"method":"outline","params":{"path":"README.md"}}' | glow api`),
+		Args:    cobra.NoArgs,
+		RunE: func(*cobra.Command, []string) error {
+			return serveAPI(os.Stdin, os.Stdout)
+		},
+	}
+)
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return e.Message
+}
+
+// apiDocument is the document a request is about: a path, URL or any other
+// argument glow takes, or the markdown itself, with settings to render it
+// with.
+type apiDocument struct {
+	Path string  `json:"path,omitempty"`
+	Text *string `json:"text,omitempty"`
+	// Name tells how to render text, like a file name would: markdown, or
+	// code in the language of its extension.
+	Name         string `json:"name,omitempty"`
+	Style        string `json:"style,omitempty"`
+	Width        *uint  `json:"width,omitempty"`
+	ColorProfile string `json:"colorProfile,omitempty"`
+}
+
+type apiListParams struct {
+	Dir string `json:"dir,omitempty"`
+}
+
+type apiRendered struct {
+	Output string `json:"output"`
+	Lines  []int  `json:"lines"`
+}
+
+type apiLineMap struct {
+	Lines []int `json:"lines"`
+}
+
+// apiHeading is a heading of a document's outline.
+type apiHeading struct {
+	Level  int    `json:"level"`
+	Text   string `json:"text"`
+	Line   int    `json:"line"`
+	Anchor string `json:"anchor"`
+}
+
+// serveAPI answers JSON-RPC requests read from r, one per line, until r
+// ends. Batches are answered with a line of their own. Requests are served
+// one at a time, in order.
+func serveAPI(r io.Reader, w io.Writer) error {
+	apiMode = true
+	defer func() { apiMode = false }()
+	styleCache = make(map[styleKey]glamour.TermRendererOption)
+	defer func() { styleCache = nil }()
+
+	// stdout is always a pipe, which isn't reason enough for the no-TTY
+	// style here, and the terminal can't be asked for its background
+	defaultStyle := viper.GetString("style")
+	if defaultStyle == styles.AutoStyle {
+		defaultStyle = styles.DarkStyle
+	}
+	s := apiServer{style: defaultStyle, width: width}
+
+	in := bufio.NewReader(r)
+	out := bufio.NewWriter(w)
+	for {
+		line, err := in.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			if resp := s.handleLine(line); resp != nil {
+				out.Write(append(resp, '\n')) //nolint:errcheck
+				if err := out.Flush(); err != nil {
+					return err
+				}
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// apiServer holds the rendering settings requests fall back to.
+type apiServer struct {
+	style string
+	width uint
+}
+
+// handleLine answers a line holding a request or a batch of them. It returns
+// nil if there's nothing to answer, as for notifications.
+func (s apiServer) handleLine(line []byte) []byte {
+	line = bytes.TrimSpace(line)
+	if line[0] != '[' {
+		resp := s.handle(line)
+		if resp == nil {
+			return nil
+		}
+		b, _ := json.Marshal(resp)
+		return b
+	}
+
+	var batch []json.RawMessage
+	if err := json.Unmarshal(line, &batch); err != nil {
+		b, _ := json.Marshal(rpcErrorResponse(nil, &rpcError{rpcParseError, err.Error()}))
+		return b
+	}
+	if len(batch) == 0 {
+		b, _ := json.Marshal(rpcErrorResponse(nil, &rpcError{rpcInvalidRequest, "empty batch"}))
+		return b
+	}
+	var resps []*rpcResponse
+	for _, req := range batch {
+		if resp := s.handle(req); resp != nil {
+			resps = append(resps, resp)
+		}
+	}
+	if len(resps) == 0 {
+		return nil
+	}
+	b, _ := json.Marshal(resps)
+	return b
+}
+
+// handle answers a single request, or returns nil for a notification.
+func (s apiServer) handle(msg []byte) *rpcResponse {
+	var req rpcRequest
+	if err := json.Unmarshal(msg, &req); err != nil {
+		var syntax *json.SyntaxError
+		if errors.As(err, &syntax) {
+			return rpcErrorResponse(nil, &rpcError{rpcParseError, err.Error()})
+		}
+		return rpcErrorResponse(nil, &rpcError{rpcInvalidRequest, err.Error()})
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return rpcErrorResponse(req.ID, &rpcError{rpcInvalidRequest, `requests need "jsonrpc": "2.0" and a method`})
+	}
+
+	result, err := s.call(req.Method, req.Params)
+	if req.ID == nil {
+		return nil
+	}
+	if err != nil {
+		var rpcErr *rpcError
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &rpcError{rpcServerError, err.Error()}
+		}
+		return rpcErrorResponse(req.ID, rpcErr)
+	}
+	return &rpcResponse{JSONRPC: "2.0", ID: req.ID, Result: result}
+}
+
+func rpcErrorResponse(id json.RawMessage, err *rpcError) *rpcResponse {
+	return &rpcResponse{JSONRPC: "2.0", ID: id, Error: err}
+}
+
+// call runs a method with its parameters.
+func (s apiServer) call(method string, params json.RawMessage) (any, error) {
+	switch method {
+	case "render", "lineMap":
+		var doc apiDocument
+		if err := decodeParams(params, &doc); err != nil {
+			return nil, err
+		}
+		out, lines, err := s.render(doc)
+		if err != nil {
+			return nil, err
+		}
+		if lines == nil {
+			lines = []int{}
+		}
+		if method == "lineMap" {
+			return apiLineMap{lines}, nil
+		}
+		return apiRendered{out, lines}, nil
+
+	case "outline":
+		var doc apiDocument
+		if err := decodeParams(params, &doc); err != nil {
+			return nil, err
+		}
+		return outline(doc)
+
+	case "list":
+		var p apiListParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		if p.Dir == "" {
+			p.Dir = "."
+		}
+		return findDocuments(p.Dir)
+
+	default:
+		return nil, &rpcError{rpcMethodNotFound, fmt.Sprintf("unknown method %q: must be one of render, lineMap, outline, list", method)}
+	}
+}
+
+// decodeParams decodes the parameters of a request, which may be left out.
+func decodeParams(params json.RawMessage, v any) error {
+	if len(params) == 0 || string(params) == "null" {
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(params))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return &rpcError{rpcInvalidParams, err.Error()}
+	}
+	return nil
+}
+
+// open returns the source of a document.
+func (d apiDocument) open() (*source.Source, error) {
+	switch {
+	case d.Text != nil && d.Path != "":
+		return nil, &rpcError{rpcInvalidParams, "give either a path or text, not both"}
+	case d.Text != nil:
+		return &source.Source{URL: d.Name, Reader: io.NopCloser(strings.NewReader(*d.Text))}, nil
+	case d.Path != "":
+		return source.Resolve(d.Path)
+	default:
+		return nil, &rpcError{rpcInvalidParams, "a path or text is needed"}
+	}
+}
+
+// render renders a document like glow FILE would, with the settings of the
+// request, and maps its lines.
+func (s apiServer) render(d apiDocument) (string, []int, error) {
+	profile := termenv.TrueColor
+	if d.ColorProfile != "" {
+		var err error
+		if profile, err = utils.ParseColorProfile(d.ColorProfile); err != nil {
+			return "", nil, &rpcError{rpcInvalidParams, err.Error()}
+		}
+	}
+	if d.Style == "" || d.Style == styles.AutoStyle {
+		d.Style = s.style
+	}
+	if err := validateStyle(d.Style); err != nil {
+		return "", nil, &rpcError{rpcInvalidParams, err.Error()}
+	}
+	if _, ok := styles.DefaultStyles[d.Style]; !ok {
+		d.Style = utils.ExpandPath(d.Style)
+	}
+
+	savedStyle, savedWidth, savedWidthFlag := style, width, widthFlag
+	savedProfile := lipgloss.ColorProfile()
+	defer func() {
+		style, width, widthFlag = savedStyle, savedWidth, savedWidthFlag
+		lipgloss.SetColorProfile(savedProfile)
+	}()
+	style, width = d.Style, s.width
+	if d.Width != nil {
+		width, widthFlag = *d.Width, "0"
+	}
+	lipgloss.SetColorProfile(profile)
+
+	src, err := d.open()
+	if err != nil {
+		return "", nil, err
+	}
+	defer src.Reader.Close() //nolint:errcheck
+	return renderSource(src)
+}
+
+// outline returns the headings of a document, with the source lines they're
+// on and their anchors.
+func outline(d apiDocument) ([]apiHeading, error) {
+	src, err := d.open()
+	if err != nil {
+		return nil, err
+	}
+	b, err := io.ReadAll(src.Reader)
+	_ = src.Reader.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	// comments in frontmatter look like headings too
+	front := strings.Count(string(b[:len(b)-len(utils.RemoveFrontmatter(b))]), "\n")
+	headings := []apiHeading{}
+	for _, h := range utils.Headings(b) {
+		if h.Line <= front {
+			continue
+		}
+		headings = append(headings, apiHeading{h.Level, h.Text, h.Line, utils.HeadingSlug(h.Text)})
+	}
+	return headings, nil
+}
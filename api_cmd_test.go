@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestServeAPI(t *testing.T) {
+	width = 80
+	dir := t.TempDir()
+	doc := "---\n# not a heading\n---\n# Title\n\nSome text.\n\n## Usage\n"
+	if err := os.WriteFile(filepath.Join(dir, "doc.md"), []byte(doc), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	requests := []string{
+		`{"jsonrpc":"2.0","id":1,"method":"outline","params":{"path":` + quote(filepath.Join(dir, "doc.md")) + `}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"render","params":{"text":"# Hi\n\nthere","style":"notty","width":40}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"list","params":{"dir":` + quote(dir) + `}}`,
+		`{"jsonrpc":"2.0","method":"render","params":{"text":"# unanswered"}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"fold"}`,
+		`{"jsonrpc":"2.0","id":5,"method":"render","params":{"txt":"typo"}}`,
+		`{"jsonrpc":"2.0","id":6,"method":"render","params":{"path":"does-not-exist.md"}}`,
+		`{not json`,
+		`[{"jsonrpc":"2.0","id":7,"method":"lineMap","params":{"text":"# Hi"}},{"jsonrpc":"2.0","id":8,"method":"outline","params":{"text":"## Sub"}}]`,
+	}
+	var out strings.Builder
+	if err := serveAPI(strings.NewReader(strings.Join(requests, "\n")+"\n"), &out); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 8 {
+		t.Fatalf("expected 8 responses, got %d:\n%s", len(lines), out.String())
+	}
+
+	var outline struct {
+		Result []apiHeading `json:"result"`
+	}
+	mustDecode(t, lines[0], &outline)
+	if want := []apiHeading{{1, "Title", 4, "title"}, {2, "Usage", 8, "usage"}}; !equalJSON(outline.Result, want) {
+		t.Errorf("outline = %+v, want %+v", outline.Result, want)
+	}
+
+	var render struct {
+		Result apiRendered `json:"result"`
+	}
+	mustDecode(t, lines[1], &render)
+	if !strings.Contains(render.Result.Output, "# Hi") || len(render.Result.Lines) != strings.Count(render.Result.Output, "\n") {
+		t.Errorf("render = %+v", render.Result)
+	}
+
+	var list struct {
+		Result []listEntry `json:"result"`
+	}
+	mustDecode(t, lines[2], &list)
+	if len(list.Result) != 1 || list.Result[0].Title != "Title" {
+		t.Errorf("list = %+v", list.Result)
+	}
+
+	for i, code := range []int{rpcMethodNotFound, rpcInvalidParams, rpcServerError, rpcParseError} {
+		var resp rpcResponse
+		mustDecode(t, lines[3+i], &resp)
+		if resp.Error == nil || resp.Error.Code != code {
+			t.Errorf("response %s: want error code %d", lines[3+i], code)
+		}
+	}
+
+	var batch []struct {
+		ID     int             `json:"id"`
+		Result json.RawMessage `json:"result"`
+	}
+	mustDecode(t, lines[7], &batch)
+	if len(batch) != 2 || batch[0].ID != 7 || !strings.Contains(string(batch[0].Result), `"lines"`) || batch[1].ID != 8 {
+		t.Errorf("batch = %s", lines[7])
+	}
+}
+
+func quote(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+func mustDecode(t *testing.T, s string, v any) {
+	t.Helper()
+	if err := json.Unmarshal([]byte(s), v); err != nil {
+		t.Fatalf("decoding %s: %v", s, err)
+	}
+}
+
+func equalJSON(a, b any) bool {
+	x, _ := json.Marshal(a)
+	y, _ := json.Marshal(b)
+	return string(x) == string(y)
+}
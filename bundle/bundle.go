@@ -0,0 +1,291 @@
+// Package bundle packs a tree of markdown documents into a single file that
+// can be shipped around and browsed without unpacking it.
+//
+// A bundle is a plain tar archive of the documents, followed by an index
+// entry listing where each document is stored and which words it contains.
+// Since it's just a tar, "tar xf docs.docs" unpacks the original tree.
+package bundle
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/charmbracelet/glow/v2/utils"
+)
+
+// Ext is the file extension of bundles.
+const Ext = ".docs"
+
+// indexName is the name of the index entry, the last one in a bundle.
+const indexName = ".glow-bundle.json"
+
+// version is the version of the index format.
+const version = 1
+
+// File is a document in a bundle.
+type File struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	Modtime time.Time `json:"modtime"`
+	Offset  int64     `json:"offset"` // of the contents in the bundle
+}
+
+type index struct {
+	Version int              `json:"version"`
+	Files   []File           `json:"files"`
+	Terms   map[string][]int `json:"terms"` // word -> indices into Files
+}
+
+// IsBundle reports whether a path names a bundle, by its extension.
+func IsBundle(p string) bool {
+	return strings.EqualFold(filepath.Ext(p), Ext)
+}
+
+// countingWriter keeps track of where in the bundle we are, so the index
+// can point at each document's contents.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// Create bundles every markdown file below root, skipping hidden files and
+// directories, and returns the number of documents bundled.
+func Create(w io.Writer, root string) (int, error) {
+	cw := &countingWriter{w: w}
+	tw := tar.NewWriter(cw)
+	idx := index{Version: version, Terms: map[string][]int{}}
+
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p != root && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		// files without an extension count as markdown elsewhere, but
+		// would drag in LICENSE, Makefile and friends here
+		if d.IsDir() || filepath.Ext(p) == "" || !utils.IsMarkdownFile(p) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+
+		f := File{
+			Path:    filepath.ToSlash(rel),
+			Size:    int64(len(b)),
+			Modtime: info.ModTime().UTC().Truncate(time.Second),
+		}
+		if err := tw.WriteHeader(&tar.Header{
+			Name:    f.Path,
+			Mode:    0o644, //nolint:mnd
+			Size:    f.Size,
+			ModTime: f.Modtime,
+		}); err != nil {
+			return err
+		}
+		// the header is written out in full, so this is where the
+		// contents start
+		f.Offset = cw.n
+		if _, err := tw.Write(b); err != nil {
+			return err
+		}
+
+		for _, t := range terms(string(b)) {
+			idx.Terms[t] = append(idx.Terms[t], len(idx.Files))
+		}
+		idx.Files = append(idx.Files, f)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if len(idx.Files) == 0 {
+		return 0, fmt.Errorf("no markdown files found in %s", root)
+	}
+
+	b, err := json.Marshal(idx)
+	if err != nil {
+		return 0, err
+	}
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    indexName,
+		Mode:    0o644, //nolint:mnd
+		Size:    int64(len(b)),
+		ModTime: time.Now().UTC().Truncate(time.Second),
+	}); err != nil {
+		return 0, err
+	}
+	if _, err := tw.Write(b); err != nil {
+		return 0, err
+	}
+	return len(idx.Files), tw.Close()
+}
+
+// Bundle is an open bundle.
+type Bundle struct {
+	f     *os.File
+	index index
+	files map[string]File
+}
+
+// Open opens a bundle and reads its index.
+func Open(name string) (*Bundle, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	b := &Bundle{f: f}
+	if err := b.readIndex(); err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return b, nil
+}
+
+// readIndex looks for the index entry. Reading from a file, the tar reader
+// seeks past the documents rather than reading them.
+func (b *Bundle) readIndex() error {
+	tr := tar.NewReader(b.f)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return errors.New("not a bundle: no index found")
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.Name != indexName {
+			continue
+		}
+
+		// documents are stored before the index, whose contents the tar
+		// reader is at now, so the index can't point past here
+		end, err := b.f.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return err
+		}
+		if err := json.NewDecoder(tr).Decode(&b.index); err != nil {
+			return fmt.Errorf("invalid index: %w", err)
+		}
+		if b.index.Version != version {
+			return fmt.Errorf("unsupported bundle version %d", b.index.Version)
+		}
+		b.files = make(map[string]File, len(b.index.Files))
+		for _, f := range b.index.Files {
+			if f.Offset < 0 || f.Size < 0 || f.Size > end-f.Offset {
+				return fmt.Errorf("invalid index: %s isn't stored in the bundle", f.Path)
+			}
+			b.files[f.Path] = f
+		}
+		for _, ids := range b.index.Terms {
+			for _, i := range ids {
+				if i < 0 || i >= len(b.index.Files) {
+					return errors.New("invalid index: search terms point at missing documents")
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// Files returns the documents in the bundle.
+func (b *Bundle) Files() []File {
+	return b.index.Files
+}
+
+// ReadFile returns the contents of a document in the bundle.
+func (b *Bundle) ReadFile(p string) ([]byte, error) {
+	f, ok := b.files[path.Clean(p)]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", p, fs.ErrNotExist)
+	}
+	buf := make([]byte, f.Size)
+	if _, err := b.f.ReadAt(buf, f.Offset); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// Search returns the paths of the documents that contain all words of the
+// query, in bundle order.
+func (b *Bundle) Search(query string) []string {
+	words := terms(query)
+	if len(words) == 0 {
+		return nil
+	}
+
+	counts := map[int]int{}
+	for _, w := range words {
+		for _, i := range b.index.Terms[w] {
+			counts[i]++
+		}
+	}
+	var matches []int
+	for i, n := range counts {
+		if n == len(words) {
+			matches = append(matches, i)
+		}
+	}
+	sort.Ints(matches)
+
+	paths := make([]string, len(matches))
+	for i, m := range matches {
+		paths[i] = b.index.Files[m].Path
+	}
+	return paths
+}
+
+// Close closes the bundle.
+func (b *Bundle) Close() error {
+	return b.f.Close()
+}
+
+// terms returns the distinct lowercased words of a text, as they're indexed
+// and searched for.
+func terms(s string) []string {
+	var (
+		words []string
+		seen  = map[string]bool{}
+	)
+	for _, w := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len(w) < 2 || seen[w] { //nolint:mnd
+			continue
+		}
+		seen[w] = true
+		words = append(words, w)
+	}
+	return words
+}
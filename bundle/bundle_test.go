@@ -0,0 +1,127 @@
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestBundleRoundTrip(t *testing.T) {
+	root := t.TempDir()
+	docs := map[string]string{
+		"README.md":        "# Project\n\nStart here.\n",
+		"guide/install.md": "# Install\n\nRun the installer.\n",
+		"guide/usage.md":   "# Usage\n\nRun it, then start.\n",
+	}
+	for name, content := range docs {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// left out: hidden, and not markdown
+	for _, name := range []string{".hidden.md", "LICENSE"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte("x"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	name := filepath.Join(t.TempDir(), "docs"+Ext)
+	var buf bytes.Buffer
+	n, err := Create(&buf, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != len(docs) {
+		t.Errorf("bundled %d documents, want %d", n, len(docs))
+	}
+	if err := os.WriteFile(name, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close() //nolint:errcheck
+	for _, f := range b.Files() {
+		got, err := b.ReadFile(f.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != docs[f.Path] {
+			t.Errorf("%s: got %q, want %q", f.Path, got, docs[f.Path])
+		}
+	}
+	if _, err := b.ReadFile("missing.md"); err == nil {
+		t.Error("read a document that isn't bundled")
+	}
+	if got, want := b.Search("run START"), []string{"guide/usage.md"}; !slices.Equal(got, want) {
+		t.Errorf("Search: got %q, want %q", got, want)
+	}
+
+	// it's a plain tar, too
+	var paths []string
+	tr := tar.NewReader(bytes.NewReader(buf.Bytes()))
+	for hdr, err := tr.Next(); err == nil; hdr, err = tr.Next() {
+		paths = append(paths, hdr.Name)
+	}
+	if len(paths) != len(docs)+1 || paths[len(paths)-1] != indexName {
+		t.Errorf("got tar entries %q", paths)
+	}
+}
+
+func TestBundleRejectsBadIndex(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		idx  index
+	}{
+		{"huge size", index{Version: version, Files: []File{{Path: "a.md", Size: 1 << 40, Offset: 512}}}},
+		{"past the end", index{Version: version, Files: []File{{Path: "a.md", Size: 10, Offset: 1 << 20}}}},
+		{"negative offset", index{Version: version, Files: []File{{Path: "a.md", Size: 1, Offset: -1}}}},
+		{"bad term", index{Version: version, Files: []File{{Path: "a.md", Size: 1, Offset: 512}}, Terms: map[string][]int{"x": {3}}}},
+	} {
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		for _, e := range []struct {
+			name string
+			b    []byte
+		}{{"a.md", []byte("# A\n")}, {indexName, must(json.Marshal(tc.idx))}} {
+			if err := tw.WriteHeader(&tar.Header{Name: e.name, Mode: 0o644, Size: int64(len(e.b))}); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := tw.Write(e.b); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := tw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		name := filepath.Join(t.TempDir(), "bad"+Ext)
+		if err := os.WriteFile(name, buf.Bytes(), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		b, err := Open(name)
+		if err == nil {
+			_ = b.Close()
+			t.Errorf("%s: opened a bundle with a bad index", tc.name)
+		} else if !strings.Contains(err.Error(), "invalid index") {
+			t.Errorf("%s: got %v, want an invalid index", tc.name, err)
+		}
+	}
+}
+
+func must(b []byte, err error) []byte {
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/charmbracelet/glow/v2/bundle"
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+)
+
+var (
+	bundleOutput string
+
+	bundleCmd = &cobra.Command{
+		Use:   "bundle",
+		Short: "Pack documentation into a single file",
+		Long:  paragraph(fmt.Sprintf("\n%s a tree of markdown documents into a single %s file, with a search index built in. Browse a bundle with glow docs%s.", keyword("Bundle"), bundle.Ext, bundle.Ext)),
+		Args:  cobra.NoArgs,
+	}
+
+	bundleCreateCmd = &cobra.Command{
+		Use:     "create [DIR]",
+		Short:   "Bundle the markdown files below a directory",
+		Example: paragraph("glow bundle create docs -o docs.docs\nglow docs.docs"),
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			dir := "."
+			if len(args) > 0 {
+				dir = args[0]
+			}
+			out := bundleOutput
+			if out == "" {
+				abs, err := filepath.Abs(dir)
+				if err != nil {
+					return err
+				}
+				out = filepath.Base(abs) + bundle.Ext
+			}
+			return createBundle(dir, out)
+		},
+	}
+
+	bundleListCmd = &cobra.Command{
+		Use:   "ls BUNDLE",
+		Short: "List the documents in a bundle",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			b, err := bundle.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer b.Close() //nolint:errcheck
+
+			tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0) //nolint:mnd
+			for _, f := range b.Files() {
+				fmt.Fprintf(tw, "%s\t%s\t%s\n", f.Path, humanize.IBytes(uint64(f.Size)), f.Modtime.Local().Format("2006-01-02 15:04")) //nolint:gosec
+			}
+			return tw.Flush()
+		},
+	}
+
+	bundleSearchCmd = &cobra.Command{
+		Use:     "search BUNDLE WORDS...",
+		Short:   "List the documents in a bundle that contain all given words",
+		Example: paragraph("glow bundle search docs.docs install linux"),
+		Args:    cobra.MinimumNArgs(2), //nolint:mnd
+		RunE: func(_ *cobra.Command, args []string) error {
+			b, err := bundle.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer b.Close() //nolint:errcheck
+
+			for _, p := range b.Search(strings.Join(args[1:], " ")) {
+				fmt.Println(p)
+			}
+			return nil
+		},
+	}
+)
+
+// createBundle bundles a directory into the given file, which is only put
+// in place once the bundle is complete.
+func createBundle(dir, out string) error {
+	f, err := os.CreateTemp(filepath.Dir(out), "."+filepath.Base(out)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name()) //nolint:errcheck
+
+	l := startLoading("Bundling " + dir)
+	n, err := bundle.Create(f, dir)
+	l.stop()
+	if err == nil {
+		err = f.Chmod(0o644) //nolint:mnd
+	}
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(f.Name(), out); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Bundled %d documents into %s\n", n, out)
+	return nil
+}
+
+func init() {
+	bundleCreateCmd.Flags().StringVarP(&bundleOutput, "output", "o", "", "bundle file (default DIR"+bundle.Ext+")")
+	bundleCmd.AddCommand(bundleCreateCmd, bundleListCmd, bundleSearchCmd)
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/glow/v2/markup"
+	"github.com/charmbracelet/glow/v2/source"
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/charmbracelet/x/ansi"
+)
+
+const (
+	// left margin of CLI output
+	cliMargin = 2
+
+	ellipsis = "…"
+)
+
+var (
+	// [text][label] and [text][], images included
+	fullReferencePattern = regexp.MustCompile(`\[([^\[\]]+)\]\[([^\[\]]*)\]`)
+	referenceDefPattern  = regexp.MustCompile(`^ {0,3}\[([^\]]+)\]:`)
+	codeSpanPattern      = regexp.MustCompile("`+[^`]*`+")
+)
+
+// renderProblem is something that keeps a document from rendering well in a
+// terminal, found by --check-render.
+type renderProblem struct {
+	line int // 1-based source line, or 0 for the whole document
+	msg  string
+}
+
+// checkRender renders the given sources into a throwaway buffer and reports
+// problems as file:line: message, for use in pre-commit hooks. It fails if
+// there were any.
+func checkRender(args []string, w io.Writer) error {
+	var problems int
+	for _, arg := range args {
+		src, err := resolveSource(arg)
+		if err != nil {
+			return err
+		}
+		b, err := io.ReadAll(src.Reader)
+		_ = src.Reader.Close()
+		if err != nil {
+			return err
+		}
+		name := sourceName(src)
+		for _, p := range checkDocument(src, b) {
+			problems++
+			if p.line > 0 {
+				fmt.Fprintf(w, "%s:%d: %s\n", name, p.line, p.msg)
+			} else {
+				fmt.Fprintf(w, "%s: %s\n", name, p.msg)
+			}
+		}
+	}
+	if problems > 0 {
+		return fmt.Errorf("found %d rendering %s", problems, plural(problems, "problem", "problems"))
+	}
+	return nil
+}
+
+// checkDocument looks for unclosed code fences and reference links without
+// definitions, renders the document, and looks for lines that come out wider
+// than the word-wrap width, such as wide tables.
+func checkDocument(src *source.Source, b []byte) []renderProblem {
+	b = markup.ToMarkdown(src.URL, b)
+	content := utils.RemoveFrontmatter(b)
+	offset := strings.Count(string(b[:len(b)-len(content)]), "\n")
+	md := strings.ReplaceAll(string(content), "\r\n", "\n")
+
+	problems := checkSource(md)
+
+	r, err := cliRenderer(src, false, int(width))
+	if err != nil {
+		return append(problems, renderProblem{0, err.Error()})
+	}
+	md = utils.ExpandTabs(md, cliTabWidth(src))
+	out, lines, err := safeRenderLineMap(r, md)
+	if err != nil {
+		return append(problems, renderProblem{0, err.Error()})
+	}
+	if width > 0 {
+		problems = append(problems, checkWidth(md, out, lines)...)
+	}
+
+	for i := range problems {
+		if problems[i].line > 0 {
+			problems[i].line += offset
+		}
+	}
+	sort.SliceStable(problems, func(i, j int) bool { return problems[i].line < problems[j].line })
+	return problems
+}
+
+// checkWidth looks for blocks that don't fit the word-wrap width: tables
+// that glamour had to cut off, and anything else that sticks out, such as
+// long lines of code.
+func checkWidth(md, out string, lines []int) []renderProblem {
+	var (
+		problems []renderProblem
+		srcLines = strings.Split(md, "\n")
+		seen     = make(map[int]bool)
+	)
+	for i, l := range strings.Split(out, "\n") {
+		if i >= len(lines) || lines[i] == 0 || lines[i] > len(srcLines) || seen[lines[i]] {
+			continue
+		}
+		n := lines[i]
+		l = strings.TrimRight(ansi.Strip(l), " ")
+		block := strings.TrimSpace(srcLines[n-1])
+
+		// glamour's margin comes on top of the word-wrap width
+		w := ansi.StringWidth(l) - cliMargin
+		switch {
+		case strings.HasPrefix(block, "|") && strings.Contains(l, ellipsis) && !strings.Contains(md, ellipsis):
+			problems = append(problems, renderProblem{n, fmt.Sprintf("table is too wide for %d columns and gets cut off", width)})
+		case w > int(width):
+			what := "line"
+			if strings.HasPrefix(block, "```") || strings.HasPrefix(block, "~~~") {
+				what = "code"
+			}
+			problems = append(problems, renderProblem{n, fmt.Sprintf("%s renders %d columns wide, more than %d", what, w, width)})
+		default:
+			continue
+		}
+		seen[n] = true
+	}
+	return problems
+}
+
+// safeRenderLineMap is utils.RenderLineMap, with panics turned into errors.
+func safeRenderLineMap(r *glamour.TermRenderer, md string) (out string, lines []int, err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			out, lines, err = "", nil, &renderPanicError{rec, []byte(md)}
+		}
+	}()
+	return utils.RenderLineMap(r, md)
+}
+
+// checkSource looks for unclosed code fences and reference links whose label
+// isn't defined, which render as plain text.
+func checkSource(md string) []renderProblem {
+	var (
+		problems  []renderProblem
+		fence     utils.CodeFence
+		fenceLine int
+		defs      = make(map[string]bool)
+		refs      []struct {
+			line  int
+			label string
+		}
+	)
+	for i, l := range strings.Split(md, "\n") {
+		wasOpen := fence.Open()
+		if fence.Scan(l) {
+			if !wasOpen {
+				fenceLine = i + 1
+			}
+			continue
+		}
+		if m := referenceDefPattern.FindStringSubmatch(l); m != nil {
+			defs[referenceLabel(m[1])] = true
+			continue
+		}
+		l = codeSpanPattern.ReplaceAllString(l, "")
+		for _, m := range fullReferencePattern.FindAllStringSubmatch(l, -1) {
+			label := m[2]
+			if label == "" {
+				label = m[1]
+			}
+			refs = append(refs, struct {
+				line  int
+				label string
+			}{i + 1, label})
+		}
+	}
+	if fence.Open() {
+		problems = append(problems, renderProblem{fenceLine, "code fence is never closed"})
+	}
+	for _, ref := range refs {
+		if !defs[referenceLabel(ref.label)] {
+			problems = append(problems, renderProblem{ref.line, fmt.Sprintf("link reference [%s] isn't defined", ref.label)})
+		}
+	}
+	return problems
+}
+
+// referenceLabel normalizes a link label, which match regardless of case and
+// whitespace.
+func referenceLabel(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/glow/v2/source"
+)
+
+func TestCheckSource(t *testing.T) {
+	md := strings.Join([]string{
+		"See [the docs][docs], [Home][] and [missing][nope].",
+		"",
+		"Not a link: `[a][b]`.",
+		"",
+		"```",
+		"[in][code]",
+		"```",
+		"",
+		"[Docs]: https://example.com",
+		"[home]: /",
+		"",
+		"~~~go",
+		"func main() {}",
+	}, "\n")
+
+	want := []renderProblem{
+		{12, "code fence is never closed"},
+		{1, "link reference [nope] isn't defined"},
+	}
+	if got := checkSource(md); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestCheckDocument(t *testing.T) {
+	style, width = "notty", 40
+
+	md := "---\ntitle: x\n---\n# Wide\n\n| a | b | c | d | e | f | g | h | i | j | k | l |\n" +
+		"|---|---|---|---|---|---|---|---|---|---|---|---|\n" +
+		"| value | value | value | value | value | value | value | value | value | value | value | value |\n\n" +
+		"```\n" + strings.Repeat("x", 60) + "\n```\n"
+
+	want := []renderProblem{
+		{6, "table is too wide for 40 columns and gets cut off"},
+		{10, "code renders 60 columns wide, more than 40"},
+	}
+	got := checkDocument(&source.Source{URL: "wide.md"}, []byte(md))
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
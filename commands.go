@@ -0,0 +1,37 @@
+package main
+
+import (
+	"time"
+
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/spf13/viper"
+)
+
+// Defaults of the limits of external commands.
+const (
+	defaultCommandTimeout   = 10 * time.Second
+	defaultCommandMaxOutput = "16MB"
+)
+
+// defaultCommandEnv are the environment variables external commands get
+// unless the config file says otherwise.
+var defaultCommandEnv = []string{
+	"PATH", "HOME", "USER", "LANG", "LC_ALL", "LC_CTYPE", "TERM", "TMPDIR",
+	"SYSTEMROOT", "TEMP", "TMP", // needed by most programs on Windows
+}
+
+var commands utils.CommandPolicy
+
+// commandPolicyFromConfig reads the commands section of the config file.
+func commandPolicyFromConfig() utils.CommandPolicy {
+	p := utils.CommandPolicy{
+		Allow:     viper.GetStringSlice("commands.allow"),
+		Timeout:   viper.GetDuration("commands.timeout"),
+		MaxOutput: int(viper.GetSizeInBytes("commands.maxOutput")),
+		Env:       defaultCommandEnv,
+	}
+	if viper.IsSet("commands.env") {
+		p.Env = viper.GetStringSlice("commands.env")
+	}
+	return p
+}
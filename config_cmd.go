@@ -17,12 +17,119 @@ const defaultConfig = `# style name or JSON path (default "auto")
 style: "auto"
 # mouse support (TUI-mode only)
 mouse: false
-# use pager to display markdown
+# use pager to display markdown (true, false or auto), or the command to page
+# with, which turns it on, e.g. "less -RS" or ["most", "-s"]; without one,
+# $GLOW_PAGER, $PAGER or less -r is used
 pager: false
-# word-wrap at width
+# environment variables for the pager, on top of LESS=-RFX and
+# LESSCHARSET=utf-8, which less gets unless they're set already
+# pagerEnv:
+#   - "LESS=-R"
+# word-wrap at width, the full terminal width (max), or the longest line of
+# each document, up to the terminal width (content)
 width: 80
+# center documents on terminals wider than that, at 88 columns if no width is
+# set
+centered: false
 # show all files, including hidden and ignored.
 all: true
+# keymap to use: default, vim or emacs (TUI-mode only). With vim keys, ?
+# searches a document backward and f1 shows the help
+keyProfile: "default"
+# how the file filter matches names: fuzzy, smartcase (fuzzy, but case
+# sensitive if there are capitals), substring or tokens (TUI-mode only)
+filterMatcher: "fuzzy"
+# how documents are sorted: by title, date or size; press y to sort by another
+# field and t to reverse the order, which is remembered until this changes
+# (TUI-mode only)
+sort: "title"
+# how long status messages are shown (TUI-mode only)
+statusMessageDuration: 3s
+# fetch documents of a remote stash again this often while they're open, e.g.
+# 30s; 0 turns it off (TUI-mode only)
+refreshInterval: 0s
+# scroll up and down by block, such as a paragraph or code block, rather than
+# by line; press S to switch (TUI-mode only)
+snapScroll: false
+# ask before quitting while a large document is still streaming in (TUI-mode only)
+confirmQuitWhileStreaming: false
+# once a document that took a while to stream in is rendered, ring the
+# terminal bell (bell), send a desktop notification (osc) or do nothing (off)
+# (TUI-mode only)
+notify: "off"
+# show the author and age of the last commit of git-tracked documents (TUI-mode only)
+gitMetadata: false
+# don't print a header with the name of each source when rendering several
+noFilename: false
+# show CriticMarkup edits, such as {++added++} and {--deleted--}, in color
+critic: false
+# show placeholders for videos, iframes and other embeds a terminal can't
+# display, and warn about large images
+embedWarnings: true
+# draw mermaid flowcharts and sequence diagrams in unicode or ascii, or show
+# their source (off)
+mermaid: "unicode"
+# expand tabs in code blocks to this many columns, and read tab-indented
+# lists with tab stops this far apart; 0 follows .editorconfig
+tabWidth: 0
+# with --stream, draw code blocks as their lines come in rather than once
+# they're closed, on a terminal (CLI-mode only)
+streamCode: false
+# abort rendering with a diagnostic once memory use crosses this size, rather
+# than running out of memory, e.g. "512MiB" (CLI-mode only)
+# maxMemory: "1GiB"
+# how absolute dates are shown, as a Go time layout, and in which time zone
+# (TUI-mode only)
+dateFormat: "02 Jan 2006"
+timezone: "Local"
+# commands code blocks can be run with by pressing x, by language; nothing is
+# run without one, and they have to be allowed under commands (TUI-mode only)
+# codeRunners:
+#   bash: "bash -c"
+#   python: "python3 -"
+# override decorations of the chosen style; an empty string removes them
+# decorations:
+#   headingPrefix: "§ "
+#   rule: "~~~"
+#   blockquote: "┃ "
+# filters CLI output runs through before it's written, in order: built-in ones
+# (uppercase-headings, strip-emoji) or commands that read the output on stdin
+# and write it to stdout (CLI-mode only)
+# outputFilters:
+#   - "strip-emoji"
+#   - "sed -e s/foo/bar/g"
+# commands glow may run on documents, such as output filters and code runners,
+# and their limits: how long each run may take, how much it may write, and
+# which environment variables it gets
+# commands:
+#   allow: ["sed"]
+#   timeout: 10s
+#   maxOutput: "16MB"
+#   env: ["PATH", "HOME", "LANG"]
+# self-hosted Gitea and Forgejo instances, whose repositories resolve to their
+# README like those on codeberg.org; set GITEA_TOKEN for private ones
+# giteaHosts:
+#   - "https://git.example.com"
+# markup language of documents without an extension, such as README: markdown,
+# rst or asciidoc
+# dialect: "markdown"
+# file names looked for when a directory or repository is given
+# readmeNames: ["README.md", "README", "readme.md"]
+# ignore .glow.yml files, in which repositories can set style, width, dialect
+# and readmeNames for their documents
+noProjectConfig: false
+# directory to browse when glow is started without arguments (TUI-mode only)
+# root: "~/notes"
+# named sets of settings, picked with --profile or GLOW_PROFILE, that replace
+# the ones above; sections such as decorations are merged key by key
+# profiles:
+#   work:
+#     style: "light"
+#     width: 100
+#     keyProfile: "vim"
+#     root: "~/work/docs"
+# profile to use when none is picked
+# profile: "work"
 `
 
 var configCmd = &cobra.Command{
@@ -56,6 +163,9 @@ var configCmd = &cobra.Command{
 func ensureConfigFile() error {
 	if configFile == "" {
 		configFile = viper.GetViper().ConfigFileUsed()
+		if configFile == "" {
+			configFile = newConfigFile
+		}
 		if err := os.MkdirAll(filepath.Dir(configFile), 0o755); err != nil {
 			return fmt.Errorf("could not write configuration file: %w", err)
 		}
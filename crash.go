@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	gap "github.com/muesli/go-app-paths"
+	"golang.org/x/term"
+)
+
+// crashExitCode is the exit code after a panic, set apart from the 1 of
+// regular errors. It's EX_SOFTWARE from sysexits.h.
+const crashExitCode = 70
+
+// Terminal state from before the TUI started, to go back to after a crash.
+var savedTerminalState *term.State
+
+// saveTerminalState remembers the state of the terminal, if stdin is one.
+func saveTerminalState() {
+	if s, err := term.GetState(int(os.Stdin.Fd())); err == nil {
+		savedTerminalState = s
+	}
+}
+
+// restoreTerminal undoes what the TUI did to the terminal: raw mode, the
+// alternate screen, a hidden cursor and mouse reporting.
+func restoreTerminal() {
+	if savedTerminalState == nil {
+		return
+	}
+	_ = term.Restore(int(os.Stdin.Fd()), savedTerminalState)
+	fmt.Fprint(os.Stdout, "\x1b[?1002l\x1b[?1003l\x1b[?1006l\x1b[?2004l\x1b[?25h\x1b[?1049l")
+}
+
+// handleCrash restores the terminal after a panic, writes a crash report
+// and exits.
+func handleCrash(r any, stack []byte) {
+	restoreTerminal()
+
+	fmt.Fprintf(os.Stderr, "Glow crashed: %v\n\n", r)
+	report, err := writeCrashReport(r, stack)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\nCould not write a crash report: %v\n", stack, err)
+	} else {
+		fmt.Fprintf(os.Stderr, "A crash report was written to %s\n", report)
+	}
+	fmt.Fprintln(os.Stderr, "Please open an issue at https://github.com/charmbracelet/glow/issues and attach it, along with what you were doing.")
+	os.Exit(crashExitCode)
+}
+
+// writeCrashReport writes the panic and the circumstances it happened in
+// next to the log file, and returns the report's path.
+func writeCrashReport(r any, stack []byte) (string, error) {
+	dir, err := gap.NewScope(gap.User, "glow").CacheDir()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil { //nolint:mnd
+		return "", err
+	}
+
+	now := time.Now()
+	var b strings.Builder
+	fmt.Fprintf(&b, "glow %s %s\n", Version, CommitSHA)
+	fmt.Fprintf(&b, "%s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(&b, "time: %s\n", now.Format(time.RFC3339))
+	fmt.Fprintf(&b, "args: %q\n", os.Args[1:])
+	fmt.Fprintf(&b, "term: %s\n\n", os.Getenv("TERM"))
+	fmt.Fprintf(&b, "panic: %v\n\n%s", r, stack)
+
+	// the pid keeps instances crashing at once from sharing a report
+	p := filepath.Join(dir, fmt.Sprintf("crash-%s-%d.log", now.Format("20060102-150405"), os.Getpid()))
+	return p, os.WriteFile(p, []byte(b.String()), 0o644) //nolint:gosec,mnd
+}
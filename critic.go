@@ -0,0 +1,227 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/muesli/termenv"
+)
+
+// criticPattern matches a CriticMarkup edit: an addition, a deletion, a
+// substitution, a comment or a highlight.
+var criticPattern = regexp.MustCompile(`^(?s:\{\+\+(.*?)\+\+\}|\{--(.*?)--\}|\{~~(.*?)~>(.*?)~~\}|\{>>(.*?)<<\}|\{==(.*?)==\})`)
+
+// criticListItem matches the first line of a list item at any depth, up to
+// its text, so where its text starts can be told.
+var criticListItem = regexp.MustCompile(`^[ \t]*([-*+]|\d{1,9}[.)])([ \t]+|$)`)
+
+// CriticMarkup edits are marked with these private use characters while
+// they're rendered, so they can be found in the output: one that opens an
+// edit of each kind, and one that closes them all.
+const (
+	criticAdd       = "\ue010"
+	criticDelete    = "\ue011"
+	criticComment   = "\ue012"
+	criticHighlight = "\ue013"
+	criticClose     = "\ue01f"
+)
+
+// criticStyles are how edits of each kind are rendered.
+var criticStyles = map[string]lipgloss.Style{
+	criticAdd:       lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#1A7F37", Dark: "#3FB950"}),
+	criticDelete:    lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#D1242F", Dark: "#F85149"}).Strikethrough(true),
+	criticComment:   lipgloss.NewStyle().Faint(true).Italic(true),
+	criticHighlight: lipgloss.NewStyle().Reverse(true),
+}
+
+// markCritic marks the CriticMarkup edits of a markdown document, leaving
+// code blocks and code spans alone. Without colors, additions and deletions
+// couldn't be told apart, so the markup is left as it is.
+func markCritic(md string) string {
+	if lipgloss.ColorProfile() == termenv.Ascii {
+		return md
+	}
+
+	var (
+		b     strings.Builder
+		fence utils.CodeFence
+		text  []string
+
+		// indented code blocks are told apart from list items going on by
+		// where the text of the innermost item starts
+		blank    = true // whether the previous line was blank
+		indented bool   // whether an indented code block is open
+		item     = -1   // column of the text of the open list item
+	)
+	flush := func() {
+		if len(text) > 0 {
+			b.WriteString(markCriticText(strings.Join(text, "")))
+			text = nil
+		}
+	}
+	for _, l := range strings.SplitAfter(md, "\n") {
+		isBlank := strings.TrimSpace(l) == ""
+		col := columns(l[:len(l)-len(strings.TrimLeft(l, " \t"))])
+		codeCol := max(item, 0) + 4 //nolint:mnd
+		if !fence.Open() && (indented && (isBlank || col >= codeCol) || blank && !isBlank && col >= codeCol) {
+			indented = true
+			flush()
+			b.WriteString(l)
+			continue
+		}
+		indented = false
+		if fence.Scan(l) {
+			flush()
+			b.WriteString(l)
+			blank = false
+			continue
+		}
+
+		switch m := criticListItem.FindString(l); {
+		case m != "" && !isBlank:
+			item = columns(m)
+		case !isBlank && blank && col < item:
+			item = -1 // the list has ended
+		}
+		blank = isBlank
+		text = append(text, l)
+	}
+	flush()
+	return b.String()
+}
+
+// columns returns how many columns text takes up, with tab stops every 4
+// columns.
+func columns(s string) int {
+	col := 0
+	for _, c := range s {
+		if c == '\t' {
+			col += 4 - col%4 //nolint:mnd
+		} else {
+			col++
+		}
+	}
+	return col
+}
+
+// criticOpen reports whether md ends inside a CriticMarkup edit.
+func criticOpen(md string) bool {
+	for _, d := range [][2]string{{"{++", "++}"}, {"{--", "--}"}, {"{~~", "~~}"}, {"{>>", "<<}"}, {"{==", "==}"}} {
+		if i := strings.LastIndex(md, d[0]); i >= 0 && !strings.Contains(md[i+len(d[0]):], d[1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// markCriticText marks the edits in text outside of code blocks, which may
+// span lines.
+func markCriticText(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		switch s[i] {
+		case '`':
+			// code spans end with as many backticks as they start with
+			n := len(s[i:]) - len(strings.TrimLeft(s[i:], "`"))
+			end := closingBackticks(s[i+n:], n)
+			if end < 0 {
+				b.WriteString(s[i : i+n])
+				i += n
+				continue
+			}
+			b.WriteString(s[i : i+n+end+n])
+			i += n + end + n
+			continue
+		case '{':
+			if m := criticPattern.FindStringSubmatch(s[i:]); m != nil {
+				switch {
+				case strings.HasPrefix(m[0], "{++"):
+					b.WriteString(criticAdd + m[1] + criticClose)
+				case strings.HasPrefix(m[0], "{--"):
+					b.WriteString(criticDelete + m[2] + criticClose)
+				case strings.HasPrefix(m[0], "{~~"):
+					b.WriteString(criticDelete + m[3] + criticClose + criticAdd + m[4] + criticClose)
+				case strings.HasPrefix(m[0], "{>>"):
+					b.WriteString(criticComment + m[5] + criticClose)
+				default:
+					b.WriteString(criticHighlight + m[6] + criticClose)
+				}
+				i += len(m[0])
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+		i++
+	}
+	return b.String()
+}
+
+// closingBackticks returns where a run of exactly n backticks starts in s,
+// or -1 if there's none.
+func closingBackticks(s string, n int) int {
+	for i := 0; i < len(s); {
+		j := strings.IndexByte(s[i:], '`')
+		if j < 0 {
+			return -1
+		}
+		i += j
+		run := len(s[i:]) - len(strings.TrimLeft(s[i:], "`"))
+		if run == n {
+			return i
+		}
+		i += run
+	}
+	return -1
+}
+
+// renderCritic finishes rendering the edits marked by markCritic: their text
+// is restyled by kind, on each line they span, and the marks are removed.
+func renderCritic(out string) string {
+	if !strings.Contains(out, criticClose) {
+		return out
+	}
+	var (
+		kind  string // of the edit being rendered, if any
+		lines = strings.Split(out, "\n")
+	)
+	for i, l := range lines {
+		var b strings.Builder
+		for l != "" {
+			j := strings.IndexAny(l, criticAdd+criticDelete+criticComment+criticHighlight+criticClose)
+			if j < 0 {
+				j = len(l)
+			}
+			seg := l[:j]
+			if kind != "" {
+				seg = styleCritic(seg, criticStyles[kind])
+			}
+			b.WriteString(seg)
+			if j == len(l) {
+				break
+			}
+			mark := l[j : j+len(criticClose)]
+			if kind = mark; mark == criticClose {
+				kind = ""
+			}
+			l = l[j+len(mark):]
+		}
+		lines[i] = b.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// styleCritic renders the text of an edit in its style, rather than the one
+// glamour gave it. Whitespace around it, such as the margin of a line it's
+// wrapped onto, is left unstyled.
+func styleCritic(seg string, style lipgloss.Style) string {
+	plain := ansi.Strip(seg)
+	text := strings.TrimSpace(plain)
+	if text == "" {
+		return plain
+	}
+	start := strings.Index(plain, text)
+	return plain[:start] + style.Render(text) + plain[start+len(text):]
+}
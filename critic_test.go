@@ -0,0 +1,58 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/muesli/termenv"
+)
+
+func TestMarkCritic(t *testing.T) {
+	old := lipgloss.ColorProfile()
+	lipgloss.SetColorProfile(termenv.ANSI)
+	t.Cleanup(func() { lipgloss.SetColorProfile(old) })
+
+	md := "{++in++} {--out--} {~~a~>b~~}\n{>>why\nnot<<} {==this==} `{++code++}` ``{--`--}``\n\n```\n{++fenced++}\n```\n"
+	want := criticAdd + "in" + criticClose + " " +
+		criticDelete + "out" + criticClose + " " +
+		criticDelete + "a" + criticClose + criticAdd + "b" + criticClose + "\n" +
+		criticComment + "why\nnot" + criticClose + " " +
+		criticHighlight + "this" + criticClose + " `{++code++}` ``{--`--}``\n\n```\n{++fenced++}\n```\n"
+	if got := markCritic(md); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// indented code is left alone, but not text going on in a list item
+	for _, tc := range []struct{ md, want string }{
+		{"Text\n\n    {++code++}\n\n\t{--more--}\n", "Text\n\n    {++code++}\n\n\t{--more--}\n"},
+		{"    {++code++}\n", "    {++code++}\n"},
+		{"Text\n    {++lazy++}\n", "Text\n    " + criticAdd + "lazy" + criticClose + "\n"},
+		{"- item\n\n    {++more++}\n", "- item\n\n    " + criticAdd + "more" + criticClose + "\n"},
+		{"- item\n\n      {++code++}\n", "- item\n\n      {++code++}\n"},
+		{"- item\n\nText\n\n    {++code++}\n", "- item\n\nText\n\n    {++code++}\n"},
+	} {
+		if got := markCritic(tc.md); got != tc.want {
+			t.Errorf("%q: got %q, want %q", tc.md, got, tc.want)
+		}
+	}
+
+	lipgloss.SetColorProfile(termenv.Ascii)
+	if got := markCritic(md); got != md {
+		t.Errorf("marked edits without colors: %q", got)
+	}
+}
+
+func TestRenderCritic(t *testing.T) {
+	out := "  \x1b[37mkeep " + criticAdd + "added\x1b[0m\x1b[37m words\n" +
+		"  more" + criticClose + " and " + criticComment + "note" + criticClose + "\n"
+	got := renderCritic(out)
+	if strings.ContainsAny(got, criticAdd+criticComment+criticClose) {
+		t.Errorf("marks left in %q", got)
+	}
+	want := "  keep added words\n  more and note\n"
+	if plain := ansi.Strip(got); plain != want {
+		t.Errorf("got %q, want %q", plain, want)
+	}
+}
@@ -0,0 +1,392 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/glamour/styles"
+	"github.com/charmbracelet/glow/v2/bundle"
+	"github.com/charmbracelet/glow/v2/markup"
+	"github.com/charmbracelet/glow/v2/source"
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+	"github.com/muesli/termenv"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// how long a client waits for the daemon before rendering by itself
+const clientDialTimeout = 100 * time.Millisecond
+
+var (
+	socketPath string
+	clientMode bool
+
+	daemonCmd = &cobra.Command{
+		Use:     "daemon",
+		Short:   "Render documents for glow --client from a long-running process",
+		Long:    paragraph(fmt.Sprintf("\n%s in the background, listening on a unix socket, for editors and scripts that run glow over and over. glow --client then hands its sources to the daemon, which has its styles loaded and its caches warm already.", keyword("Run glow"))),
+		Example: paragraph("glow daemon &\nglow --client README.md"),
+		Args:    cobra.NoArgs,
+		RunE: func(*cobra.Command, []string) error {
+			return runDaemon(socketPath)
+		},
+	}
+)
+
+// defaultSocketPath returns where the daemon listens unless told otherwise,
+// one per user: in the user's runtime directory, or in a private directory
+// of theirs below the temp directory, where nobody else can put a socket.
+func defaultSocketPath() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "glow.sock")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("glow-%d", os.Getuid()), "daemon.sock")
+}
+
+// daemonRequest asks the daemon to render sources, as glow would if run in
+// the given directory with the given settings. Markdown read from stdin is
+// sent along in full.
+type daemonRequest struct {
+	Dir          string          `json:"dir"`
+	Args         []string        `json:"args,omitempty"`
+	Stdin        *string         `json:"stdin,omitempty"`
+	Style        string          `json:"style"`
+	Width        uint            `json:"width"`
+	ContentWidth bool            `json:"content_width,omitempty"`
+	ColorProfile termenv.Profile `json:"color_profile"`
+	Delimiter    string          `json:"delimiter,omitempty"`
+	Separator    bool            `json:"separator,omitempty"`
+	NoFilename   bool            `json:"no_filename,omitempty"`
+	Only         []string        `json:"only,omitempty"`
+	Critic       bool            `json:"critic,omitempty"`
+	Auth         string          `json:"auth,omitempty"`
+
+	// settings from the client's config and the .glow.yml of its documents
+	MaxCodeLines  uint                `json:"max_code_lines,omitempty"`
+	TabWidth      uint                `json:"tab_width,omitempty"`
+	NoGuessLang   bool                `json:"no_guess_lang,omitempty"`
+	EmbedWarnings bool                `json:"embed_warnings,omitempty"`
+	Mermaid       string              `json:"mermaid,omitempty"`
+	Dialect       string              `json:"dialect,omitempty"`
+	ReadmeNames   []string            `json:"readme_names,omitempty"`
+	GiteaHosts    []string            `json:"gitea_hosts,omitempty"`
+	Decorations   utils.Decorations   `json:"decorations"`
+	OutputFilters []string            `json:"output_filters,omitempty"`
+	Commands      utils.CommandPolicy `json:"commands"`
+}
+
+type daemonResponse struct {
+	Output string `json:"output"`
+	Error  string `json:"error,omitempty"`
+}
+
+// runDaemon serves render requests on a unix socket until interrupted.
+func runDaemon(path string) error {
+	// hardened clients render by themselves, and the protections can't be
+	// turned off for the others once they're on
+	if hardened {
+		return errors.New("the daemon can't be hardened: hardened glow renders by itself")
+	}
+	if conn, err := net.DialTimeout("unix", path, clientDialTimeout); err == nil {
+		_ = conn.Close()
+		return fmt.Errorf("a glow daemon is listening on %s already", path)
+	}
+	// a socket nobody answers on is left over from a daemon that died
+	_ = os.Remove(path)
+
+	if path == defaultSocketPath() {
+		if err := privateDir(filepath.Dir(path)); err != nil {
+			return err
+		}
+	}
+	l, err := listenSocket(path)
+	if err != nil {
+		return err
+	}
+	log.Info("daemon listening", "socket", path)
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sig
+		_ = l.Close()
+	}()
+
+	styleCache = make(map[styleKey]glamour.TermRendererOption)
+	var mu sync.Mutex
+	for {
+		conn, err := l.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		go func() {
+			// requests change the working directory and rendering settings,
+			// so they're served one at a time
+			mu.Lock()
+			defer mu.Unlock()
+			serveDaemonRequest(conn)
+		}()
+	}
+}
+
+func serveDaemonRequest(conn net.Conn) {
+	defer conn.Close() //nolint:errcheck
+
+	var req daemonRequest
+	if err := json.NewDecoder(bufio.NewReader(conn)).Decode(&req); err != nil {
+		log.Error("bad daemon request", "err", err)
+		return
+	}
+	out, err := renderRequest(req)
+	resp := daemonResponse{Output: out}
+	if err != nil {
+		resp.Error = err.Error()
+	}
+	if err := json.NewEncoder(conn).Encode(resp); err != nil {
+		log.Error("could not answer daemon request", "err", err)
+	}
+}
+
+// renderRequest renders the sources of a request like executeArgs would.
+// Every setting that affects rendering comes from the request, so nothing
+// carries over from the daemon's own options or from earlier requests.
+func renderRequest(req daemonRequest) (string, error) {
+	if err := os.Chdir(req.Dir); err != nil {
+		return "", err
+	}
+	// diagnostics are the client's business, which renders by itself when
+	// it wants any
+	profiler, summary, events, watchdog = nil, nil, nil, nil
+	lineMapPath = ""
+	style, width = req.Style, req.Width
+	widthFlag = "0"
+	if req.ContentWidth {
+		widthFlag = widthContent
+	}
+	delimiter, separator, noFilename = req.Delimiter, req.Separator, req.NoFilename
+	onlySections, critic = req.Only, req.Critic
+	maxCodeLines, tabWidth, noGuessLang = req.MaxCodeLines, req.TabWidth, req.NoGuessLang
+	embedWarnings, decorations = req.EmbedWarnings, req.Decorations
+	mermaidMode = req.Mermaid
+	if mermaidMode == "" {
+		mermaidMode = mermaidOff
+	}
+	if err := markup.SetDialect(req.Dialect); err != nil {
+		return "", err
+	}
+	source.ReadmeNames = defaultReadmeNames
+	if len(req.ReadmeNames) > 0 {
+		source.ReadmeNames = req.ReadmeNames
+	}
+	if err := source.SetAuthToken(req.Auth); err != nil {
+		return "", err
+	}
+	if err := source.SetGiteaHosts(req.GiteaHosts); err != nil {
+		return "", err
+	}
+	commands = req.Commands
+	var err error
+	if outputFilters, err = newOutputFilters(req.OutputFilters); err != nil {
+		return "", err
+	}
+	lipgloss.SetColorProfile(req.ColorProfile)
+
+	if req.Stdin != nil {
+		src := &source.Source{Reader: io.NopCloser(strings.NewReader(*req.Stdin))}
+		out, _, err := renderSource(src)
+		return out, err
+	}
+
+	var out string
+	for i, arg := range req.Args {
+		src, err := source.Resolve(arg)
+		if err != nil {
+			return "", err
+		}
+		s, _, err := renderSource(src)
+		_ = src.Reader.Close()
+		if err != nil {
+			return "", err
+		}
+		if len(req.Args) > 1 {
+			out += sourceHeader(i, arg)
+		}
+		out += s
+	}
+	return out, nil
+}
+
+// tryClient hands the sources glow was asked to render to the daemon. It
+// reports false if they should be rendered locally after all: when no daemon
+// answers, and for anything but plain rendering, such as streaming, line maps
+// and diagnostics, which the daemon can't write where the client would.
+// Hardened glow renders locally too, as the daemon fetches URLs without its
+// protections.
+func tryClient(args []string) (bool, error) {
+	if hardened || stream || lineMapPath != "" || slices.Contains(args, "-") ||
+		streamJSON != "" || showSummary || renderProfilePath != "" || watchdog != nil {
+		return false, nil
+	}
+	pipe, err := stdinIsPipe()
+	if err != nil {
+		return false, err
+	}
+	if pipe {
+		return executeClient(nil, os.Stdin, os.Stdout)
+	}
+
+	// directories and bundles are browsed in the TUI
+	if len(args) == 0 {
+		return false, nil
+	}
+	if len(args) == 1 {
+		if info, err := os.Stat(args[0]); bundle.IsBundle(args[0]) || err == nil && info.IsDir() {
+			return false, nil
+		}
+	}
+	return executeClient(args, nil, os.Stdout)
+}
+
+// executeClient has the daemon render the given sources, or stdin if there
+// are none, and displays the result. It reports false if no daemon answered.
+func executeClient(args []string, stdin io.Reader, w io.Writer) (bool, error) {
+	if err := checkSocket(socketPath); err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			log.Warn("not using the glow daemon", "err", err)
+		}
+		return false, nil
+	}
+	conn, err := net.DialTimeout("unix", socketPath, clientDialTimeout)
+	if err != nil {
+		log.Debug("no glow daemon, rendering locally", "socket", socketPath, "err", err)
+		return false, nil
+	}
+	defer conn.Close() //nolint:errcheck
+
+	dir, err := os.Getwd()
+	if err != nil {
+		return true, err
+	}
+	req := daemonRequest{
+		Dir:          dir,
+		Args:         args,
+		Style:        clientStyle(),
+		Width:        width,
+		ContentWidth: widthFlag == widthContent,
+		ColorProfile: lipgloss.ColorProfile(),
+		Delimiter:    delimiter,
+		Separator:    separator,
+		NoFilename:   noFilename,
+		Only:         onlySections,
+		Critic:       critic,
+		Auth:         authToken,
+
+		MaxCodeLines:  maxCodeLines,
+		TabWidth:      tabWidth,
+		NoGuessLang:   noGuessLang,
+		EmbedWarnings: embedWarnings,
+		Mermaid:       mermaidMode,
+		Dialect:       viper.GetString("dialect"),
+		ReadmeNames:   source.ReadmeNames,
+		GiteaHosts:    viper.GetStringSlice("giteaHosts"),
+		Decorations:   decorations,
+		OutputFilters: viper.GetStringSlice("outputFilters"),
+		Commands:      commands,
+	}
+	if stdin != nil {
+		b, err := io.ReadAll(stdin)
+		if err != nil {
+			return true, err
+		}
+		s := string(b)
+		req.Stdin = &s
+	}
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return true, err
+	}
+
+	var resp daemonResponse
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return true, fmt.Errorf("could not read the daemon's response: %w", err)
+	}
+	if resp.Error != "" {
+		return true, errors.New(resp.Error)
+	}
+	if stdin != nil {
+		args = []string{"-"}
+	}
+	return true, display(resp.Output, args, w)
+}
+
+// clientStyle returns the style to ask the daemon for: auto is decided here,
+// where the terminal is, and style files are given by absolute path.
+func clientStyle() string {
+	if style == styles.AutoStyle {
+		if utils.Term.HasDarkBackground() {
+			return styles.DarkStyle
+		}
+		return styles.LightStyle
+	}
+	if _, ok := styles.DefaultStyles[style]; ok {
+		return style
+	}
+	if p, err := filepath.Abs(utils.ExpandPath(style)); err == nil {
+		return p
+	}
+	return style
+}
+
+// styleKey identifies a glamour style option.
+type styleKey struct {
+	style       string
+	isCode      bool
+	decorations string
+}
+
+// styleCache holds glamour style options once they've been loaded, so
+// custom styles aren't read and parsed for every document. It's only used by
+// the daemon and glow api, where it outlives documents. Renderers themselves aren't kept,
+// as link references and footnotes would carry over from one document to the
+// next.
+var styleCache map[styleKey]glamour.TermRendererOption
+
+// defaultReadmeNames are the readme names of requests that don't set any.
+var defaultReadmeNames = source.ReadmeNames
+
+// glamourStyle returns the glamour option for the style of CLI output.
+func glamourStyle(style string, isCode bool) glamour.TermRendererOption {
+	if styleCache == nil {
+		return utils.GlamourStyle(style, isCode, decorations)
+	}
+	deco, _ := json.Marshal(decorations)
+	k := styleKey{style, isCode, string(deco)}
+	opt, ok := styleCache[k]
+	if !ok {
+		opt = utils.LoadGlamourStyle(style, isCode, decorations)
+		styleCache[k] = opt
+	}
+	return opt
+}
+
+func init() {
+	daemonCmd.Flags().StringVar(&socketPath, "socket", defaultSocketPath(), "unix socket to listen on")
+}
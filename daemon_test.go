@@ -0,0 +1,152 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDaemonSocket(t *testing.T) {
+	dir := t.TempDir()
+	private := filepath.Join(dir, "glow")
+	if err := privateDir(private); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(private, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := privateDir(private); err == nil {
+		t.Error("took a directory others can enter for a private one")
+	}
+	_ = os.Chmod(private, 0o700)
+
+	path := filepath.Join(private, "daemon.sock")
+	l, err := listenSocket(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close() //nolint:errcheck
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("socket mode = %o, want 600", perm)
+	}
+	if err := checkSocket(path); err != nil {
+		t.Errorf("refused own socket: %v", err)
+	}
+
+	file := filepath.Join(private, "file")
+	if err := os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkSocket(file); err == nil {
+		t.Error("took a file for a socket")
+	}
+}
+
+func TestDaemonRequestSettings(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd) //nolint:errcheck
+	defer func() { maxCodeLines, tabWidth, mermaidMode = 0, 0, mermaidOff }()
+
+	dir := t.TempDir()
+	doc := "```\n1\n2\n3\n4\n5\n```\n"
+	if err := os.WriteFile(filepath.Join(dir, "doc.md"), []byte(doc), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	out, err := renderRequest(daemonRequest{Dir: dir, Args: []string{"doc.md"}, Style: "notty", Width: 40, MaxCodeLines: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out, "5") {
+		t.Errorf("code block not truncated to the client's max code lines:\n%s", out)
+	}
+	if mermaidMode != mermaidOff {
+		t.Errorf("mermaid = %q for a request without it", mermaidMode)
+	}
+
+	hardened = true
+	defer func() { hardened = false }()
+	if ok, err := tryClient([]string{"doc.md"}); ok || err != nil {
+		t.Errorf("hardened glow used the daemon: %v, %v", ok, err)
+	}
+}
+
+func TestDaemonRequestsDontShareOptions(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd) //nolint:errcheck
+	defer func() { outputFilters, summary = nil, nil }()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "doc.md"), []byte("# quiet\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// the daemon's own options
+	outputFilters, err = newOutputFilters([]string{filterUppercaseHeadings})
+	if err != nil {
+		t.Fatal(err)
+	}
+	summary = newRenderSummary()
+
+	req := daemonRequest{Dir: dir, Args: []string{"doc.md"}, Style: "notty", Width: 40}
+	out, err := renderRequest(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out, "QUIET") || summary != nil {
+		t.Errorf("the daemon's options applied to a request:\n%s", out)
+	}
+
+	req.OutputFilters = []string{filterUppercaseHeadings}
+	if out, err = renderRequest(req); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "QUIET") {
+		t.Errorf("the client's output filters weren't applied:\n%s", out)
+	}
+}
+
+func TestClientRendersStreamsLocally(t *testing.T) {
+	// a daemon that hangs up on everyone
+	defer func(p string) { socketPath = p }(socketPath)
+	socketPath = filepath.Join(t.TempDir(), "daemon.sock")
+	l, err := listenSocket(socketPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close() //nolint:errcheck
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.Close()
+		}
+	}()
+
+	defer func() { stream, streamJSON, showSummary = false, "", false }()
+	for name, set := range map[string]func(){
+		"--stream":      func() { stream = true },
+		"--stream-json": func() { streamJSON = "2" },
+		"--summary":     func() { showSummary = true },
+	} {
+		stream, streamJSON, showSummary = false, "", false
+		set()
+		if ok, err := tryClient([]string{"doc.md"}); ok || err != nil {
+			t.Errorf("%s used the daemon: %v, %v", name, ok, err)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+//go:build !windows
+
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// listenSocket listens on a unix socket only its owner can connect to. The
+// umask keeps it private from the start, rather than from a chmod after
+// others could have connected.
+func listenSocket(path string) (net.Listener, error) {
+	old := syscall.Umask(0o177) //nolint:mnd
+	defer syscall.Umask(old)
+	return net.Listen("unix", path)
+}
+
+// privateDir creates a directory only the user can enter, or makes sure the
+// one there is.
+func privateDir(dir string) error {
+	if err := os.Mkdir(dir, 0o700); err != nil && !os.IsExist(err) { //nolint:mnd
+		return err
+	}
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return err
+	}
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !info.IsDir() || !ok || int(st.Uid) != os.Getuid() || info.Mode().Perm()&0o077 != 0 {
+		return fmt.Errorf("%s isn't a private directory of yours", dir)
+	}
+	return nil
+}
+
+// checkSocket makes sure a socket is the user's own, so that documents and
+// tokens aren't handed to a daemon someone else started there. The socket
+// can't be swapped for another as long as its directory is the user's or
+// sticky, as /tmp is.
+func checkSocket(path string) error {
+	uid := os.Getuid()
+	owned := func(p string) (os.FileInfo, bool, error) {
+		info, err := os.Lstat(p)
+		if err != nil {
+			return nil, false, err
+		}
+		st, ok := info.Sys().(*syscall.Stat_t)
+		return info, ok && int(st.Uid) == uid, nil
+	}
+
+	info, ok, err := owned(path)
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSocket == 0 || !ok {
+		return fmt.Errorf("%s isn't a socket of yours", path)
+	}
+	dir, ok, err := owned(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	if !ok && dir.Mode().Perm()&0o022 != 0 && dir.Mode()&os.ModeSticky == 0 {
+		return fmt.Errorf("others can replace %s", path)
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+//go:build windows
+
+package main
+
+import (
+	"net"
+	"os"
+)
+
+// listenSocket listens on a unix socket, which windows keeps private to
+// whoever may write to its directory.
+func listenSocket(path string) (net.Listener, error) {
+	return net.Listen("unix", path)
+}
+
+// privateDir creates a directory, which is private to the user on windows
+// when it's below their profile or temp directory.
+func privateDir(dir string) error {
+	if err := os.Mkdir(dir, 0o700); err != nil && !os.IsExist(err) { //nolint:mnd
+		return err
+	}
+	return nil
+}
+
+// checkSocket has nothing to check on windows, where sockets are guarded by
+// the ACLs of their directories.
+func checkSocket(string) error {
+	return nil
+}
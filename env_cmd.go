@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/spf13/cobra"
+)
+
+var envCmd = &cobra.Command{
+	Use:     "env",
+	Short:   "Show how glow decides on colors and sees the terminal",
+	Long:    paragraph(fmt.Sprintf("\n%s the environment variables glow looks at to decide on colors and to see the terminal, and what it ends up with. GLOW_COLOR_PROFILE wins over NO_COLOR, which wins over FORCE_COLOR, which wins over CLICOLOR_FORCE and CLICOLOR; without any of them, colors depend on the terminal. GLOW_FORCE_TTY, GLOW_COLS, GLOW_ROWS and GLOW_BACKGROUND fix what's otherwise detected from it.", keyword("Print"))),
+	Example: paragraph("glow env\nNO_COLOR=1 glow env\nGLOW_FORCE_TTY=1 GLOW_COLS=80 glow env"),
+	Args:    cobra.NoArgs,
+	RunE: func(*cobra.Command, []string) error {
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0) //nolint:mnd
+		for _, k := range append(utils.ColorVariables, utils.TerminalVariables...) {
+			v, ok := os.LookupEnv(k)
+			if !ok {
+				v = "(unset)"
+			} else if v == "" {
+				v = `""`
+			}
+			fmt.Fprintf(tw, "%s\t%s\n", k, v)
+		}
+		isTerminal := utils.Term.IsTerminal(os.Stdout)
+		fmt.Fprintf(tw, "stdout is a terminal\t%t\n", isTerminal)
+		if isTerminal {
+			if w, h, err := utils.Term.Size(os.Stdout); err == nil {
+				fmt.Fprintf(tw, "terminal size\t%dx%d\n", w, h)
+			}
+		}
+
+		d := utils.DecideColor(os.Stdout)
+		fmt.Fprintf(tw, "color profile\t%s (%s)\n", d.Profile.Name(), d.Reason)
+		return tw.Flush()
+	},
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/glow/v2/source"
+)
+
+// events reports progress as newline-delimited JSON when --stream-json is
+// set, for tools that wrap glow. All of its methods are safe to call on a
+// nil stream.
+var events *eventStream
+
+// event is a single line of --stream-json output. Its kind is one of
+// "source", "chunk", "warning", "error" or "done".
+type event struct {
+	Kind       string    `json:"event"`
+	Time       time.Time `json:"time"`
+	Source     string    `json:"source,omitempty"`
+	Document   int       `json:"document,omitempty"` // 1-based, within a source
+	BytesIn    int       `json:"bytes_in,omitempty"`
+	BytesOut   int       `json:"bytes_out,omitempty"`
+	Message    string    `json:"message,omitempty"`
+	Sources    int       `json:"sources,omitempty"`
+	DurationMS int64     `json:"duration_ms,omitempty"`
+}
+
+type eventStream struct {
+	mu    sync.Mutex
+	enc   *json.Encoder
+	start time.Time
+
+	sources  int
+	bytesOut int
+}
+
+// newEventStream writes events to the given file descriptor.
+func newEventStream(fd string) (*eventStream, error) {
+	n, err := strconv.ParseUint(fd, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid file descriptor for --stream-json: %s", fd)
+	}
+	f := os.Stderr
+	if n != 2 { //nolint:mnd
+		f = os.NewFile(uintptr(n), "stream-json")
+		if _, err := f.Stat(); err != nil {
+			return nil, fmt.Errorf("file descriptor %d is not open for --stream-json", n)
+		}
+	}
+	return &eventStream{enc: json.NewEncoder(f), start: time.Now()}, nil
+}
+
+func (s *eventStream) emit(e event) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e.Time = time.Now()
+	_ = s.enc.Encode(e)
+}
+
+// source reports that a source was resolved and read.
+func (s *eventStream) source(src *source.Source, size int) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.sources++
+	s.mu.Unlock()
+	s.emit(event{Kind: "source", Source: sourceName(src), BytesIn: size})
+}
+
+// chunk reports that a document of a source was rendered.
+func (s *eventStream) chunk(src *source.Source, doc, in, out int) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.bytesOut += out
+	s.mu.Unlock()
+	s.emit(event{Kind: "chunk", Source: sourceName(src), Document: doc, BytesIn: in, BytesOut: out})
+}
+
+// warn reports something that didn't stop rendering, but changed its
+// outcome.
+func (s *eventStream) warn(src *source.Source, msg string) {
+	s.emit(event{Kind: "warning", Source: sourceName(src), Message: msg})
+}
+
+// finish reports the outcome of the whole run.
+func (s *eventStream) finish(err error) {
+	if s == nil {
+		return
+	}
+	if err != nil {
+		s.emit(event{Kind: "error", Message: err.Error()})
+		return
+	}
+	s.mu.Lock()
+	e := event{Kind: "done", Sources: s.sources, BytesOut: s.bytesOut, DurationMS: time.Since(s.start).Milliseconds()}
+	s.mu.Unlock()
+	s.emit(e)
+}
+
+// sourceName names a source in events.
+func sourceName(src *source.Source) string {
+	if src == nil {
+		return ""
+	}
+	if src.URL == "" {
+		return "-"
+	}
+	return src.URL
+}
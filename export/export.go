@@ -0,0 +1,281 @@
+// Package export converts markdown documents into other formats.
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+	"gopkg.in/yaml.v3"
+)
+
+// Export formats. HTML is the default.
+const (
+	FormatHTML = "html"
+	FormatText = "txt"
+	FormatMan  = "man"
+	FormatPDF  = "pdf"
+
+	// Plain text with markers for the structure of the document, for
+	// text-to-speech.
+	FormatSemantic = "semantic"
+)
+
+// Formats lists the supported export formats.
+var Formats = []string{FormatHTML, FormatText, FormatPDF, FormatMan, FormatSemantic}
+
+// markdownExtensions are the extensions of documents picked up when
+// exporting a tree.
+var markdownExtensions = []string{".md", ".mdown", ".mkdn", ".mkd", ".markdown"}
+
+func isMarkdown(p string) bool {
+	ext := strings.ToLower(path.Ext(p))
+	for _, v := range markdownExtensions {
+		if ext == v {
+			return true
+		}
+	}
+	return false
+}
+
+// OutputPath returns the path of the exported file for a markdown path.
+func OutputPath(p, format string) string {
+	return strings.TrimSuffix(p, path.Ext(p)) + "." + format
+}
+
+// Document converts a single markdown document. The name is used to derive
+// a title when the document doesn't have one.
+func Document(w io.Writer, md []byte, name, format string) error {
+	switch format {
+	case FormatHTML:
+		doc := parse(md)
+		body, err := doc.html()
+		if err != nil {
+			return err
+		}
+		return writePage(w, page{Title: doc.title(name), Body: body})
+	case FormatText:
+		s, err := parse(md).text()
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, s)
+		return err
+	case FormatPDF:
+		return parse(md).pdf(w, name)
+	case FormatMan:
+		_, err := io.WriteString(w, parse(md).man(name))
+		return err
+	case FormatSemantic:
+		_, err := io.WriteString(w, parse(md).semantic())
+		return err
+	default:
+		return fmt.Errorf("unsupported export format %q: must be one of %s", format, strings.Join(Formats, ", "))
+	}
+}
+
+// Report summarizes an exported tree.
+type Report struct {
+	Documents int
+	Assets    int
+
+	// links that don't lead anywhere in the exported tree, by document and
+	// line
+	Unresolved []UnresolvedLink
+}
+
+// Tree converts all markdown documents below root into dir, keeping the
+// directory structure, with their includes resolved. Relative links between
+// documents are rewritten to point at the exported files, with their
+// fragments pointing at the IDs the headings get, linked local files such as
+// images are copied along, and an index of all documents is generated unless
+// the tree has an index document of its own. Links that can't be resolved
+// are listed in the report.
+func Tree(root, dir, format string) (Report, error) {
+	var report Report
+	if format != FormatHTML {
+		return report, fmt.Errorf("unsupported export format %q for a tree: only %s is supported", format, FormatHTML)
+	}
+
+	var docs []string
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && p != root && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if !d.IsDir() && isMarkdown(p) {
+			rel, err := filepath.Rel(root, p)
+			if err != nil {
+				return err
+			}
+			docs = append(docs, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+	sort.Strings(docs)
+
+	// headings of all documents need to be known before links to them can
+	// be checked
+	t := tree{root: root, docs: make(map[string]*document, len(docs))}
+	for _, rel := range docs {
+		p := filepath.Join(root, filepath.FromSlash(rel))
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return report, err
+		}
+		md, includes, err := utils.ResolveIncludes(string(b), filepath.Dir(p))
+		if err != nil {
+			return report, fmt.Errorf("%s: %w", rel, err)
+		}
+		t.docs[rel] = parse([]byte(md))
+		t.docs[rel].includes = includes
+	}
+
+	var (
+		entries  []indexEntry
+		assets   = map[string]bool{}
+		hasIndex bool
+	)
+	for _, rel := range docs {
+		doc := t.docs[rel]
+		linked, unresolved := doc.rewriteLinks(rel, format, t)
+		for _, a := range linked {
+			assets[a] = true
+		}
+		report.Unresolved = append(report.Unresolved, unresolved...)
+		body, err := doc.html()
+		if err != nil {
+			return report, fmt.Errorf("%s: %w", rel, err)
+		}
+
+		out := OutputPath(rel, format)
+		if out == "index."+format {
+			hasIndex = true
+		}
+		p := page{
+			Title: doc.title(path.Base(rel)),
+			Body:  body,
+			Index: relativeTo(rel, "index."+format),
+		}
+		if err := writePageFile(filepath.Join(dir, filepath.FromSlash(out)), p); err != nil {
+			return report, err
+		}
+		entries = append(entries, indexEntry{Path: out, Title: p.Title})
+		report.Documents++
+	}
+
+	for a := range assets {
+		src := filepath.Join(root, filepath.FromSlash(a))
+		if st, err := os.Stat(src); err != nil || st.IsDir() {
+			continue
+		}
+		if err := copyFile(src, filepath.Join(dir, filepath.FromSlash(a))); err != nil {
+			return report, err
+		}
+		report.Assets++
+	}
+
+	if !hasIndex {
+		if err := writeIndex(filepath.Join(dir, "index."+format), filepath.Base(root), entries); err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+// document is a parsed markdown document.
+type document struct {
+	source []byte
+	node   ast.Node
+
+	// lines of frontmatter before the source
+	front int
+
+	// lines of the document that included documents came in at
+	includes utils.IncludeMap
+
+	meta struct {
+		Title   string `yaml:"title"`
+		Section string `yaml:"section"` // of man pages
+	}
+}
+
+func parse(md []byte) *document {
+	body := utils.RemoveFrontmatter(md)
+	doc := &document{source: body}
+	if front := md[:len(md)-len(body)]; len(front) > 0 {
+		_ = yaml.Unmarshal(front, &doc.meta)
+		doc.front = bytes.Count(front, []byte("\n"))
+	}
+	doc.node = newMarkdown().Parser().Parse(text.NewReader(body), withHeadingIDs())
+	return doc
+}
+
+// title returns the frontmatter title, the first top-level heading or the
+// file name, in that order.
+func (d *document) title(name string) string {
+	if d.meta.Title != "" {
+		return d.meta.Title
+	}
+	var title string
+	_ = ast.Walk(d.node, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if h, ok := n.(*ast.Heading); ok && entering && h.Level == 1 {
+			title = string(h.Text(d.source)) //nolint:staticcheck
+			return ast.WalkStop, nil
+		}
+		return ast.WalkContinue, nil
+	})
+	if title != "" {
+		return title
+	}
+	return strings.TrimSuffix(name, path.Ext(name))
+}
+
+// Title returns the title of a markdown document: its frontmatter title, its
+// first top-level heading or else its file name without extension.
+func Title(md []byte, name string) string {
+	return parse(md).title(name)
+}
+
+// relativeTo returns the path of target, relative to the directory of the
+// document at from. Both are relative to the root of the tree.
+func relativeTo(from, target string) string {
+	depth := strings.Count(from, "/")
+	return strings.Repeat("../", depth) + target
+}
+
+func writePageFile(name string, p page) error {
+	var buf bytes.Buffer
+	if err := writePage(&buf, p); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil { //nolint:mnd
+		return err
+	}
+	return os.WriteFile(name, buf.Bytes(), 0o644) //nolint:gosec,mnd
+}
+
+func copyFile(src, dst string) error {
+	b, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil { //nolint:mnd
+		return err
+	}
+	return os.WriteFile(dst, b, 0o644) //nolint:gosec,mnd
+}
@@ -0,0 +1,167 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"path"
+	"sort"
+
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer/html"
+)
+
+const pageCSS = `body { max-width: 46rem; margin: 2rem auto; padding: 0 1rem; font: 16px/1.6 -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; color: #222; }
+nav { margin-bottom: 2rem; font-size: .9rem; }
+a { color: #6c50ff; }
+pre, code { font-family: ui-monospace, SFMono-Regular, Menlo, Consolas, monospace; font-size: .9em; }
+pre { background: #f6f6f6; padding: 1rem; overflow-x: auto; }
+code { background: #f6f6f6; padding: .1em .3em; }
+pre code { background: none; padding: 0; }
+blockquote { margin: 0; padding-left: 1rem; border-left: 3px solid #ddd; color: #666; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ddd; padding: .3rem .6rem; }
+img { max-width: 100%; }
+@media print {
+  body { max-width: none; margin: 0; padding: 0; font-size: 11pt; color: #000; }
+  nav { display: none; }
+  a { color: inherit; }
+  a[href^="http"]::after { content: " (" attr(href) ")"; font-size: .85em; }
+  pre { white-space: pre-wrap; }
+  pre, blockquote, table, img { break-inside: avoid; }
+  h1, h2, h3, h4, h5, h6 { break-after: avoid; }
+}
+`
+
+var pageTemplate = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>{{.CSS}}</style>
+</head>
+<body>
+{{- if .Index}}
+<nav><a href="{{.Index}}">Index</a></nav>
+{{- end}}
+<main>
+{{.Body}}
+</main>
+</body>
+</html>
+`))
+
+// page is an exported HTML page.
+type page struct {
+	Title string
+	Body  template.HTML
+	Index string // relative link to the index, if any
+	CSS   template.CSS
+}
+
+func writePage(w io.Writer, p page) error {
+	p.CSS = pageCSS
+	return pageTemplate.Execute(w, p)
+}
+
+func newMarkdown() goldmark.Markdown {
+	return goldmark.New(
+		goldmark.WithExtensions(extension.GFM),
+		goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+		goldmark.WithRendererOptions(html.WithUnsafe()),
+	)
+}
+
+// html renders the document's body.
+func (d *document) html() (template.HTML, error) {
+	var buf bytes.Buffer
+	if err := newMarkdown().Renderer().Render(&buf, d.source, d.node); err != nil {
+		return "", err
+	}
+	return template.HTML(buf.String()), nil //nolint:gosec
+}
+
+// headingIDs generates the same anchors as GitHub, so links to a heading
+// keep working after the export.
+type headingIDs map[string]int
+
+func withHeadingIDs() parser.ParseOption {
+	return parser.WithContext(parser.NewContext(parser.WithIDs(headingIDs{})))
+}
+
+func (ids headingIDs) Generate(value []byte, _ ast.NodeKind) []byte {
+	slug := utils.HeadingSlug(string(value))
+	if slug == "" {
+		slug = "heading"
+	}
+	n := ids[slug]
+	ids[slug]++
+	if n > 0 {
+		slug = fmt.Sprintf("%s-%d", slug, n)
+	}
+	return []byte(slug)
+}
+
+func (ids headingIDs) Put(value []byte) {
+	ids[string(value)]++
+}
+
+type indexEntry struct {
+	Path  string
+	Title string
+}
+
+var indexTemplate = template.Must(template.New("index").Parse(`<h1>{{.Title}}</h1>
+{{range .Dirs}}
+{{- if .Name}}<h2>{{.Name}}</h2>{{end}}
+<ul>
+{{- range .Entries}}
+<li><a href="{{.Path}}">{{.Title}}</a></li>
+{{- end}}
+</ul>
+{{end}}`))
+
+// writeIndex writes a page listing all documents, grouped by directory.
+func writeIndex(name, title string, entries []indexEntry) error {
+	type dir struct {
+		Name    string
+		Entries []indexEntry
+	}
+	byDir := map[string]*dir{}
+	var dirs []*dir
+	for _, e := range entries {
+		name := path.Dir(e.Path)
+		if name == "." {
+			name = ""
+		}
+		d, ok := byDir[name]
+		if !ok {
+			d = &dir{Name: name}
+			byDir[name] = d
+			dirs = append(dirs, d)
+		}
+		d.Entries = append(d.Entries, e)
+	}
+	sort.SliceStable(dirs, func(i, j int) bool {
+		return dirs[i].Name < dirs[j].Name
+	})
+
+	var buf bytes.Buffer
+	err := indexTemplate.Execute(&buf, struct {
+		Title string
+		Dirs  []*dir
+	}{title, dirs})
+	if err != nil {
+		return err
+	}
+	return writePageFile(name, page{
+		Title: title,
+		Body:  template.HTML(buf.String()), //nolint:gosec
+	})
+}
@@ -0,0 +1,155 @@
+package export
+
+import (
+	"bytes"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/yuin/goldmark/ast"
+)
+
+// UnresolvedLink is a link of an exported document that doesn't lead
+// anywhere in the exported tree.
+type UnresolvedLink struct {
+	Document string // relative to the root of the tree
+	Line     int
+	Link     string
+	Reason   string
+}
+
+// tree holds the documents of a tree being exported, by path relative to its
+// root, so links between them can be checked.
+type tree struct {
+	root string
+	docs map[string]*document
+}
+
+// rewriteLinks points relative links to other documents at their exported
+// counterparts, and fragments at the IDs of the headings they refer to. It
+// returns the other local files the document refers to, relative to the root
+// of the tree, and the links that can't be resolved.
+func (d *document) rewriteLinks(rel, format string, t tree) ([]string, []UnresolvedLink) {
+	var (
+		assets     []string
+		unresolved []UnresolvedLink
+	)
+	_ = ast.Walk(d.node, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		var dest *[]byte
+		switch n := n.(type) {
+		case *ast.Link:
+			dest = &n.Destination
+		case *ast.Image:
+			dest = &n.Destination
+		default:
+			return ast.WalkContinue, nil
+		}
+		fail := func(reason string) {
+			unresolved = append(unresolved, UnresolvedLink{rel, d.line(n), string(*dest), reason})
+		}
+
+		u, err := url.Parse(string(*dest))
+		if err != nil || u.Scheme != "" || u.Host != "" || path.IsAbs(u.Path) {
+			return ast.WalkContinue, nil
+		}
+
+		// a heading of this document
+		if u.Path == "" {
+			if u.Fragment == "" {
+				return ast.WalkContinue, nil
+			}
+			if id, ok := d.anchor(u.Fragment); ok {
+				u.Fragment, u.RawFragment = id, ""
+				*dest = []byte(u.String())
+			} else {
+				fail("no heading for #" + u.Fragment)
+			}
+			return ast.WalkContinue, nil
+		}
+
+		target := path.Join(path.Dir(rel), u.Path)
+		if target == ".." || strings.HasPrefix(target, "../") {
+			fail("outside of the exported tree")
+			return ast.WalkContinue, nil
+		}
+		if !isMarkdown(u.Path) {
+			if _, err := os.Stat(filepath.Join(t.root, filepath.FromSlash(target))); err != nil {
+				fail("no such file")
+				return ast.WalkContinue, nil
+			}
+			assets = append(assets, target)
+			return ast.WalkContinue, nil
+		}
+
+		u.Path = OutputPath(u.Path, format)
+		if doc, ok := t.docs[target]; !ok {
+			fail("no such document")
+		} else if u.Fragment != "" {
+			if id, ok := doc.anchor(u.Fragment); ok {
+				u.Fragment, u.RawFragment = id, ""
+			} else {
+				fail("no heading for #" + u.Fragment + " in " + target)
+			}
+		}
+		*dest = []byte(u.String())
+		return ast.WalkContinue, nil
+	})
+	return assets, unresolved
+}
+
+// anchor returns the ID of the heading a fragment refers to: either its ID
+// already, or its text, which is turned into an ID the way headings' IDs
+// are. Fragments are matched against the IDs the headings got, so of two
+// headings with the same text, the second one is #text-1, as on GitHub.
+func (d *document) anchor(fragment string) (string, bool) {
+	if fragment == "top" {
+		return fragment, true
+	}
+	var found string
+	slug := utils.HeadingSlug(fragment)
+	_ = ast.Walk(d.node, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		h, ok := n.(*ast.Heading)
+		if !ok || !entering {
+			return ast.WalkContinue, nil
+		}
+		id, _ := h.AttributeString("id")
+		if b, ok := id.([]byte); ok {
+			switch string(b) {
+			case fragment:
+				found = fragment
+				return ast.WalkStop, nil
+			case slug:
+				if found == "" {
+					found = slug
+				}
+			}
+		}
+		return ast.WalkSkipChildren, nil
+	})
+	return found, found != ""
+}
+
+// line returns the line of the source a link is on, counting frontmatter:
+// that of its text, or else the first line of the block it's in. It's 0 if
+// neither is known.
+func (d *document) line(n ast.Node) int {
+	if t, ok := n.FirstChild().(*ast.Text); ok {
+		return d.lineAt(t.Segment.Start)
+	}
+	for ; n != nil; n = n.Parent() {
+		if n.Type() == ast.TypeBlock && n.Lines().Len() > 0 {
+			return d.lineAt(n.Lines().At(0).Start)
+		}
+	}
+	return 0
+}
+
+func (d *document) lineAt(offset int) int {
+	return d.includes.Line(d.front + bytes.Count(d.source[:offset], []byte("\n")) + 1)
+}
@@ -0,0 +1,44 @@
+package export
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var hrefRe = regexp.MustCompile(`href="([^"]*)"`)
+
+func TestTreeLinks(t *testing.T) {
+	dir := t.TempDir()
+	report, err := Tree(filepath.Join("testdata", "links"), dir, FormatHTML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Documents != 2 || report.Assets != 1 {
+		t.Errorf("exported %d documents and %d assets, want 2 and 1", report.Documents, report.Assets)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "logo.txt")); err != nil {
+		t.Errorf("linked file not copied: %v", err)
+	}
+
+	// the golden file lists the broken links, then where the links of each
+	// exported page lead
+	var b strings.Builder
+	for _, u := range report.Unresolved {
+		fmt.Fprintf(&b, "%s:%d: %s: %s\n", u.Document, u.Line, u.Link, u.Reason)
+	}
+	for _, name := range []string{"README.html", "guide/install.html"} {
+		page, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		fmt.Fprintf(&b, "\n%s:\n", name)
+		for _, m := range hrefRe.FindAllStringSubmatch(string(page), -1) {
+			fmt.Fprintf(&b, "%s\n", m[1])
+		}
+	}
+	golden(t, "links", []byte(b.String()))
+}
@@ -0,0 +1,238 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/yuin/goldmark/ast"
+	east "github.com/yuin/goldmark/extension/ast"
+)
+
+// Titles like "glow(1)" name the man page and its section.
+var manTitlePattern = regexp.MustCompile(`^\s*([^\s()]+)\s*\((\w+)\)\s*$`)
+
+// man renders the document as a roff man page. The leading top-level heading
+// or the frontmatter title names the page, and the section is taken from
+// titles like "glow(1)", or the frontmatter, defaulting to 1. Other headings
+// become sections and subsections, code blocks examples and emphasis fonts.
+func (d *document) man(name string) string {
+	m := &manWriter{source: d.source}
+
+	title, section := d.title(name), d.meta.Section
+	first := d.node.FirstChild()
+	if h, ok := first.(*ast.Heading); ok && h.Level == 1 {
+		first = first.NextSibling() // the title
+	}
+	if sm := manTitlePattern.FindStringSubmatch(title); sm != nil {
+		title, section = sm[1], sm[2]
+	}
+	if section == "" {
+		section = "1"
+	}
+	fmt.Fprintf(&m.buf, ".TH %s %s %s\n", manQuote(manEscape(strings.ToUpper(title))), manQuote(section),
+		manQuote(time.Now().Format("2006-01-02")))
+
+	for n := first; n != nil; n = n.NextSibling() {
+		m.block(n)
+	}
+
+	out := m.buf.String()
+	if m.tables {
+		// ask man to run tbl
+		out = "'\\\" t\n" + out
+	}
+	return out
+}
+
+type manWriter struct {
+	source []byte
+	buf    bytes.Buffer
+	tables bool
+}
+
+// line writes a request, or a line of text.
+func (m *manWriter) line(s string) {
+	m.buf.WriteString(s)
+	m.buf.WriteByte('\n')
+}
+
+// text writes running text, keeping lines from being taken as requests.
+func (m *manWriter) text(s string) {
+	for _, l := range strings.Split(strings.TrimSpace(s), "\n") {
+		l = strings.TrimSpace(l)
+		if strings.HasPrefix(l, ".") || strings.HasPrefix(l, "'") {
+			l = `\&` + l
+		}
+		m.line(l)
+	}
+}
+
+func (m *manWriter) block(n ast.Node) {
+	switch n := n.(type) {
+	case *ast.Heading:
+		// plain text, as headings are set in bold anyway
+		text := string(n.Text(m.source)) //nolint:staticcheck
+		if n.Level <= 2 {                //nolint:mnd
+			m.line(".SH " + manQuote(manEscape(strings.ToUpper(text))))
+		} else {
+			m.line(".SS " + manQuote(manEscape(text)))
+		}
+
+	case *ast.Paragraph, *ast.TextBlock:
+		m.line(".PP")
+		m.text(m.inline(n))
+
+	case *ast.FencedCodeBlock, *ast.CodeBlock:
+		m.line(".PP")
+		m.line(".EX")
+		lines := n.Lines()
+		for i := 0; i < lines.Len(); i++ {
+			seg := lines.At(i)
+			l := strings.TrimRight(string(seg.Value(m.source)), "\n")
+			l = manEscape(l)
+			if strings.HasPrefix(l, ".") || strings.HasPrefix(l, "'") {
+				l = `\&` + l
+			}
+			m.line(l)
+		}
+		m.line(".EE")
+
+	case *ast.Blockquote:
+		m.line(".RS 4")
+		for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+			m.block(c)
+		}
+		m.line(".RE")
+
+	case *ast.List:
+		m.list(n)
+
+	case *ast.ThematicBreak:
+		m.line(".PP")
+
+	case *east.Table:
+		m.table(n)
+
+	default:
+		// raw HTML and the like have no place in a man page
+	}
+}
+
+func (m *manWriter) list(l *ast.List) {
+	nested := l.Parent() != nil && l.Parent().Kind() == ast.KindListItem
+	if nested {
+		m.line(".RS")
+	}
+	i := l.Start
+	for item := l.FirstChild(); item != nil; item = item.NextSibling() {
+		if l.IsOrdered() {
+			m.line(fmt.Sprintf(".IP %d. 4", i))
+			i++
+		} else {
+			m.line(`.IP \(bu 2`)
+		}
+		for c := item.FirstChild(); c != nil; c = c.NextSibling() {
+			switch c.(type) {
+			case *ast.Paragraph, *ast.TextBlock:
+				if c != item.FirstChild() {
+					m.line(".IP")
+				}
+				m.text(m.inline(c))
+			default:
+				m.block(c)
+			}
+		}
+	}
+	if nested {
+		m.line(".RE")
+	}
+}
+
+// table writes a table for tbl, with a bold header row.
+func (m *manWriter) table(t *east.Table) {
+	m.tables = true
+	var rows [][]string
+	for r := t.FirstChild(); r != nil; r = r.NextSibling() {
+		var cells []string
+		for c := r.FirstChild(); c != nil; c = c.NextSibling() {
+			cells = append(cells, strings.ReplaceAll(m.inline(c), "\t", " "))
+		}
+		rows = append(rows, cells)
+	}
+	if len(rows) == 0 {
+		return
+	}
+
+	cols := len(rows[0])
+	m.line(".TS")
+	m.line("allbox tab(\t);")
+	m.line(strings.TrimSpace(strings.Repeat("lB ", cols)))
+	m.line(strings.TrimSpace(strings.Repeat("l ", cols)) + ".")
+	for _, r := range rows {
+		for len(r) < cols {
+			r = append(r, "")
+		}
+		m.line(strings.Join(r[:cols], "\t"))
+	}
+	m.line(".TE")
+}
+
+// inline renders the inline content of a node, with font escapes for
+// emphasis and code.
+func (m *manWriter) inline(n ast.Node) string {
+	var b strings.Builder
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		switch c := c.(type) {
+		case *ast.Text:
+			b.WriteString(manEscape(string(c.Segment.Value(m.source))))
+			switch {
+			case c.HardLineBreak():
+				b.WriteString("\n.br\n")
+			case c.SoftLineBreak():
+				b.WriteString("\n")
+			}
+		case *ast.String:
+			b.WriteString(manEscape(string(c.Value)))
+		case *ast.CodeSpan:
+			b.WriteString(`\fB` + manEscape(string(c.Text(m.source))) + `\fR`) //nolint:staticcheck
+		case *ast.Emphasis:
+			font := `\fI`
+			if c.Level == 2 { //nolint:mnd
+				font = `\fB`
+			}
+			b.WriteString(font + m.inline(c) + `\fR`)
+		case *east.Strikethrough:
+			b.WriteString(m.inline(c))
+		case *ast.Link:
+			text := m.inline(c)
+			b.WriteString(text)
+			if dest := manEscape(string(c.Destination)); dest != text && !strings.HasPrefix(string(c.Destination), "#") {
+				b.WriteString(` <\fI` + dest + `\fR>`)
+			}
+		case *ast.AutoLink:
+			b.WriteString(`\fI` + manEscape(string(c.URL(m.source))) + `\fR`)
+		case *ast.Image:
+			b.WriteString(m.inline(c))
+		case *ast.RawHTML:
+			// left out
+		default:
+			b.WriteString(m.inline(c))
+		}
+	}
+	return b.String()
+}
+
+// manEscape escapes text for roff: backslashes, and dashes so they're not
+// turned into hyphens.
+func manEscape(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\e`)
+	return strings.ReplaceAll(s, "-", `\-`)
+}
+
+// manQuote quotes an argument of a request.
+func manQuote(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `\(dq`) + `"`
+}
@@ -0,0 +1,158 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf16"
+)
+
+// Layout of PDF exports: A4 pages, in points, with the plain text export set
+// in 10pt Courier, whose characters are 6pt wide, so its 80 columns fit.
+const (
+	pdfPageWidth  = 595
+	pdfPageHeight = 842
+	pdfMargin     = 50
+	pdfFontSize   = 10
+	pdfLeading    = 12
+	pdfLinesPage  = (pdfPageHeight - 2*pdfMargin) / pdfLeading
+	pdfColumns    = (pdfPageWidth - 2*pdfMargin) / 6
+)
+
+// pdfRunes maps characters glamour's plain text uses outside of Latin-1 to
+// the Windows-1252 encoding of PDF's standard fonts, or to look-alikes.
+var pdfRunes = map[rune]byte{
+	'€': 0x80, '…': 0x85, '‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94,
+	'•': 0x95, '–': 0x96, '—': 0x97, '™': 0x99,
+	'│': '|', '─': '-', '┼': '+', '├': '+', '┤': '+', '┬': '+', '┴': '+',
+	'┌': '+', '┐': '+', '└': '+', '┘': '+', '→': '>', '←': '<',
+}
+
+// pdf lays the plain text export of the document out on pages. PDF's
+// standard fonts need nothing embedded, but only cover Latin-1 and a few
+// more characters; others are shown as question marks.
+func (d *document) pdf(w io.Writer, name string) error {
+	s, err := d.text()
+	if err != nil {
+		return err
+	}
+	// glamour pads lines to the full width, and doesn't wrap code, so lines
+	// are trimmed, and broken if they're still too long for the page
+	var lines []string
+	for _, l := range strings.Split(strings.Trim(s, "\n"), "\n") {
+		r := []rune(strings.TrimRight(l, " "))
+		for len(r) > pdfColumns {
+			lines = append(lines, string(r[:pdfColumns]))
+			r = r[pdfColumns:]
+		}
+		lines = append(lines, string(r))
+	}
+
+	var pages [][]string
+	for len(lines) > 0 {
+		n := min(len(lines), pdfLinesPage)
+		pages = append(pages, lines[:n])
+		lines = lines[n:]
+	}
+	if len(pages) == 0 {
+		pages = append(pages, nil)
+	}
+
+	p := &pdfWriter{}
+	p.header()
+	// objects 1 to 4 are the catalog, the page tree, the font and the
+	// document info; each page then gets one, and one for its contents
+	p.object(1, "<< /Type /Catalog /Pages 2 0 R >>")
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	p.object(2, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	p.object(3, "<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+	p.object(4, fmt.Sprintf("<< /Title %s /Producer (Glow) >>", pdfTextString(d.title(name))))
+	for i, lines := range pages {
+		page, contents := 5+2*i, 6+2*i
+		p.object(page, fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, contents))
+		p.stream(contents, pdfPageContents(lines))
+	}
+	p.trailer(4 + 2*len(pages))
+
+	_, err = w.Write(p.buf.Bytes())
+	return err
+}
+
+// pdfPageContents returns the content stream drawing lines of text from the
+// top of a page.
+func pdfPageContents(lines []string) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "BT /F1 %d Tf %d TL %d %d Td\n", pdfFontSize, pdfLeading, pdfMargin, pdfPageHeight-pdfMargin-pdfFontSize)
+	for _, l := range lines {
+		b.WriteByte('(')
+		for _, r := range l {
+			c, ok := pdfRunes[r]
+			switch {
+			case ok:
+			case r < 0x20:
+				c = ' '
+			case r < 0x7f || (r >= 0xa0 && r <= 0xff):
+				c = byte(r)
+			default:
+				c = '?'
+			}
+			if c == '(' || c == ')' || c == '\\' {
+				b.WriteByte('\\')
+			}
+			b.WriteByte(c)
+		}
+		b.WriteString(") Tj T*\n")
+	}
+	b.WriteString("ET\n")
+	return b.Bytes()
+}
+
+// pdfTextString encodes a string for the document info, which isn't limited
+// to the font's characters, as UTF-16.
+func pdfTextString(s string) string {
+	var b strings.Builder
+	b.WriteString("<FEFF")
+	for _, u := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&b, "%04X", u)
+	}
+	b.WriteString(">")
+	return b.String()
+}
+
+// pdfWriter writes numbered objects, keeping track of where they start for
+// the cross-reference table. Objects have to be written in order.
+type pdfWriter struct {
+	buf     bytes.Buffer
+	offsets []int
+}
+
+func (p *pdfWriter) header() {
+	// the binary comment tells transfer programs the file isn't text
+	p.buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+}
+
+func (p *pdfWriter) object(n int, dict string) {
+	p.offsets = append(p.offsets, p.buf.Len())
+	fmt.Fprintf(&p.buf, "%d 0 obj\n%s\nendobj\n", n, dict)
+}
+
+func (p *pdfWriter) stream(n int, data []byte) {
+	p.offsets = append(p.offsets, p.buf.Len())
+	fmt.Fprintf(&p.buf, "%d 0 obj\n<< /Length %d >>\nstream\n", n, len(data))
+	p.buf.Write(data)
+	p.buf.WriteString("endstream\nendobj\n")
+}
+
+func (p *pdfWriter) trailer(objects int) {
+	xref := p.buf.Len()
+	fmt.Fprintf(&p.buf, "xref\n0 %d\n0000000000 65535 f \n", objects+1)
+	for _, off := range p.offsets {
+		fmt.Fprintf(&p.buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&p.buf, "trailer\n<< /Size %d /Root 1 0 R /Info 4 0 R >>\nstartxref\n%d\n%%%%EOF\n", objects+1, xref)
+}
@@ -0,0 +1,62 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestPDF(t *testing.T) {
+	md := "# Notes (draft)\n\nCafé \\\\ 50% → done\n\n" + strings.Repeat("line\n\n", 100) +
+		"```\n" + strings.Repeat("x", 100) + "\n```\n"
+	var buf bytes.Buffer
+	if err := Document(&buf, []byte(md), "notes.md", FormatPDF); err != nil {
+		t.Fatal(err)
+	}
+	b := buf.Bytes()
+
+	if !bytes.HasPrefix(b, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(b, []byte("%%EOF\n")) {
+		t.Fatal("not framed as a PDF")
+	}
+	// every entry of the cross-reference table points at its object
+	xref := regexp.MustCompile(`(?m)^startxref\n(\d+)$`).FindSubmatch(b)
+	if xref == nil {
+		t.Fatal("no startxref")
+	}
+	start, _ := strconv.Atoi(string(xref[1]))
+	entries := regexp.MustCompile(`(?m)^(\d{10}) 00000 n $`).FindAllSubmatch(b[start:], -1)
+	for i, e := range entries {
+		off, _ := strconv.Atoi(string(e[1]))
+		if want := fmt.Sprintf("%d 0 obj\n", i+1); !bytes.HasPrefix(b[off:], []byte(want)) {
+			t.Errorf("object %d isn't at offset %d", i+1, off)
+		}
+	}
+	if got := bytes.Count(b, []byte("/Type /Page ")); got != 4 {
+		t.Errorf("got %d pages, want 4", got)
+	}
+
+	// lines fit on the page, long code being broken rather than cut off
+	var xs int
+	for _, l := range regexp.MustCompile(`(?m)^\((.*)\) Tj T\*$`).FindAllSubmatch(b, -1) {
+		if len(l[1]) > pdfColumns+2 {
+			t.Errorf("line too long for the page: %q", l[1])
+		}
+		xs += bytes.Count(l[1], []byte("x"))
+	}
+	if xs != 100 {
+		t.Errorf("got %d characters of the long line, want 100", xs)
+	}
+
+	for _, want := range []string{
+		"(  # Notes \\(draft\\)) Tj",     // parentheses escaped
+		"(  Caf\xe9 \\\\ 50% > done) Tj", // Latin-1, a backslash and a look-alike
+		"/Title <FEFF004E006F00740065007300200028006400720061006600740029>",
+	} {
+		if !bytes.Contains(b, []byte(want)) {
+			t.Errorf("PDF doesn't contain %q", want)
+		}
+	}
+}
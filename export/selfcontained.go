@@ -0,0 +1,173 @@
+package export
+
+import (
+	"bytes"
+	"encoding/base64"
+	"html/template"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/util"
+)
+
+// Style code is highlighted with in self-contained pages.
+const highlightStyle = "github"
+
+// SelfContained converts a markdown document to an HTML page that needs
+// nothing besides itself, to attach to tickets and emails: code is
+// highlighted with inline styles, and images are embedded as data URIs. Paths
+// of images are relative to dir, the directory of the document, and only
+// images below it are embedded. The images that couldn't be embedded are
+// returned; they're linked to as before.
+func SelfContained(w io.Writer, md []byte, name, dir string) ([]UnresolvedLink, error) {
+	doc := parse(md)
+	missing := doc.embedImages(name, dir)
+
+	r := newMarkdown().Renderer()
+	r.AddOptions(renderer.WithNodeRenderers(util.Prioritized(codeHighlighter{}, 200))) //nolint:mnd
+	var buf bytes.Buffer
+	if err := r.Render(&buf, doc.source, doc.node); err != nil {
+		return missing, err
+	}
+	return missing, writePage(w, page{
+		Title: doc.title(name),
+		Body:  template.HTML(buf.String()), //nolint:gosec
+	})
+}
+
+// Images larger than this aren't embedded.
+const maxEmbeddedImage = 10 << 20 // 10 MiB
+
+// embedImages replaces the local images of a document with data URIs.
+// Images on the web are left alone.
+func (d *document) embedImages(name, dir string) []UnresolvedLink {
+	var missing []UnresolvedLink
+	_ = ast.Walk(d.node, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		img, ok := n.(*ast.Image)
+		if !ok || !entering {
+			return ast.WalkContinue, nil
+		}
+		dest := string(img.Destination)
+		u, err := url.Parse(dest)
+		if err != nil || u.Scheme != "" || u.Host != "" || u.Path == "" {
+			return ast.WalkContinue, nil
+		}
+		fail := func(reason string) {
+			missing = append(missing, UnresolvedLink{name, d.line(n), dest, reason})
+		}
+
+		if dir == "" {
+			fail("relative to a document that isn't a local file")
+			return ast.WalkContinue, nil
+		}
+		b, reason := readImage(dir, filepath.FromSlash(u.Path))
+		if reason != "" {
+			fail(reason)
+			return ast.WalkContinue, nil
+		}
+		img.Destination = []byte("data:" + mediaType(u.Path, b) + ";base64," + base64.StdEncoding.EncodeToString(b))
+		return ast.WalkContinue, nil
+	})
+	return missing
+}
+
+// readImage reads an image to embed, at a path relative to dir, the
+// directory of the document. Only images below it are embedded, so a
+// document can't pull in other files of whoever exports it; the reason is
+// returned for those that aren't.
+func readImage(dir, path string) ([]byte, string) {
+	if filepath.IsAbs(path) {
+		return nil, "outside of the document's directory"
+	}
+	root, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return nil, "no such file"
+	}
+	p, err := filepath.EvalSymlinks(filepath.Join(root, path))
+	if err != nil {
+		return nil, "no such file"
+	}
+	if rel, err := filepath.Rel(root, p); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil, "outside of the document's directory"
+	}
+
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, "no such file"
+	}
+	defer f.Close() //nolint:errcheck
+	if info, err := f.Stat(); err != nil || !info.Mode().IsRegular() {
+		return nil, "not a file"
+	}
+	b, err := io.ReadAll(io.LimitReader(f, maxEmbeddedImage+1))
+	if err != nil {
+		return nil, err.Error()
+	}
+	if len(b) > maxEmbeddedImage {
+		return nil, "larger than 10 MiB"
+	}
+	if !strings.HasPrefix(mediaType(p, b), "image/") {
+		return nil, "not an image"
+	}
+	return b, ""
+}
+
+// mediaType returns the media type of a file, going by its extension or else
+// its content.
+func mediaType(name string, b []byte) string {
+	if t := mime.TypeByExtension(filepath.Ext(name)); t != "" {
+		t, _, _ = strings.Cut(t, ";")
+		return t
+	}
+	t, _, _ := strings.Cut(http.DetectContentType(b), ";")
+	return t
+}
+
+// codeHighlighter renders fenced code blocks with their language highlighted
+// with inline styles, so the page doesn't need a stylesheet for them.
+type codeHighlighter struct{}
+
+func (codeHighlighter) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindFencedCodeBlock, renderHighlighted)
+}
+
+func renderHighlighted(w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	block := n.(*ast.FencedCodeBlock)
+	var code bytes.Buffer
+	for i := 0; i < block.Lines().Len(); i++ {
+		l := block.Lines().At(i)
+		code.Write(l.Value(source))
+	}
+
+	lexer := lexers.Get(string(block.Language(source)))
+	if lexer == nil {
+		_, _ = w.WriteString("<pre><code>")
+		html.DefaultWriter.RawWrite(w, code.Bytes())
+		_, _ = w.WriteString("</code></pre>\n")
+		return ast.WalkSkipChildren, nil
+	}
+	tokens, err := chroma.Coalesce(lexer).Tokenise(nil, code.String())
+	if err != nil {
+		return ast.WalkStop, err
+	}
+	f := chromahtml.New(chromahtml.WithClasses(false))
+	if err := f.Format(w, styles.Get(highlightStyle), tokens); err != nil {
+		return ast.WalkStop, err
+	}
+	return ast.WalkSkipChildren, nil
+}
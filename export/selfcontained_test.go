@@ -0,0 +1,86 @@
+package export
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// png is the start of a PNG file, enough to be taken for one.
+var png = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR")
+
+func TestSelfContainedImages(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "docs")
+	for name, b := range map[string][]byte{
+		"docs/logo.png":     png,
+		"docs/img/icon.png": png,
+		"docs/notes.txt":    []byte("not an image"),
+		"secret.png":        png,
+	} {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, b, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	big := filepath.Join(dir, "big.png")
+	if err := os.WriteFile(big, append(png, make([]byte, maxEmbeddedImage)...), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(root, "secret.png"), filepath.Join(dir, "link.png")); err != nil {
+		t.Fatal(err)
+	}
+
+	md := strings.Join([]string{
+		"![logo](logo.png)",
+		"![icon](img/icon.png)",
+		"![up](../secret.png)",
+		"![abs](" + filepath.ToSlash(filepath.Join(root, "secret.png")) + ")",
+		"![link](link.png)",
+		"![text](notes.txt)",
+		"![big](big.png)",
+		"![gone](gone.png)",
+		"![web](https://example.com/a.png)",
+	}, "\n\n") + "\n"
+
+	var b bytes.Buffer
+	missing, err := SelfContained(&b, []byte(md), "doc.md", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(b.String(), "data:image/png;base64,"); n != 2 {
+		t.Errorf("embedded %d images, want 2", n)
+	}
+	if !strings.Contains(b.String(), `src="https://example.com/a.png"`) {
+		t.Error("image on the web not left alone")
+	}
+
+	want := map[string]string{
+		"../secret.png": "outside of the document's directory",
+		"link.png":      "outside of the document's directory",
+		"notes.txt":     "not an image",
+		"big.png":       "larger than 10 MiB",
+		"gone.png":      "no such file",
+	}
+	got := make(map[string]string)
+	for _, l := range missing {
+		if filepath.IsAbs(filepath.FromSlash(l.Link)) {
+			l.Link = "abs"
+		}
+		got[l.Link] = l.Reason
+	}
+	want["abs"] = "outside of the document's directory"
+	for link, reason := range want {
+		if got[link] != reason {
+			t.Errorf("%s: got %q, want %q", link, got[link], reason)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("got %d unresolved images, want %d: %v", len(got), len(want), got)
+	}
+}
@@ -0,0 +1,211 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	east "github.com/yuin/goldmark/extension/ast"
+)
+
+// semantic renders the document as plain text that spells out its structure
+// with markers, such as "HEADING 2:" and "CODE PYTHON BEGIN", for
+// text-to-speech and other programs to consume, and for snapshots that diff
+// well. Paragraphs are kept on a single line each, and formatting that isn't
+// structure, such as emphasis, is left out.
+func (d *document) semantic() string {
+	w := &semanticWriter{source: d.source}
+	if d.meta.Title != "" {
+		w.line("TITLE: " + d.meta.Title)
+		w.buf.WriteByte('\n')
+	}
+	for n := d.node.FirstChild(); n != nil; n = n.NextSibling() {
+		if w.block(n) {
+			w.buf.WriteByte('\n')
+		}
+	}
+	return strings.TrimSuffix(w.buf.String(), "\n")
+}
+
+type semanticWriter struct {
+	source []byte
+	buf    strings.Builder
+
+	// how deep in lists and quotes the writer is, for indentation
+	depth int
+}
+
+// line writes a line, indented to the current depth.
+func (w *semanticWriter) line(s string) {
+	w.buf.WriteString(strings.Repeat("  ", w.depth))
+	w.buf.WriteString(s)
+	w.buf.WriteByte('\n')
+}
+
+// block writes a block, and reports whether it wrote anything.
+func (w *semanticWriter) block(n ast.Node) bool {
+	switch n := n.(type) {
+	case *ast.Heading:
+		w.line(fmt.Sprintf("HEADING %d: %s", n.Level, w.inline(n)))
+
+	case *ast.Paragraph, *ast.TextBlock:
+		w.line(w.inline(n))
+
+	case *ast.FencedCodeBlock, *ast.CodeBlock:
+		marker := "CODE"
+		if f, ok := n.(*ast.FencedCodeBlock); ok && f.Language(w.source) != nil {
+			marker += " " + strings.ToUpper(string(f.Language(w.source)))
+		}
+		w.line(marker + " BEGIN")
+		lines := n.Lines()
+		for i := 0; i < lines.Len(); i++ {
+			seg := lines.At(i)
+			w.line(strings.TrimRight(string(seg.Value(w.source)), "\r\n"))
+		}
+		w.line(marker + " END")
+
+	case *ast.Blockquote:
+		w.line("QUOTE BEGIN")
+		w.children(n)
+		w.line("QUOTE END")
+
+	case *ast.List:
+		w.list(n)
+
+	case *ast.ThematicBreak:
+		w.line("SEPARATOR")
+
+	case *east.Table:
+		w.table(n)
+
+	default:
+		// raw HTML has no structure to speak of
+		return false
+	}
+	return true
+}
+
+// children writes the blocks in a node, one level deeper.
+func (w *semanticWriter) children(n ast.Node) {
+	w.depth++
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		w.block(c)
+	}
+	w.depth--
+}
+
+// list writes a list, with the text that starts each item on the item's
+// line, and whatever else is in it below.
+func (w *semanticWriter) list(l *ast.List) {
+	kind := "LIST"
+	if l.IsOrdered() {
+		kind = "NUMBERED LIST"
+	}
+	w.line(kind + " BEGIN")
+	i := l.Start
+	for item := l.FirstChild(); item != nil; item = item.NextSibling() {
+		marker := "LIST ITEM"
+		if l.IsOrdered() {
+			marker += fmt.Sprintf(" %d", i)
+			i++
+		}
+
+		first := item.FirstChild()
+		switch first.(type) {
+		case *ast.Paragraph, *ast.TextBlock:
+			if box, ok := first.FirstChild().(*east.TaskCheckBox); ok {
+				if box.IsChecked {
+					marker += ", DONE"
+				} else {
+					marker += ", NOT DONE"
+				}
+			}
+			w.line(marker + ": " + w.inline(first))
+			first = first.NextSibling()
+		default:
+			w.line(marker + ":")
+		}
+
+		w.depth++
+		for c := first; c != nil; c = c.NextSibling() {
+			w.block(c)
+		}
+		w.depth--
+	}
+	w.line(kind + " END")
+}
+
+// table writes a table row by row, naming the column of each cell, so rows
+// can be read out on their own.
+func (w *semanticWriter) table(t *east.Table) {
+	var rows [][]string
+	for r := t.FirstChild(); r != nil; r = r.NextSibling() {
+		var cells []string
+		for c := r.FirstChild(); c != nil; c = c.NextSibling() {
+			cells = append(cells, w.inline(c))
+		}
+		rows = append(rows, cells)
+	}
+	if len(rows) == 0 {
+		return
+	}
+
+	header := rows[0]
+	w.line(fmt.Sprintf("TABLE BEGIN: %s, %s", count(len(header), "COLUMN"), count(len(rows)-1, "ROW")))
+	w.line("TABLE HEADER: " + strings.Join(header, " | "))
+	for i, r := range rows[1:] {
+		cells := make([]string, len(r))
+		for j, c := range r {
+			if j < len(header) && header[j] != "" {
+				c = header[j] + ": " + c
+			}
+			cells[j] = c
+		}
+		w.line(fmt.Sprintf("TABLE ROW %d: %s", i+1, strings.Join(cells, " | ")))
+	}
+	w.line("TABLE END")
+}
+
+// count returns a number of things, such as "1 ROW" or "2 ROWS".
+func count(n int, thing string) string {
+	if n != 1 {
+		thing += "S"
+	}
+	return fmt.Sprintf("%d %s", n, thing)
+}
+
+// inline renders the inline content of a node as a single line of text.
+// Links and images say where they point to.
+func (w *semanticWriter) inline(n ast.Node) string {
+	var b strings.Builder
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		switch c := c.(type) {
+		case *ast.Text:
+			b.Write(c.Segment.Value(w.source))
+			if c.SoftLineBreak() || c.HardLineBreak() {
+				b.WriteByte(' ')
+			}
+		case *ast.String:
+			b.Write(c.Value)
+		case *ast.CodeSpan:
+			b.Write(c.Text(w.source)) //nolint:staticcheck
+		case *east.Strikethrough:
+			b.WriteString("(DELETED: " + w.inline(c) + ")")
+		case *ast.Link:
+			text := w.inline(c)
+			b.WriteString(text)
+			if dest := string(c.Destination); dest != text && !strings.HasPrefix(dest, "#") {
+				b.WriteString(" (LINK: " + dest + ")")
+			}
+		case *ast.AutoLink:
+			b.Write(c.URL(w.source))
+		case *ast.Image:
+			b.WriteString("(IMAGE: " + w.inline(c) + ")")
+		case *ast.RawHTML, *east.TaskCheckBox:
+			// left out
+		default:
+			b.WriteString(w.inline(c))
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
@@ -0,0 +1,44 @@
+package export
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// update rewrites golden files with the current output, for when it changed
+// on purpose: go test ./export -update
+var update = flag.Bool("update", false, "update golden files")
+
+// golden compares output with the golden file of a test in testdata.
+func golden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	p := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.WriteFile(p, got, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output differs from %s:\n%s", p, got)
+	}
+}
+
+func TestSemantic(t *testing.T) {
+	md, err := os.ReadFile(filepath.Join("testdata", "semantic.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if err := Document(&b, md, "semantic.md", FormatSemantic); err != nil {
+		t.Fatal(err)
+	}
+	golden(t, "semantic", b.Bytes())
+}
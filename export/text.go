@@ -0,0 +1,24 @@
+package export
+
+import (
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/glamour/styles"
+	"github.com/muesli/termenv"
+)
+
+// Width plain text exports are wrapped at.
+const textWidth = 80
+
+// text renders the document as plain text, the way glow prints it when not
+// writing to a terminal.
+func (d *document) text() (string, error) {
+	r, err := glamour.NewTermRenderer(
+		glamour.WithStyles(styles.NoTTYStyleConfig),
+		glamour.WithColorProfile(termenv.Ascii),
+		glamour.WithWordWrap(textWidth),
+	)
+	if err != nil {
+		return "", err
+	}
+	return r.Render(string(d.source))
+}
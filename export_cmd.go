@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/glow/v2/export"
+	"github.com/charmbracelet/glow/v2/markup"
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportOutput string
+	exportAll    bool
+	exportFormat string
+
+	exportSelfContained bool
+
+	exportCmd = &cobra.Command{
+		Use:     "export [SOURCE|DIR]",
+		Short:   "Export markdown to other formats",
+		Long:    paragraph(fmt.Sprintf("\n%s a markdown source to HTML, plain text or a man page. With --all, every markdown file below DIR is exported into a static site, keeping relative links and anchors working.", keyword("Export"))),
+		Example: paragraph("glow export README.md -o README.html\nglow export --self-contained report.md -o report.html\nglow export --all docs -o site/\nglow export --format man cli.md > glow.1"),
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			arg := "-"
+			if len(args) > 0 {
+				arg = args[0]
+			}
+			if exportSelfContained && (exportAll || exportFormat != export.FormatHTML) {
+				return fmt.Errorf("--self-contained only works for a single document exported to %s", export.FormatHTML)
+			}
+			if exportAll {
+				return exportTree(arg)
+			}
+			return exportSource(arg)
+		},
+	}
+)
+
+// exportSource exports a single markdown source to the output file, or
+// stdout.
+func exportSource(arg string) error {
+	src, err := resolveSource(arg)
+	if err != nil {
+		return err
+	}
+	defer src.Reader.Close() //nolint:errcheck
+	b, err := io.ReadAll(src.Reader)
+	if err != nil {
+		return err
+	}
+	b = markup.ToMarkdown(src.URL, b)
+	if dir := localDir(src); dir != "" {
+		md, _, err := utils.ResolveIncludes(string(b), dir)
+		if err != nil {
+			return err
+		}
+		b = []byte(md)
+	}
+
+	write := func(w io.Writer) error {
+		if !exportSelfContained {
+			return export.Document(w, b, filepath.Base(src.URL), exportFormat)
+		}
+		missing, err := export.SelfContained(w, b, filepath.Base(src.URL), localDir(src))
+		for _, m := range missing {
+			fmt.Fprintf(os.Stderr, "%s:%d: image %s couldn't be embedded: %s\n", m.Document, m.Line, m.Link, m.Reason)
+		}
+		return err
+	}
+
+	if exportOutput == "" {
+		return write(os.Stdout)
+	}
+	f, err := os.Create(exportOutput)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// exportTree exports a directory of markdown files to the output directory.
+func exportTree(dir string) error {
+	if dir == "-" {
+		dir = "."
+	}
+	if exportOutput == "" {
+		return fmt.Errorf("exporting a directory requires an output directory, set one with -o")
+	}
+	l := startLoading("Exporting " + dir)
+	report, err := export.Tree(dir, exportOutput, exportFormat)
+	l.stop()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Exported %d documents and %d files to %s\n", report.Documents, report.Assets, exportOutput)
+	for _, u := range report.Unresolved {
+		fmt.Fprintf(os.Stderr, "%s:%d: link to %s doesn't resolve: %s\n", filepath.Join(dir, filepath.FromSlash(u.Document)), u.Line, u.Link, u.Reason)
+	}
+	return nil
+}
+
+func init() {
+	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "output file, or directory with --all")
+	exportCmd.Flags().BoolVar(&exportAll, "all", false, "export all markdown files below DIR")
+	exportCmd.Flags().StringVarP(&exportFormat, "format", "f", export.FormatHTML, "export format: "+strings.Join(export.Formats, " or "))
+	exportCmd.Flags().BoolVar(&exportSelfContained, "self-contained", false, "make a single HTML file, with code highlighted and images embedded, to attach to tickets and emails")
+}
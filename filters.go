@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/glow/v2/source"
+)
+
+// Built-in output filters.
+const (
+	filterUppercaseHeadings = "uppercase-headings"
+	filterStripEmoji        = "strip-emoji"
+)
+
+// outputFilters post-process rendered output in CLI mode, in order. They're
+// set up from the outputFilters config key.
+var outputFilters []outputFilter
+
+// filterInput is what a filter knows about the output it's given.
+type filterInput struct {
+	src *source.Source
+
+	// the source line each line of output was rendered from, if the filter
+	// needs lines
+	lines []int
+
+	// the source lines of headings
+	headings map[int]bool
+}
+
+// outputFilter turns rendered output into the output glow writes: a built-in
+// one, or a command that reads the output on stdin and writes the filtered
+// output to stdout.
+type outputFilter struct {
+	name       string
+	needsLines bool
+	apply      func(out string, in filterInput) (string, error)
+}
+
+// newOutputFilters sets up the filters configured under outputFilters.
+// Anything that isn't a built-in filter is run as a command, with its
+// arguments split on spaces; there's no shell involved. Commands have to be
+// allowed by the command policy.
+func newOutputFilters(specs []string) ([]outputFilter, error) {
+	var filters []outputFilter
+	for _, spec := range specs {
+		switch spec {
+		case filterUppercaseHeadings:
+			filters = append(filters, outputFilter{spec, true, uppercaseHeadings})
+		case filterStripEmoji:
+			filters = append(filters, outputFilter{spec, false, func(out string, _ filterInput) (string, error) {
+				return stripEmoji(out), nil
+			}})
+		default:
+			args := strings.Fields(spec)
+			if len(args) == 0 {
+				return nil, fmt.Errorf("output filter %q: no command given", spec)
+			}
+			if err := commands.Check(args[0]); err != nil {
+				return nil, fmt.Errorf("output filter %q: %w", spec, err)
+			}
+			filters = append(filters, outputFilter{spec, false, commandFilter(args)})
+		}
+	}
+	return filters, nil
+}
+
+// filtersNeedLines reports whether any of the filters needs to know which
+// source lines the output was rendered from.
+func filtersNeedLines(filters []outputFilter) bool {
+	for _, f := range filters {
+		if f.needsLines {
+			return true
+		}
+	}
+	return false
+}
+
+// applyOutputFilters runs rendered output through the filters. Once a filter
+// adds or removes lines, the output can't be mapped to source lines anymore,
+// which is an error if a line map was requested.
+func applyOutputFilters(filters []outputFilter, out string, in filterInput) (string, []int, error) {
+	for _, f := range filters {
+		s, err := f.apply(out, in)
+		if err != nil {
+			return "", nil, fmt.Errorf("output filter %q: %w", f.name, err)
+		}
+		if in.lines != nil && strings.Count(s, "\n") != len(in.lines) {
+			if lineMapPath != "" {
+				return "", nil, fmt.Errorf("output filter %q changed the number of lines, so there can't be a line map", f.name)
+			}
+			in.lines = nil
+		}
+		out = s
+	}
+	return out, in.lines, nil
+}
+
+// commandFilter runs output through a command, within the limits of the
+// command policy. The command can tell which source it's filtering by
+// GLOW_SOURCE.
+func commandFilter(args []string) func(string, filterInput) (string, error) {
+	return func(out string, in filterInput) (string, error) {
+		return commands.Run(args, out, "GLOW_SOURCE="+in.src.URL)
+	}
+}
+
+// escapePattern matches ANSI escape sequences: CSI sequences, such as colors,
+// and OSC sequences, such as hyperlinks.
+var escapePattern = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)`)
+
+// uppercaseHeadings uppercases the text of the lines rendered from headings,
+// leaving escape sequences alone.
+func uppercaseHeadings(out string, in filterInput) (string, error) {
+	if in.lines == nil || len(in.headings) == 0 {
+		return out, nil
+	}
+	lines := strings.Split(out, "\n")
+	for i, l := range in.lines {
+		if in.headings[l] {
+			lines[i] = mapPrintable(lines[i], strings.ToUpper)
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// mapPrintable applies f to the text between the escape sequences of s.
+func mapPrintable(s string, f func(string) string) string {
+	var b strings.Builder
+	prev := 0
+	for _, loc := range escapePattern.FindAllStringIndex(s, -1) {
+		b.WriteString(f(s[prev:loc[0]]))
+		b.WriteString(s[loc[0]:loc[1]])
+		prev = loc[1]
+	}
+	b.WriteString(f(s[prev:]))
+	return b.String()
+}
+
+// emojiPresentation is the variation selector that has the symbol before it
+// shown as an emoji.
+const emojiPresentation = 0xfe0f
+
+// emoji are the ranges of runes strip-emoji removes: pictographs, symbols
+// shown as emoji, flags, and the modifiers and joiners emoji are built from.
+var emoji = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x200d, Hi: 0x200d, Stride: 1},
+		{Lo: 0x20e3, Hi: 0x20e3, Stride: 1},
+		{Lo: 0x231a, Hi: 0x231b, Stride: 1},
+		{Lo: 0x23e9, Hi: 0x23ec, Stride: 1},
+		{Lo: 0x23f0, Hi: 0x23f0, Stride: 1},
+		{Lo: 0x23f3, Hi: 0x23f3, Stride: 1},
+		{Lo: 0x2600, Hi: 0x26ff, Stride: 1},
+		{Lo: 0x2705, Hi: 0x2705, Stride: 1},
+		{Lo: 0x270a, Hi: 0x270b, Stride: 1},
+		{Lo: 0x2728, Hi: 0x2728, Stride: 1},
+		{Lo: 0x274c, Hi: 0x274c, Stride: 1},
+		{Lo: 0x274e, Hi: 0x274e, Stride: 1},
+		{Lo: 0x2753, Hi: 0x2755, Stride: 1},
+		{Lo: 0x2757, Hi: 0x2757, Stride: 1},
+		{Lo: 0x2795, Hi: 0x2797, Stride: 1},
+		{Lo: 0x27b0, Hi: 0x27b0, Stride: 1},
+		{Lo: 0x27bf, Hi: 0x27bf, Stride: 1},
+		{Lo: 0x2b1b, Hi: 0x2b1c, Stride: 1},
+		{Lo: 0x2b50, Hi: 0x2b50, Stride: 1},
+		{Lo: 0x2b55, Hi: 0x2b55, Stride: 1},
+		{Lo: emojiPresentation, Hi: emojiPresentation, Stride: 1},
+	},
+	R32: []unicode.Range32{
+		{Lo: 0x1f000, Hi: 0x1faff, Stride: 1},
+		{Lo: 0xe0020, Hi: 0xe007f, Stride: 1},
+	},
+}
+
+// stripEmoji removes emoji, along with a space following one, so that
+// "🎉 Released" becomes "Released". Symbols that are only shown as emoji
+// when followed by a variation selector, such as ❤️, are removed with it.
+func stripEmoji(s string) string {
+	var b strings.Builder
+	stripped := false
+	runes := []rune(s)
+	for i, r := range runes {
+		if unicode.Is(emoji, r) || i+1 < len(runes) && runes[i+1] == emojiPresentation {
+			stripped = true
+			continue
+		}
+		if stripped && r == ' ' {
+			stripped = false
+			continue
+		}
+		stripped = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/glow/v2/source"
+	"github.com/charmbracelet/glow/v2/utils"
+)
+
+func TestUppercaseHeadings(t *testing.T) {
+	out := "\n\x1b[1m## Intro\x1b[0m\n\nsome \x1b]8;;http://x\x07text\x1b]8;;\x07\n"
+	in := filterInput{
+		src:      &source.Source{},
+		lines:    []int{1, 1, 3, 3},
+		headings: map[int]bool{1: true},
+	}
+	got, err := uppercaseHeadings(out, in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "\n\x1b[1m## INTRO\x1b[0m\n\nsome \x1b]8;;http://x\x07text\x1b]8;;\x07\n"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestStripEmoji(t *testing.T) {
+	for in, want := range map[string]string{
+		"🎉 Released":            "Released",
+		"ship it 🚀":             "ship it ",
+		"👍🏽 and ❤️ done":        "and done",
+		"flags 🇩🇪 too":          "flags too",
+		"[✓] tasks stay ticked": "[✓] tasks stay ticked",
+	} {
+		if got := stripEmoji(in); got != want {
+			t.Errorf("stripEmoji(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestApplyOutputFilters(t *testing.T) {
+	defer func(path string, p utils.CommandPolicy) { lineMapPath, commands = path, p }(lineMapPath, commands)
+	commands = utils.CommandPolicy{Allow: []string{"sed"}, Timeout: time.Minute}
+
+	filters, err := newOutputFilters([]string{"strip-emoji", "sed -e $d"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := filterInput{src: &source.Source{}, lines: []int{1, 2}}
+
+	lineMapPath = ""
+	out, lines, err := applyOutputFilters(filters, "🎉 a\nb\n", in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "a\n" || lines != nil {
+		t.Errorf("got %q and %v, want %q and no lines", out, lines, "a\n")
+	}
+
+	lineMapPath = "-"
+	if _, _, err := applyOutputFilters(filters, "🎉 a\nb\n", in); err == nil {
+		t.Error("expected an error for a filter that removes lines while mapping lines")
+	}
+
+	if _, err := newOutputFilters([]string{"no-such-glow-filter"}); err == nil {
+		t.Error("expected an error for a missing command")
+	}
+	if _, err := newOutputFilters([]string{"cat"}); err == nil {
+		t.Error("expected an error for a command that isn't allowed")
+	}
+}
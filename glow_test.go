@@ -1,7 +1,17 @@
 package main
 
 import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
+
+	"github.com/charmbracelet/glow/v2/source"
+	"github.com/spf13/viper"
 )
 
 func TestGlowFlags(t *testing.T) {
@@ -12,7 +22,13 @@ func TestGlowFlags(t *testing.T) {
 		{
 			args: []string{"-p"},
 			check: func() bool {
-				return pager
+				return pager == pagerOn
+			},
+		},
+		{
+			args: []string{"--pager=auto"},
+			check: func() bool {
+				return pager == pagerAuto
 			},
 		},
 		{
@@ -24,7 +40,7 @@ func TestGlowFlags(t *testing.T) {
 		{
 			args: []string{"-w", "40"},
 			check: func() bool {
-				return width == 40
+				return widthFlag.columns() == 40
 			},
 		},
 	}
@@ -39,3 +55,112 @@ func TestGlowFlags(t *testing.T) {
 		}
 	}
 }
+
+// TestPagerFlagValue checks that --pager only takes a value attached with =,
+// so a document after -p or --pager stays a document.
+func TestPagerFlagValue(t *testing.T) {
+	defer func() { pager = pagerOff }()
+	for _, tc := range []struct {
+		args []string
+		mode pagerMode
+		rest []string
+	}{
+		{[]string{"--pager=auto", "README.md"}, pagerAuto, []string{"README.md"}},
+		{[]string{"-p=auto", "README.md"}, pagerAuto, []string{"README.md"}},
+		{[]string{"--pager=false", "README.md"}, pagerOff, []string{"README.md"}},
+		{[]string{"-p", "README.md"}, pagerOn, []string{"README.md"}},
+		{[]string{"--pager", "auto"}, pagerOn, []string{"auto"}},
+	} {
+		pager = pagerOff
+		fs := rootCmd.Flags()
+		if err := fs.Parse(tc.args); err != nil {
+			t.Fatal(err)
+		}
+		if pager != tc.mode || !slices.Equal(fs.Args(), tc.rest) {
+			t.Errorf("%q: got pager %s and arguments %q, want %s and %q", tc.args, pager, fs.Args(), tc.mode, tc.rest)
+		}
+	}
+}
+
+func TestSplitStdin(t *testing.T) {
+	delimiter = "%%"
+	defer func() { delimiter = "" }()
+
+	for in, want := range map[string][]string{
+		"# One\n%%\n# Two\n%%\n": {"stdin 1", "# One\n", "stdin 2", "# Two\n"},
+		"# Only\n":               {"-", "# Only\n"},
+		"%%\n\n%%\n":             nil,
+	} {
+		var got []string
+		src := &source.Source{Reader: io.NopCloser(strings.NewReader(in))}
+		err := splitStdin(src, func(name string, src *source.Source) error {
+			b, err := io.ReadAll(src.Reader)
+			got = append(got, name, string(b))
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("%q: got %q, want %q", in, got, want)
+		}
+	}
+}
+
+// pipeRender renders markdown piped to glow, as in echo "# hi" | glow, and
+// returns the output.
+func pipeRender(tb testing.TB, md string) string {
+	tb.Helper()
+	dir := tb.TempDir()
+	in, err := os.CreateTemp(dir, "in")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if _, err := in.WriteString(md); err != nil {
+		tb.Fatal(err)
+	}
+	if _, err := in.Seek(0, io.SeekStart); err != nil {
+		tb.Fatal(err)
+	}
+	out, err := os.CreateTemp(dir, "out")
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	stdin, stdout := os.Stdin, os.Stdout
+	os.Stdin, os.Stdout = in, out
+	rootCmd.SetArgs([]string{"--pager=false", "--style", "notty", "--width", "40"})
+	err = rootCmd.Execute()
+	os.Stdin, os.Stdout = stdin, stdout
+	if err != nil {
+		tb.Fatal(err)
+	}
+	b, err := os.ReadFile(out.Name())
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestPipeSkipsTUISetup(t *testing.T) {
+	defer func(c, n string) { configFile, newConfigFile = c, n }(configFile, newConfigFile)
+	configFile, newConfigFile = "", filepath.Join(t.TempDir(), "glow.yml")
+	// a setting only the TUI reads, which would fail it
+	viper.Set("keyProfile", "nonsense")
+	defer viper.Set("keyProfile", nil)
+
+	if out := pipeRender(t, "# hi\n"); !strings.Contains(out, "hi") {
+		t.Errorf("got %q, want the rendered document", out)
+	}
+	if _, err := os.Stat(newConfigFile); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("piping through glow created the config file: %v", err)
+	}
+}
+
+// BenchmarkPipeRender measures what glow does for piped input on top of
+// starting up, which is mostly initializing the syntax highlighter.
+func BenchmarkPipeRender(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		pipeRender(b, "# hi\n")
+	}
+}
@@ -0,0 +1,271 @@
+// Package graph builds the graph of links between the documents below a
+// directory, wiki-links included, and writes it out for visualization.
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/glow/v2/markup"
+	"github.com/charmbracelet/glow/v2/source"
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/text"
+)
+
+// Output formats. DOT is the default.
+const (
+	FormatDOT     = "dot"
+	FormatJSON    = "json"
+	FormatMermaid = "mermaid"
+)
+
+// Formats lists the supported output formats.
+var Formats = []string{FormatDOT, FormatJSON, FormatMermaid}
+
+var (
+	// [[Page]], [[dir/Page#Heading]] or [[Page|shown text]]
+	wikiLinkPattern = regexp.MustCompile(`\[\[([^\]|#]+)(?:#[^\]|]*)?(?:\|[^\]]*)?\]\]`)
+	codeSpanPattern = regexp.MustCompile("`+[^`]*`+")
+)
+
+// Graph is the documents below a directory and the links between them.
+// Documents are identified by their slash-separated path relative to the
+// directory.
+type Graph struct {
+	Documents []string `json:"documents"`
+	Links     []Link   `json:"links"`
+}
+
+// Link is a link from one document to another.
+type Link struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Build scans the documents below root and the links between them. Links to
+// files that aren't documents, to other sites and within a document are
+// left out, as are hidden directories.
+func Build(root string) (*Graph, error) {
+	g := &Graph{}
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && p != root && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		// files without an extension count as markdown when named, not here
+		if !d.IsDir() && filepath.Ext(p) != "" && utils.IsMarkdownFile(p) {
+			rel, err := filepath.Rel(root, p)
+			if err != nil {
+				return err
+			}
+			g.Documents = append(g.Documents, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(g.Documents)
+
+	docs := make(map[string]bool, len(g.Documents))
+	for _, d := range g.Documents {
+		docs[d] = true
+	}
+
+	seen := make(map[Link]bool)
+	for _, doc := range g.Documents {
+		b, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(doc)))
+		if err != nil {
+			return nil, err
+		}
+		b = markup.ToMarkdown(doc, b)
+
+		var targets []string
+		for _, dest := range linkDestinations(b) {
+			targets = append(targets, resolveLink(doc, dest, docs))
+		}
+		for _, name := range wikiLinks(b) {
+			targets = append(targets, resolveWikiLink(doc, name, g.Documents))
+		}
+		for _, t := range targets {
+			l := Link{doc, t}
+			if t == "" || t == doc || seen[l] {
+				continue
+			}
+			seen[l] = true
+			g.Links = append(g.Links, l)
+		}
+	}
+	return g, nil
+}
+
+// Orphans returns the documents no other document links to.
+func (g *Graph) Orphans() []string {
+	linked := make(map[string]bool)
+	for _, l := range g.Links {
+		linked[l.To] = true
+	}
+	var orphans []string
+	for _, d := range g.Documents {
+		if !linked[d] {
+			orphans = append(orphans, d)
+		}
+	}
+	return orphans
+}
+
+// Write writes the graph in one of the supported formats. Orphaned
+// documents are drawn dashed, or listed separately in JSON.
+func (g *Graph) Write(w io.Writer, format string) error {
+	orphans := make(map[string]bool)
+	for _, o := range g.Orphans() {
+		orphans[o] = true
+	}
+
+	switch format {
+	case FormatDOT:
+		fmt.Fprintln(w, "digraph docs {")
+		fmt.Fprintln(w, "  rankdir=LR;")
+		fmt.Fprintln(w, "  node [shape=box];")
+		for _, d := range g.Documents {
+			if orphans[d] {
+				fmt.Fprintf(w, "  %q [style=dashed];\n", d)
+			} else {
+				fmt.Fprintf(w, "  %q;\n", d)
+			}
+		}
+		for _, l := range g.Links {
+			fmt.Fprintf(w, "  %q -> %q;\n", l.From, l.To)
+		}
+		_, err := fmt.Fprintln(w, "}")
+		return err
+
+	case FormatMermaid:
+		ids := make(map[string]string, len(g.Documents))
+		fmt.Fprintln(w, "graph LR")
+		for i, d := range g.Documents {
+			ids[d] = fmt.Sprintf("d%d", i)
+			fmt.Fprintf(w, "  %s[%q]\n", ids[d], d)
+		}
+		for _, l := range g.Links {
+			fmt.Fprintf(w, "  %s --> %s\n", ids[l.From], ids[l.To])
+		}
+		for _, o := range g.Orphans() {
+			fmt.Fprintf(w, "  style %s stroke-dasharray: 5 5\n", ids[o])
+		}
+		return nil
+
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(struct {
+			*Graph
+			Orphans []string `json:"orphans"`
+		}{g, g.Orphans()})
+
+	default:
+		return fmt.Errorf("unsupported graph format %q: must be one of %s", format, strings.Join(Formats, ", "))
+	}
+}
+
+// linkDestinations returns the destinations of the links in a document.
+func linkDestinations(md []byte) []string {
+	md = utils.RemoveFrontmatter(md)
+	doc := goldmark.New(goldmark.WithExtensions(extension.GFM)).Parser().Parse(text.NewReader(md))
+
+	var dests []string
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if l, ok := n.(*ast.Link); ok && entering {
+			dests = append(dests, string(l.Destination))
+		}
+		return ast.WalkContinue, nil
+	})
+	return dests
+}
+
+// wikiLinks returns the page names of the wiki-links in a document, leaving
+// out code.
+func wikiLinks(md []byte) []string {
+	var (
+		names []string
+		fence utils.CodeFence
+	)
+	for _, l := range strings.Split(string(md), "\n") {
+		if fence.Scan(l) {
+			continue
+		}
+		l = codeSpanPattern.ReplaceAllString(l, "")
+		for _, m := range wikiLinkPattern.FindAllStringSubmatch(l, -1) {
+			names = append(names, strings.TrimSpace(m[1]))
+		}
+	}
+	return names
+}
+
+// resolveLink returns the document a link destination points at, or "".
+// Links to a directory point at its README or index, if it has one.
+func resolveLink(from, dest string, docs map[string]bool) string {
+	u, err := url.Parse(dest)
+	if err != nil || u.Scheme != "" || u.Host != "" || u.Path == "" {
+		return ""
+	}
+	p := u.Path
+	if !path.IsAbs(p) {
+		p = path.Join(path.Dir(from), p)
+	}
+	p = strings.TrimPrefix(path.Clean(p), "/")
+	if docs[p] {
+		return p
+	}
+	for _, name := range append([]string{"index.md"}, source.ReadmeNames...) {
+		if d := path.Join(p, name); docs[d] {
+			return d
+		}
+	}
+	return ""
+}
+
+// resolveWikiLink returns the document a wiki-link names, or "". Names are
+// matched against paths, or else file names, without extension and ignoring
+// case. Among documents with the same name, the closest to the linking one
+// wins.
+func resolveWikiLink(from, name string, docs []string) string {
+	name = strings.ToLower(strings.TrimSuffix(name, path.Ext(name)))
+	var best string
+	for _, d := range docs {
+		stem := strings.ToLower(strings.TrimSuffix(d, path.Ext(d)))
+		switch {
+		case stem == name:
+			return d
+		case path.Base(stem) == name && !strings.Contains(name, "/"):
+			if best == "" || distance(from, d) < distance(from, best) {
+				best = d
+			}
+		}
+	}
+	return best
+}
+
+// distance counts the directories between two documents.
+func distance(a, b string) int {
+	da, db := strings.Split(path.Dir(a), "/"), strings.Split(path.Dir(b), "/")
+	i := 0
+	for i < len(da) && i < len(db) && da[i] == db[i] {
+		i++
+	}
+	return len(da) - i + len(db) - i
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/glow/v2/graph"
+	"github.com/spf13/cobra"
+)
+
+var (
+	graphOutput string
+	graphFormat string
+
+	graphCmd = &cobra.Command{
+		Use:     "graph [DIR]",
+		Short:   "Export the links between documents as a graph",
+		Long:    paragraph(fmt.Sprintf("\n%s the links between the markdown documents below DIR, wiki-links included, as a Graphviz, JSON or mermaid graph. Documents no other document links to are listed as orphans.", keyword("Graph"))),
+		Example: paragraph("glow graph docs | dot -Tsvg > docs.svg\nglow graph --format mermaid -o docs.mmd"),
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			dir := "."
+			if len(args) > 0 {
+				dir = args[0]
+			}
+			return writeGraph(dir)
+		},
+	}
+)
+
+// writeGraph writes the link graph of a directory to the output file, or
+// stdout, and lists orphaned documents on stderr.
+func writeGraph(dir string) error {
+	if !slices.Contains(graph.Formats, graphFormat) {
+		return fmt.Errorf("unsupported graph format %q: must be one of %s", graphFormat, strings.Join(graph.Formats, ", "))
+	}
+	l := startLoading("Scanning " + dir)
+	g, err := graph.Build(dir)
+	l.stop()
+	if err != nil {
+		return err
+	}
+
+	if graphOutput == "" {
+		err = g.Write(os.Stdout, graphFormat)
+	} else {
+		var f *os.File
+		if f, err = os.Create(graphOutput); err != nil {
+			return err
+		}
+		if err = g.Write(f, graphFormat); err != nil {
+			_ = f.Close()
+			return err
+		}
+		err = f.Close()
+	}
+	if err != nil {
+		return err
+	}
+
+	if orphans := g.Orphans(); len(orphans) > 0 {
+		fmt.Fprintf(os.Stderr, "%d of %d documents have no links to them:\n", len(orphans), len(g.Documents))
+		for _, o := range orphans {
+			fmt.Fprintln(os.Stderr, "  "+o)
+		}
+	}
+	return nil
+}
+
+func init() {
+	graphCmd.Flags().StringVarP(&graphOutput, "output", "o", "", "output file")
+	graphCmd.Flags().StringVarP(&graphFormat, "format", "f", graph.FormatDOT, "graph format: "+strings.Join(graph.Formats, ", "))
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/glow/v2/source"
+	"github.com/charmbracelet/glow/v2/utils"
+)
+
+func TestIncludes(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		t.Helper()
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	doc := write("doc.md", "# Guide\n<!-- include: parts/install.md -->\n```\n<!-- include: parts/install.md -->\n```\nend\n")
+	write("parts/install.md", "---\ntitle: x\n---\n## Install\n<!-- include: ../note.md -->")
+	write("note.md", "run it")
+
+	b, _ := os.ReadFile(doc)
+	got, includes, err := utils.ResolveIncludes(string(b), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# Guide\n## Install\nrun it\n```\n<!-- include: parts/install.md -->\n```\nend\n"
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+	for l, want := range map[int]int{1: 1, 2: 2, 3: 2, 4: 3, 7: 6} {
+		if got := includes.Line(l); got != want {
+			t.Errorf("line %d: got %d, want %d", l, got, want)
+		}
+	}
+
+	style, width = "notty", 80
+	out, _, err := renderCLI(&source.Source{URL: doc}, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "run it") {
+		t.Errorf("included text not rendered:\n%s", out)
+	}
+
+	// a cycle, and includes nested too deep
+	write("a.md", "<!-- include: b.md -->\n")
+	write("b.md", "<!-- include: a.md -->\n")
+	if _, _, err := utils.ResolveIncludes("<!-- include: a.md -->", dir); err == nil || !strings.Contains(err.Error(), "a.md -> b.md -> a.md") {
+		t.Errorf("expected a cycle error, got %v", err)
+	}
+	for i := 0; i <= utils.MaxIncludeDepth; i++ {
+		write(filepath.Join("deep", string(rune('a'+i))+".md"), "<!-- include: "+string(rune('b'+i))+".md -->\n")
+	}
+	if _, _, err := utils.ResolveIncludes("<!-- include: deep/a.md -->", dir); err == nil || !strings.Contains(err.Error(), "nested") {
+		t.Errorf("expected a depth error, got %v", err)
+	}
+	if _, _, err := utils.ResolveIncludes("<!-- include: missing.md -->", dir); err == nil {
+		t.Error("expected an error for a missing include")
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/glow/v2/export"
+	"github.com/charmbracelet/glow/v2/markup"
+	"github.com/charmbracelet/glow/v2/ui"
+	"github.com/muesli/gitcha"
+	"github.com/spf13/cobra"
+)
+
+var (
+	listNull bool
+
+	listCmd = &cobra.Command{
+		Use:     "list [DIR]",
+		Hidden:  true,
+		Short:   "List the markdown documents below a directory, with their titles",
+		Long:    paragraph(fmt.Sprintf("\n%s the markdown documents glow finds below DIR, one per line as the path and title separated by a tab. With --null, entries end in a NUL byte instead, for fuzzy pickers like fzf --read0.", keyword("List"))),
+		Example: paragraph("glow list --null docs | fzf --read0 -d '\\t' --with-nth 2 | cut -f1 | xargs glow"),
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			dir := "."
+			if len(args) > 0 {
+				dir = args[0]
+			}
+			return listDocuments(dir)
+		},
+	}
+)
+
+// listEntry is a markdown document found below a directory.
+type listEntry struct {
+	Path  string `json:"path"`
+	Title string `json:"title"`
+}
+
+// listDocuments writes the paths and titles of the documents below dir to
+// stdout, skipping the same files as the TUI does.
+func listDocuments(dir string) error {
+	l := startLoading("Scanning " + dir)
+	entries, err := findDocuments(dir)
+	l.stop()
+	if err != nil {
+		return err
+	}
+
+	end := byte('\n')
+	if listNull {
+		end = 0
+	}
+	w := bufio.NewWriter(os.Stdout)
+	for _, e := range entries {
+		fmt.Fprintf(w, "%s\t%s%c", e.Path, e.Title, end)
+	}
+	return w.Flush()
+}
+
+// findDocuments returns the documents below dir sorted by path, with paths
+// relative to dir as given. Titles are kept on a single line.
+func findDocuments(dir string) ([]listEntry, error) {
+	root, err := filepath.Abs(dir)
+	if err == nil {
+		root, err = filepath.EvalSymlinks(root)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var ch chan gitcha.SearchResult
+	if showAllFiles {
+		ch, err = gitcha.FindAllFilesExcept(root, ui.MarkdownExtensions, nil)
+	} else {
+		ch, err = gitcha.FindFilesExcept(root, ui.MarkdownExtensions, []string{"node_modules", ".*"})
+	}
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for res := range ch {
+		rel, err := filepath.Rel(root, res.Path)
+		if err != nil {
+			continue
+		}
+		paths = append(paths, filepath.Join(dir, rel))
+	}
+	sort.Strings(paths)
+
+	entries := make([]listEntry, 0, len(paths))
+	for _, p := range paths {
+		b, err := os.ReadFile(p)
+		if err != nil {
+			continue
+		}
+		title := export.Title(markup.ToMarkdown(p, b), filepath.Base(p))
+		// keep titles from breaking up entries
+		title = strings.Join(strings.Fields(title), " ")
+		entries = append(entries, listEntry{p, title})
+	}
+	return entries, nil
+}
+
+func init() {
+	listCmd.Flags().BoolVarP(&listNull, "null", "0", false, "end entries with a NUL byte rather than a newline")
+	listCmd.Flags().BoolVar(&listNull, "print0", false, "same as --null")
+	_ = listCmd.Flags().MarkHidden("print0")
+}
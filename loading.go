@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/glow/v2/source"
+)
+
+const (
+	// how long something may take before a spinner is shown, so quick
+	// loads don't flicker
+	loadingDelay = 250 * time.Millisecond
+
+	// how long something may take before the time it's taking is shown
+	loadingElapsedAfter = 2 * time.Second
+)
+
+// showLoading is whether spinners are shown on stderr. It's only set when
+// stderr is a terminal that isn't receiving --stream-json events.
+var showLoading bool
+
+// loadingIndicator shows a spinner on stderr while something slow happens.
+// All of its methods are safe to call on a nil indicator, which is what
+// startLoading returns when spinners aren't shown.
+type loadingIndicator struct {
+	label string
+	start time.Time
+	done  chan struct{}
+	wg    sync.WaitGroup
+	once  sync.Once
+}
+
+// startLoading shows a spinner with the given label until stop is called.
+func startLoading(label string) *loadingIndicator {
+	if !showLoading {
+		return nil
+	}
+	l := &loadingIndicator{label: label, start: time.Now(), done: make(chan struct{})}
+	l.wg.Add(1)
+	go l.spin()
+	return l
+}
+
+func (l *loadingIndicator) spin() {
+	defer l.wg.Done()
+
+	select {
+	case <-l.done:
+		return
+	case <-time.After(loadingDelay):
+	}
+
+	s := spinner.MiniDot
+	tick := time.NewTicker(s.FPS)
+	defer tick.Stop()
+	for frame := 0; ; frame++ {
+		line := s.Frames[frame%len(s.Frames)] + " " + l.label
+		if elapsed := time.Since(l.start); elapsed >= loadingElapsedAfter {
+			line += fmt.Sprintf(" (%s)", elapsed.Truncate(time.Second))
+		}
+		fmt.Fprint(os.Stderr, "\r\x1b[K"+line)
+
+		select {
+		case <-l.done:
+			fmt.Fprint(os.Stderr, "\r\x1b[K")
+			return
+		case <-tick.C:
+		}
+	}
+}
+
+// stop removes the spinner, if it's been shown.
+func (l *loadingIndicator) stop() {
+	if l == nil {
+		return
+	}
+	l.once.Do(func() { close(l.done) })
+	l.wg.Wait()
+}
+
+// resolveSource resolves a source with a spinner shown until it's been read,
+// as fetching it may take a while.
+func resolveSource(arg string) (*source.Source, error) {
+	l := startLoading("Loading " + arg)
+	src, err := source.Resolve(arg)
+	if err != nil {
+		l.stop()
+		return nil, err
+	}
+	if l != nil {
+		src.Reader = &loadingReader{src.Reader, l}
+	}
+	return src, nil
+}
+
+// loadingReader stops a spinner once it's been read to the end, or closed.
+type loadingReader struct {
+	io.ReadCloser
+	loading *loadingIndicator
+}
+
+func (r *loadingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if err != nil {
+		r.loading.stop()
+	}
+	return n, err
+}
+
+func (r *loadingReader) Close() error {
+	r.loading.stop()
+	return r.ReadCloser.Close()
+}
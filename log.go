@@ -1,9 +1,9 @@
 package main
 
 import (
-	"io"
 	"os"
 	"path/filepath"
+	"sync"
 
 	"github.com/charmbracelet/log"
 	gap "github.com/muesli/go-app-paths"
@@ -18,22 +18,41 @@ func getLogFilePath() (string, error) {
 }
 
 func setupLog() (func() error, error) {
-	log.SetOutput(io.Discard)
-	// Log to file, if set
 	logFile, err := getLogFilePath()
 	if err != nil {
 		return nil, err
 	}
-	if err := os.MkdirAll(filepath.Dir(logFile), 0o755); err != nil {
-		// log disabled
-		return func() error { return nil }, nil
-	}
-	f, err := os.OpenFile(logFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
-	if err != nil {
-		// log disabled
-		return func() error { return nil }, nil
-	}
+	f := &lazyLogFile{path: logFile}
 	log.SetOutput(f)
 	log.SetLevel(log.DebugLevel)
 	return f.Close, nil
 }
+
+// lazyLogFile opens the log file the first time something is logged, so
+// runs that log nothing, such as most renders of piped input, don't touch
+// the disk for it. If it can't be opened, logging is disabled.
+type lazyLogFile struct {
+	path string
+	once sync.Once
+	f    *os.File
+}
+
+func (l *lazyLogFile) Write(p []byte) (int, error) {
+	l.once.Do(func() {
+		if err := os.MkdirAll(filepath.Dir(l.path), 0o755); err != nil {
+			return
+		}
+		l.f, _ = os.OpenFile(l.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	})
+	if l.f == nil {
+		return len(p), nil
+	}
+	return l.f.Write(p)
+}
+
+func (l *lazyLogFile) Close() error {
+	if l.f == nil {
+		return nil
+	}
+	return l.f.Close()
+}
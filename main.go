@@ -1,47 +1,84 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"net/http"
 	"net/url"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"runtime/debug"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/caarlos0/env/v11"
 	"github.com/charmbracelet/glamour"
 	"github.com/charmbracelet/glamour/styles"
+	"github.com/charmbracelet/glow/v2/bundle"
+	"github.com/charmbracelet/glow/v2/markup"
+	"github.com/charmbracelet/glow/v2/mermaid"
+	"github.com/charmbracelet/glow/v2/source"
 	"github.com/charmbracelet/glow/v2/ui"
 	"github.com/charmbracelet/glow/v2/utils"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/x/ansi"
 	gap "github.com/muesli/go-app-paths"
+	"github.com/muesli/termenv"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
-	"golang.org/x/term"
 )
 
+// mermaidOff shows mermaid diagrams as their source rather than drawing them.
+const mermaidOff = "off"
+
+var mermaidModes = []string{mermaid.Unicode, mermaid.ASCII, mermaidOff}
+
 var (
 	// Version as provided by goreleaser.
 	Version = ""
 	// CommitSHA as provided by goreleaser.
 	CommitSHA = ""
 
-	readmeNames      = []string{"README.md", "README", "Readme.md", "Readme", "readme.md", "readme"}
-	configFile       string
-	pager            bool
-	style            string
-	width            uint
-	showAllFiles     bool
-	showLineNumbers  bool
-	preserveNewLines bool
-	mouse            bool
+	configFile        string
+	newConfigFile     string // where to create a config file if there's none
+	pager             = pagerOff
+	style             string
+	width             uint
+	widthFlag         widthSetting = "0"
+	showAllFiles      bool
+	showLineNumbers   bool
+	preserveNewLines  bool
+	mouse             bool
+	delimiter         string
+	separator         bool
+	noFilename        bool
+	critic            bool
+	watch             bool
+	renderProfilePath string
+	lineMapPath       string
+	streamJSON        string
+	showSummary       bool
+	checkRenderMode   bool
+	hardened          bool
+	authToken         string
+	maxMemory         string
+	maxCodeLines      uint
+	decorations       utils.Decorations
+	noGuessLang       bool
+	mermaidMode       string
+	stream            bool
+	streamCode        bool
+	embedWarnings     bool
+	tabWidth          uint
+	centered          bool
+	termWidth         uint
 
 	rootCmd = &cobra.Command{
-		Use:   "glow [SOURCE|DIR]",
+		Use:   "glow [SOURCE...|DIR]",
 		Short: "Render markdown on the CLI, with pizzazz!",
 		Long: paragraph(
 			fmt.Sprintf("\nRender markdown on the CLI, %s!", keyword("with pizzazz")),
@@ -49,97 +86,17 @@ var (
 		SilenceErrors:    false,
 		SilenceUsage:     true,
 		TraverseChildren: true,
-		Args:             cobra.MaximumNArgs(1),
+		Args:             cobra.ArbitraryArgs,
 		ValidArgsFunction: func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
 			return nil, cobra.ShellCompDirectiveDefault
 		},
-		PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
-			return validateOptions(cmd)
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return validateOptions(cmd, args)
 		},
 		RunE: execute,
 	}
 )
 
-// source provides a readable markdown source.
-type source struct {
-	reader io.ReadCloser
-	URL    string
-}
-
-// sourceFromArg parses an argument and creates a readable source for it.
-func sourceFromArg(arg string) (*source, error) {
-	// from stdin
-	if arg == "-" {
-		return &source{reader: os.Stdin}, nil
-	}
-
-	// a GitHub or GitLab URL (even without the protocol):
-	src, err := readmeURL(arg)
-	if src != nil && err == nil {
-		// if there's an error, try next methods...
-		return src, nil
-	}
-
-	// HTTP(S) URLs:
-	if u, err := url.ParseRequestURI(arg); err == nil && strings.Contains(arg, "://") {
-		if u.Scheme != "" {
-			if u.Scheme != "http" && u.Scheme != "https" {
-				return nil, fmt.Errorf("%s is not a supported protocol", u.Scheme)
-			}
-			// consumer of the source is responsible for closing the ReadCloser.
-			resp, err := http.Get(u.String()) // nolint:bodyclose
-			if err != nil {
-				return nil, err
-			}
-			if resp.StatusCode != http.StatusOK {
-				return nil, fmt.Errorf("HTTP status %d", resp.StatusCode)
-			}
-			return &source{resp.Body, u.String()}, nil
-		}
-	}
-
-	// a directory:
-	if len(arg) == 0 {
-		// use the current working dir if no argument was supplied
-		arg = "."
-	}
-	st, err := os.Stat(arg)
-	if err == nil && st.IsDir() {
-		var src *source
-		_ = filepath.Walk(arg, func(path string, _ os.FileInfo, err error) error {
-			if err != nil {
-				return err
-			}
-			for _, v := range readmeNames {
-				if strings.EqualFold(filepath.Base(path), v) {
-					r, err := os.Open(path)
-					if err != nil {
-						continue
-					}
-
-					u, _ := filepath.Abs(path)
-					src = &source{r, u}
-
-					// abort filepath.Walk
-					return errors.New("source found")
-				}
-			}
-			return nil
-		})
-
-		if src != nil {
-			return src, nil
-		}
-
-		return nil, errors.New("missing markdown source")
-	}
-
-	// a file:
-	r, err := os.Open(arg)
-	u, _ := filepath.Abs(arg)
-	return &source{r, u}, err
-}
-
 // validateStyle checks if the style is a default style, if not, checks that
 // the custom style exists.
 func validateStyle(style string) error {
@@ -154,35 +111,168 @@ func validateStyle(style string) error {
 	return nil
 }
 
-func validateOptions(cmd *cobra.Command) error {
+// decorationsFromConfig reads the style decoration overrides from the
+// "decorations" config section.
+func decorationsFromConfig() utils.Decorations {
+	get := func(key string) *string {
+		key = "decorations." + key
+		if !viper.IsSet(key) {
+			return nil
+		}
+		v := viper.GetString(key)
+		return &v
+	}
+	return utils.Decorations{
+		HeadingPrefix: get("headingPrefix"),
+		HeadingSuffix: get("headingSuffix"),
+		Rule:          get("rule"),
+		BlockQuote:    get("blockquote"),
+	}
+}
+
+func validateOptions(cmd *cobra.Command, args []string) error {
+	if err := applyProfile(viper.GetString("profile")); err != nil {
+		return err
+	}
+	if err := applyProjectConfig(args); err != nil {
+		return err
+	}
+
 	// grab config values from Viper
-	width = viper.GetUint("width")
+	if err := widthFlag.Set(viper.GetString("width")); err != nil {
+		return err
+	}
+	width = widthFlag.columns()
 	mouse = viper.GetBool("mouse")
-	pager = viper.GetBool("pager")
+	pagerSetting, command, err := parsePagerConfig(pagerConfig())
+	if err != nil {
+		return err
+	}
+	pagerCommand = command
+	if !cmd.Flags().Changed("pager") {
+		pager = pagerSetting
+	}
 	showAllFiles = viper.GetBool("all")
 	preserveNewLines = viper.GetBool("preserveNewLines")
+	maxCodeLines = viper.GetUint("maxCodeLines")
+	noGuessLang = viper.GetBool("noGuessLang")
+	streamCode = viper.GetBool("streamCode")
+	if stream && lineMapPath != "" {
+		return errors.New("--line-map doesn't work with --stream")
+	}
+	if stream && len(onlySections) > 0 {
+		return errors.New("--only doesn't work with --stream, which renders blocks before the sections they're in end")
+	}
+	if stream && len(args) > 1 {
+		return errors.New("--stream renders a single source")
+	}
+	mermaidMode = viper.GetString("mermaid")
+	if !slices.Contains(mermaidModes, mermaidMode) {
+		return fmt.Errorf("invalid mermaid setting %q: must be one of %s", mermaidMode, strings.Join(mermaidModes, ", "))
+	}
+	embedWarnings = viper.GetBool("embedWarnings")
+	tabWidth = viper.GetUint("tabWidth")
+	centered = viper.GetBool("centered")
+	noFilename = viper.GetBool("noFilename")
+	critic = viper.GetBool("critic")
+	commands = commandPolicyFromConfig()
+	filters, err := newOutputFilters(viper.GetStringSlice("outputFilters"))
+	if err != nil {
+		return err
+	}
+	outputFilters = filters
+	if pagerEnvOverrides, err = parsePagerEnv(viper.GetStringSlice("pagerEnv")); err != nil {
+		return err
+	}
+	if hardened = viper.GetBool("hardened"); hardened {
+		source.Harden()
+	}
+	if err := source.SetAuthToken(authToken); err != nil {
+		return fmt.Errorf("invalid --auth: %w", err)
+	}
+	if err := source.SetGiteaHosts(viper.GetStringSlice("giteaHosts")); err != nil {
+		return err
+	}
+	if names := viper.GetStringSlice("readmeNames"); len(names) > 0 {
+		source.ReadmeNames = names
+	}
+	if err := markup.SetDialect(viper.GetString("dialect")); err != nil {
+		return err
+	}
+	limit, err := parseMemoryLimit(viper.GetString("maxMemory"))
+	if err != nil {
+		return err
+	}
+	if limit > 0 {
+		watchdog = newMemoryWatchdog(limit)
+	}
+
+	if showSummary {
+		summary = newRenderSummary()
+	}
+	if streamJSON != "" {
+		var err error
+		if events, err = newEventStream(streamJSON); err != nil {
+			return err
+		}
+	}
+	// spinners would garble events written to stderr
+	showLoading = utils.Term.IsTerminal(os.Stderr) && streamJSON != "2"
 
 	// validate the glamour style
 	style = viper.GetString("style")
 	if err := validateStyle(style); err != nil {
 		return err
 	}
+	decorations = decorationsFromConfig()
 
-	isTerminal := term.IsTerminal(int(os.Stdout.Fd()))
+	// decide on colors once, so the CLI and the TUI agree
+	colors := utils.DecideColor(os.Stdout)
+	lipgloss.SetColorProfile(colors.Profile)
+
+	isTerminal := utils.Term.IsTerminal(os.Stdout)
 	// We want to use a special no-TTY style, when stdout is not a terminal
-	// and there was no specific style passed by arg
-	if !isTerminal && !cmd.Flags().Changed("style") {
+	// and there was no specific style passed by arg, unless colors were
+	// forced on
+	if !isTerminal && colors.Profile == termenv.Ascii && !cmd.Flags().Changed("style") {
 		style = "notty"
 	}
+	// monochrome terminals, such as e-ink displays, can't tell the colors
+	// of the regular styles apart
+	if isTerminal && colors.Profile == termenv.Ascii && style == styles.AutoStyle {
+		style = utils.MonoStyle
+	}
+	// glamour falls back to the no-TTY style when asked to pick one for a
+	// pipe, which would drop forced colors
+	if !isTerminal && colors.Profile != termenv.Ascii && style == styles.AutoStyle {
+		style = styles.DarkStyle
+		if !utils.Term.HasDarkBackground() {
+			style = styles.LightStyle
+		}
+	}
 
 	// Detect terminal width
-	if !cmd.Flags().Changed("width") {
+	if isTerminal {
+		if w, _, err := utils.Term.Size(os.Stdout); err == nil {
+			termWidth = uint(w)
+		}
+	}
+	switch {
+	case widthFlag == widthMax || widthFlag == widthContent:
+		// the whole terminal, without the cap below; content narrows it
+		// down for each document
+		width = termWidth
+		if width == 0 {
+			width = 80
+		}
+	case !cmd.Flags().Changed("width"):
 		if isTerminal && width == 0 {
-			w, _, err := term.GetSize(int(os.Stdout.Fd()))
-			if err == nil {
-				width = uint(w)
-			}
+			width = termWidth
 
+			// centered documents get a column that's comfortable to read
+			if centered {
+				width = min(width, utils.CenteredWidth)
+			}
 			if width > 120 {
 				width = 120
 			}
@@ -205,24 +295,62 @@ func stdinIsPipe() (bool, error) {
 	return false, nil
 }
 
-func execute(cmd *cobra.Command, args []string) error {
-	// if stdin is a pipe then use stdin for input. note that you can also
-	// explicitly use a - to read from stdin.
+func execute(cmd *cobra.Command, args []string) (err error) {
+	if renderProfilePath != "" {
+		if profiler, err = newRenderProfile(renderProfilePath); err != nil {
+			return err
+		}
+		// the CPU profile is only written out once it's stopped, which has
+		// to happen when rendering fails too
+		defer func() {
+			if ferr := profiler.finish(os.Stderr); err == nil {
+				err = ferr
+			}
+		}()
+	}
+
+	args = utils.ExpandGlobs(args)
+	if checkRenderMode {
+		if len(args) == 0 {
+			if yes, _ := stdinIsPipe(); !yes {
+				return errors.New("--check-render needs documents to check")
+			}
+			args = []string{"-"}
+		}
+		return checkRender(args, os.Stdout)
+	}
+	if clientMode && !watch {
+		if ok, err := tryClient(args); ok || err != nil {
+			return err
+		}
+	}
+
+	// if stdin is a pipe then use stdin for input, unless it's placed among
+	// other sources with an explicit -.
 	if yes, err := stdinIsPipe(); err != nil {
 		return err
-	} else if yes {
-		src := &source{reader: os.Stdin}
-		defer src.reader.Close() //nolint:errcheck
+	} else if yes && !slices.Contains(args, "-") {
+		src := &source.Source{Reader: os.Stdin}
+		defer src.Reader.Close() //nolint:errcheck
 		return executeCLI(cmd, src, os.Stdout)
 	}
 
 	switch len(args) {
-	// TUI running on cwd
+	// TUI running on the configured root, or cwd
 	case 0:
-		return runTUI("")
+		return runTUI(utils.ExpandPath(viper.GetString("root")))
 
-	// TUI with possible dir argument
+	// TUI with possible dir or bundle argument
 	case 1:
+		if bundle.IsBundle(args[0]) {
+			p, err := filepath.Abs(args[0])
+			if err != nil {
+				return err
+			}
+			viper.Set("remote", p)
+			return runTUI("")
+		}
+
 		// Validate that the argument is a directory. If it's not treat it as
 		// an argument to the non-TUI version of Glow (via fallthrough).
 		info, err := os.Stat(args[0])
@@ -236,86 +364,443 @@ func execute(cmd *cobra.Command, args []string) error {
 
 	// CLI
 	default:
-		for _, arg := range args {
-			if err := executeArg(cmd, arg, os.Stdout); err != nil {
-				return err
-			}
+		if watch {
+			return watchCLI(cmd, args, os.Stdout)
 		}
+		return executeArgs(cmd, args, os.Stdout)
 	}
+}
 
-	return nil
+// executeArgs renders all sources in order, each under a header with its
+// name, and displays them as a single document. With --delimiter, stdin
+// counts as one source for each of its documents.
+func executeArgs(cmd *cobra.Command, args []string, w io.Writer) error {
+	if len(args) == 1 {
+		src, err := resolveSource(args[0])
+		if err != nil {
+			return err
+		}
+		defer src.Reader.Close() //nolint:errcheck
+		return executeCLI(cmd, src, w)
+	}
+	if lineMapPath != "" {
+		return errors.New("--line-map only works with a single source")
+	}
+
+	var (
+		out   string
+		names []string
+	)
+	render := func(name string, src *source.Source) error {
+		s, _, err := renderSource(src)
+		_ = src.Reader.Close()
+		if err != nil {
+			return err
+		}
+		out += sourceHeader(len(names), name) + s
+		names = append(names, name)
+		return nil
+	}
+	for _, arg := range args {
+		// create an io.Reader from the markdown source in cli-args
+		src, err := resolveSource(arg)
+		if err != nil {
+			return err
+		}
+		if arg == "-" && delimiter != "" {
+			err = splitStdin(src, render)
+		} else {
+			err = render(arg, src)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return display(out, names, w)
 }
 
-func executeArg(cmd *cobra.Command, arg string, w io.Writer) error {
-	// create an io.Reader from the markdown source in cli-args
-	src, err := sourceFromArg(arg)
+// splitStdin reads stdin and renders each of its documents, between lines
+// that are --delimiter, as a source of its own. Empty documents, such as
+// after a trailing delimiter, are skipped.
+func splitStdin(src *source.Source, render func(string, *source.Source) error) error {
+	b, err := io.ReadAll(src.Reader)
+	_ = src.Reader.Close()
 	if err != nil {
 		return err
 	}
-	defer src.reader.Close() //nolint:errcheck
-	return executeCLI(cmd, src, w)
+	var parts [][]byte
+	for _, part := range utils.SplitDocuments(b, delimiter) {
+		if len(bytes.TrimSpace(part)) > 0 {
+			parts = append(parts, part)
+		}
+	}
+	for i, part := range parts {
+		name := "-"
+		if len(parts) > 1 {
+			name = fmt.Sprintf("stdin %d", i+1)
+		}
+		if err := render(name, &source.Source{Reader: io.NopCloser(bytes.NewReader(part))}); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func executeCLI(cmd *cobra.Command, src *source, w io.Writer) error {
-	b, err := io.ReadAll(src.reader)
+func executeCLI(_ *cobra.Command, src *source.Source, w io.Writer) error {
+	if stream {
+		return streamCLI(src, w)
+	}
+	out, lines, err := renderSource(src)
 	if err != nil {
 		return err
 	}
+	if err := writeLineMap(src, lines); err != nil {
+		return err
+	}
+	return display(out, []string{sourceName(src)}, w)
+}
 
-	b = utils.RemoveFrontmatter(b)
-
-	// render
-	var baseURL string
-	u, err := url.ParseRequestURI(src.URL)
-	if err == nil {
-		u.Path = filepath.Dir(u.Path)
-		baseURL = u.String() + "/"
+// renderSource reads and renders a markdown source. If a line map was
+// requested, or glow api is serving, it also returns the source line each line of output was
+// rendered from, or 0 for lines glow added.
+func renderSource(src *source.Source) (string, []int, error) {
+	watchdog.rendering(src, 0)
+	stop := profiler.track("read")
+	b, err := io.ReadAll(src.Reader)
+	stop()
+	if err != nil {
+		return "", nil, err
+	}
+	events.source(src, len(b))
+	summary.read(len(b))
+	if len(bytes.TrimSpace(b)) == 0 {
+		events.warn(src, "source is empty")
 	}
 
-	isCode := !utils.IsMarkdownFile(src.URL)
+	// skip ahead to the referenced line or heading
+	line := src.Line
+	if src.Anchor != "" {
+		line = utils.AnchorLine(b, src.Anchor)
+		if line == 0 {
+			return "", nil, fmt.Errorf("no heading found for anchor #%s", src.Anchor)
+		}
+	}
+	skipped := 0
+	if line > 1 {
+		skipped = utils.FenceStart(b, line-1)
+		b = utils.SkipLines(b, skipped)
+	}
 
-	// initialize glamour
-	r, err := glamour.NewTermRenderer(
-		glamour.WithColorProfile(lipgloss.ColorProfile()),
-		utils.GlamourStyle(style, isCode),
-		glamour.WithWordWrap(int(width)),
-		glamour.WithBaseURL(baseURL),
-		glamour.WithPreservedNewLines(),
+	// render each document with a fresh renderer, so link references and
+	// footnotes don't bleed from one document into the next
+	var (
+		out   string
+		lines []int
 	)
+	for i, doc := range utils.SplitDocuments(b, delimiter) {
+		if i > 0 && separator {
+			sep := separatorView()
+			out += sep
+			lines = append(lines, make([]int, strings.Count(sep, "\n"))...)
+		}
+		watchdog.rendering(src, i+1)
+		s, docLines, err := safeRenderCLI(src, doc)
+		if err != nil {
+			return "", nil, err
+		}
+		out += s
+		events.chunk(src, i+1, len(doc), len(s))
+		for _, l := range docLines {
+			if l > 0 {
+				l += skipped
+			}
+			lines = append(lines, l)
+		}
+		// the document and the delimiter line after it
+		skipped += strings.Count(string(doc), "\n") + 1
+	}
+	return out, lines, nil
+}
+
+// writeLineMap writes the line map of a rendered source as JSON to the file
+// given by --line-map, or to stderr for "-".
+func writeLineMap(src *source.Source, lines []int) error {
+	if lineMapPath == "" {
+		return nil
+	}
+	b, err := json.Marshal(struct {
+		Source string `json:"source,omitempty"`
+		Lines  []int  `json:"lines"`
+	}{src.URL, lines})
 	if err != nil {
 		return err
 	}
+	b = append(b, '\n')
+	if lineMapPath == "-" {
+		_, err = os.Stderr.Write(b)
+		return err
+	}
+	return os.WriteFile(lineMapPath, b, 0o644) //nolint:gosec,mnd
+}
+
+// display writes rendered output of the given sources, through the pager if
+// requested.
+func display(out string, names []string, w io.Writer) error {
+	if centered {
+		out = utils.Center(out, int(width), int(termWidth))
+	}
+	summary.output(out)
+	if shouldPage(pager, out) {
+		return runPager(out, names)
+	}
+
+	defer profiler.track("output")()
+	_, err := fmt.Fprint(w, out)
+	return err
+}
+
+// renderPanicError is what a panic while rendering a document turns into, so
+// that pathological input fails its source rather than crashing glow halfway
+// through its output. It carries the document that caused it.
+type renderPanicError struct {
+	Value any
+	Chunk []byte
+}
+
+func (e *renderPanicError) Error() string {
+	excerpt, _, _ := strings.Cut(strings.TrimSpace(string(e.Chunk)), "\n")
+	if len(excerpt) > 60 { //nolint:mnd
+		excerpt = excerpt[:60] + "…"
+	}
+	return fmt.Sprintf("could not render the document starting with %q: %v", excerpt, e.Value)
+}
+
+// safeRenderCLI is renderCLI, with panics turned into errors.
+func safeRenderCLI(src *source.Source, b []byte) (out string, lines []int, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Error("panic while rendering", "source", sourceName(src), "panic", r, "stack", string(debug.Stack()))
+			out, lines, err = "", nil, &renderPanicError{r, b}
+		}
+	}()
+	return renderCLI(src, b)
+}
+
+// renderCLI renders a single markdown document from the given source, and
+// maps its lines to source lines if a line map was requested.
+func renderCLI(src *source.Source, b []byte) (string, []int, error) {
+	b = markup.ToMarkdown(src.URL, b)
+	content := utils.RemoveFrontmatter(b)
+	frontmatter := strings.Count(string(b[:len(b)-len(content)]), "\n")
+	b = content
+
+	isCode := !utils.IsMarkdownFile(src.URL)
 
 	s := string(b)
 	ext := filepath.Ext(src.URL)
+	var includes utils.IncludeMap
 	if isCode {
 		s = utils.WrapCodeBlock(string(b), ext)
+	} else {
+		// documents from the web don't get to include local files
+		if dir := localDir(src); dir != "" {
+			var err error
+			if s, includes, err = utils.ResolveIncludes(s, dir); err != nil {
+				return "", nil, err
+			}
+		}
+		if len(onlySections) > 0 {
+			var err error
+			if s, err = selectSections(s, onlySections); err != nil {
+				return "", nil, err
+			}
+		}
+		if mermaidMode != mermaidOff {
+			s = utils.RenderMermaid(s, mermaidMode, int(width))
+		}
+		if t := utils.TruncateCodeBlocks(s, int(maxCodeLines), ""); t != s {
+			events.warn(src, fmt.Sprintf("code blocks truncated to %d lines", maxCodeLines))
+			s = t
+		}
+		if !noGuessLang {
+			s = utils.GuessCodeLanguages(s)
+		}
+		s = markAlerts(s)
+		if critic {
+			s = markCritic(s)
+		}
+		if embedWarnings {
+			var n int
+			if s, n = utils.MarkEmbeds(s, localDir(src)); n > 0 {
+				events.warn(src, fmt.Sprintf("%d embeds or images can't be displayed", n))
+			}
+		}
+	}
+	tabs := cliTabWidth(src)
+	if !isCode {
+		s = utils.NormalizeListIndentation(s, tabs)
 	}
+	s = utils.ExpandTabs(s, tabs)
 
-	out, err := r.Render(s)
+	wrap := int(width)
+	if widthFlag == widthContent {
+		r, err := cliRenderer(src, isCode, 0)
+		if err != nil {
+			return "", nil, err
+		}
+		if wrap, err = contentWidth(r, s, wrap); err != nil {
+			return "", nil, err
+		}
+	}
+	r, err := cliRenderer(src, isCode, wrap)
 	if err != nil {
-		return err
+		return "", nil, err
+	}
+	if !isCode && profiler != nil {
+		profiler.profileBlocks(r, []byte(s))
+	}
+	if summary != nil {
+		if isCode {
+			summary.rendered(1)
+		} else {
+			summary.rendered(utils.CountBlocks([]byte(s)))
+		}
 	}
 
-	// display
-	if pager || cmd.Flags().Changed("pager") {
-		pagerCmd := os.Getenv("PAGER")
-		if pagerCmd == "" {
-			pagerCmd = "less -r"
+	defer profiler.track("render")()
+	var (
+		out   string
+		lines []int
+	)
+	if lineMapPath == "" && !apiMode && !filtersNeedLines(outputFilters) {
+		out, err = r.Render(s)
+	} else {
+		out, lines, err = utils.RenderLineMap(r, s)
+		for i, l := range lines {
+			if l > 0 {
+				lines[i] = includes.Line(l) + frontmatter
+			}
 		}
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	if !isCode {
+		out = renderAlerts(out, utils.BlockQuoteToken(style, decorations))
+		out = renderCritic(out)
+	}
+	if !src.Cached.IsZero() {
+		events.warn(src, "couldn't be fetched, showing the copy cached on "+src.Cached.Format(time.DateTime))
+		out, lines = cachedBanner(r, src, out, lines)
+	}
+	if len(outputFilters) > 0 {
+		headings := make(map[int]bool)
+		for _, h := range utils.Headings([]byte(s)) {
+			headings[includes.Line(h.Line)+frontmatter] = true
+		}
+		return applyOutputFilters(outputFilters, out, filterInput{src, lines, headings})
+	}
+	return out, lines, nil
+}
+
+// cachedBanner puts a note above a document rendered from a cached copy,
+// saying when it was fetched.
+func cachedBanner(r *glamour.TermRenderer, src *source.Source, out string, lines []int) (string, []int) {
+	banner, err := r.Render(fmt.Sprintf("> **Cached from %s**: the latest version couldn't be fetched.",
+		src.Cached.Format("02 Jan 2006 15:04")))
+	if err != nil {
+		return out, lines
+	}
+	banner = strings.TrimRight(banner, "\n") + "\n"
+	if lines != nil {
+		lines = append(make([]int, strings.Count(banner, "\n")), lines...)
+	}
+	return banner + out, lines
+}
 
-		pa := strings.Split(pagerCmd, " ")
-		c := exec.Command(pa[0], pa[1:]...) // nolint:gosec
-		c.Stdin = strings.NewReader(out)
-		c.Stdout = os.Stdout
-		return c.Run()
+// localDir returns the directory of a local source, for resolving relative
+// paths in it, or an empty string for other sources.
+func localDir(src *source.Source) string {
+	if src.URL == "" || strings.Contains(src.URL, "://") {
+		return ""
 	}
+	return filepath.Dir(src.URL)
+}
 
-	_, err = fmt.Fprint(w, out)
-	return err
+// cliTabWidth returns the tab width for code blocks and lists of a source: the
+// configured one, or else what .editorconfig files say for local sources.
+func cliTabWidth(src *source.Source) int {
+	if tabWidth > 0 || localDir(src) == "" {
+		return int(tabWidth)
+	}
+	return utils.EditorConfigTabWidth(src.URL)
+}
+
+// cliRenderer returns a glamour renderer configured for CLI output, word
+// wrapping at the given width.
+func cliRenderer(src *source.Source, isCode bool, wrap int) (*glamour.TermRenderer, error) {
+	defer profiler.track("setup")()
+
+	var baseURL string
+	u, err := url.ParseRequestURI(src.URL)
+	if err == nil {
+		u.Path = filepath.Dir(u.Path)
+		baseURL = u.String() + "/"
+	}
+
+	// initialize glamour
+	return glamour.NewTermRenderer(
+		glamour.WithColorProfile(lipgloss.ColorProfile()),
+		glamourStyle(style, isCode),
+		glamour.WithWordWrap(wrap),
+		glamour.WithBaseURL(baseURL),
+		glamour.WithPreservedNewLines(),
+	)
+}
+
+// separatorView returns the horizontal rule printed between documents.
+func separatorView() string {
+	w := max(0, int(width)-4)
+	return "\n" + separatorStyle.Render(strings.Repeat("─", w)) + "\n\n"
+}
+
+// filenameView returns the header printed above each of several sources: a
+// horizontal rule with the source's name in it.
+func filenameView(name string) string {
+	name = displayName(name)
+	rule := separatorStyle.UnsetPadding()
+	w := max(0, int(width)-4-4-ansi.StringWidth(name))
+	return "\n" + strings.Repeat(" ", 2) + rule.Render("── ") + filenameStyle.Render(name) +
+		rule.Render(" "+strings.Repeat("─", w)) + "\n"
+}
+
+// displayName returns the name of a source as it's shown to users.
+func displayName(name string) string {
+	if name == "-" {
+		return "stdin"
+	}
+	return name
+}
+
+// sourceHeader returns what's printed before the i-th of several sources:
+// its filename header, or with --no-filename, a horizontal rule between it
+// and the one before.
+func sourceHeader(i int, name string) string {
+	switch {
+	case !noFilename:
+		return filenameView(name)
+	case i > 0:
+		return separatorView()
+	default:
+		return ""
+	}
 }
 
 func runTUI(workingDirectory string) error {
+	if err := ensureConfigFile(); err != nil {
+		log.Error("Could not create default configuration", "error", err)
+	}
+
 	// Read environment to get debugging stuff
 	cfg, err := env.ParseAs[ui.Config]()
 	if err != nil {
@@ -333,8 +818,55 @@ func runTUI(workingDirectory string) error {
 	cfg.GlamourMaxWidth = width
 	cfg.EnableMouse = mouse
 	cfg.PreserveNewLines = preserveNewLines
+	cfg.KeyProfile = viper.GetString("keyProfile")
+	if !slices.Contains(ui.KeyProfiles, cfg.KeyProfile) {
+		return fmt.Errorf("invalid key profile %q: must be one of %s", cfg.KeyProfile, strings.Join(ui.KeyProfiles, ", "))
+	}
+	cfg.FilterMatcher = viper.GetString("filterMatcher")
+	if !slices.Contains(ui.Matchers, cfg.FilterMatcher) {
+		return fmt.Errorf("invalid filter matcher %q: must be one of %s", cfg.FilterMatcher, strings.Join(ui.Matchers, ", "))
+	}
+	cfg.Sort = viper.GetString("sort")
+	if !slices.Contains(ui.SortFields, cfg.Sort) {
+		return fmt.Errorf("invalid sort %q: must be one of %s", cfg.Sort, strings.Join(ui.SortFields, ", "))
+	}
+	cfg.Remote = viper.GetString("remote")
+	cfg.MaxCodeLines = int(maxCodeLines)
+	cfg.Decorations = decorations
+	cfg.GuessCodeLanguage = !noGuessLang
+	if mermaidMode != mermaidOff {
+		cfg.Mermaid = mermaidMode
+	}
+	cfg.EmbedWarnings = embedWarnings
+	cfg.TabWidth = int(tabWidth)
+	cfg.CodeRunners = viper.GetStringMapString("codeRunners")
+	for lang, command := range cfg.CodeRunners {
+		if strings.TrimSpace(command) == "" {
+			return fmt.Errorf("code runner for %s: no command given", lang)
+		}
+	}
+	cfg.Commands = commands
+	cfg.StatusMessageDuration = viper.GetDuration("statusMessageDuration")
+	cfg.RefreshInterval = viper.GetDuration("refreshInterval")
+	cfg.ConfirmQuitWhileStreaming = viper.GetBool("confirmQuitWhileStreaming")
+	cfg.SnapScroll = viper.GetBool("snapScroll")
+	cfg.Centered = centered
+	cfg.Watch = watch
+	cfg.Notify = viper.GetString("notify")
+	if !slices.Contains(ui.Notifies, cfg.Notify) {
+		return fmt.Errorf("invalid notify setting %q: must be one of %s", cfg.Notify, strings.Join(ui.Notifies, ", "))
+	}
+	cfg.GitMetadata = viper.GetBool("gitMetadata")
+	cfg.DateFormat = viper.GetString("dateFormat")
+	cfg.Timezone = viper.GetString("timezone")
+	if _, err := time.LoadLocation(cfg.Timezone); err != nil {
+		return fmt.Errorf("invalid timezone %q: %w", cfg.Timezone, err)
+	}
+	cfg.Profile = configProfile
+	cfg.OnPanic = handleCrash
 
 	// Run Bubble Tea program
+	saveTerminalState()
 	if _, err := ui.NewProgram(cfg).Run(); err != nil {
 		return err
 	}
@@ -343,12 +875,25 @@ func runTUI(workingDirectory string) error {
 }
 
 func main() {
+	defer func() {
+		if r := recover(); r != nil {
+			handleCrash(r, debug.Stack())
+		}
+	}()
+
 	closer, err := setupLog()
 	if err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
-	if err := rootCmd.Execute(); err != nil {
+	if utils.Term, err = utils.TerminalFromEnv(os.Getenv); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	err = rootCmd.Execute()
+	events.finish(err)
+	summary.print(os.Stderr)
+	if err != nil {
 		_ = closer()
 		os.Exit(1)
 	}
@@ -368,15 +913,49 @@ func init() {
 	rootCmd.InitDefaultCompletionCmd()
 
 	// "Glow Classic" cli arguments
-	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", fmt.Sprintf("config file (default %s)", viper.GetViper().ConfigFileUsed()))
-	rootCmd.Flags().BoolVarP(&pager, "pager", "p", false, "display with pager")
+	defaultConfigFile := viper.GetViper().ConfigFileUsed()
+	if defaultConfigFile == "" {
+		defaultConfigFile = newConfigFile
+	}
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", fmt.Sprintf("config file (default %s)", defaultConfigFile))
+	rootCmd.PersistentFlags().String("profile", "", "config profile to use, from the profiles section of the config file")
+	rootCmd.PersistentFlags().StringVar(&authToken, "auth", "", "tokens to fetch READMEs of private repositories with, as service=token pairs for github, gitlab, codeberg or gitea (default $GITHUB_TOKEN, $GLAB_TOKEN, …)")
+	rootCmd.Flags().VarP(&pager, "pager", "p", "display with pager (true, false or auto, attached as in --pager=auto)")
+	rootCmd.Flags().Lookup("pager").NoOptDefVal = string(pagerOn)
 	rootCmd.Flags().StringVarP(&style, "style", "s", styles.AutoStyle, "style name or JSON path")
-	rootCmd.Flags().UintVarP(&width, "width", "w", 0, "word-wrap at width (set to 0 to disable)")
+	rootCmd.Flags().VarP(&widthFlag, "width", "w", "word-wrap at width (set to 0 to disable), the full terminal width (max) or the longest line (content)")
 	rootCmd.Flags().BoolVarP(&showAllFiles, "all", "a", false, "show system files and directories (TUI-mode only)")
 	rootCmd.Flags().BoolVarP(&showLineNumbers, "line-numbers", "l", false, "show line numbers (TUI-mode only)")
 	rootCmd.Flags().BoolVarP(&preserveNewLines, "preserve-new-lines", "n", false, "preserve newlines in the output")
 	rootCmd.Flags().BoolVarP(&mouse, "mouse", "m", false, "enable mouse wheel (TUI-mode only)")
+	rootCmd.Flags().String("remote", "", "browse a remote stash: sftp://host/path, s3://bucket/prefix or webdavs://host/path (TUI-mode only)")
 	_ = rootCmd.Flags().MarkHidden("mouse")
+	rootCmd.Flags().UintVar(&maxCodeLines, "max-code-lines", 0, "truncate code blocks longer than this many lines (0 to disable)")
+	rootCmd.Flags().BoolVar(&noGuessLang, "no-guess-lang", false, "don't guess the language of code blocks without one")
+	rootCmd.Flags().StringVar(&mermaidMode, "mermaid", mermaid.Unicode, "draw mermaid diagrams in unicode or ascii, or show their source (off)")
+	rootCmd.Flags().UintVar(&tabWidth, "tab-width", 0, "expand tabs in code blocks and lists to this many columns (0 to follow .editorconfig)")
+	rootCmd.Flags().StringVar(&delimiter, "delimiter", "", "line separating concatenated documents, which are then rendered independently; among several sources, those of stdin get headers of their own")
+	rootCmd.Flags().BoolVar(&separator, "separator", false, "print a horizontal rule between documents")
+	rootCmd.Flags().StringVar(&delimiter, "stdin-separator", "", "")
+	_ = rootCmd.Flags().MarkDeprecated("stdin-separator", "use --delimiter, and - to give stdin headers among other sources")
+	rootCmd.Flags().StringSliceVar(&onlySections, "only", nil, "render only the sections with these headings, by name or regular expression, and their subsections")
+	rootCmd.Flags().BoolVar(&stream, "stream", false, "render a single source, such as a program's output piped in, block by block as it comes in")
+	rootCmd.Flags().BoolVar(&streamCode, "stream-code", false, "with --stream, draw code blocks on a terminal as their lines come in rather than once they're closed")
+	rootCmd.Flags().BoolVar(&watch, "watch", false, "render again whenever a file changes, or in the TUI, the open document's")
+	rootCmd.Flags().BoolVar(&critic, "critic", false, "show CriticMarkup additions, deletions and comments in color")
+	rootCmd.Flags().BoolVar(&noFilename, "no-filename", false, "don't print a header with the name of each source when rendering several")
+	rootCmd.Flags().StringVar(&renderProfilePath, "render-profile", "", "report render timings to stderr, or write a CPU profile to a .pprof file, attached as in --render-profile=cpu.pprof")
+	rootCmd.Flags().Lookup("render-profile").NoOptDefVal = "-"
+	rootCmd.Flags().BoolVar(&hardened, "hardened", false, "when fetching URLs, refuse local network addresses, limit redirects and size, and require explicit URLs")
+	rootCmd.Flags().StringVar(&streamJSON, "stream-json", "", "write progress events as JSON lines to stderr, or to a file descriptor, attached as in --stream-json=3")
+	rootCmd.Flags().Lookup("stream-json").NoOptDefVal = "2"
+	rootCmd.Flags().BoolVar(&showSummary, "summary", false, "print bytes in and out, blocks rendered, time taken and a hash of the output to stderr")
+	rootCmd.Flags().BoolVar(&checkRenderMode, "check-render", false, "only report unclosed code fences, undefined link references and output wider than --width, failing if there are any")
+	rootCmd.Flags().StringVar(&lineMapPath, "line-map", "", "write a JSON map from output lines to source lines to the given file, or stderr for -")
+	rootCmd.Flags().StringVar(&maxMemory, "max-memory", "", "abort rendering with a diagnostic once memory use crosses this size, such as 512MiB")
+	rootCmd.Flags().Bool("no-project-config", false, "ignore .glow.yml files of the directories documents are in")
+	rootCmd.Flags().BoolVar(&clientMode, "client", false, "have a running glow daemon render the sources, if there is one")
+	rootCmd.Flags().StringVar(&socketPath, "socket", defaultSocketPath(), "unix socket of the glow daemon")
 
 	// Config bindings
 	_ = viper.BindPFlag("style", rootCmd.Flags().Lookup("style"))
@@ -386,12 +965,33 @@ func init() {
 	_ = viper.BindPFlag("preserveNewLines", rootCmd.Flags().Lookup("preserve-new-lines"))
 	_ = viper.BindPFlag("showLineNumbers", rootCmd.Flags().Lookup("line-numbers"))
 	_ = viper.BindPFlag("all", rootCmd.Flags().Lookup("all"))
+	_ = viper.BindPFlag("remote", rootCmd.Flags().Lookup("remote"))
+	_ = viper.BindPFlag("maxCodeLines", rootCmd.Flags().Lookup("max-code-lines"))
+	_ = viper.BindPFlag("noGuessLang", rootCmd.Flags().Lookup("no-guess-lang"))
+	_ = viper.BindPFlag("mermaid", rootCmd.Flags().Lookup("mermaid"))
+	_ = viper.BindPFlag("streamCode", rootCmd.Flags().Lookup("stream-code"))
+	_ = viper.BindPFlag("tabWidth", rootCmd.Flags().Lookup("tab-width"))
+	_ = viper.BindPFlag("hardened", rootCmd.Flags().Lookup("hardened"))
+	_ = viper.BindPFlag("noFilename", rootCmd.Flags().Lookup("no-filename"))
+	_ = viper.BindPFlag("critic", rootCmd.Flags().Lookup("critic"))
+	_ = viper.BindPFlag("maxMemory", rootCmd.Flags().Lookup("max-memory"))
+	_ = viper.BindPFlag("noProjectConfig", rootCmd.Flags().Lookup("no-project-config"))
+	_ = viper.BindPFlag("profile", rootCmd.PersistentFlags().Lookup("profile"))
 
 	viper.SetDefault("style", styles.AutoStyle)
 	viper.SetDefault("width", 0)
 	viper.SetDefault("all", true)
+	viper.SetDefault("keyProfile", ui.KeyProfileDefault)
+	viper.SetDefault("filterMatcher", ui.MatcherFuzzy)
+	viper.SetDefault("sort", ui.SortTitle)
+	viper.SetDefault("confirmQuitWhileStreaming", false)
+	viper.SetDefault("notify", ui.NotifyOff)
+	viper.SetDefault("embedWarnings", true)
+	viper.SetDefault("mermaid", mermaid.Unicode)
+	viper.SetDefault("commands.timeout", defaultCommandTimeout)
+	viper.SetDefault("commands.maxOutput", defaultCommandMaxOutput)
 
-	rootCmd.AddCommand(configCmd, manCmd)
+	rootCmd.AddCommand(bundleCmd, configCmd, envCmd, exportCmd, graphCmd, daemonCmd, apiCmd, listCmd, manCmd, recordCmd, renderCmd, soakCmd, styleCmd)
 }
 
 func tryLoadConfigFromDefaultPlaces() {
@@ -430,10 +1030,7 @@ func tryLoadConfigFromDefaultPlaces() {
 		return
 	}
 
-	if viper.ConfigFileUsed() == "" {
-		configFile = filepath.Join(dirs[0], "glow.yml")
-	}
-	if err := ensureConfigFile(); err != nil {
-		log.Error("Could not create default configuration", "error", err)
-	}
+	// the config file is only created once it's needed, when the TUI starts,
+	// so piping through glow doesn't write anything
+	newConfigFile = filepath.Join(dirs[0], "glow.yml")
 }
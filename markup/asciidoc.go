@@ -0,0 +1,409 @@
+package markup
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	adocAttribute  = regexp.MustCompile(`^:(!?\w[\w-]*!?):\s*(.*)$`)
+	adocHeading    = regexp.MustCompile(`^(={1,6}|#{1,6})\s+(.+?)(?:\s+=+)?$`)
+	adocBlockAttrs = regexp.MustCompile(`^\[(.*)\]$`)
+	adocBlockTitle = regexp.MustCompile(`^\.([^.\s].*)$`)
+	adocDelimiter  = regexp.MustCompile(`^(-{4,}|\.{4,}|_{4,}|={4,}|\*{4,}|\+{4,}|/{4,}|--|\|={3,})$`)
+	adocAdmonition = regexp.MustCompile(`^(NOTE|TIP|IMPORTANT|WARNING|CAUTION):\s+(.*)$`)
+	adocBullet     = regexp.MustCompile(`^(\*{1,5}|-)\s+(.*)$`)
+	adocOrdered    = regexp.MustCompile(`^(\.{1,5}|\d+\.)\s+(.*)$`)
+	adocTerm       = regexp.MustCompile(`^(.+?)(:{2,4}|;;)(?:\s+(.*))?$`)
+	adocBlockMacro = regexp.MustCompile(`^(\w+)::(\S*?)\[(.*)\]$`)
+	adocAttrRef    = regexp.MustCompile(`\{([\w-]+)\}`)
+
+	adocMono      = regexp.MustCompile("``(.+?)``|`([^`\\s](?:[^`]*[^`\\s])?)`|\\+\\+\\+(.+?)\\+\\+\\+|\\+([^+\\s](?:[^+]*[^+\\s])?)\\+")
+	adocLink      = regexp.MustCompile(`\b(?:link:([^\s\[]+)|((?:https?|ftp|irc)://[^\s\[]+|mailto:[^\s\[]+))\[([^\]]*)\]`)
+	adocXref      = regexp.MustCompile(`<<([^,>]+)(?:,\s*([^>]+))?>>|\bxref:([^\s\[]+)\[([^\]]*)\]`)
+	adocImage     = regexp.MustCompile(`\bimage:([^:\s\[][^\s\[]*)\[([^\]]*)\]`)
+	adocKbd       = regexp.MustCompile(`\b(?:kbd|btn):\[([^\]]*)\]`)
+	adocFootnote  = regexp.MustCompile(`\s*\bfootnote:[\w-]*\[([^\]]*)\]`)
+	adocAnchor    = regexp.MustCompile(`\[\[[^\]]*\]\]`)
+	adocStrong    = regexp.MustCompile(`(^|[^\w*])\*([^*\s](?:[^*]*?[^*\s])?)\*([^\w*]|$)`)
+	adocEmphasis  = regexp.MustCompile(`(^|[^\w_])_([^_\s](?:[^_]*?[^_\s])?)_([^\w_]|$)`)
+	adocUEmphasis = regexp.MustCompile(`__(.+?)__`)
+	adocProtected = regexp.MustCompile("\x00(\\d+)\x00")
+)
+
+// Attributes every AsciiDoc document has, for character replacements.
+var adocBuiltinAttributes = map[string]string{
+	"empty": "", "sp": " ", "nbsp": " ", "zwsp": "​", "wj": "⁠",
+	"apos": "'", "quot": `"`, "lsquo": "‘", "rsquo": "’", "ldquo": "“", "rdquo": "”",
+	"deg": "°", "plus": "+", "brvbar": "¦", "vbar": "|", "amp": "&", "lt": "<",
+	"gt": ">", "startsb": "[", "endsb": "]", "caret": "^", "asterisk": "*",
+	"tilde": "~", "backslash": `\`, "backtick": "`", "two-colons": "::",
+	"two-semicolons": ";;", "cpp": "C++",
+}
+
+// adocConverter converts AsciiDoc line by line.
+type adocConverter struct {
+	attrs map[string]string // document attributes
+}
+
+func asciidocToMarkdown(s string) string {
+	c := adocConverter{attrs: maps.Clone(adocBuiltinAttributes)}
+	return c.convert(strings.Split(s, "\n"))
+}
+
+func (c adocConverter) convert(lines []string) string {
+	var (
+		b      strings.Builder
+		attrs  string // attributes of the next block
+		title  string // title of the next block
+		quoted bool   // inside an admonition or quote paragraph
+	)
+	// blockTitle writes the title of the block about to be written.
+	blockTitle := func() {
+		if title != "" {
+			b.WriteString("**" + c.text(title) + "**\n\n")
+			title = ""
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		l := strings.TrimRight(lines[i], " \t")
+		if blank(l) {
+			b.WriteString("\n")
+			quoted = false
+			continue
+		}
+		if quoted {
+			b.WriteString("> " + c.text(strings.TrimSpace(l)) + "\n")
+			continue
+		}
+		style, args := adocStyle(attrs)
+		item := strings.TrimLeft(l, " \t") // list items may be indented
+
+		switch {
+		case strings.HasPrefix(l, "//") && !adocDelimiter.MatchString(l): // comment
+		case adocAttribute.MatchString(l):
+			m := adocAttribute.FindStringSubmatch(l)
+			if name := strings.Trim(m[1], "!"); name != m[1] {
+				delete(c.attrs, name)
+			} else {
+				c.attrs[name] = c.substitute(m[2])
+			}
+		case adocDelimiter.MatchString(l):
+			j := i + 1
+			for j < len(lines) && strings.TrimRight(lines[j], " \t") != l {
+				j++
+			}
+			blockTitle()
+			b.WriteString(c.block(l, attrs, lines[i+1:min(j, len(lines))]))
+			attrs, i = "", j
+		case adocBlockAttrs.MatchString(l) && !strings.HasPrefix(l, "[["):
+			attrs = adocBlockAttrs.FindStringSubmatch(l)[1]
+		case strings.HasPrefix(l, "[[") && strings.HasSuffix(l, "]]"): // anchor
+		case adocBlockTitle.MatchString(l):
+			title = adocBlockTitle.FindStringSubmatch(l)[1]
+		case adocHeading.MatchString(l):
+			m := adocHeading.FindStringSubmatch(l)
+			b.WriteString(strings.Repeat("#", len(m[1])) + " " + c.text(m[2]) + "\n")
+		case l == "'''" || l == "---" || l == "- - -" || l == "***" || l == "* * *":
+			b.WriteString("\n---\n")
+		case l == "<<<" || l == "+": // page break, list continuation
+		case adocBlockMacro.MatchString(l):
+			blockTitle()
+			m := adocBlockMacro.FindStringSubmatch(l)
+			b.WriteString(c.blockMacro(m[1], m[2], m[3]))
+		case adocAdmonition.MatchString(l):
+			blockTitle()
+			m := adocAdmonition.FindStringSubmatch(l)
+			b.WriteString("> **" + admonitionTitle(m[1]) + ":** " + c.text(m[2]) + "\n")
+			quoted = true
+		case adocBullet.MatchString(item):
+			blockTitle()
+			m := adocBullet.FindStringSubmatch(item)
+			depth := len(m[1])
+			if m[1] == "-" {
+				depth = 1
+			}
+			b.WriteString(strings.Repeat("  ", depth-1) + "- " + c.text(m[2]) + "\n")
+		case adocOrdered.MatchString(item):
+			blockTitle()
+			m := adocOrdered.FindStringSubmatch(item)
+			depth := len(m[1])
+			if strings.Trim(m[1], ".") != "" {
+				depth = 1
+			}
+			b.WriteString(strings.Repeat("   ", depth-1) + "1. " + c.text(m[2]) + "\n")
+		case adocTerm.MatchString(l) && !strings.Contains(l, "://"):
+			blockTitle()
+			m := adocTerm.FindStringSubmatch(l)
+			b.WriteString("- **" + c.text(strings.TrimSpace(m[1])) + "**")
+			if m[3] != "" {
+				b.WriteString(": " + c.text(m[3]))
+			}
+			b.WriteString("\n")
+
+		case l[0] == ' ' || l[0] == '\t' || style == "source" || style == "listing" || style == "literal":
+			// literal paragraphs, indented or styled
+			block, n := paragraph(lines[i:])
+			lang := ""
+			if style == "source" && len(args) > 0 {
+				lang = args[0]
+			}
+			blockTitle()
+			b.WriteString(fence(dedent(block), lang))
+			attrs, i = "", i+max(n, 1)-1
+		case adocIsAdmonition(style):
+			blockTitle()
+			b.WriteString("> **" + admonitionTitle(style) + ":** " + c.text(l) + "\n")
+			attrs, quoted = "", true
+		case style == "quote" || style == "verse":
+			blockTitle()
+			b.WriteString("> " + c.text(l) + "\n")
+			attrs, quoted = "", true
+		default:
+			blockTitle()
+			attrs = ""
+			b.WriteString(c.text(l) + "\n")
+		}
+	}
+	return b.String()
+}
+
+// block converts a delimited block.
+func (c adocConverter) block(delimiter, attrs string, lines []string) string {
+	style, args := adocStyle(attrs)
+	switch delimiter[0] {
+	case '-':
+		if delimiter == "--" { // open block, styled like any other
+			break
+		}
+		lang := ""
+		if style == "source" && len(args) > 0 {
+			lang = args[0]
+		}
+		return fence(lines, lang)
+	case '.':
+		return fence(lines, "")
+	case '+':
+		return strings.Join(lines, "\n") + "\n"
+	case '/':
+		return ""
+	case '|':
+		return c.table(lines, attrs)
+	case '_', '*':
+		style = "quote"
+	case '=':
+		if style == "" {
+			style = "example"
+		}
+	}
+
+	content := c.convert(lines)
+	switch {
+	case adocIsAdmonition(style):
+		return quote("**" + admonitionTitle(style) + ":** " + strings.TrimLeft(content, "\n"))
+	case style == "quote" || style == "verse" || style == "example" || style == "sidebar":
+		if len(args) > 0 {
+			content = strings.TrimRight(content, "\n") + "\n\n— " + c.text(strings.Join(args, ", ")) + "\n"
+		}
+		return quote(content)
+	case style == "source" || style == "listing" || style == "literal":
+		return fence(lines, "")
+	}
+	return content
+}
+
+// blockMacro converts a block macro such as image::logo.png[Logo]. Videos
+// and audio become HTML, which the renderer shows as a placeholder.
+func (c adocConverter) blockMacro(name, target, attrs string) string {
+	target = c.substitute(target)
+	first, _, _ := strings.Cut(attrs, ",")
+	switch name {
+	case "image":
+		return fmt.Sprintf("![%s](%s)\n", strings.Trim(first, `"`), target)
+	case "video", "audio":
+		return fmt.Sprintf("<%s src=%q></%s>\n", name, target, name)
+	}
+	return ""
+}
+
+// table converts a table to a markdown table, using the first row as its
+// header.
+func (c adocConverter) table(lines []string, attrs string) string {
+	var cells []string
+	cols := 0
+	for i, l := range lines {
+		l = strings.TrimSpace(l)
+		if !strings.HasPrefix(l, "|") {
+			if l != "" && len(cells) > 0 {
+				cells[len(cells)-1] += " " + l
+			}
+			continue
+		}
+		row := strings.Split(l[1:], "|")
+		if cols == 0 && (i+1 == len(lines) || strings.TrimSpace(lines[i+1]) == "" || strings.HasPrefix(strings.TrimSpace(lines[i+1]), "|")) {
+			cols = len(row)
+		}
+		for _, cell := range row {
+			cells = append(cells, strings.TrimSpace(cell))
+		}
+	}
+	if n := adocColumns(attrs); n > 0 {
+		cols = n
+	}
+	if cols == 0 || len(cells) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(cells); i += cols {
+		row := make([]string, cols)
+		for j := range row {
+			if i+j < len(cells) {
+				row[j] = c.text(cells[i+j])
+			}
+		}
+		b.WriteString("| " + strings.Join(row, " | ") + " |\n")
+		if i == 0 {
+			b.WriteString("|" + strings.Repeat(" --- |", cols) + "\n")
+		}
+	}
+	return b.String()
+}
+
+// text converts inline markup.
+func (c adocConverter) text(s string) string {
+	s = c.substitute(s)
+
+	// spans that must not be touched by the conversion of emphasis
+	var kept []string
+	keep := func(span string) string {
+		kept = append(kept, span)
+		return "\x00" + strconv.Itoa(len(kept)-1) + "\x00"
+	}
+
+	s = adocMono.ReplaceAllStringFunc(s, func(match string) string {
+		m := adocMono.FindStringSubmatch(match)
+		switch {
+		case m[1] != "" || m[2] != "":
+			return keep(code(strings.Trim(m[1]+m[2], "+")))
+		case m[3] != "":
+			return keep(m[3])
+		}
+		return keep(m[4])
+	})
+	s = adocImage.ReplaceAllStringFunc(s, func(match string) string {
+		m := adocImage.FindStringSubmatch(match)
+		alt, _, _ := strings.Cut(m[2], ",")
+		return keep("![" + alt + "](" + m[1] + ")")
+	})
+	s = adocLink.ReplaceAllStringFunc(s, func(match string) string {
+		m := adocLink.FindStringSubmatch(match)
+		u := m[1] + m[2]
+		text, _, _ := strings.Cut(m[3], ",")
+		text = strings.TrimSuffix(strings.Trim(text, `"`), "^")
+		if text == "" {
+			return keep("<" + u + ">")
+		}
+		return "[" + text + "](" + keep(u) + ")"
+	})
+	s = adocXref.ReplaceAllStringFunc(s, func(match string) string {
+		m := adocXref.FindStringSubmatch(match)
+		for _, text := range []string{m[2], m[4], m[1], m[3]} {
+			if text != "" {
+				return text
+			}
+		}
+		return match
+	})
+	s = adocKbd.ReplaceAllStringFunc(s, func(match string) string {
+		return keep(code(adocKbd.FindStringSubmatch(match)[1]))
+	})
+	s = adocFootnote.ReplaceAllString(s, " ($1)")
+	s = adocAnchor.ReplaceAllString(s, "")
+
+	// constrained markup needs a second pass where two spans share the
+	// character between them
+	for i := 0; i < 2; i++ {
+		s = adocStrong.ReplaceAllString(s, "$1**$2**$3")
+	}
+	for i := 0; i < 2; i++ {
+		s = adocEmphasis.ReplaceAllString(s, "$1*$2*$3")
+	}
+	s = adocUEmphasis.ReplaceAllString(s, "*$1*")
+	if strings.HasSuffix(s, " +") {
+		s = strings.TrimSuffix(s, " +") + `\`
+	}
+
+	return adocProtected.ReplaceAllStringFunc(s, func(match string) string {
+		n, err := strconv.Atoi(adocProtected.FindStringSubmatch(match)[1])
+		if err != nil || n >= len(kept) {
+			return match
+		}
+		return kept[n]
+	})
+}
+
+// substitute replaces references to attributes that are defined.
+func (c adocConverter) substitute(s string) string {
+	return adocAttrRef.ReplaceAllStringFunc(s, func(match string) string {
+		if v, ok := c.attrs[match[1:len(match)-1]]; ok {
+			return v
+		}
+		return match
+	})
+}
+
+// adocStyle returns the style of a block, its first positional attribute,
+// and the positional attributes after it.
+func adocStyle(attrs string) (string, []string) {
+	if attrs == "" {
+		return "", nil
+	}
+	var positional []string
+	for _, a := range strings.Split(attrs, ",") {
+		a = strings.TrimSpace(a)
+		if !strings.Contains(a, "=") {
+			positional = append(positional, strings.Trim(a, `"`))
+		}
+	}
+	if len(positional) == 0 {
+		return "", nil
+	}
+	style, _, _ := strings.Cut(positional[0], "#") // [source#id,go]
+	style, _, _ = strings.Cut(style, "%")          // [source%linenums,go]
+	return strings.ToLower(strings.TrimPrefix(style, ".")), positional[1:]
+}
+
+func adocIsAdmonition(style string) bool {
+	switch style {
+	case "note", "tip", "important", "warning", "caution":
+		return true
+	}
+	return false
+}
+
+// adocColumns returns the number of columns from a cols attribute, such as
+// cols="1,2,1" or cols="3*".
+func adocColumns(attrs string) int {
+	i := strings.Index(attrs, "cols=")
+	if i < 0 {
+		return 0
+	}
+	v := strings.Trim(attrs[i+len("cols="):], `"'`)
+	if j := strings.IndexAny(v, `"'`); j >= 0 {
+		v = v[:j]
+	}
+	if n, ok := strings.CutSuffix(v, "*"); ok {
+		if k, err := strconv.Atoi(n); err == nil {
+			return k
+		}
+	}
+	if k, err := strconv.Atoi(v); err == nil && !strings.Contains(v, ",") {
+		return k
+	}
+	return strings.Count(v, ",") + 1
+}
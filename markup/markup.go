@@ -0,0 +1,161 @@
+// Package markup converts other lightweight markup languages, such as
+// reStructuredText and AsciiDoc, to markdown, so they can be rendered like
+// any other document.
+//
+// The converters are native and deliberately forgiving: they map the
+// structures a terminal can show (sections, lists, literal and code blocks,
+// admonitions, tables, links and inline markup) and leave out the rest,
+// rather than failing on documents they don't fully understand.
+package markup
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// converters maps file extensions to converters.
+var converters = map[string]func(string) string{
+	".rst":      rstToMarkdown,
+	".rest":     rstToMarkdown,
+	".adoc":     asciidocToMarkdown,
+	".asciidoc": asciidocToMarkdown,
+	".asc":      asciidocToMarkdown,
+}
+
+// Extensions are the file extensions of supported markup languages.
+var Extensions = []string{".rst", ".rest", ".adoc", ".asciidoc", ".asc"}
+
+// Dialects are the markup languages documents without an extension can be
+// written in.
+var Dialects = []string{"markdown", "rst", "asciidoc"}
+
+// dialects maps the names of markup languages to converters. Markdown needs
+// none.
+var dialects = map[string]func(string) string{
+	"markdown": nil,
+	"rst":      rstToMarkdown,
+	"asciidoc": asciidocToMarkdown,
+}
+
+// dialect converts documents without an extension, such as README.
+var dialect func(string) string
+
+// SetDialect sets the markup language of documents without an extension,
+// which are otherwise taken for markdown. Empty means markdown.
+func SetDialect(name string) error {
+	if name == "" {
+		dialect = nil
+		return nil
+	}
+	convert, ok := dialects[strings.ToLower(name)]
+	if !ok {
+		return fmt.Errorf("invalid dialect %q: must be one of %s", name, strings.Join(Dialects, ", "))
+	}
+	dialect = convert
+	return nil
+}
+
+// Supported returns whether a file is in a markup language that can be
+// converted to markdown.
+func Supported(filename string) bool {
+	_, ok := converters[strings.ToLower(filepath.Ext(filename))]
+	return ok
+}
+
+// Converts reports whether ToMarkdown converts a document of the given name,
+// by its extension or the dialect, rather than returning it as is.
+func Converts(filename string) bool {
+	return converter(filename) != nil
+}
+
+// converter returns the function converting a document of the given name.
+func converter(filename string) func(string) string {
+	ext := strings.ToLower(filepath.Ext(filename))
+	if filename != "" && ext == "" {
+		return dialect
+	}
+	return converters[ext]
+}
+
+// ToMarkdown converts a document to markdown based on its file name, or the
+// dialect for files without an extension. Documents that aren't in a
+// supported language are returned as they are.
+func ToMarkdown(filename string, b []byte) []byte {
+	convert := converter(filename)
+	if convert == nil {
+		return b
+	}
+	// NUL marks spans kept from conversion, so documents can't have any;
+	// markdown replaces them anyway
+	s := strings.ReplaceAll(string(b), "\r\n", "\n")
+	s = strings.ReplaceAll(s, "\x00", "\uFFFD")
+	return []byte(convert(s))
+}
+
+// blank reports whether a line is empty or only whitespace. Converters end
+// blocks at the same lines paragraph does.
+func blank(l string) bool {
+	return strings.TrimSpace(l) == ""
+}
+
+// fence wraps lines into a fenced code block, using a fence longer than any
+// backtick run in them.
+func fence(lines []string, lang string) string {
+	marker := "```"
+	for _, l := range lines {
+		for strings.Contains(l, marker) {
+			marker += "`"
+		}
+	}
+	return marker + lang + "\n" + strings.Join(lines, "\n") + "\n" + marker + "\n"
+}
+
+// quote prefixes the lines of a markdown block to make it a blockquote.
+func quote(md string) string {
+	lines := strings.Split(strings.TrimRight(md, "\n"), "\n")
+	for i, l := range lines {
+		if l == "" {
+			lines[i] = ">"
+			continue
+		}
+		lines[i] = "> " + l
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// dedent removes the indentation common to all non-blank lines.
+func dedent(lines []string) []string {
+	common := -1
+	for _, l := range lines {
+		if strings.TrimSpace(l) == "" {
+			continue
+		}
+		if n := indentation(l); common < 0 || n < common {
+			common = n
+		}
+	}
+	out := make([]string, len(lines))
+	for i, l := range lines {
+		if len(l) >= common && common > 0 {
+			out[i] = l[common:]
+		} else {
+			out[i] = strings.TrimLeft(l, " \t")
+		}
+	}
+	return out
+}
+
+// indentation returns the number of leading spaces and tabs of a line.
+func indentation(l string) int {
+	return len(l) - len(strings.TrimLeft(l, " \t"))
+}
+
+// admonitionTitle returns the title an admonition is shown with.
+func admonitionTitle(kind string) string {
+	kind = strings.ToLower(kind)
+	if kind == "seealso" {
+		return "See also"
+	}
+	return strings.ToUpper(kind[:1]) + kind[1:]
+}
@@ -0,0 +1,83 @@
+package markup
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestToMarkdown(t *testing.T) {
+	for _, tc := range []struct {
+		name, in string
+		want     []string
+	}{
+		{"doc.adoc", "= Title\n\nSome *bold* text.\n", []string{"# Title", "**bold**"}},
+		{"doc.adoc", "Intro\n\n  \nmore\n", []string{"Intro", "more"}},
+		{"doc.adoc", "Intro\n\n \r\f\v\n  literal\n", []string{"Intro", "literal"}},
+		{"doc.adoc", "\x000\x00{", []string{"�0�{"}},
+		{"doc.rst", "Title\n=====\n\nSome **bold** text.\n", []string{"# Title", "**bold**"}},
+		{"doc.rst", "Intro\n\n  \n+--+\n|a |\n+--+\n", []string{"Intro", "|a |"}},
+		{"doc.md", "# Title\x00\n", []string{"# Title\x00"}},
+	} {
+		got := string(convertWithin(t, tc.name, tc.in))
+		for _, want := range tc.want {
+			if !strings.Contains(got, want) {
+				t.Errorf("%s %q: %q is missing from %q", tc.name, tc.in, want, got)
+			}
+		}
+	}
+}
+
+// FuzzToMarkdown feeds arbitrary documents to the converters, which must
+// neither panic nor hang on them. Run it with
+//
+//	go test ./markup -fuzz FuzzToMarkdown
+func TestConverts(t *testing.T) {
+	for name, want := range map[string]bool{"doc.rst": true, "doc.ADOC": true, "doc.md": false, "NOTES": false, "": false} {
+		if got := Converts(name); got != want {
+			t.Errorf("Converts(%q) = %v, want %v", name, got, want)
+		}
+	}
+
+	// documents without an extension are converted in the dialect's
+	// language, so they're no longer markdown as written
+	if err := SetDialect("rst"); err != nil {
+		t.Fatal(err)
+	}
+	defer SetDialect("") //nolint:errcheck
+	if !Converts("NOTES") {
+		t.Error("a document in the dialect isn't converted")
+	}
+}
+
+func FuzzToMarkdown(f *testing.F) {
+	for _, seed := range []string{
+		"= Title\n:attr: value\n\n[source,go]\n----\nfunc main() {}\n----\n\n* item\n** nested\n",
+		"NOTE: careful\n\n|===\n|a |b\n|===\n\nlink:http://x[text] `mono` _em_\n",
+		"Intro\n\n  \nmore\n",
+		"\x000\x00{",
+		"Title\n=====\n\n.. note:: careful\n\n::\n\n  literal\n\n+--+\n|a |\n+--+\n",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		for _, name := range []string{"doc.adoc", "doc.rst"} {
+			convertWithin(t, name, s)
+		}
+	})
+}
+
+// convertWithin converts a document, failing if that takes suspiciously
+// long, as when a converter loops forever.
+func convertWithin(t *testing.T, name, s string) []byte {
+	t.Helper()
+	done := make(chan []byte, 1)
+	go func() { done <- ToMarkdown(name, []byte(s)) }()
+	select {
+	case b := <-done:
+		return b
+	case <-time.After(5 * time.Second):
+		t.Fatalf("converting %s %q hangs", name, s)
+		return nil
+	}
+}
@@ -0,0 +1,423 @@
+package markup
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	rstDirective  = regexp.MustCompile(`^\.\.\s+([\w:-]+)::(?:\s+(.*))?$`)
+	rstTarget     = regexp.MustCompile("^\\.\\.\\s+_`?([^:`]+)`?:\\s*(.*)$")
+	rstOption     = regexp.MustCompile(`^:([\w -]+):\s*(.*)$`)
+	rstBullet     = regexp.MustCompile(`^(\s*)[-*+•‣⁃]\s+(.*)$`)
+	rstEnumerated = regexp.MustCompile(`^(\s*)\(?(\d+|#)[.)]\s+(.*)$`)
+	rstField      = regexp.MustCompile(`^:([^:]+):\s+(.*)$`)
+	rstSimpleRule = regexp.MustCompile(`^=+( +=+)+$`)
+	rstLiteral    = regexp.MustCompile("``(.+?)``")
+	rstInline     = regexp.MustCompile(
+		":([\\w:+.-]+):`([^`]+)`" + // role
+			"|`([^`<]*?)\\s*<([^>`]+)>`__?" + // link with an embedded URL
+			"|`([^`]+)`(__?)?" + // reference or interpreted text
+			"|\\[(#?\\w*|\\*)\\]_" + // footnote reference
+			"|\\b(\\w[\\w.-]*)__?\\b") // reference to a target
+)
+
+// Roles showing their text or title as is, rather than as code.
+var rstTextRoles = map[string]bool{
+	"ref": true, "doc": true, "term": true, "numref": true, "any": true,
+	"abbr": true, "sub": true, "sup": true, "subscript": true, "superscript": true,
+	"title-reference": true, "title": true, "t": true,
+}
+
+var rstAdmonitions = map[string]bool{
+	"note": true, "warning": true, "tip": true, "important": true, "hint": true,
+	"caution": true, "danger": true, "attention": true, "error": true,
+	"seealso": true,
+}
+
+// rstConverter converts reStructuredText line by line.
+type rstConverter struct {
+	targets map[string]string // link targets by normalized name
+	levels  *[]string         // section adornment styles in order of appearance
+}
+
+func rstToMarkdown(s string) string {
+	lines := strings.Split(s, "\n")
+	c := rstConverter{targets: map[string]string{}, levels: &[]string{}}
+	for _, l := range lines {
+		if m := rstTarget.FindStringSubmatch(strings.TrimSpace(l)); m != nil {
+			c.targets[rstRefName(m[1])] = strings.TrimSpace(m[2])
+		}
+	}
+	return c.convert(lines)
+}
+
+func (c rstConverter) convert(lines []string) string {
+	var (
+		b             strings.Builder
+		literal       bool // the last paragraph announced a literal block
+		literalIndent int
+		inList        bool
+	)
+	for i := 0; i < len(lines); i++ {
+		l := lines[i]
+		trimmed := strings.TrimSpace(l)
+		indent := indentation(l)
+
+		if blank(l) {
+			b.WriteString("\n")
+			continue
+		}
+
+		// literal blocks, announced by a paragraph ending with ::
+		if literal {
+			literal = false
+			if indent > literalIndent {
+				block, n := indentedBlock(lines[i:], literalIndent)
+				b.WriteString(fence(dedent(block), ""))
+				i += n - 1
+				continue
+			}
+		}
+
+		// section titles, with or without an overline
+		if rstAdornment(trimmed) && indent == 0 && i+2 < len(lines) &&
+			strings.TrimSpace(lines[i+2]) == trimmed && strings.TrimSpace(lines[i+1]) != "" {
+			b.WriteString(c.heading("over"+trimmed[:1], strings.TrimSpace(lines[i+1])))
+			i += 2
+			inList = false
+			continue
+		}
+		if indent == 0 && i+1 < len(lines) && rstAdornment(lines[i+1]) &&
+			utf8.RuneCountInString(lines[i+1]) >= utf8.RuneCountInString(trimmed) {
+			b.WriteString(c.heading(lines[i+1][:1], trimmed))
+			i++
+			inList = false
+			continue
+		}
+
+		// transitions
+		if rstAdornment(trimmed) && len(trimmed) >= 4 {
+			b.WriteString("\n---\n")
+			continue
+		}
+
+		// directives, comments and link targets
+		if strings.HasPrefix(trimmed, "..") && (trimmed == ".." || strings.HasPrefix(trimmed, ".. ")) {
+			block, n := indentedBlock(lines[i+1:], indent)
+			i += n
+			if m := rstDirective.FindStringSubmatch(trimmed); m != nil {
+				b.WriteString(c.directive(strings.ToLower(m[1]), strings.TrimSpace(m[2]), dedent(block)))
+			}
+			continue
+		}
+
+		// tables
+		if strings.HasPrefix(trimmed, "+-") || strings.HasPrefix(trimmed, "+=") {
+			block, n := paragraph(lines[i:])
+			b.WriteString(fence(block, ""))
+			i += max(n, 1) - 1
+			continue
+		}
+		if rstSimpleRule.MatchString(trimmed) {
+			block, n := rstSimpleTable(lines[i:])
+			b.WriteString(block)
+			i += n - 1
+			continue
+		}
+
+		// indented blocks: list item continuations, definitions and quotes
+		if indent > 0 && !rstBullet.MatchString(l) && !rstEnumerated.MatchString(l) {
+			if inList {
+				b.WriteString(c.text(l) + "\n")
+				continue
+			}
+			block, n := indentedBlock(lines[i:], indent-1)
+			b.WriteString(quote(c.convert(dedent(block))))
+			i += n - 1
+			continue
+		}
+
+		// a definition list term, directly followed by its definition
+		if indent == 0 && i+1 < len(lines) && strings.TrimSpace(lines[i+1]) != "" &&
+			indentation(lines[i+1]) > 0 && !strings.HasSuffix(trimmed, "::") &&
+			!rstBullet.MatchString(l) && !rstEnumerated.MatchString(l) {
+			block, n := indentedBlock(lines[i+1:], 0)
+			b.WriteString("**" + c.text(trimmed) + "**\n")
+			b.WriteString(quote(c.convert(dedent(block))))
+			i += n
+			inList = false
+			continue
+		}
+
+		switch {
+		case rstBullet.MatchString(l):
+			m := rstBullet.FindStringSubmatch(l)
+			l = m[1] + "- " + m[2]
+			inList = true
+		case rstEnumerated.MatchString(l):
+			m := rstEnumerated.FindStringSubmatch(l)
+			n := m[2]
+			if n == "#" {
+				n = "1"
+			}
+			l = m[1] + n + ". " + m[3]
+			inList = true
+		case indent == 0 && rstField.MatchString(l):
+			m := rstField.FindStringSubmatch(l)
+			l = "- **" + m[1] + ":** " + m[2]
+			inList = true
+		case indent == 0:
+			inList = false
+		}
+
+		if strings.HasSuffix(trimmed, "::") {
+			literal, literalIndent = true, indent
+			switch {
+			case trimmed == "::":
+				continue
+			case strings.HasSuffix(trimmed, " ::"):
+				l = strings.TrimSuffix(strings.TrimRight(l, " "), " ::")
+			default:
+				l = strings.TrimSuffix(strings.TrimRight(l, " "), ":")
+			}
+		}
+		b.WriteString(c.text(l) + "\n")
+	}
+	return b.String()
+}
+
+// heading returns a markdown heading for a section title, its level
+// depending on when its adornment style was first seen.
+func (c rstConverter) heading(style, title string) string {
+	level := 0
+	for i, s := range *c.levels {
+		if s == style {
+			level = i + 1
+		}
+	}
+	if level == 0 {
+		*c.levels = append(*c.levels, style)
+		level = len(*c.levels)
+	}
+	return strings.Repeat("#", min(level, 6)) + " " + c.text(title) + "\n" //nolint:mnd
+}
+
+// directive converts a directive with its arguments and (dedented) content.
+// Unknown directives are left out.
+func (c rstConverter) directive(name, args string, block []string) string {
+	opts := map[string]string{}
+	for len(block) > 0 {
+		m := rstOption.FindStringSubmatch(block[0])
+		if m == nil {
+			break
+		}
+		opts[m[1]] = m[2]
+		block = block[1:]
+	}
+	content := strings.Join(block, "\n")
+
+	switch {
+	case name == "code-block" || name == "code" || name == "sourcecode":
+		for len(block) > 0 && strings.TrimSpace(block[0]) == "" {
+			block = block[1:]
+		}
+		return fence(block, args)
+	case name == "image" || name == "figure":
+		img := fmt.Sprintf("![%s](%s)\n", opts["alt"], args)
+		if name == "figure" && strings.TrimSpace(content) != "" {
+			img += "\n" + c.convert(block)
+		}
+		return img
+	case rstAdmonitions[name]:
+		return quote(fmt.Sprintf("**%s:** %s", admonitionTitle(name), strings.TrimSpace(c.convert(append([]string{args}, block...)))))
+	case name == "admonition" || name == "topic" || name == "sidebar":
+		return quote(fmt.Sprintf("**%s**\n\n%s", c.text(args), c.convert(block)))
+	case name == "rubric":
+		return "**" + c.text(args) + "**\n"
+	case name == "container" || name == "only" || name == "compound" || name == "epigraph" ||
+		name == "highlights" || name == "pull-quote":
+		return c.convert(block)
+	}
+	return ""
+}
+
+// text converts inline markup.
+func (c rstConverter) text(s string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range rstLiteral.FindAllStringSubmatchIndex(s, -1) {
+		b.WriteString(c.inline(s[last:m[0]]))
+		b.WriteString(code(s[m[2]:m[3]]))
+		last = m[1]
+	}
+	b.WriteString(c.inline(s[last:]))
+	return b.String()
+}
+
+func (c rstConverter) inline(s string) string {
+	return rstInline.ReplaceAllStringFunc(s, func(match string) string {
+		m := rstInline.FindStringSubmatch(match)
+		switch {
+		case m[1] != "": // role
+			role := m[1][strings.LastIndex(strings.TrimSuffix(m[1], ":"), ":")+1:]
+			text := m[2]
+			if i := strings.Index(text, " <"); i > 0 && strings.HasSuffix(text, ">") {
+				text = text[:i]
+			}
+			switch {
+			case role == "emphasis":
+				return "*" + text + "*"
+			case role == "strong":
+				return "**" + text + "**"
+			case rstTextRoles[role]:
+				return strings.TrimPrefix(text, "~")
+			}
+			return code(strings.TrimPrefix(text, "~"))
+		case m[4] != "": // embedded URL
+			u := m[4]
+			if strings.HasSuffix(u, "_") {
+				u = c.targets[rstRefName(strings.TrimSuffix(u, "_"))]
+			}
+			if m[3] == "" {
+				return "<" + u + ">"
+			}
+			if u == "" {
+				return m[3]
+			}
+			return "[" + m[3] + "](" + u + ")"
+		case m[5] != "": // reference or interpreted text
+			if m[6] == "" {
+				return "*" + m[5] + "*"
+			}
+			if u := c.target(m[5]); u != "" {
+				return "[" + m[5] + "](" + u + ")"
+			}
+			return m[5]
+		case m[8] != "": // reference to a target
+			if u := c.target(m[8]); u != "" {
+				return "[" + m[8] + "](" + u + ")"
+			}
+			return match
+		}
+		return "[" + m[7] + "]" // footnote reference
+	})
+}
+
+// target returns the URL of a link target, following indirect targets.
+func (c rstConverter) target(name string) string {
+	u := c.targets[rstRefName(name)]
+	for i := 0; i < 5 && strings.HasSuffix(u, "_") && !strings.Contains(u, " "); i++ {
+		u = c.targets[rstRefName(strings.Trim(strings.TrimSuffix(u, "_"), "`"))]
+	}
+	return u
+}
+
+// rstRefName normalizes a reference name: case and whitespace don't matter.
+func rstRefName(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
+
+// rstAdornment returns whether a line is a section adornment or transition:
+// a repeated punctuation character.
+func rstAdornment(l string) bool {
+	l = strings.TrimRight(l, " ")
+	if len(l) < 3 || strings.Trim(l, l[:1]) != "" {
+		return false
+	}
+	r, _ := utf8.DecodeRuneInString(l)
+	return r < utf8.RuneSelf && (unicode.IsPunct(r) || unicode.IsSymbol(r))
+}
+
+// rstSimpleTable converts a simple table with a header to a markdown table,
+// and fences other ones. It returns the number of lines used.
+func rstSimpleTable(lines []string) (string, int) {
+	n, rules := 0, 0
+	for n < len(lines) {
+		l := strings.TrimSpace(lines[n])
+		if l == "" && rules >= 2 && rstSimpleRule.MatchString(strings.TrimSpace(lines[n-1])) {
+			break
+		}
+		if rstSimpleRule.MatchString(l) {
+			rules++
+		}
+		n++
+	}
+	block := lines[:n]
+	if rules != 3 { //nolint:mnd
+		return fence(block, ""), n
+	}
+
+	// column starts, from the runs of = in the top rule
+	var cols []int
+	top := block[0]
+	for i := range top {
+		if top[i] == '=' && (i == 0 || top[i-1] == ' ') {
+			cols = append(cols, i)
+		}
+	}
+	cells := func(l string) string {
+		row := make([]string, len(cols))
+		for i, start := range cols {
+			if start >= len(l) {
+				continue
+			}
+			end := len(l)
+			if i+1 < len(cols) && cols[i+1] < end {
+				end = cols[i+1]
+			}
+			row[i] = strings.ReplaceAll(strings.TrimSpace(l[start:end]), "|", `\|`)
+		}
+		return "| " + strings.Join(row, " | ") + " |"
+	}
+
+	var b strings.Builder
+	rule := 0
+	for _, l := range block {
+		if rstSimpleRule.MatchString(strings.TrimSpace(l)) {
+			rule++
+			if rule == 2 {
+				b.WriteString("|" + strings.Repeat(" --- |", len(cols)) + "\n")
+			}
+			continue
+		}
+		if strings.TrimSpace(l) != "" {
+			b.WriteString(cells(l) + "\n")
+		}
+	}
+	return b.String(), n
+}
+
+// indentedBlock returns the lines indented deeper than base, with blank
+// lines in between, and how many lines that is.
+func indentedBlock(lines []string, base int) ([]string, int) {
+	n := 0
+	for n < len(lines) && (strings.TrimSpace(lines[n]) == "" || indentation(lines[n]) > base) {
+		n++
+	}
+	for n > 0 && strings.TrimSpace(lines[n-1]) == "" {
+		n--
+	}
+	return lines[:n], n
+}
+
+// paragraph returns the lines up to the next blank one, and how many lines
+// that is.
+func paragraph(lines []string) ([]string, int) {
+	n := 0
+	for n < len(lines) && !blank(lines[n]) {
+		n++
+	}
+	return lines[:n], n
+}
+
+// code returns inline code, with enough backticks to hold the text.
+func code(s string) string {
+	if strings.Contains(s, "`") {
+		return "`` " + s + " ``"
+	}
+	return "`" + s + "`"
+}
@@ -0,0 +1,202 @@
+package mermaid
+
+import (
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// Directions a line can leave a cell in.
+const (
+	up uint8 = 1 << iota
+	down
+	left
+	right
+)
+
+type lineStyle int
+
+const (
+	solid lineStyle = iota
+	dotted
+	thick
+)
+
+// wide marks the cell taken up by the right half of a wide character.
+const wide = rune(-1)
+
+type cell struct {
+	r     rune // drawn over lines, if set
+	lines uint8
+	style lineStyle
+}
+
+// canvas is a grid of characters that grows as it's drawn on. Lines are
+// drawn as the directions they leave each cell in, so that lines crossing or
+// meeting each other end up joined by the right junction.
+type canvas struct {
+	rows [][]cell
+}
+
+func (c *canvas) at(x, y int) *cell {
+	for len(c.rows) <= y {
+		c.rows = append(c.rows, nil)
+	}
+	for len(c.rows[y]) <= x {
+		c.rows[y] = append(c.rows[y], cell{})
+	}
+	return &c.rows[y][x]
+}
+
+// width returns the number of columns drawn on.
+func (c *canvas) width() int {
+	w := 0
+	for _, row := range c.rows {
+		w = max(w, len(row))
+	}
+	return w
+}
+
+func (c *canvas) put(x, y int, r rune) {
+	if x < 0 || y < 0 {
+		return
+	}
+	c.at(x, y).r = r
+}
+
+// text writes s from x on, left to right.
+func (c *canvas) text(x, y int, s string) {
+	for _, r := range s {
+		c.put(x, y, r)
+		x++
+		if runewidth.RuneWidth(r) == 2 { //nolint:mnd
+			c.put(x, y, wide)
+			x++
+		}
+	}
+}
+
+// free reports whether nothing has been drawn from x to x+n-1 on row y.
+func (c *canvas) free(x, y, n int) bool {
+	if x < 0 || y < 0 {
+		return false
+	}
+	for i := x; i < x+n; i++ {
+		if y < len(c.rows) && i < len(c.rows[y]) && (c.rows[y][i].r != 0 || c.rows[y][i].lines != 0) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *canvas) addLines(x, y int, dirs uint8, style lineStyle) {
+	if x < 0 || y < 0 {
+		return
+	}
+	cl := c.at(x, y)
+	switch {
+	case cl.lines == 0:
+		cl.style = style
+	case cl.style != style:
+		cl.style = solid
+	}
+	cl.lines |= dirs
+}
+
+// hline draws a horizontal line between two columns, both included.
+func (c *canvas) hline(y, x1, x2 int, style lineStyle) {
+	if x1 > x2 {
+		x1, x2 = x2, x1
+	}
+	if x1 == x2 {
+		c.addLines(x1, y, left|right, style)
+		return
+	}
+	for x := x1; x <= x2; x++ {
+		var dirs uint8
+		if x > x1 {
+			dirs |= left
+		}
+		if x < x2 {
+			dirs |= right
+		}
+		c.addLines(x, y, dirs, style)
+	}
+}
+
+// vline draws a vertical line between two rows, both included.
+func (c *canvas) vline(x, y1, y2 int, style lineStyle) {
+	if y1 > y2 {
+		y1, y2 = y2, y1
+	}
+	if y1 == y2 {
+		c.addLines(x, y1, up|down, style)
+		return
+	}
+	for y := y1; y <= y2; y++ {
+		var dirs uint8
+		if y > y1 {
+			dirs |= up
+		}
+		if y < y2 {
+			dirs |= down
+		}
+		c.addLines(x, y, dirs, style)
+	}
+}
+
+// box draws a box with its top left corner at x, y and its text lines
+// centered in it, clearing whatever was below it.
+func (c *canvas) box(x, y, w, h int, b boxChars, text []string) {
+	for j := y; j < y+h; j++ {
+		for i := x; i < x+w; i++ {
+			c.put(i, j, ' ')
+		}
+	}
+	for i := x + 1; i < x+w-1; i++ {
+		c.put(i, y, b.h)
+		c.put(i, y+h-1, b.h)
+	}
+	for j := y + 1; j < y+h-1; j++ {
+		c.put(x, j, b.v)
+		c.put(x+w-1, j, b.v)
+	}
+	c.put(x, y, b.tl)
+	c.put(x+w-1, y, b.tr)
+	c.put(x, y+h-1, b.bl)
+	c.put(x+w-1, y+h-1, b.br)
+	for i, l := range text {
+		c.text(x+(w-runewidth.StringWidth(l))/2, y+1+i, l) //nolint:mnd
+	}
+}
+
+// String returns what was drawn, with lines drawn in the given characters
+// and trailing blanks left out.
+func (c *canvas) String(cs *charset) string {
+	var b strings.Builder
+	rows := c.rows
+	for len(rows) > 0 && strings.TrimSpace(c.row(rows[len(rows)-1], cs)) == "" {
+		rows = rows[:len(rows)-1]
+	}
+	for _, row := range rows {
+		b.WriteString(strings.TrimRight(c.row(row, cs), " "))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+func (c *canvas) row(row []cell, cs *charset) string {
+	var b strings.Builder
+	for _, cl := range row {
+		switch {
+		case cl.r == wide:
+		case cl.r != 0:
+			b.WriteRune(cl.r)
+		case cl.lines != 0:
+			b.WriteRune(cs.line(cl.lines, cl.style))
+		default:
+			b.WriteByte(' ')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,856 @@
+package mermaid
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+)
+
+type shape int
+
+const (
+	rectShape shape = iota
+	roundShape
+	decisionShape
+)
+
+type headKind int
+
+const (
+	noHead headKind = iota
+	arrowHead
+	crossHead
+	circleHead
+)
+
+type node struct {
+	id    string
+	label []string
+	shape shape
+}
+
+type edge struct {
+	from, to         int
+	style            lineStyle
+	fromHead, toHead headKind
+	label            string
+}
+
+type flowchart struct {
+	td    bool // top down or bottom up, rather than left to right or back
+	flip  bool // bottom up or right to left
+	nodes []node
+	ids   map[string]int
+	edges []edge
+}
+
+// shapes are the brackets node labels are put in, by the one they open
+// with, longest first. Shapes that can't be drawn in text are drawn as
+// rectangles or rounded boxes.
+var shapes = []struct {
+	open, close string
+	shape       shape
+}{
+	{"(((", ")))", roundShape},
+	{"((", "))", roundShape},
+	{"([", "])", roundShape},
+	{"[[", "]]", rectShape},
+	{"[(", ")]", rectShape},
+	{"[/", "]", rectShape},
+	{`[\`, "]", rectShape},
+	{"{{", "}}", decisionShape},
+	{"(", ")", roundShape},
+	{"[", "]", rectShape},
+	{"{", "}", decisionShape},
+	{">", "]", rectShape},
+}
+
+var (
+	nodeID    = regexp.MustCompile(`^[\p{L}\p{N}_]+(?:-[\p{L}\p{N}_]+)*`)
+	nodeClass = regexp.MustCompile(`^:::[\w-]+`)
+	// A -- text --> B, A -. text .-> B and A == text ==> B
+	labelledLink = regexp.MustCompile(`^(<)?(--|==|-\.)\s*([^\s\-=.>|<][^|]*?)\s*(-{2,}|={2,}|\.-)(>|[xo]\b)?`)
+	// A --> B, A --- B, A -.-> B, A ==> B, with an optional |text|
+	plainLink = regexp.MustCompile(`^(<)?(-{2,}|={2,}|-\.+-)(>|[xo]\b)?(?:\s*\|([^|]*)\|)?`)
+)
+
+// ignoredStatements start statements that don't change what's drawn.
+var ignoredStatements = []string{"classDef", "class", "style", "linkStyle", "click", "subgraph", "end", "direction", "accTitle", "accDescr"}
+
+func parseFlowchart(dir string, lines []string) (*flowchart, error) {
+	f := &flowchart{ids: make(map[string]int)}
+	switch dir {
+	case "TD", "TB":
+		f.td = true
+	case "BT":
+		f.td, f.flip = true, true
+	case "LR":
+	case "RL":
+		f.flip = true
+	default:
+		return nil, fmt.Errorf("unsupported flowchart direction %q", dir)
+	}
+
+	for _, l := range lines {
+		for _, s := range splitStatements(l) {
+			if first, _, _ := strings.Cut(s, " "); containsWord(ignoredStatements, strings.TrimRight(first, ":")) {
+				continue
+			}
+			if err := f.statement(s); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(f.nodes) == 0 {
+		return nil, fmt.Errorf("flowchart has no nodes")
+	}
+	return f, nil
+}
+
+func containsWord(words []string, w string) bool {
+	for _, v := range words {
+		if v == w {
+			return true
+		}
+	}
+	return false
+}
+
+// splitStatements splits a line at semicolons that aren't in labels.
+func splitStatements(l string) []string {
+	var (
+		out   []string
+		depth int
+		quote bool
+		start int
+	)
+	for i, r := range l {
+		switch {
+		case r == '"':
+			quote = !quote
+		case quote:
+		case strings.ContainsRune("([{", r):
+			depth++
+		case strings.ContainsRune(")]}", r):
+			depth--
+		case r == ';' && depth <= 0:
+			out = append(out, l[start:i])
+			start = i + 1
+		}
+	}
+	out = append(out, l[start:])
+
+	stmts := out[:0]
+	for _, s := range out {
+		if s = strings.TrimSpace(s); s != "" {
+			stmts = append(stmts, s)
+		}
+	}
+	return stmts
+}
+
+// statement reads a node, or a chain of nodes joined by links, such as
+// A --> B & C -.-> D.
+func (f *flowchart) statement(s string) error {
+	prev, rest, err := f.nodeGroup(s)
+	if err != nil {
+		return err
+	}
+	for {
+		rest = strings.TrimLeft(rest, " \t")
+		if rest == "" {
+			return nil
+		}
+		e, n, ok := parseLink(rest)
+		if !ok {
+			return fmt.Errorf("can't read %q in %q", rest, s)
+		}
+		var next []int
+		if next, rest, err = f.nodeGroup(rest[n:]); err != nil {
+			return err
+		}
+		for _, a := range prev {
+			for _, b := range next {
+				e.from, e.to = a, b
+				f.edges = append(f.edges, e)
+			}
+		}
+		prev = next
+	}
+}
+
+// nodeGroup reads nodes joined by &.
+func (f *flowchart) nodeGroup(s string) ([]int, string, error) {
+	var nodes []int
+	for {
+		n, rest, err := f.node(strings.TrimLeft(s, " \t"))
+		if err != nil {
+			return nil, "", err
+		}
+		nodes = append(nodes, n)
+		s = strings.TrimLeft(rest, " \t")
+		if !strings.HasPrefix(s, "&") {
+			return nodes, s, nil
+		}
+		s = s[1:]
+	}
+}
+
+// node reads a node's id and, if it's given one, its label and shape.
+func (f *flowchart) node(s string) (int, string, error) {
+	id := nodeID.FindString(s)
+	if id == "" {
+		return 0, "", fmt.Errorf("expected a node at %q", s)
+	}
+	s = s[len(id):]
+
+	var (
+		label    []string
+		sh       shape
+		labelled bool
+	)
+	for _, b := range shapes {
+		if !strings.HasPrefix(s, b.open) {
+			continue
+		}
+		text, rest, ok := bracketed(s[len(b.open):], b.close)
+		if !ok {
+			return 0, "", fmt.Errorf("unclosed %s in %q", b.open, s)
+		}
+		if b.open == "[/" || b.open == `[\` {
+			text = strings.TrimRight(text, `/\`)
+		}
+		label, sh, labelled = labelLines(text), b.shape, true
+		s = rest
+		break
+	}
+	s = s[len(nodeClass.FindString(s)):]
+
+	i, ok := f.ids[id]
+	if !ok {
+		i = len(f.nodes)
+		f.ids[id] = i
+		f.nodes = append(f.nodes, node{id: id, label: []string{id}})
+	}
+	if labelled {
+		f.nodes[i].label, f.nodes[i].shape = label, sh
+	}
+	return i, s, nil
+}
+
+// bracketed returns the text before a closing bracket, which may be quoted,
+// and what follows the bracket.
+func bracketed(s, closing string) (string, string, bool) {
+	if t := strings.TrimLeft(s, " "); strings.HasPrefix(t, `"`) {
+		if end := strings.Index(t[1:], `"`); end >= 0 {
+			if after := strings.TrimLeft(t[end+2:], " "); strings.HasPrefix(after, closing) {
+				return t[1 : end+1], after[len(closing):], true
+			}
+		}
+	}
+	end := strings.Index(s, closing)
+	if end < 0 {
+		return "", "", false
+	}
+	return s[:end], s[end+len(closing):], true
+}
+
+// parseLink reads a link between nodes, returning it and its length.
+func parseLink(s string) (edge, int, bool) {
+	if m := labelledLink.FindStringSubmatch(s); m != nil {
+		return newEdge(m[1], m[2]+m[4], m[5], m[3]), len(m[0]), true
+	}
+	if m := plainLink.FindStringSubmatch(s); m != nil {
+		return newEdge(m[1], m[2], m[3], m[4]), len(m[0]), true
+	}
+	return edge{}, 0, false
+}
+
+func newEdge(tail, line, head, label string) edge {
+	e := edge{label: strings.Join(labelLines(strings.Trim(label, `"`)), " ")}
+	switch {
+	case strings.Contains(line, "="):
+		e.style = thick
+	case strings.Contains(line, "."):
+		e.style = dotted
+	}
+	if tail == "<" {
+		e.fromHead = arrowHead
+	}
+	switch head {
+	case ">":
+		e.toHead = arrowHead
+	case "x":
+		e.toHead = crossHead
+	case "o":
+		e.toHead = circleHead
+	}
+	return e
+}
+
+// A flowchart is drawn in layers: each node is put in the layer below the
+// nodes that link to it, and links spanning several layers pass through a
+// placeholder in each of them. Layers are stacked along the main axis, down
+// or to the right, and their nodes are lined up along the cross axis.
+
+// placed is a node or placeholder in its layer.
+type placed struct {
+	node         int // -1 for placeholders
+	layer        int
+	main, cross  int // extent along each axis
+	w, h         int
+	pos          int // along the cross axis
+	style        lineStyle
+	upper, lower []int
+}
+
+func (p *placed) center() int {
+	return p.pos + p.cross/2 //nolint:mnd
+}
+
+// segment is part of an edge, between adjacent layers.
+type segment struct {
+	upper, lower int
+	edge         int
+	first, last  bool
+	offset       int // from the centers, to keep parallel segments apart
+}
+
+// ends returns where a segment leaves the node above and reaches the one
+// below, along the cross axis.
+func (s segment) ends(nodes []*placed) (int, int) {
+	return nodes[s.upper].center() + s.offset, nodes[s.lower].center() + s.offset
+}
+
+// laidEdge is an edge running from the layer above to the one below.
+type laidEdge struct {
+	style               lineStyle
+	topHead, bottomHead headKind
+	label               string
+}
+
+func (f *flowchart) draw(cs *charset) string {
+	layers := f.layers()
+
+	// place nodes, and break up edges into segments between layers
+	var (
+		nodes    []*placed
+		segments []segment
+		edges    []laidEdge
+	)
+	for i, n := range f.nodes {
+		p := &placed{node: i, layer: layers[i]}
+		p.w, p.h = labelWidth(n.label)+4, len(n.label)+2 //nolint:mnd
+		// odd sizes across keep centers of nodes aligned
+		if f.td {
+			p.w |= 1
+			p.main, p.cross = p.h, p.w
+		} else {
+			p.h |= 1
+			p.main, p.cross = p.w, p.h
+		}
+		nodes = append(nodes, p)
+	}
+	for _, e := range f.edges {
+		if e.from == e.to {
+			continue
+		}
+		top, bottom, topHead, bottomHead := e.from, e.to, e.fromHead, e.toHead
+		if layers[top] > layers[bottom] {
+			top, bottom, topHead, bottomHead = bottom, top, bottomHead, topHead
+		}
+		i := len(edges)
+		edges = append(edges, laidEdge{e.style, topHead, bottomHead, e.label})
+		prev := top
+		for l := layers[top] + 1; l <= layers[bottom]; l++ {
+			next := bottom
+			if l < layers[bottom] {
+				next = len(nodes)
+				nodes = append(nodes, &placed{node: -1, layer: l, cross: 1, style: e.style})
+			}
+			segments = append(segments, segment{prev, next, i, prev == top, next == bottom, 0})
+			nodes[prev].lower = append(nodes[prev].lower, next)
+			nodes[next].upper = append(nodes[next].upper, prev)
+			prev = next
+		}
+	}
+
+	// segments between the same nodes run side by side, as far apart as the
+	// smaller node allows
+	parallel := make(map[[2]int]int)
+	for i, s := range segments {
+		pair := [2]int{s.upper, s.lower}
+		room := min(nodes[s.upper].cross, nodes[s.lower].cross)/2 - 1 //nolint:mnd
+		segments[i].offset = max(0, min(2*parallel[pair], room))      //nolint:mnd
+		parallel[pair]++
+	}
+
+	byLayer := orderLayers(nodes)
+	band := make([]int, len(byLayer))
+	for l, layer := range byLayer {
+		band[l] = 1
+		for _, v := range layer {
+			band[l] = max(band[l], nodes[v].main)
+		}
+		for _, v := range layer {
+			if nodes[v].node < 0 {
+				nodes[v].main = band[l]
+			}
+		}
+	}
+	f.placeAcross(nodes, byLayer)
+
+	// the gap after each layer has room for a line leaving the layer, one
+	// track for each bunch of lines running across, labels and arrowheads
+	stub := 1
+	if !f.td {
+		stub = 2
+	}
+	var (
+		gaps   = make([]gap, len(byLayer))
+		starts = make([]int, len(byLayer))
+	)
+	for i, s := range segments {
+		g := &gaps[nodes[s.upper].layer]
+		g.segments = append(g.segments, i)
+	}
+	for l := range gaps {
+		gaps[l].layout(nodes, segments, edges, f.td)
+		if l > 0 {
+			starts[l] = starts[l-1] + band[l-1] + stub + gaps[l-1].size()
+		}
+	}
+
+	var c canvas
+	pt := func(m, k int) (int, int) {
+		if f.td {
+			return k, m
+		}
+		return m, k
+	}
+	mainLine := func(k, m1, m2 int, style lineStyle) {
+		if f.td {
+			c.vline(k, m1, m2, style)
+		} else {
+			c.hline(k, m1, m2, style)
+		}
+	}
+	crossLine := func(m, k1, k2 int, style lineStyle) {
+		if f.td {
+			c.hline(m, k1, k2, style)
+		} else {
+			c.vline(m, k1, k2, style)
+		}
+	}
+	put := func(m, k int, r rune) {
+		x, y := pt(m, k)
+		c.put(x, y, r)
+	}
+
+	for _, p := range nodes {
+		if p.node < 0 {
+			mainLine(p.center(), starts[p.layer], starts[p.layer]+band[p.layer]-1, p.style)
+		}
+	}
+	// where lines meet boxes: heads are drawn next to them, and ports on
+	// their borders unless a head is drawn next to it
+	type port struct {
+		m, k, head int
+		r          rune
+	}
+	var (
+		heads = make(map[[2]int]rune)
+		ports []port
+	)
+	for i, s := range segments {
+		u, v, e := nodes[s.upper], nodes[s.lower], edges[s.edge]
+		l := u.layer
+		g := &gaps[l]
+		x1, x2 := s.ends(nodes)
+		m0 := starts[l] + u.main
+		if u.node < 0 {
+			m0 = starts[l] + band[l]
+		}
+		arrow := starts[l+1] - 1
+		end := arrow
+		if v.node < 0 {
+			end = starts[l+1]
+		}
+		if x1 == x2 {
+			mainLine(x1, m0, end, e.style)
+		} else {
+			track := starts[l] + band[l] + stub + g.tracks[i]
+			mainLine(x1, m0, track, e.style)
+			crossLine(track, x1, x2, e.style)
+			mainLine(x2, track, end, e.style)
+		}
+
+		if s.first && e.topHead != noHead {
+			heads[[2]int{m0, x1}] = cs.head(e.topHead, f.td, false)
+		} else if u.node >= 0 {
+			b := cs.box(f.nodes[u.node].shape)
+			p := b.portRight
+			if f.td {
+				p = b.portDown
+			}
+			ports = append(ports, port{m0 - 1, x1, m0, p})
+		}
+		if s.last && e.bottomHead != noHead {
+			heads[[2]int{arrow, x2}] = cs.head(e.bottomHead, f.td, true)
+		} else if v.node >= 0 {
+			b := cs.box(f.nodes[v.node].shape)
+			p := b.portLeft
+			if f.td {
+				p = b.portUp
+			}
+			ports = append(ports, port{arrow + 1, x2, arrow, p})
+		}
+	}
+
+	for _, p := range nodes {
+		if p.node < 0 {
+			continue
+		}
+		x, y := pt(starts[p.layer], p.pos)
+		c.box(x, y, p.w, p.h, cs.box(f.nodes[p.node].shape), f.nodes[p.node].label)
+	}
+	for _, p := range ports {
+		if _, ok := heads[[2]int{p.head, p.k}]; !ok {
+			put(p.m, p.k, p.r)
+		}
+	}
+	for at, r := range heads {
+		put(at[0], at[1], r)
+	}
+
+	for l, g := range gaps {
+		at := starts[l] + band[l] + stub + g.trackCount
+		for _, lb := range g.labels {
+			w := runewidth.StringWidth(lb.text)
+			if !f.td {
+				c.text(at+(g.labelSpan-w-2)/2, lb.k, " "+lb.text+" ") //nolint:mnd
+				continue
+			}
+			x := lb.k + 2 //nolint:mnd
+			if !c.free(x, at, w) && c.free(lb.k-1-w, at, w) {
+				x = lb.k - 1 - w
+			}
+			c.text(x, at, lb.text)
+		}
+	}
+	return c.String(cs)
+}
+
+// layers puts each node in the layer after the nodes that link to it. Links
+// that close a cycle are left out; they're drawn the other way around.
+func (f *flowchart) layers() []int {
+	n := len(f.nodes)
+	out := make([][]int, n)
+	for _, e := range f.edges {
+		if e.from != e.to {
+			out[e.from] = append(out[e.from], e.to)
+		}
+	}
+
+	// find links back to a node on the way to the one they're from
+	var (
+		succ  = make([][]int, n)
+		state = make([]int, n) // 0 unseen, 1 on the way, 2 done
+		visit func(int)
+	)
+	visit = func(v int) {
+		state[v] = 1
+		for _, w := range out[v] {
+			switch state[w] {
+			case 0:
+				succ[v] = append(succ[v], w)
+				visit(w)
+			case 1:
+				succ[w] = append(succ[w], v)
+			default:
+				succ[v] = append(succ[v], w)
+			}
+		}
+		state[v] = 2 //nolint:mnd
+	}
+	for v := 0; v < n; v++ {
+		if state[v] == 0 {
+			visit(v)
+		}
+	}
+
+	// longest paths from the nodes nothing links to
+	layer := make([]int, n)
+	indegree := make([]int, n)
+	for _, ws := range succ {
+		for _, w := range ws {
+			indegree[w]++
+		}
+	}
+	var queue []int
+	for v := 0; v < n; v++ {
+		if indegree[v] == 0 {
+			queue = append(queue, v)
+		}
+	}
+	last := 0
+	for len(queue) > 0 {
+		v := queue[0]
+		queue = queue[1:]
+		for _, w := range succ[v] {
+			layer[w] = max(layer[w], layer[v]+1)
+			last = max(last, layer[w])
+			if indegree[w]--; indegree[w] == 0 {
+				queue = append(queue, w)
+			}
+		}
+	}
+	if f.flip {
+		for v := range layer {
+			layer[v] = last - layer[v]
+		}
+	}
+	return layer
+}
+
+// orderLayers orders the nodes of each layer to keep links from crossing,
+// by moving nodes towards the average position of their neighbors, layer by
+// layer, down and up again a few times.
+func orderLayers(nodes []*placed) [][]int {
+	var layers [][]int
+	for i, p := range nodes {
+		for len(layers) <= p.layer {
+			layers = append(layers, nil)
+		}
+		layers[p.layer] = append(layers[p.layer], i)
+	}
+	index := make([]int, len(nodes))
+	for _, layer := range layers {
+		for i, v := range layer {
+			index[v] = i
+		}
+	}
+	sweep := func(layer []int, neighbors func(*placed) []int) {
+		weight := make(map[int]float64, len(layer))
+		for i, v := range layer {
+			ns := neighbors(nodes[v])
+			if len(ns) == 0 {
+				weight[v] = float64(i)
+				continue
+			}
+			sum := 0
+			for _, u := range ns {
+				sum += index[u]
+			}
+			weight[v] = float64(sum) / float64(len(ns))
+		}
+		sort.SliceStable(layer, func(i, j int) bool { return weight[layer[i]] < weight[layer[j]] })
+		for i, v := range layer {
+			index[v] = i
+		}
+	}
+	for i := 0; i < 4; i++ {
+		for l := 1; l < len(layers); l++ {
+			sweep(layers[l], func(p *placed) []int { return p.upper })
+		}
+		if i == 3 { //nolint:mnd
+			break
+		}
+		for l := len(layers) - 2; l >= 0; l-- {
+			sweep(layers[l], func(p *placed) []int { return p.lower })
+		}
+	}
+	return layers
+}
+
+// placeAcross lines up the nodes of each layer, centering layers on each
+// other.
+func (f *flowchart) placeAcross(nodes []*placed, layers [][]int) {
+	spacing := 1
+	if f.td {
+		spacing = 3
+	}
+	size := make([]int, len(layers))
+	widest := 0
+	for l, layer := range layers {
+		for i, v := range layer {
+			if i > 0 {
+				size[l] += spacing
+			}
+			size[l] += nodes[v].cross
+		}
+		widest = max(widest, size[l])
+	}
+	for l, layer := range layers {
+		pos := (widest - size[l]) / 2 //nolint:mnd
+		for _, v := range layer {
+			nodes[v].pos = pos
+			pos += nodes[v].cross + spacing
+		}
+	}
+}
+
+// gap is the space between a layer and the next.
+type gap struct {
+	segments   []int
+	tracks     map[int]int // by segment
+	trackCount int
+	labels     []gapLabel
+	labelSpan  int
+}
+
+type gapLabel struct {
+	k    int
+	text string
+}
+
+func (g *gap) size() int {
+	return g.trackCount + g.labelSpan + 1
+}
+
+// layout gives each bunch of lines leaving a node for another column of
+// the next layer, or reaching a node from several columns, a track to run
+// across on. Bunches that don't overlap share a track. Labels of lines
+// ending at the same node are joined.
+func (g *gap) layout(nodes []*placed, segments []segment, edges []laidEdge, td bool) {
+	type bunch struct {
+		k     int
+		below bool
+	}
+	type span struct {
+		bunch  bunch
+		lo, hi int
+	}
+	var (
+		fanOut = make(map[int]int)
+		fanIn  = make(map[int]int)
+		spans  = make(map[bunch]*span)
+		labels = make(map[int][]string)
+		order  []int
+	)
+	for _, i := range g.segments {
+		if x1, x2 := segments[i].ends(nodes); x1 != x2 {
+			fanOut[x1]++
+			fanIn[x2]++
+		}
+	}
+	bunches := make(map[int]bunch)
+	for _, i := range g.segments {
+		s := segments[i]
+		x1, x2 := s.ends(nodes)
+		if s.last && edges[s.edge].label != "" && !containsWord(labels[x2], edges[s.edge].label) {
+			if len(labels[x2]) == 0 {
+				order = append(order, x2)
+			}
+			labels[x2] = append(labels[x2], edges[s.edge].label)
+		}
+		if x1 == x2 {
+			continue
+		}
+		b := bunch{x1, false}
+		if fanIn[x2] > fanOut[x1] {
+			b = bunch{x2, true}
+		}
+		bunches[i] = b
+		sp, ok := spans[b]
+		if !ok {
+			sp = &span{b, b.k, b.k}
+			spans[b] = sp
+		}
+		sp.lo, sp.hi = min(sp.lo, x1, x2), max(sp.hi, x1, x2)
+	}
+
+	sorted := make([]*span, 0, len(spans))
+	for _, sp := range spans {
+		sorted = append(sorted, sp)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if a.lo != b.lo {
+			return a.lo < b.lo
+		}
+		if a.hi != b.hi {
+			return a.hi < b.hi
+		}
+		if a.bunch.below != b.bunch.below {
+			return b.bunch.below
+		}
+		return a.bunch.k < b.bunch.k
+	})
+	tracks := make(map[bunch]int)
+	var taken []int // where each track is taken up to
+	for _, sp := range sorted {
+		t := 0
+		for t < len(taken) && taken[t] >= sp.lo-1 {
+			t++
+		}
+		if t == len(taken) {
+			taken = append(taken, 0)
+		}
+		taken[t] = sp.hi
+		tracks[sp.bunch] = t
+	}
+	g.tracks = make(map[int]int)
+	for i, b := range bunches {
+		g.tracks[i] = tracks[b]
+	}
+	g.trackCount = len(taken)
+
+	sort.Ints(order)
+	widest := 0
+	for _, k := range order {
+		text := strings.Join(labels[k], ", ")
+		g.labels = append(g.labels, gapLabel{k, text})
+		widest = max(widest, runewidth.StringWidth(text))
+	}
+	switch {
+	case len(g.labels) == 0:
+	case td:
+		g.labelSpan = 1
+	default:
+		g.labelSpan = widest + 4 //nolint:mnd
+	}
+}
+
+func labelWidth(lines []string) int {
+	w := 0
+	for _, l := range lines {
+		w = max(w, runewidth.StringWidth(l))
+	}
+	return w
+}
+
+func (cs *charset) box(s shape) boxChars {
+	switch s {
+	case roundShape:
+		return cs.round
+	case decisionShape:
+		return cs.decision
+	default:
+		return cs.rect
+	}
+}
+
+// head returns the character a line ends in, pointing forward along the
+// main axis or back.
+func (cs *charset) head(h headKind, td, forward bool) rune {
+	switch h {
+	case crossHead:
+		return cs.cross
+	case circleHead:
+		return cs.circle
+	}
+	switch {
+	case td && forward:
+		return cs.arrowDown
+	case td:
+		return cs.arrowUp
+	case forward:
+		return cs.arrowRight
+	default:
+		return cs.arrowLeft
+	}
+}
@@ -0,0 +1,175 @@
+// Package mermaid draws mermaid diagrams with box-drawing characters, so
+// they can be shown in a terminal.
+//
+// Flowcharts and sequence diagrams are supported with the syntax they're
+// mostly written in: node shapes, edge styles and labels, participants,
+// messages, notes and blocks such as loop. Styling, click handlers and other
+// diagram types are not; Render fails for diagrams it can't draw, so they
+// can be shown as they are instead.
+package mermaid
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Charsets diagrams can be drawn with.
+const (
+	Unicode = "unicode"
+	ASCII   = "ascii"
+)
+
+// Render draws a mermaid diagram in the given charset.
+func Render(src, charset string) (string, error) {
+	cs := &unicodeChars
+	if charset == ASCII {
+		cs = &asciiChars
+	}
+	lines := statements(src)
+	if len(lines) == 0 {
+		return "", errors.New("empty diagram")
+	}
+	header := strings.Fields(lines[0])
+	switch header[0] {
+	case "graph", "flowchart":
+		dir := "TD"
+		if len(header) > 1 {
+			dir = strings.ToUpper(header[1])
+		}
+		f, err := parseFlowchart(dir, lines[1:])
+		if err != nil {
+			return "", err
+		}
+		return f.draw(cs), nil
+	case "sequenceDiagram":
+		s, err := parseSequence(lines[1:])
+		if err != nil {
+			return "", err
+		}
+		return s.draw(cs), nil
+	default:
+		return "", fmt.Errorf("unsupported diagram type %q", header[0])
+	}
+}
+
+// statements returns the trimmed lines of a diagram, without front matter,
+// comments, directives and blank lines.
+func statements(src string) []string {
+	lines := strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n")
+	if len(lines) > 0 && strings.TrimSpace(lines[0]) == "---" {
+		for i := 1; i < len(lines); i++ {
+			if strings.TrimSpace(lines[i]) == "---" {
+				lines = lines[i+1:]
+				break
+			}
+		}
+	}
+	var out []string
+	for _, l := range lines {
+		l = strings.TrimSpace(l)
+		if l == "" || strings.HasPrefix(l, "%%") {
+			continue
+		}
+		out = append(out, l)
+	}
+	return out
+}
+
+var lineBreak = regexp.MustCompile(`(?i)<br\s*/?>`)
+
+// labelLines splits a label into its lines, which are separated by <br>
+// tags.
+func labelLines(s string) []string {
+	lines := lineBreak.Split(strings.TrimSpace(s), -1)
+	for i, l := range lines {
+		lines[i] = strings.TrimSpace(l)
+	}
+	return lines
+}
+
+// boxChars are the characters a box is drawn with.
+type boxChars struct {
+	tl, tr, bl, br, h, v rune
+	// where a line meets the box at the bottom, on the right, at the top and
+	// on the left
+	portDown, portRight, portUp, portLeft rune
+}
+
+// charset is a set of characters diagrams are drawn with.
+type charset struct {
+	// junctions, by the directions lines leave them in
+	junctions map[uint8]rune
+	// horizontal and vertical lines, by style
+	horizontal, vertical [3]rune
+	// arrowheads pointing up, down, left and right, and other heads
+	arrowUp, arrowDown, arrowLeft, arrowRight rune
+	openArrowLeft, openArrowRight             rune
+	cross, circle                             rune
+	rect, round, decision                     boxChars
+}
+
+func (cs *charset) line(dirs uint8, style lineStyle) rune {
+	switch dirs {
+	case left, right, left | right:
+		return cs.horizontal[style]
+	case up, down, up | down:
+		return cs.vertical[style]
+	}
+	return cs.junctions[dirs]
+}
+
+var unicodeChars = charset{
+	junctions: map[uint8]rune{
+		down | right:             '┌',
+		down | left:              '┐',
+		up | right:               '└',
+		up | left:                '┘',
+		up | down | right:        '├',
+		up | down | left:         '┤',
+		down | left | right:      '┬',
+		up | left | right:        '┴',
+		up | down | left | right: '┼',
+	},
+	horizontal:     [3]rune{'─', '┄', '━'},
+	vertical:       [3]rune{'│', '┆', '┃'},
+	arrowUp:        '▲',
+	arrowDown:      '▼',
+	arrowLeft:      '◀',
+	arrowRight:     '▶',
+	openArrowLeft:  '◁',
+	openArrowRight: '▷',
+	cross:          '×',
+	circle:         '●',
+	rect:           boxChars{'┌', '┐', '└', '┘', '─', '│', '┬', '├', '┴', '┤'},
+	round:          boxChars{'╭', '╮', '╰', '╯', '─', '│', '┬', '├', '┴', '┤'},
+	decision:       boxChars{'╔', '╗', '╚', '╝', '═', '║', '╤', '╟', '╧', '╢'},
+}
+
+var asciiChars = charset{
+	junctions: map[uint8]rune{
+		down | right:             '+',
+		down | left:              '+',
+		up | right:               '+',
+		up | left:                '+',
+		up | down | right:        '+',
+		up | down | left:         '+',
+		down | left | right:      '+',
+		up | left | right:        '+',
+		up | down | left | right: '+',
+	},
+	horizontal:     [3]rune{'-', '.', '='},
+	vertical:       [3]rune{'|', ':', '#'},
+	arrowUp:        '^',
+	arrowDown:      'v',
+	arrowLeft:      '<',
+	arrowRight:     '>',
+	openArrowLeft:  '<',
+	openArrowRight: '>',
+	cross:          'x',
+	circle:         'o',
+	rect:           boxChars{'+', '+', '+', '+', '-', '|', '+', '+', '+', '+'},
+	round:          boxChars{'.', '.', '\'', '\'', '-', '|', '+', '+', '+', '+'},
+	decision:       boxChars{'/', '\\', '\\', '/', '-', '|', '+', '+', '+', '+'},
+}
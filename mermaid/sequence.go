@@ -0,0 +1,357 @@
+package mermaid
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+)
+
+type participant struct {
+	id    string
+	label []string
+	actor bool
+}
+
+type itemKind int
+
+const (
+	messageItem itemKind = iota
+	noteItem
+	blockItem   // loop, alt, opt and the like
+	sectionItem // else, and, option
+	endItem
+)
+
+type noteSide int
+
+const (
+	leftOf noteSide = iota
+	rightOf
+	over
+)
+
+type seqItem struct {
+	kind     itemKind
+	from, to int
+	dashed   bool
+	head     headKind
+	open     bool // an open arrowhead, for async messages
+	side     noteSide
+	text     string
+}
+
+type sequence struct {
+	participants []participant
+	ids          map[string]int
+	items        []seqItem
+}
+
+var (
+	participantDecl = regexp.MustCompile(`^(?:create\s+)?(participant|actor)\s+(.+?)(?:\s+as\s+(.+))?$`)
+	message         = regexp.MustCompile(`^(.+?)\s*(--?>>|--?>|--?x|--?\))\s*[+-]?\s*(.+?)\s*(?::(.*))?$`)
+	note            = regexp.MustCompile(`(?i)^note\s+(left of|right of|over)\s+([^:]+?)\s*:(.*)$`)
+)
+
+var (
+	blockKeywords   = []string{"loop", "alt", "opt", "par", "critical", "break", "rect"}
+	sectionKeywords = []string{"else", "and", "option"}
+	// ignoredSequenceStatements start statements that don't change what's
+	// drawn.
+	ignoredSequenceStatements = []string{"activate", "deactivate", "title", "destroy", "links", "link", "properties", "details", "accTitle", "accDescr"}
+)
+
+func parseSequence(lines []string) (*sequence, error) {
+	s := &sequence{ids: make(map[string]int)}
+	var (
+		open       []string // blocks and boxes not yet closed
+		autonumber bool
+		number     int
+	)
+	for _, l := range lines {
+		keyword, rest, _ := strings.Cut(l, " ")
+		rest = strings.TrimSpace(rest)
+		keyword = strings.TrimRight(keyword, ":")
+		switch {
+		case keyword == "autonumber":
+			autonumber = true
+		case containsWord(ignoredSequenceStatements, keyword):
+		case keyword == "box":
+			open = append(open, keyword)
+		case containsWord(blockKeywords, keyword):
+			open = append(open, keyword)
+			if keyword == "rect" {
+				rest = ""
+			}
+			s.items = append(s.items, seqItem{kind: blockItem, text: strings.TrimSpace(keyword + " " + rest)})
+		case containsWord(sectionKeywords, keyword):
+			s.items = append(s.items, seqItem{kind: sectionItem, text: strings.TrimSpace(keyword + " " + rest)})
+		case keyword == "end":
+			if len(open) == 0 {
+				return nil, fmt.Errorf("end without a block")
+			}
+			if open[len(open)-1] != "box" {
+				s.items = append(s.items, seqItem{kind: endItem})
+			}
+			open = open[:len(open)-1]
+		default:
+			if m := participantDecl.FindStringSubmatch(l); m != nil {
+				i := s.participant(m[2])
+				s.participants[i].actor = m[1] == "actor"
+				if m[3] != "" {
+					s.participants[i].label = labelLines(m[3])
+				}
+				continue
+			}
+			if m := note.FindStringSubmatch(l); m != nil {
+				it := seqItem{kind: noteItem, text: m[3]}
+				switch strings.ToLower(m[1]) {
+				case "left of":
+					it.side = leftOf
+				case "right of":
+					it.side = rightOf
+				default:
+					it.side = over
+				}
+				a, b, _ := strings.Cut(m[2], ",")
+				it.from = s.participant(a)
+				it.to = it.from
+				if b != "" {
+					it.to = s.participant(b)
+				}
+				s.items = append(s.items, it)
+				continue
+			}
+			if m := message.FindStringSubmatch(l); m != nil {
+				it := seqItem{kind: messageItem, from: s.participant(m[1]), to: s.participant(m[3]), text: strings.TrimSpace(m[4])}
+				arrow := m[2]
+				it.dashed = strings.HasPrefix(arrow, "--")
+				switch {
+				case strings.HasSuffix(arrow, ">>"):
+					it.head = arrowHead
+				case strings.HasSuffix(arrow, ")"):
+					it.head, it.open = arrowHead, true
+				case strings.HasSuffix(arrow, "x"):
+					it.head = crossHead
+				}
+				if autonumber {
+					number++
+					it.text = strings.TrimSpace(fmt.Sprintf("%d. %s", number, it.text))
+				}
+				s.items = append(s.items, it)
+				continue
+			}
+			return nil, fmt.Errorf("can't read %q", l)
+		}
+	}
+	if len(s.participants) == 0 {
+		return nil, fmt.Errorf("sequence diagram has no participants")
+	}
+	return s, nil
+}
+
+// participant returns the index of a participant, adding it the first time
+// it's mentioned.
+func (s *sequence) participant(id string) int {
+	id = strings.TrimSpace(id)
+	if i, ok := s.ids[id]; ok {
+		return i
+	}
+	s.ids[id] = len(s.participants)
+	s.participants = append(s.participants, participant{id: id, label: []string{id}})
+	return len(s.participants) - 1
+}
+
+// A sequence diagram is drawn as a row of participants, with a lifeline
+// below each of them and one row after another for messages, notes and the
+// lines around blocks, then the participants again at the bottom.
+
+func (s *sequence) draw(cs *charset) string {
+	n := len(s.participants)
+	widths := make([]int, n)
+	height := 0
+	for i, p := range s.participants {
+		widths[i] = (labelWidth(p.label) + 4) | 1 //nolint:mnd
+		height = max(height, len(p.label)+2)      //nolint:mnd
+	}
+
+	// space participants out so that what's drawn between them fits
+	centers := make([]int, n)
+	x := 0
+	for i, w := range widths {
+		centers[i] = x + w/2 //nolint:mnd
+		x += w + 2           //nolint:mnd
+	}
+	apart := func(a, b, d int) {
+		if a > b {
+			a, b = b, a
+		}
+		if short := d - (centers[b] - centers[a]); short > 0 {
+			for i := b; i < n; i++ {
+				centers[i] += short
+			}
+		}
+	}
+	for _, it := range s.items {
+		w := runewidth.StringWidth(it.text)
+		switch {
+		case it.kind == messageItem && it.from != it.to:
+			apart(it.from, it.to, w+4) //nolint:mnd
+		case it.kind == messageItem && it.from+1 < n:
+			apart(it.from, it.from+1, w+6) //nolint:mnd
+		case it.kind == noteItem:
+			w = labelWidth(labelLines(it.text)) + 4 //nolint:mnd
+			switch {
+			case it.side == rightOf && it.from+1 < n:
+				apart(it.from, it.from+1, w+4) //nolint:mnd
+			case it.side == leftOf && it.from > 0:
+				apart(it.from-1, it.from, w+4) //nolint:mnd
+			case it.side == over && it.from == it.to:
+				if it.from > 0 {
+					apart(it.from-1, it.from, w/2+3) //nolint:mnd
+				}
+				if it.from+1 < n {
+					apart(it.from, it.from+1, w/2+3) //nolint:mnd
+				}
+			}
+		}
+	}
+
+	// notes left of the first participant push everything right
+	shift := 0
+	for _, it := range s.items {
+		if it.kind == noteItem {
+			x, _ := s.noteSpan(it, centers)
+			shift = max(shift, -x)
+		}
+	}
+	for i := range centers {
+		centers[i] += shift
+	}
+
+	var (
+		c      canvas
+		blocks []int // rows of block lines, drawn once the width is known
+		labels []string
+		y      = height
+	)
+	for _, it := range s.items {
+		switch it.kind {
+		case messageItem:
+			style := solid
+			if it.dashed {
+				style = dotted
+			}
+			a := centers[it.from]
+			if it.from == it.to {
+				c.hline(y, a, a+2, style)
+				c.vline(a+2, y, y+1, style)
+				c.hline(y+1, a+1, a+2, style)
+				c.put(a+1, y+1, s.head(cs, it, false))
+				c.text(a+4, y, it.text) //nolint:mnd
+				y += 2                  //nolint:mnd
+				continue
+			}
+			b := centers[it.to]
+			c.text((a+b)/2-runewidth.StringWidth(it.text)/2, y, it.text) //nolint:mnd
+			y++
+			end := b
+			if it.head != noHead && b > a {
+				end = b - 1
+			} else if it.head != noHead {
+				end = b + 1
+			}
+			c.hline(y, a, end, style)
+			if it.head != noHead {
+				c.put(end, y, s.head(cs, it, b > a))
+			}
+			y++
+		case noteItem:
+			text := labelLines(it.text)
+			x, w := s.noteSpan(it, centers)
+			c.box(x, y, w, len(text)+2, cs.rect, text) //nolint:mnd
+			y += len(text) + 2                         //nolint:mnd
+		case blockItem, sectionItem, endItem:
+			blocks = append(blocks, y)
+			labels = append(labels, it.text)
+			y++
+		}
+	}
+
+	// lifelines, leaving the cells messages and notes were drawn in alone
+	for _, x := range centers {
+		for j := height - 1; j <= y; j++ {
+			if c.at(x, j).r != 0 {
+				continue
+			}
+			var dirs uint8
+			if j > height-1 {
+				dirs |= up
+			}
+			if j < y {
+				dirs |= down
+			}
+			c.addLines(x, j, dirs, solid)
+		}
+	}
+	for i, p := range s.participants {
+		b := cs.rect
+		if p.actor {
+			b = cs.round
+		}
+		x := centers[i] - widths[i]/2 //nolint:mnd
+		c.box(x, 0, widths[i], height, b, p.label)
+		c.box(x, y, widths[i], height, b, p.label)
+		c.put(centers[i], height-1, b.portDown)
+		c.put(centers[i], y, b.portUp)
+	}
+
+	width := c.width()
+	for i, row := range blocks {
+		for x := 0; x < width; x++ {
+			if c.free(x, row, 1) {
+				c.put(x, row, cs.horizontal[dotted])
+			}
+		}
+		if labels[i] != "" {
+			c.text(2, row, " "+labels[i]+" ") //nolint:mnd
+		}
+	}
+	return c.String(cs)
+}
+
+// noteSpan returns where a note starts and how wide it is.
+func (s *sequence) noteSpan(it seqItem, centers []int) (int, int) {
+	w := labelWidth(labelLines(it.text)) + 4 //nolint:mnd
+	a, b := centers[it.from], centers[it.to]
+	switch {
+	case it.side == rightOf:
+		return a + 2, w //nolint:mnd
+	case it.side == leftOf:
+		return a - 1 - w, w
+	case it.from == it.to:
+		return a - w/2, w //nolint:mnd
+	}
+	if a > b {
+		a, b = b, a
+	}
+	w = max(w, b-a+5)       //nolint:mnd
+	return (a+b)/2 - w/2, w //nolint:mnd
+}
+
+// head returns the character a message ends in.
+func (s *sequence) head(cs *charset, it seqItem, right bool) rune {
+	switch {
+	case it.head == crossHead:
+		return cs.cross
+	case it.open && right:
+		return cs.openArrowRight
+	case it.open:
+		return cs.openArrowLeft
+	case right:
+		return cs.arrowRight
+	default:
+		return cs.arrowLeft
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/glow/v2/mermaid"
+	"github.com/charmbracelet/glow/v2/utils"
+)
+
+func TestMermaid(t *testing.T) {
+	flowchart := "graph TD\nA[Start] -->|go| B{Done?}\nB -. no .-> A\n"
+	got, err := mermaid.Render(flowchart, mermaid.Unicode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"│ Start │", "║ Done? ║", "▼", "▲", "go", "no", "┆"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("flowchart has no %q:\n%s", want, got)
+		}
+	}
+
+	sequence := "sequenceDiagram\nparticipant A as Alice\nA->>Bob: hi\nloop daily\nBob-->>A: hello\nend\n"
+	got, err = mermaid.Render(sequence, mermaid.ASCII)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"| Alice |", "| Bob |", "----->|", "|<.....", " loop daily "} {
+		if !strings.Contains(got, want) {
+			t.Errorf("sequence diagram has no %q:\n%s", want, got)
+		}
+	}
+	if strings.Count(got, "| Alice |") != 2 {
+		t.Errorf("want participants above and below the diagram:\n%s", got)
+	}
+
+	if _, err := mermaid.Render("pie title Pets\n\"Dogs\" : 386\n", mermaid.Unicode); err == nil {
+		t.Error("drew a pie chart")
+	}
+	if _, err := mermaid.Render("graph TD\nA --> B(unclosed\n", mermaid.Unicode); err == nil {
+		t.Error("drew a node that isn't closed")
+	}
+}
+
+func TestRenderMermaid(t *testing.T) {
+	md := "Text\n\n  ```mermaid\n  graph LR\n  A --> B\n  ```\n\n```mermaid\npie\n```\n"
+	got := utils.RenderMermaid(md, mermaid.Unicode, 0)
+	if strings.Contains(got, "A --> B") || !strings.Contains(got, "  ```text\n  ┌───┐") {
+		t.Errorf("flowchart not drawn in place:\n%s", got)
+	}
+	if !strings.HasSuffix(got, "```mermaid\npie\n```\n") {
+		t.Errorf("pie chart not left as is:\n%s", got)
+	}
+
+	if narrow := utils.RenderMermaid(md, mermaid.Unicode, 10); narrow != md {
+		t.Errorf("drew a diagram wider than the width:\n%s", narrow)
+	}
+	unclosed := "```mermaid\ngraph LR\nA --> B\n"
+	if got := utils.RenderMermaid(unclosed, mermaid.Unicode, 0); got != unclosed {
+		t.Errorf("drew an unclosed block:\n%s", got)
+	}
+}
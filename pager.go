@@ -0,0 +1,246 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/spf13/viper"
+)
+
+// pagerMode controls whether CLI output is displayed with a pager.
+type pagerMode string
+
+const (
+	pagerOff  pagerMode = "false"
+	pagerOn   pagerMode = "true"
+	pagerAuto pagerMode = "auto" // only page when the output doesn't fit
+)
+
+func (p *pagerMode) String() string { return string(*p) }
+
+func (p *pagerMode) Type() string { return "mode" }
+
+func (p *pagerMode) Set(s string) error {
+	switch strings.ToLower(s) {
+	case "true", "1", "yes", "on":
+		*p = pagerOn
+	case "false", "0", "no", "off", "":
+		*p = pagerOff
+	case "auto":
+		*p = pagerAuto
+	default:
+		return fmt.Errorf("invalid pager mode %q: use true, false or auto", s)
+	}
+	return nil
+}
+
+// pagerCommand is the pager's command line from the config file, if it sets
+// one.
+var pagerCommand []string
+
+// pagerConfig returns the pager setting of the config file. Viper reads
+// $GLOW_PAGER as the setting too, but that's only the command to page with,
+// which doesn't turn paging on by itself, so it's kept out of the way.
+func pagerConfig() any {
+	if !viper.InConfig("pager") {
+		return nil
+	}
+	if cmd, ok := os.LookupEnv("GLOW_PAGER"); ok {
+		_ = os.Unsetenv("GLOW_PAGER")
+		defer os.Setenv("GLOW_PAGER", cmd) //nolint:errcheck
+	}
+	return viper.Get("pager")
+}
+
+// parsePagerConfig reads the pager setting of the config file: a mode, or
+// the command to page with, as a string or a list of arguments, which turns
+// paging on.
+func parsePagerConfig(v any) (pagerMode, []string, error) {
+	var mode pagerMode
+	switch v := v.(type) {
+	case nil:
+		return pagerOff, nil, nil
+	case bool:
+		return pagerMode(fmt.Sprint(v)), nil, nil
+	case string:
+		if mode.Set(v) == nil {
+			return mode, nil, nil
+		}
+		args, err := splitCommand(v)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid pager %q: %w", v, err)
+		}
+		return pagerOn, args, nil
+	case []string:
+		if len(v) == 0 || v[0] == "" {
+			return "", nil, fmt.Errorf("invalid pager %q: no command", v)
+		}
+		return pagerOn, v, nil
+	case []any:
+		args := make([]string, len(v))
+		for i, a := range v {
+			s, ok := a.(string)
+			if !ok {
+				return "", nil, fmt.Errorf("invalid pager argument %v: must be a string", a)
+			}
+			args[i] = s
+		}
+		return parsePagerConfig(args)
+	default:
+		return "", nil, fmt.Errorf("invalid pager %v: use true, false, auto or a command", v)
+	}
+}
+
+// splitCommand splits a command line into its arguments the way a POSIX
+// shell would, minus expansions: single quotes keep everything in them,
+// double quotes everything but backslash escapes, and a backslash escapes
+// the character after it.
+func splitCommand(s string) ([]string, error) {
+	var (
+		args  []string
+		arg   strings.Builder
+		inArg bool
+		quote rune
+		rs    = []rune(s)
+	)
+	for i := 0; i < len(rs); i++ {
+		r := rs[i]
+		switch {
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+		case r == '\\' && i+1 < len(rs) && (quote == 0 || strings.ContainsRune("\"\\$`", rs[i+1])):
+			i++
+			arg.WriteRune(rs[i])
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inArg = r, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("no command")
+	}
+	return args, nil
+}
+
+// shouldPage reports whether the rendered output should be sent to a pager.
+// In auto mode that's only the case when stdout is a terminal and the output
+// is taller than it, similar to less -F.
+func shouldPage(mode pagerMode, out string) bool {
+	switch mode {
+	case pagerOn:
+		return true
+	case pagerAuto:
+		if !utils.Term.IsTerminal(os.Stdout) {
+			return false
+		}
+		_, h, err := utils.Term.Size(os.Stdout)
+		if err != nil {
+			return false
+		}
+		return strings.Count(strings.TrimRight(out, "\n"), "\n")+1 > h
+	default:
+		return false
+	}
+}
+
+// lessEnv are the environment variables that make less display glow's output
+// well: with colors, quitting right away if it fits on one screen, and
+// leaving it on the screen afterwards. They're set when less is the pager,
+// unless they're set already.
+var lessEnv = []string{"LESS=-RFX", "LESSCHARSET=utf-8"}
+
+// pagerEnvOverrides are the pager's environment variables from the config
+// file, which are set whatever the pager is.
+var pagerEnvOverrides []string
+
+// parsePagerEnv checks the pager's environment variables from the config
+// file.
+func parsePagerEnv(env []string) ([]string, error) {
+	for _, kv := range env {
+		if k, _, ok := strings.Cut(kv, "="); !ok || k == "" {
+			return nil, fmt.Errorf("invalid pager environment variable %q: must be NAME=value", kv)
+		}
+	}
+	return env, nil
+}
+
+// runPager displays output with the pager pagerArgs picks. The names of the
+// sources are passed on as GLOW_DOC_TITLE, for pager prompts.
+func runPager(out string, names []string) error {
+	pa, err := pagerArgs()
+	if err != nil {
+		return err
+	}
+	c := exec.Command(pa[0], pa[1:]...) // nolint:gosec
+	c.Stdin = strings.NewReader(out)
+	c.Stdout = os.Stdout
+	c.Env = pagerEnv(pa[0], names)
+	return c.Run()
+}
+
+// pagerArgs returns the pager's command line: the one from the config file,
+// or else the first of $GLOW_PAGER, $PAGER and less -r that's set.
+func pagerArgs() ([]string, error) {
+	if len(pagerCommand) > 0 {
+		return pagerCommand, nil
+	}
+	for _, env := range []string{"GLOW_PAGER", "PAGER"} {
+		if s := strings.TrimSpace(os.Getenv(env)); s != "" {
+			args, err := splitCommand(s)
+			if err != nil {
+				return nil, fmt.Errorf("invalid $%s: %w", env, err)
+			}
+			return args, nil
+		}
+	}
+	return []string{"less", "-r"}, nil
+}
+
+// pagerEnv returns the environment of a pager: glow's own, with lessEnv for
+// less, the variables from the config file, and GLOW_DOC_TITLE.
+func pagerEnv(pager string, names []string) []string {
+	env := os.Environ()
+	if strings.TrimSuffix(filepath.Base(pager), ".exe") == "less" {
+		for _, kv := range lessEnv {
+			k, _, _ := strings.Cut(kv, "=")
+			if _, ok := os.LookupEnv(k); !ok {
+				env = append(env, kv)
+			}
+		}
+	}
+	env = append(env, pagerEnvOverrides...)
+
+	titles := make([]string, len(names))
+	for i, n := range names {
+		titles[i] = displayName(n)
+	}
+	return append(env, "GLOW_DOC_TITLE="+strings.Join(titles, ", "))
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestPagerEnv(t *testing.T) {
+	t.Setenv("LESS", "-S")
+	old := pagerEnvOverrides
+	pagerEnvOverrides = []string{"FOO=bar"}
+	t.Cleanup(func() { pagerEnvOverrides = old })
+
+	env := pagerEnv("/usr/bin/less", []string{"README.md", "-"})
+	for _, kv := range []string{"LESS=-S", "LESSCHARSET=utf-8", "FOO=bar", "GLOW_DOC_TITLE=README.md, stdin"} {
+		if !slices.Contains(env, kv) {
+			t.Errorf("%s is missing", kv)
+		}
+	}
+	if slices.Contains(env, "LESS=-RFX") {
+		t.Error("LESS was overridden")
+	}
+
+	if env := pagerEnv("more", nil); slices.Contains(env, "LESSCHARSET=utf-8") {
+		t.Error("less settings given to another pager")
+	}
+}
+
+func TestParsePagerEnv(t *testing.T) {
+	if _, err := parsePagerEnv([]string{"LESS=-R", "EMPTY="}); err != nil {
+		t.Error(err)
+	}
+	for _, kv := range []string{"LESS", "=x"} {
+		if _, err := parsePagerEnv([]string{kv}); err == nil {
+			t.Errorf("%q: expected an error", kv)
+		}
+	}
+}
+
+func TestSplitCommand(t *testing.T) {
+	for in, want := range map[string][]string{
+		"less -r":                          {"less", "-r"},
+		`  less  -R   "--prompt=Glow %f" `: {"less", "-R", "--prompt=Glow %f"},
+		`bat --style='plain, numbers'`:     {"bat", "--style=plain, numbers"},
+		`my\ pager "say \"hi\"" '\n'`:      {"my pager", `say "hi"`, `\n`},
+		`""`:                               {""},
+	} {
+		got, err := splitCommand(in)
+		if err != nil {
+			t.Errorf("%q: %v", in, err)
+			continue
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("%q: got %q, want %q", in, got, want)
+		}
+	}
+	for _, in := range []string{"", "   ", `less "-R`, "less '-R"} {
+		if _, err := splitCommand(in); err == nil {
+			t.Errorf("%q: expected an error", in)
+		}
+	}
+}
+
+func TestPagerConfig(t *testing.T) {
+	for _, tc := range []struct {
+		value any
+		mode  pagerMode
+		args  []string
+	}{
+		{nil, pagerOff, nil},
+		{true, pagerOn, nil},
+		{"auto", pagerAuto, nil},
+		{"less -RS", pagerOn, []string{"less", "-RS"}},
+		{[]any{"most", "-s"}, pagerOn, []string{"most", "-s"}},
+	} {
+		mode, args, err := parsePagerConfig(tc.value)
+		if err != nil {
+			t.Errorf("%v: %v", tc.value, err)
+			continue
+		}
+		if mode != tc.mode || !slices.Equal(args, tc.args) {
+			t.Errorf("%v: got %s %q, want %s %q", tc.value, mode, args, tc.mode, tc.args)
+		}
+	}
+	for _, v := range []any{[]any{}, []any{"less", 1}, 3, `less "-R`} {
+		if _, _, err := parsePagerConfig(v); err == nil {
+			t.Errorf("%v: expected an error", v)
+		}
+	}
+
+	old := pagerCommand
+	t.Cleanup(func() { pagerCommand = old })
+	pagerCommand = nil
+	t.Setenv("GLOW_PAGER", "")
+	t.Setenv("PAGER", `most -s "+/a b"`)
+	if got, _ := pagerArgs(); !slices.Equal(got, []string{"most", "-s", "+/a b"}) {
+		t.Errorf("got %q, want $PAGER", got)
+	}
+	t.Setenv("GLOW_PAGER", "bat -p")
+	if got, _ := pagerArgs(); !slices.Equal(got, []string{"bat", "-p"}) {
+		t.Errorf("got %q, want $GLOW_PAGER", got)
+	}
+	pagerCommand = []string{"less", "-S"}
+	if got, _ := pagerArgs(); !slices.Equal(got, pagerCommand) {
+		t.Errorf("got %q, want the config's pager", got)
+	}
+	pagerCommand = nil
+	t.Setenv("GLOW_PAGER", "")
+	t.Setenv("PAGER", "")
+	if got, _ := pagerArgs(); !slices.Equal(got, []string{"less", "-r"}) {
+		t.Errorf("got %q, want less -r", got)
+	}
+}
+
+// TestGlowPagerDoesntPage checks that $GLOW_PAGER, the command to page with,
+// doesn't turn paging on without -p, although Viper reads it as the pager
+// setting.
+func TestGlowPagerDoesntPage(t *testing.T) {
+	dir := t.TempDir()
+	marker := filepath.Join(dir, "paged")
+	t.Setenv("GLOW_PAGER", "sh -c 'cat >/dev/null; touch "+marker+"'")
+	defer func() { pager = pagerOff }()
+	rootCmd.Flags().Lookup("pager").Changed = false
+
+	doc := filepath.Join(dir, "doc.md")
+	if err := os.WriteFile(doc, []byte("# hi\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	out, err := os.Create(filepath.Join(dir, "out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close() //nolint:errcheck
+
+	stdout := os.Stdout
+	os.Stdout = out
+	rootCmd.SetArgs([]string{"--style", "notty", doc})
+	err = rootCmd.Execute()
+	os.Stdout = stdout
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pager != pagerOff {
+		t.Errorf("got pager %s, want it off", pager)
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("output was paged")
+	}
+	if b, _ := os.ReadFile(out.Name()); !strings.Contains(string(b), "hi") {
+		t.Errorf("got %q, want the rendered document", b)
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime/pprof"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/glow/v2/utils"
+)
+
+// blockPhase is the phase of the separate pass that times blocks on their
+// own.
+const blockPhase = "blocks"
+
+// profiler collects render timings when --render-profile is set. All of its
+// methods are safe to call on a nil profiler.
+var profiler *renderProfile
+
+type timing struct {
+	count int
+	total time.Duration
+}
+
+// renderProfile records where time goes while rendering in CLI mode.
+type renderProfile struct {
+	start  time.Time
+	phases map[string]*timing
+	order  []string
+	blocks map[string]*timing
+
+	// CPU profile output, if a .pprof file was requested.
+	cpu *os.File
+}
+
+func newRenderProfile(dest string) (*renderProfile, error) {
+	p := &renderProfile{
+		start:  time.Now(),
+		phases: map[string]*timing{},
+		blocks: map[string]*timing{},
+	}
+	if dest == "-" {
+		return p, nil
+	}
+	if filepath.Ext(dest) != ".pprof" {
+		return nil, fmt.Errorf("render profile must be written to a .pprof file: %s", dest)
+	}
+
+	f, err := os.Create(dest)
+	if err != nil {
+		return nil, err
+	}
+	if err := pprof.StartCPUProfile(f); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	p.cpu = f
+	return p, nil
+}
+
+// track starts timing a phase and returns a function that stops it.
+func (p *renderProfile) track(phase string) func() {
+	if p == nil {
+		return func() {}
+	}
+	start := time.Now()
+	return func() {
+		t, ok := p.phases[phase]
+		if !ok {
+			t = &timing{}
+			p.phases[phase] = t
+			p.order = append(p.order, phase)
+		}
+		t.count++
+		t.total += time.Since(start)
+	}
+}
+
+// profileBlocks times rendering each top-level block of a document on its
+// own, aggregated by block type. That's a separate pass on top of rendering
+// the document, which is timed as a phase of its own and said so in the
+// report. CPU profiles are left alone, as the extra pass would skew them.
+func (p *renderProfile) profileBlocks(r *glamour.TermRenderer, b []byte) {
+	if p == nil || p.cpu != nil {
+		return
+	}
+	defer p.track(blockPhase)()
+
+	blocks := utils.Blocks(b)
+	for i, block := range blocks {
+		end := len(b)
+		if i+1 < len(blocks) {
+			end = blocks[i+1].Start
+		}
+		t0 := time.Now()
+		_, _ = r.Render(string(b[block.Start:end]))
+		t, ok := p.blocks[block.Kind]
+		if !ok {
+			t = &timing{}
+			p.blocks[block.Kind] = t
+		}
+		t.count++
+		t.total += time.Since(t0)
+	}
+}
+
+// finish stops CPU profiling, or writes the timing report to w.
+func (p *renderProfile) finish(w io.Writer) error {
+	if p == nil {
+		return nil
+	}
+	if p.cpu != nil {
+		pprof.StopCPUProfile()
+		return p.cpu.Close()
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0) //nolint:mnd
+	fmt.Fprintln(tw, "PHASE\tCOUNT\tTOTAL\t")
+	for _, name := range p.order {
+		t := p.phases[name]
+		fmt.Fprintf(tw, "%s\t%d\t%s\t\n", name, t.count, t.total.Round(time.Microsecond))
+	}
+	fmt.Fprintf(tw, "total\t\t%s\t\n", time.Since(p.start).Round(time.Microsecond))
+
+	if len(p.blocks) > 0 {
+		kinds := make([]string, 0, len(p.blocks))
+		for k := range p.blocks {
+			kinds = append(kinds, k)
+		}
+		sort.Slice(kinds, func(i, j int) bool {
+			return p.blocks[kinds[i]].total > p.blocks[kinds[j]].total
+		})
+
+		fmt.Fprintln(tw, "\t\t\t")
+		fmt.Fprintf(tw, "blocks rendered one at a time, in the %s phase rather than with the document:\n", blockPhase)
+		fmt.Fprintln(tw, "BLOCK\tCOUNT\tTOTAL\tAVG")
+		for _, k := range kinds {
+			t := p.blocks[k]
+			avg := t.total / time.Duration(t.count)
+			fmt.Fprintf(tw, "%s\t%d\t%s\t%s\n", k, t.count, t.total.Round(time.Microsecond), avg.Round(time.Microsecond))
+		}
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// configProfile is the name of the config profile in use, if any.
+var configProfile string
+
+// applyProfile lays the settings of a profile from the "profiles" section of
+// the config file over the rest of it. Settings of the profile replace those
+// at the top level, except for sections such as decorations and codeRunners,
+// which are merged key by key. Flags and environment variables still take
+// precedence over both.
+func applyProfile(name string) error {
+	if name == "" {
+		return nil
+	}
+	profiles := viper.GetStringMap("profiles")
+	settings, ok := profiles[strings.ToLower(name)].(map[string]any)
+	if !ok {
+		if len(profiles) == 0 {
+			return fmt.Errorf("unknown profile %q: the config file doesn't define any", name)
+		}
+		names := make([]string, 0, len(profiles))
+		for n := range profiles {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("unknown profile %q: must be one of %s", name, strings.Join(names, ", "))
+	}
+
+	// profiles don't nest
+	delete(settings, "profile")
+	delete(settings, "profiles")
+	if err := viper.MergeConfigMap(settings); err != nil {
+		return fmt.Errorf("could not apply profile %q: %w", name, err)
+	}
+	configProfile = name
+	return nil
+}
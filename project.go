@@ -0,0 +1,146 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/glamour/styles"
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/charmbracelet/log"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// projectConfigName is the name of the file a repository can keep its
+// rendering settings in, so everyone reading its documents sees them alike.
+const projectConfigName = ".glow.yml"
+
+// projectKeys are the settings a project config may hold.
+var projectKeys = []string{"style", "width", "dialect", "readmeNames"}
+
+// projectConfigPath is the project config in use, if any.
+var projectConfigPath string
+
+// applyProjectConfig lays the settings of the .glow.yml nearest to the
+// documents glow was given over its defaults. The user's own config takes
+// precedence, except for settings glow wrote into it when creating it, and
+// flags and environment variables take precedence over both.
+func applyProjectConfig(args []string) error {
+	if viper.GetBool("noProjectConfig") {
+		return nil
+	}
+	dir := projectDir(args)
+	if dir == "" {
+		return nil
+	}
+	path := findProjectConfig(dir)
+	if path == "" {
+		return nil
+	}
+
+	generated := make(map[string]any)
+	_ = yaml.Unmarshal([]byte(defaultConfig), &generated)
+	settings, err := readProjectConfig(path, func(key string) bool {
+		return viper.InConfig(key) && fmt.Sprint(viper.Get(key)) != fmt.Sprint(generated[key])
+	})
+	if err != nil {
+		return err
+	}
+	if err := viper.MergeConfigMap(settings); err != nil {
+		return fmt.Errorf("could not apply %s: %w", path, err)
+	}
+	projectConfigPath = path
+	log.Debug("Using project config", "path", path)
+	return nil
+}
+
+// projectDir returns the directory to look for a project config from: that
+// of the first document glow was given if it's a local one, or the working
+// directory if none was given.
+func projectDir(args []string) string {
+	if len(args) == 0 {
+		wd, _ := os.Getwd()
+		return wd
+	}
+	path, _, _ := utils.ParseTarget(args[0])
+	info, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+	if !info.IsDir() {
+		path = filepath.Dir(path)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return ""
+	}
+	return abs
+}
+
+// findProjectConfig looks for a project config in dir and up from there,
+// until the root of a git repository.
+func findProjectConfig(dir string) string {
+	for ; ; dir = filepath.Dir(dir) {
+		path := filepath.Join(dir, projectConfigName)
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			return path
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".git")); !errors.Is(err, fs.ErrNotExist) {
+			return ""
+		}
+		if filepath.Dir(dir) == dir {
+			return ""
+		}
+	}
+}
+
+// readProjectConfig reads the settings of a project config that the user
+// hasn't set themselves. Settings a project config can't hold are left out,
+// so that files written for newer versions of glow still work. Style files
+// are relative to the project config.
+func readProjectConfig(path string, userSet func(key string) bool) (map[string]any, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var all map[string]any
+	if err := yaml.Unmarshal(b, &all); err != nil {
+		return nil, fmt.Errorf("could not parse %s: %w", path, err)
+	}
+
+	settings := make(map[string]any)
+	for key, value := range all {
+		i := indexFold(projectKeys, key)
+		if i < 0 {
+			log.Warn("Ignoring setting a project config can't hold", "path", path, "key", key)
+			continue
+		}
+		key = projectKeys[i]
+		if userSet(key) {
+			continue
+		}
+		if s, ok := value.(string); ok && key == "style" && styles.DefaultStyles[s] == nil && s != styles.AutoStyle {
+			if s = utils.ExpandPath(s); !filepath.IsAbs(s) {
+				s = filepath.Join(filepath.Dir(path), s)
+			}
+			value = s
+		}
+		settings[key] = value
+	}
+	return settings, nil
+}
+
+// indexFold returns the index of the first of ss that's equal to s under
+// Unicode case-folding, or -1.
+func indexFold(ss []string, s string) int {
+	for i, v := range ss {
+		if strings.EqualFold(v, s) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindProjectConfig(t *testing.T) {
+	repo := t.TempDir()
+	docs := filepath.Join(repo, "docs", "guide")
+	for _, dir := range []string{filepath.Join(repo, ".git"), docs} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := findProjectConfig(docs); got != "" {
+		t.Errorf("found %s, though the repository has no project config", got)
+	}
+
+	path := filepath.Join(repo, projectConfigName)
+	if err := os.WriteFile(path, []byte("style: dark\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if got := findProjectConfig(docs); got != path {
+		t.Errorf("findProjectConfig(%s) = %q, want %q", docs, got, path)
+	}
+	if got := projectDir([]string{filepath.Join(docs, "intro.md:12")}); got != "" {
+		t.Errorf("projectDir of a missing document = %q, want none", got)
+	}
+	if got := projectDir([]string{docs}); got != docs {
+		t.Errorf("projectDir of a directory = %q, want %q", got, docs)
+	}
+}
+
+func TestReadProjectConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, projectConfigName)
+	config := "style: styles/team.json\nWidth: 72\ndialect: rst\nreadmeNames: [INDEX.md]\npager: true\n"
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	settings, err := readProjectConfig(path, func(key string) bool { return key == "dialect" })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := settings["style"], filepath.Join(dir, "styles", "team.json"); got != want {
+		t.Errorf("style = %v, want %v relative to the project config", got, want)
+	}
+	if settings["width"] != 72 {
+		t.Errorf("width = %v, want 72", settings["width"])
+	}
+	if _, ok := settings["dialect"]; ok {
+		t.Error("the user's dialect was overridden")
+	}
+	if _, ok := settings["pager"]; ok {
+		t.Error("a project config can't set the pager")
+	}
+	if len(settings) != 3 {
+		t.Errorf("settings = %v, want style, width and readmeNames", settings)
+	}
+}
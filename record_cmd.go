@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/glamour/styles"
+	"github.com/charmbracelet/glow/v2/export"
+	"github.com/charmbracelet/glow/v2/markup"
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/muesli/termenv"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// most rows a recording is tall; longer documents scroll by
+	recordMaxHeight = 40
+
+	// how long revealing a line takes, relative to the pause after a block
+	recordLineDelayDivisor = 10
+)
+
+var (
+	recordCast  string
+	recordWidth uint
+	recordStyle string
+	recordDelay time.Duration
+
+	recordCmd = &cobra.Command{
+		Use:     "record SOURCE",
+		Short:   "Record a document being revealed as an asciinema cast",
+		Long:    paragraph(fmt.Sprintf("\n%s a markdown source being revealed block by block, a line at a time, as an asciinema v2 cast to share or play back with asciinema play.", keyword("Record"))),
+		Example: paragraph("glow record README.md --cast readme.cast\nglow record README.md --delay 1s | asciinema play -"),
+		Args:    cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			if recordStyle == styles.AutoStyle {
+				return errors.New("record needs an explicit style, auto depends on the terminal")
+			}
+			if err := validateStyle(recordStyle); err != nil {
+				return err
+			}
+			return recordSource(args[0])
+		},
+	}
+)
+
+// recordSource renders a source and writes its recording to the cast file, or
+// stdout.
+func recordSource(arg string) error {
+	src, err := resolveSource(arg)
+	if err != nil {
+		return err
+	}
+	b, err := io.ReadAll(src.Reader)
+	_ = src.Reader.Close()
+	if err != nil {
+		return err
+	}
+	b = markup.ToMarkdown(src.URL, b)
+
+	style := recordStyle
+	if _, ok := styles.DefaultStyles[style]; !ok {
+		style = utils.ExpandPath(style)
+	}
+	// casts are played back in terminals of their own, so they get all the
+	// colors regardless of this one
+	r, err := glamour.NewTermRenderer(
+		glamour.WithColorProfile(termenv.TrueColor),
+		utils.GlamourStyle(style, false, decorations),
+		glamour.WithWordWrap(int(recordWidth)),
+		glamour.WithPreservedNewLines(),
+	)
+	if err != nil {
+		return err
+	}
+	md := utils.GuessCodeLanguages(string(utils.RemoveFrontmatter(b)))
+	out, lines, err := utils.RenderLineMap(r, md)
+	if err != nil {
+		return err
+	}
+	title := export.Title(b, filepath.Base(src.URL))
+
+	if recordCast == "" {
+		return writeCast(os.Stdout, out, lines, title, recordDelay, time.Now())
+	}
+	f, err := os.Create(recordCast)
+	if err != nil {
+		return err
+	}
+	if err := writeCast(f, out, lines, title, recordDelay, time.Now()); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// castHeader is the first line of an asciinema v2 cast.
+type castHeader struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env"`
+}
+
+// writeCast writes rendered output as an asciinema v2 cast. Output lines
+// rendered from the same block, as told by the line map, are revealed one
+// after the other, followed by a pause of the given delay.
+func writeCast(w io.Writer, out string, lines []int, title string, delay time.Duration, now time.Time) error {
+	rendered := strings.Split(strings.TrimRight(out, "\n"), "\n")
+
+	width := 1
+	for _, l := range rendered {
+		width = max(width, ansi.StringWidth(l))
+	}
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	enc.SetEscapeHTML(false)
+	err := enc.Encode(castHeader{
+		Version:   2, //nolint:mnd
+		Width:     width,
+		Height:    min(len(rendered)+1, recordMaxHeight),
+		Timestamp: now.Unix(),
+		Title:     title,
+		Env:       map[string]string{"TERM": "xterm-256color"},
+	})
+	if err != nil {
+		return err
+	}
+
+	var t time.Duration
+	for i, l := range rendered {
+		if i > 0 && i < len(lines) && lines[i] != lines[i-1] {
+			t += delay
+		} else {
+			t += delay / recordLineDelayDivisor
+		}
+		if err := enc.Encode([]any{t.Seconds(), "o", l + "\r\n"}); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+func init() {
+	recordCmd.Flags().StringVar(&recordCast, "cast", "", "cast file to write (default stdout)")
+	recordCmd.Flags().UintVarP(&recordWidth, "width", "w", 80, "word-wrap at width") //nolint:mnd
+	recordCmd.Flags().StringVarP(&recordStyle, "style", "s", styles.DarkStyle, "style name or JSON path")
+	recordCmd.Flags().DurationVar(&recordDelay, "delay", 500*time.Millisecond, "pause after each block; lines are revealed ten times as fast") //nolint:mnd
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteCast(t *testing.T) {
+	var buf bytes.Buffer
+	out := "\n  Title\n\n  First paragraph,\n  wrapped.\n\n"
+	lines := []int{1, 1, 3, 3, 3, 3}
+	if err := writeCast(&buf, out, lines, "Title", time.Second, time.Unix(1700000000, 0)); err != nil {
+		t.Fatal(err)
+	}
+
+	events := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	var header castHeader
+	if err := json.Unmarshal([]byte(events[0]), &header); err != nil {
+		t.Fatal(err)
+	}
+	if header.Version != 2 || header.Width != 18 || header.Height != 6 || header.Title != "Title" || header.Timestamp != 1700000000 {
+		t.Errorf("unexpected header %+v", header)
+	}
+
+	// lines of a block follow each other quickly, blocks are a second apart
+	want := []struct {
+		time float64
+		data string
+	}{
+		{0.1, "\r\n"},
+		{0.2, "  Title\r\n"},
+		{1.2, "\r\n"},
+		{1.3, "  First paragraph,\r\n"},
+		{1.4, "  wrapped.\r\n"},
+	}
+	if len(events)-1 != len(want) {
+		t.Fatalf("expected %d events, got %d", len(want), len(events)-1)
+	}
+	for i, w := range want {
+		var ev []any
+		if err := json.Unmarshal([]byte(events[i+1]), &ev); err != nil {
+			t.Fatal(err)
+		}
+		if ev[0].(float64) != w.time || ev[1] != "o" || ev[2] != w.data {
+			t.Errorf("event %d: expected [%v o %q], got %v", i, w.time, w.data, ev)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/glamour/styles"
+	"github.com/charmbracelet/glow/v2/markup"
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/muesli/termenv"
+	"github.com/spf13/cobra"
+)
+
+var (
+	renderWidth        uint
+	renderStyle        string
+	renderColorProfile string
+	renderNoGuessLang  bool
+
+	renderCmd = &cobra.Command{
+		Use:     "render [FILE...]",
+		Short:   "Render markdown deterministically, for scripts",
+		Long:    paragraph(fmt.Sprintf("\n%s markdown from files, or stdin if none are given, to stdout. Unlike glow itself, render never looks at the terminal, the config file, profiles, .glow.yml files or GLOW_ environment variables, and never pages, so the same input and flags always produce the same output.", keyword("Render"))),
+		Example: paragraph("glow render README.md\necho '# hi' | glow render --width 40 --color-profile ascii"),
+		Args:    cobra.ArbitraryArgs,
+		// glow's own options, which come from the config file, profiles,
+		// .glow.yml and the environment, are left alone
+		PersistentPreRunE: func(*cobra.Command, []string) error { return nil },
+		RunE: func(_ *cobra.Command, args []string) error {
+			profile, err := utils.ParseColorProfile(renderColorProfile)
+			if err != nil {
+				return err
+			}
+			if renderStyle == styles.AutoStyle {
+				return errors.New("render needs an explicit style, auto depends on the terminal")
+			}
+			if err := validateStyle(renderStyle); err != nil {
+				return err
+			}
+
+			if len(args) == 0 {
+				return renderDeterministic(os.Stdout, os.Stdin, "", profile)
+			}
+			for _, arg := range utils.ExpandGlobs(args) {
+				f, err := os.Open(arg)
+				if err != nil {
+					return err
+				}
+				err = renderDeterministic(os.Stdout, f, arg, profile)
+				_ = f.Close()
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+)
+
+// renderDeterministic renders a markdown document using only the render
+// command's flags. Files without a markdown extension are rendered as code.
+func renderDeterministic(w io.Writer, r io.Reader, name string, profile termenv.Profile) error {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	b = markup.ToMarkdown(name, b)
+
+	isCode := name != "" && !utils.IsMarkdownFile(name)
+	style := renderStyle
+	if _, ok := styles.DefaultStyles[style]; !ok {
+		style = utils.ExpandPath(style)
+	}
+	tr, err := glamour.NewTermRenderer(
+		glamour.WithColorProfile(profile),
+		utils.GlamourStyle(style, isCode, utils.Decorations{}),
+		glamour.WithWordWrap(int(renderWidth)),
+		glamour.WithPreservedNewLines(),
+	)
+	if err != nil {
+		return err
+	}
+
+	s := string(utils.RemoveFrontmatter(b))
+	if isCode {
+		s = utils.WrapCodeBlock(string(b), filepath.Ext(name))
+	} else if !renderNoGuessLang {
+		s = utils.GuessCodeLanguages(s)
+	}
+	out, err := tr.Render(s)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, out)
+	return err
+}
+
+func init() {
+	renderCmd.Flags().UintVarP(&renderWidth, "width", "w", 80, "word-wrap at width (set to 0 to disable)") //nolint:mnd
+	renderCmd.Flags().StringVarP(&renderStyle, "style", "s", styles.DarkStyle, "style name or JSON path")
+	renderCmd.Flags().BoolVar(&renderNoGuessLang, "no-guess-lang", false, "don't guess the language of code blocks without one")
+	renderCmd.Flags().StringVar(&renderColorProfile, "color-profile", "truecolor", "color profile: ascii, ansi, ansi256 or truecolor")
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/glow/v2/source"
+	"github.com/muesli/termenv"
+	"github.com/spf13/viper"
+)
+
+// FuzzRenderCLI feeds arbitrary documents to the CLI renderer, which may
+// fail on them but must not panic. Run it with
+//
+//	go test -fuzz FuzzRenderCLI
+func FuzzRenderCLI(f *testing.F) {
+	for _, seed := range []string{
+		"# Title\n\nSome *text* with `code`.\n",
+		"- a\n\t- b\n\t\t1. c\n",
+		"| a | b |\n|---|---|\n| 1 | 2 |\n",
+		"```go\nfunc main() {}\n```\n",
+		"---\ntitle: x\n---\n[[link]] [ref]\n\n[ref]: http://x\n",
+		"> quote\n>\n> > nested\n",
+		"<video src=x.mp4></video>\n![img](x.png)\n",
+	} {
+		f.Add([]byte(seed))
+	}
+
+	style, width = "notty", 80
+	f.Fuzz(func(t *testing.T, b []byte) {
+		_, _, err := safeRenderCLI(&source.Source{URL: "fuzz.md"}, b)
+		var perr *renderPanicError
+		if errors.As(err, &perr) {
+			t.Fatalf("rendering panicked: %v", perr.Value)
+		}
+	})
+}
+
+func TestRenderPanicError(t *testing.T) {
+	err := &renderPanicError{"boom", []byte("\n# A heading\nmore\n")}
+	want := `could not render the document starting with "# A heading": boom`
+	if err.Error() != want {
+		t.Errorf("got %q, want %q", err.Error(), want)
+	}
+}
+
+func TestRenderCommandIgnoresConfig(t *testing.T) {
+	dir := t.TempDir()
+	doc := filepath.Join(dir, "NOTES")
+	if err := os.WriteFile(doc, []byte("Title\n=====\n\n.. note:: careful\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// a project config, and config and environment settings, that would
+	// change the output or fail if they were applied
+	if err := os.WriteFile(filepath.Join(dir, projectConfigName), []byte("style: dracula\nwidth: 20\ndialect: rst\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range map[string]any{"dialect": "rst", "width": 30, "keyProfile": "nonsense"} {
+		viper.Set(k, v)
+	}
+	defer func() {
+		for _, k := range []string{"dialect", "width", "keyProfile"} {
+			viper.Set(k, nil)
+		}
+	}()
+	t.Setenv("GLOW_STYLE", "light")
+
+	var want bytes.Buffer
+	renderStyle, renderWidth = "notty", 40
+	if err := renderDeterministic(&want, strings.NewReader("Title\n=====\n\n.. note:: careful\n"), "NOTES", termenv.Ascii); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := os.CreateTemp(dir, "out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = out
+	rootCmd.SetArgs([]string{"render", "--style", "notty", "--width", "40", "--color-profile", "ascii", doc})
+	err = rootCmd.Execute()
+	os.Stdout = stdout
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(out.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want.String() {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want.String())
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/glow/v2/utils"
+)
+
+// onlySections are the sections --only renders, by their headings.
+var onlySections []string
+
+// sectionMatcher tells whether a heading is one of those asked for: by name,
+// ignoring case, by anchor, or by a regular expression matching all of it.
+type sectionMatcher struct {
+	names    []string
+	patterns []*regexp.Regexp
+}
+
+func newSectionMatcher(specs []string) sectionMatcher {
+	var m sectionMatcher
+	for _, s := range specs {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		m.names = append(m.names, s)
+		// names that aren't valid regular expressions, like "C++", are
+		// still matched as names
+		if re, err := regexp.Compile(`(?i)^(?:` + s + `)$`); err == nil {
+			m.patterns = append(m.patterns, re)
+		}
+	}
+	return m
+}
+
+func (m sectionMatcher) match(heading string) bool {
+	for _, n := range m.names {
+		if strings.EqualFold(n, heading) || utils.HeadingSlug(n) == utils.HeadingSlug(heading) {
+			return true
+		}
+	}
+	for _, re := range m.patterns {
+		if re.MatchString(heading) {
+			return true
+		}
+	}
+	return false
+}
+
+// selectSections keeps the sections of a markdown document whose headings
+// match, along with their subsections: everything up to the next heading of
+// the same or a higher level. The lines of the other sections are blanked
+// rather than removed, so lines keep their numbers. It fails if no heading
+// matches.
+func selectSections(md string, specs []string) (string, error) {
+	m := newSectionMatcher(specs)
+	lines := strings.Split(md, "\n")
+	keep := make([]bool, len(lines))
+
+	var (
+		level   int // of the section being kept, 0 if none is
+		start   int // the line it starts on
+		matched bool
+	)
+	mark := func(end int) {
+		for i := start; i < end; i++ {
+			keep[i] = true
+		}
+	}
+	for _, h := range utils.Headings([]byte(md)) {
+		i := h.Line - 1
+		if level > 0 && h.Level <= level {
+			mark(i)
+			level = 0
+		}
+		if level == 0 && m.match(h.Text) {
+			level, start, matched = h.Level, i, true
+		}
+	}
+	if level > 0 {
+		mark(len(lines))
+	}
+	if !matched {
+		return "", fmt.Errorf("no heading matches %s", strings.Join(specs, ", "))
+	}
+
+	for i := range lines {
+		if !keep[i] {
+			lines[i] = ""
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
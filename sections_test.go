@@ -0,0 +1,52 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSelectSections(t *testing.T) {
+	md := strings.Join([]string{
+		"# Tool",
+		"intro",
+		"## Installation",
+		"get it",
+		"### From Source",
+		"```sh",
+		"# Usage",
+		"```",
+		"## Usage",
+		"use it",
+		"## C++ API",
+		"call it",
+		"## License",
+		"MIT",
+	}, "\n")
+
+	for _, tt := range []struct {
+		only []string
+		kept []int // lines left, 0-based
+	}{
+		{[]string{"installation"}, []int{2, 3, 4, 5, 6, 7}},
+		{[]string{"from-source", "usage"}, []int{4, 5, 6, 7, 8, 9}},
+		{[]string{"C++ API"}, []int{10, 11}},
+		{[]string{"lic.*"}, []int{12, 13}},
+		{[]string{"Tool"}, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13}},
+	} {
+		got, err := selectSections(md, tt.only)
+		if err != nil {
+			t.Fatalf("%v: %v", tt.only, err)
+		}
+		want := make([]string, 14)
+		for _, i := range tt.kept {
+			want[i] = strings.Split(md, "\n")[i]
+		}
+		if got != strings.Join(want, "\n") {
+			t.Errorf("%v: got\n%s", tt.only, got)
+		}
+	}
+
+	if _, err := selectSections(md, []string{"Changelog"}); err == nil {
+		t.Error("expected an error when no heading matches")
+	}
+}
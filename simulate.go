@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/glamour/ansi"
+	"github.com/muesli/termenv"
+)
+
+// colorDeficiencies simulate how colors look with a color vision deficiency,
+// as matrices applied to linear RGB: those of Machado, Oliveira and Fernandes
+// (2009) at full severity, and luminance alone for achromatopsia.
+var colorDeficiencies = map[string][3][3]float64{
+	"protanopia": {
+		{0.152286, 1.052583, -0.204868},
+		{0.114503, 0.786281, 0.099216},
+		{-0.003882, -0.048116, 1.051998},
+	},
+	"deuteranopia": {
+		{0.367322, 0.860646, -0.227968},
+		{0.280085, 0.672501, 0.047413},
+		{-0.011820, 0.042940, 0.968881},
+	},
+	"tritanopia": {
+		{1.255528, -0.076749, -0.178779},
+		{-0.078411, 0.930809, 0.147602},
+		{0.004733, 0.691367, 0.303900},
+	},
+	"achromatopsia": {
+		{0.2126, 0.7152, 0.0722},
+		{0.2126, 0.7152, 0.0722},
+		{0.2126, 0.7152, 0.0722},
+	},
+}
+
+// colorDeficiencyNames lists the deficiencies that can be simulated.
+func colorDeficiencyNames() []string {
+	names := make([]string, 0, len(colorDeficiencies))
+	for n := range colorDeficiencies {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// simulateStyle returns a style, as JSON, with all its colors as they look
+// with a color vision deficiency.
+func simulateStyle(style ansi.StyleConfig, deficiency string) ([]byte, error) {
+	m, ok := colorDeficiencies[strings.ToLower(deficiency)]
+	if !ok {
+		return nil, fmt.Errorf("unknown color vision deficiency %q: must be one of %s",
+			deficiency, strings.Join(colorDeficiencyNames(), ", "))
+	}
+
+	// colors are set all over a style, code highlighting included, so
+	// they're looked for by key rather than by field
+	b, err := json.Marshal(style)
+	if err != nil {
+		return nil, err
+	}
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+	simulateColors(v, m)
+	return json.Marshal(v)
+}
+
+// simulateColors transforms the colors of a style decoded from JSON in place.
+func simulateColors(v interface{}, m [3][3]float64) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, c := range v {
+			if s, ok := c.(string); ok && (k == "color" || k == "background_color") {
+				v[k] = simulateColor(s, m)
+				continue
+			}
+			simulateColors(c, m)
+		}
+	case []interface{}:
+		for _, c := range v {
+			simulateColors(c, m)
+		}
+	}
+}
+
+// simulateColor transforms a color, given as hex or as an ANSI color number,
+// into the hex color it looks like. Anything else is returned unchanged.
+func simulateColor(s string, m [3][3]float64) string {
+	c := termenv.TrueColor.Color(s)
+	if c == nil {
+		return s
+	}
+	rgb := termenv.ConvertToRGB(c)
+	in := [3]float64{linear(rgb.R), linear(rgb.G), linear(rgb.B)}
+	var out [3]uint8
+	for i, row := range m {
+		v := row[0]*in[0] + row[1]*in[1] + row[2]*in[2]
+		out[i] = uint8(math.Round(255 * gamma(math.Max(0, math.Min(1, v))))) //nolint:mnd
+	}
+	return fmt.Sprintf("#%02x%02x%02x", out[0], out[1], out[2])
+}
+
+// linear converts an sRGB channel to linear light, and gamma back.
+func linear(v float64) float64 {
+	if v <= 0.04045 { //nolint:mnd
+		return v / 12.92 //nolint:mnd
+	}
+	return math.Pow((v+0.055)/1.055, 2.4) //nolint:mnd
+}
+
+func gamma(v float64) float64 {
+	if v <= 0.0031308 { //nolint:mnd
+		return v * 12.92 //nolint:mnd
+	}
+	return 1.055*math.Pow(v, 1/2.4) - 0.055 //nolint:mnd
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/glamour/styles"
+)
+
+func TestSimulateColor(t *testing.T) {
+	for _, tc := range []struct {
+		deficiency, color, want string
+	}{
+		{"deuteranopia", "#ffffff", "#ffffff"},
+		{"protanopia", "#000000", "#000000"},
+		{"achromatopsia", "#ff0000", "#7f7f7f"},
+		{"achromatopsia", "15", "#ffffff"},
+		{"tritanopia", "not a color", "not a color"},
+	} {
+		if got := simulateColor(tc.color, colorDeficiencies[tc.deficiency]); got != tc.want {
+			t.Errorf("%s %s: got %s, want %s", tc.deficiency, tc.color, got, tc.want)
+		}
+	}
+}
+
+func TestSimulateStyle(t *testing.T) {
+	b, err := simulateStyle(styles.DarkStyleConfig, "Achromatopsia")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		t.Fatal(err)
+	}
+	var check func(interface{})
+	check = func(v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			for k, c := range v {
+				if s, ok := c.(string); ok && (k == "color" || k == "background_color") {
+					if len(s) != 7 || s[1:3] != s[3:5] || s[3:5] != s[5:7] {
+						t.Errorf("%s is not gray: %s", k, s)
+					}
+				}
+				check(c)
+			}
+		case []interface{}:
+			for _, c := range v {
+				check(c)
+			}
+		}
+	}
+	check(v)
+
+	if _, err := simulateStyle(styles.DarkStyleConfig, "nope"); err == nil || !strings.Contains(err.Error(), "deuteranopia") {
+		t.Errorf("expected an error listing the deficiencies, got %v", err)
+	}
+}
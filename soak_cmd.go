@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/charmbracelet/glow/v2/source"
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+)
+
+// soakDelimiter separates the documents of the soak case that has many.
+const soakDelimiter = "<!-- soak -->"
+
+// soakCase generates a pathological input at a given scale.
+type soakCase struct {
+	name     string
+	generate func(scale int) []byte
+}
+
+// soakCases are inputs known to stress markdown renderers: deep nesting,
+// huge lines and tables, and constructs that are never closed and make
+// parsers backtrack.
+var soakCases = []soakCase{
+	{"deep-list", func(n int) []byte {
+		var b bytes.Buffer
+		for i := 0; i < 100*n; i++ {
+			fmt.Fprintf(&b, "%s- item %d\n", strings.Repeat("  ", i), i)
+		}
+		return b.Bytes()
+	}},
+	{"deep-quote", func(n int) []byte {
+		var b bytes.Buffer
+		for i := 1; i <= 100*n; i++ {
+			fmt.Fprintf(&b, "%s quote %d\n", strings.Repeat(">", i), i)
+		}
+		return b.Bytes()
+	}},
+	{"long-line", func(n int) []byte {
+		return []byte(strings.Repeat("word ", 200_000*n) + "\n")
+	}},
+	{"long-word", func(n int) []byte {
+		return []byte(strings.Repeat("x", 10_000*n) + "\n")
+	}},
+	{"wide-table", func(n int) []byte {
+		var b bytes.Buffer
+		cols := 20 * n
+		b.WriteString("|" + strings.Repeat(" head |", cols) + "\n")
+		b.WriteString("|" + strings.Repeat("---|", cols) + "\n")
+		for i := 0; i < 500*n; i++ {
+			b.WriteString("|" + strings.Repeat(fmt.Sprintf(" cell %d |", i), cols) + "\n")
+		}
+		return b.Bytes()
+	}},
+	{"unclosed-emphasis", func(n int) []byte {
+		return []byte(strings.Repeat("*a _b **c __d ", 20_000*n) + "\n")
+	}},
+	{"brackets", func(n int) []byte {
+		return []byte(strings.Repeat("[", 2_000*n) + "x" + strings.Repeat("](", 2_000*n) + "\n")
+	}},
+	{"fences", func(n int) []byte {
+		var b bytes.Buffer
+		for i := 0; i < 2_000*n; i++ {
+			fmt.Fprintf(&b, "```go\nfunc f%d() {}\n```\n\n", i)
+		}
+		// and one that's never closed
+		b.WriteString("```\n" + strings.Repeat("code\n", 10_000*n))
+		return b.Bytes()
+	}},
+	{"documents", func(n int) []byte {
+		var b bytes.Buffer
+		for i := 0; i < 2_000*n; i++ {
+			fmt.Fprintf(&b, "# Document %d\n\nSome *text*.\n%s\n", i, soakDelimiter)
+		}
+		return b.Bytes()
+	}},
+}
+
+var (
+	soakScale int
+	soakOnly  []string
+
+	soakCmd = &cobra.Command{
+		Use:    "soak",
+		Hidden: true,
+		Short:  "Render generated pathological inputs, for release validation",
+		Long: paragraph(fmt.Sprintf("\n%s generated inputs that stress the renderer, such as deeply nested lists, huge tables and unclosed emphasis, through the same path as glow FILE, and reports the time, peak memory and output size of each. Combine with --max-memory to check the watchdog, and --scale to grow the inputs.",
+			keyword("Render"))),
+		Example: paragraph("glow soak\nglow --max-memory 1GiB soak --scale 10 --case deep-list,wide-table"),
+		Args:    cobra.NoArgs,
+		RunE: func(*cobra.Command, []string) error {
+			if soakScale < 1 {
+				return fmt.Errorf("invalid scale %d: must be at least 1", soakScale)
+			}
+			cases := soakCases
+			if len(soakOnly) > 0 {
+				cases = nil
+				for _, c := range soakCases {
+					if slices.Contains(soakOnly, c.name) {
+						cases = append(cases, c)
+					}
+				}
+				if len(cases) != len(soakOnly) {
+					return fmt.Errorf("unknown soak case in %s: must be among %s",
+						strings.Join(soakOnly, ", "), strings.Join(soakCaseNames(), ", "))
+				}
+			}
+			return soak(os.Stdout, cases, soakScale)
+		},
+	}
+)
+
+func init() {
+	soakCmd.Flags().IntVar(&soakScale, "scale", 1, "multiply the size of the generated inputs")
+	soakCmd.Flags().StringSliceVar(&soakOnly, "case", nil, fmt.Sprintf("run only these cases: %s", strings.Join(soakCaseNames(), ", ")))
+}
+
+// soakCaseNames lists the names of the soak cases.
+func soakCaseNames() []string {
+	names := make([]string, len(soakCases))
+	for i, c := range soakCases {
+		names[i] = c.name
+	}
+	return names
+}
+
+// soak renders each case and writes a line of measurements for it, failing
+// if any of them couldn't be rendered.
+func soak(w io.Writer, cases []soakCase, scale int) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0) //nolint:mnd
+	fmt.Fprintln(tw, "CASE\tIN\tOUT\tTIME\tPEAK RSS\tPEAK HEAP\t")
+
+	var failed []string
+	for _, c := range cases {
+		in := c.generate(scale)
+		res, err := soakOne(c.name, in)
+		if err != nil {
+			failed = append(failed, c.name)
+			fmt.Fprintf(tw, "%s\t%s\tfailed: %v\t\t\t\t\n", c.name, humanize.IBytes(uint64(len(in))), err)
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t\n", c.name,
+			humanize.IBytes(uint64(len(in))), humanize.IBytes(uint64(res.out)),
+			res.took.Round(time.Millisecond), humanize.IBytes(res.peak.rss), humanize.IBytes(res.peak.heap))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d soak cases failed: %s", len(failed), len(cases), strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+type soakResult struct {
+	out  int
+	took time.Duration
+	peak memorySample
+}
+
+// soakOne renders a generated input the way glow FILE would, while recording
+// the peak memory use. The watchdog set with --max-memory, if any, keeps
+// guarding it.
+func soakOne(name string, in []byte) (soakResult, error) {
+	if name == "documents" {
+		saved := delimiter
+		delimiter = soakDelimiter
+		defer func() { delimiter = saved }()
+	}
+
+	runtime.GC()
+	rec := newMemoryWatchdog(0)
+	rec.start()
+	defer rec.stop()
+
+	start := time.Now()
+	out, _, err := renderSource(&source.Source{
+		URL:    "soak://" + name + ".md",
+		Reader: io.NopCloser(bytes.NewReader(in)),
+	})
+	if err != nil {
+		return soakResult{}, err
+	}
+	return soakResult{len(out), time.Since(start), rec.peakUsage()}, nil
+}
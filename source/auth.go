@@ -0,0 +1,161 @@
+package source
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Environment variables the tokens for GitHub, GitLab, Codeberg and Gitea are
+// taken from, in order of preference.
+var (
+	githubTokenEnv   = []string{"GITHUB_TOKEN", "GH_TOKEN"}
+	gitlabTokenEnv   = []string{"GLAB_TOKEN", "GITLAB_TOKEN"}
+	codebergTokenEnv = []string{"CODEBERG_TOKEN"}
+	giteaTokenEnv    = []string{"GITEA_TOKEN"}
+)
+
+// authServices are the services tokens can be given for with SetAuthToken,
+// with the environment variables their tokens are otherwise taken from.
+var authServices = map[string][]string{
+	"github":   githubTokenEnv,
+	"gitlab":   gitlabTokenEnv,
+	"codeberg": codebergTokenEnv,
+	"gitea":    giteaTokenEnv,
+}
+
+// authTokens are the tokens set with SetAuthToken, by the first environment
+// variable of their service.
+var authTokens map[string]string
+
+// SetAuthToken sets the tokens READMEs of private GitHub, GitLab, Codeberg
+// and Gitea repositories are fetched with, instead of those in the
+// environment. Tokens are given as comma-separated service=token pairs, such
+// as "github=ghp_…,gitlab=glpat-…", so that none is sent to a service it
+// isn't meant for; a token on its own is GitHub's.
+func SetAuthToken(tokens string) error {
+	authTokens = nil
+	if tokens == "" {
+		return nil
+	}
+	authTokens = make(map[string]string)
+	for _, t := range strings.Split(tokens, ",") {
+		service, tok, ok := strings.Cut(t, "=")
+		if _, known := authServices[strings.ToLower(service)]; !ok || !known {
+			service, tok = "github", t
+		}
+		if tok == "" {
+			return fmt.Errorf("no token given for %s", service)
+		}
+		authTokens[authServices[strings.ToLower(service)][0]] = tok
+	}
+	return nil
+}
+
+// authFlag returns how to pass a token for the service of the given
+// environment variables with --auth.
+func authFlag(env []string) string {
+	for name, e := range authServices {
+		if e[0] == env[0] {
+			return "--auth " + name + "=TOKEN"
+		}
+	}
+	return "--auth"
+}
+
+// token returns the token to authenticate with: the one set for the service
+// with SetAuthToken, or else the first of its environment variables set.
+// None is used in hardened mode, so private repositories can't be read on
+// behalf of others.
+func token(env []string) string {
+	if hardened {
+		return ""
+	}
+	if tok := authTokens[env[0]]; tok != "" {
+		return tok
+	}
+	for _, e := range env {
+		if v := os.Getenv(e); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// AuthError is returned when the README of a repository couldn't be fetched
+// because the service wants a token, or refused the one given.
+type AuthError struct {
+	Service string // GitHub, GitLab, Codeberg or Gitea
+	Repo    string // as owner/repo
+	Status  int    // HTTP status of the service's answer
+
+	// The file asked for, as the branch, tag or commit and the path, if
+	// not the README of the repository; with Dir, the directory whose
+	// README was asked for.
+	File string
+	Dir  bool
+
+	// Whether a token was given, and whether the rate limit for requests
+	// without one was used up.
+	Token       bool
+	RateLimited bool
+
+	env []string
+}
+
+func (e *AuthError) Error() string {
+	switch {
+	case e.Status == http.StatusUnauthorized:
+		return fmt.Sprintf("%s rejected the token for %s (401 Unauthorized): check that it's valid and hasn't expired", e.Service, e.Repo)
+	case e.RateLimited:
+		return fmt.Sprintf("%s rate limit exceeded fetching %s (403 Forbidden)%s", e.Service, e.Repo, e.hint("for a higher limit, "))
+	case e.Status == http.StatusForbidden && e.Token:
+		return fmt.Sprintf("%s denied access to %s (403 Forbidden): the token may lack the scope to read it", e.Service, e.Repo)
+	case e.Status == http.StatusForbidden:
+		return fmt.Sprintf("%s denied access to %s (403 Forbidden)%s", e.Service, e.Repo, e.hint(""))
+	case e.Token:
+		return fmt.Sprintf("can't find %s in %s on %s, or the token doesn't give access to it", e.what(), e.Repo, e.Service)
+	default:
+		return fmt.Sprintf("can't find %s in %s on %s%s", e.what(), e.Repo, e.Service, e.hint("if it's a private repository, "))
+	}
+}
+
+// what names what was asked for.
+func (e *AuthError) what() string {
+	switch {
+	case e.File == "":
+		return "a README"
+	case e.Dir:
+		return "a README in " + e.File
+	default:
+		return e.File
+	}
+}
+
+// hint tells how to authenticate, unless a token was given already or tokens
+// aren't used.
+func (e *AuthError) hint(when string) string {
+	if e.Token || hardened {
+		return ""
+	}
+	return fmt.Sprintf(": %sset %s or pass a token with %s", when, e.env[0], authFlag(e.env))
+}
+
+// checkAuth returns an AuthError for answers of a service that may be
+// because of a missing or refused token: 401, 403 and 404, as private
+// repositories are hidden rather than forbidden.
+func checkAuth(service string, env []string, repo string, res *http.Response, token bool) error {
+	switch res.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound:
+		return &AuthError{
+			Service:     service,
+			Repo:        repo,
+			Status:      res.StatusCode,
+			Token:       token,
+			RateLimited: res.StatusCode == http.StatusForbidden && res.Header.Get("X-RateLimit-Remaining") == "0",
+			env:         env,
+		}
+	}
+	return nil
+}
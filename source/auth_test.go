@@ -0,0 +1,140 @@
+package source
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestToken(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GH_TOKEN", "gh")
+	if got := token(githubTokenEnv); got != "gh" {
+		t.Errorf("expected the token from GH_TOKEN, got %q", got)
+	}
+	t.Setenv("GITHUB_TOKEN", "github")
+	if got := token(githubTokenEnv); got != "github" {
+		t.Errorf("expected the token from GITHUB_TOKEN, got %q", got)
+	}
+
+	if err := SetAuthToken("flag"); err != nil {
+		t.Fatal(err)
+	}
+	defer SetAuthToken("") //nolint:errcheck
+	if got := token(githubTokenEnv); got != "flag" {
+		t.Errorf("expected the token given, got %q", got)
+	}
+	t.Setenv("GITLAB_TOKEN", "")
+	if got := token(gitlabTokenEnv); got != "" {
+		t.Errorf("expected GitHub's token not to be sent to GitLab, got %q", got)
+	}
+
+	for _, tc := range []struct {
+		auth    string
+		env     []string
+		want    string
+		invalid bool
+	}{
+		{"gitlab=glpat", gitlabTokenEnv, "glpat", false},
+		{"gitlab=glpat", githubTokenEnv, "github", false},
+		{"GitHub=a,codeberg=b", codebergTokenEnv, "b", false},
+		{"github=a,gitea=c", giteaTokenEnv, "c", false},
+		{"base64==", githubTokenEnv, "base64==", false},
+		{"gitlab=", nil, "", true},
+	} {
+		err := SetAuthToken(tc.auth)
+		if tc.invalid {
+			if err == nil {
+				t.Errorf("%q: expected an error", tc.auth)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", tc.auth, err)
+			continue
+		}
+		if got := token(tc.env); got != tc.want {
+			t.Errorf("%q: expected %q for %s, got %q", tc.auth, tc.want, tc.env[0], got)
+		}
+	}
+
+	hardened = true
+	defer func() { hardened = false }()
+	if got := token(githubTokenEnv); got != "" {
+		t.Errorf("expected no token in hardened mode, got %q", got)
+	}
+}
+
+func TestCheckAuth(t *testing.T) {
+	for _, tc := range []struct {
+		status int
+		header http.Header
+		token  bool
+		want   string
+	}{
+		{http.StatusOK, nil, false, ""},
+		{http.StatusUnauthorized, nil, true, "rejected the token"},
+		{http.StatusForbidden, nil, true, "may lack the scope"},
+		{http.StatusForbidden, nil, false, "set GITHUB_TOKEN or pass a token with --auth github=TOKEN"},
+		{http.StatusForbidden, http.Header{"X-Ratelimit-Remaining": {"0"}}, false, "rate limit exceeded"},
+		{http.StatusNotFound, nil, false, "if it's a private repository, set GITHUB_TOKEN"},
+		{http.StatusNotFound, nil, true, "the token doesn't give access"},
+	} {
+		res := &http.Response{StatusCode: tc.status, Header: tc.header}
+		err := checkAuth("GitHub", githubTokenEnv, "org/repo", res, tc.token)
+		if tc.want == "" {
+			if err != nil {
+				t.Errorf("%d: expected no error, got %v", tc.status, err)
+			}
+			continue
+		}
+		var authErr *AuthError
+		if !errors.As(err, &authErr) || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%d: expected an auth error saying %q, got %v", tc.status, tc.want, err)
+		}
+	}
+}
+
+func TestGetWithRetryHeader(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("Authorization")
+	}))
+	defer srv.Close()
+
+	resp, err := getWithRetry(srv.URL, http.Header{"Authorization": {"Bearer secret"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if got != "Bearer secret" {
+		t.Errorf("expected the token to be sent, got %q", got)
+	}
+}
+
+func TestRedirectDropsTokens(t *testing.T) {
+	var got http.Header
+	other := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		got = r.Header
+	}))
+	defer other.Close()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the same server by another name is another host
+		http.Redirect(w, r, strings.Replace(other.URL, "127.0.0.1", "localhost", 1), http.StatusFound)
+	}))
+	defer srv.Close()
+
+	resp, err := getWithRetry(srv.URL, http.Header{"Private-Token": {"secret"}, "Authorization": {"Bearer secret"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if got == nil {
+		t.Fatal("redirect not followed")
+	}
+	if got.Get("Private-Token") != "" || got.Get("Authorization") != "" {
+		t.Errorf("token sent to another host: %v", got)
+	}
+}
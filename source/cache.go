@@ -0,0 +1,93 @@
+package source
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/glow/v2/utils"
+	gap "github.com/muesli/go-app-paths"
+)
+
+// cacheDir returns the directory READMEs are cached in, so they can still
+// be shown when fetching them fails. Replaced in tests.
+var cacheDir = func() (string, error) {
+	dir, err := gap.NewScope(gap.User, "glow").CacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "readmes"), nil
+}
+
+// cachedReadme is a README as fetched last time.
+type cachedReadme struct {
+	URL     string    `json:"url"`
+	Fetched time.Time `json:"fetched"`
+	Body    string    `json:"body"`
+}
+
+// readmeCachePath returns where the README of a repository is cached.
+func readmeCachePath(host, owner, repo string) (string, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+	name := strings.ToLower(strings.Join([]string{host, owner, repo}, "_"))
+	return filepath.Join(dir, filepath.Base(name)+".json"), nil
+}
+
+// cacheReadme reads a fetched README and keeps a copy of it. It returns a
+// source reading the same content. Nothing is cached in hardened mode.
+func cacheReadme(src *Source, host, owner, repo string) (*Source, error) {
+	if hardened {
+		return src, nil
+	}
+	defer src.Reader.Close() //nolint:errcheck
+	b, err := io.ReadAll(src.Reader)
+	if err != nil {
+		return nil, err
+	}
+	src.Reader = io.NopCloser(strings.NewReader(string(b)))
+
+	path, err := readmeCachePath(host, owner, repo)
+	if err != nil {
+		return src, nil //nolint:nilerr
+	}
+	data, err := json.Marshal(cachedReadme{src.URL, time.Now(), string(b)})
+	if err != nil {
+		return src, nil //nolint:nilerr
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err == nil { //nolint:mnd
+		// atomically, as other instances may be reading it
+		_ = utils.WriteFileAtomic(path, data, 0o600) //nolint:mnd
+	}
+	return src, nil
+}
+
+// loadCachedReadme returns the cached README of a repository, or nil if
+// there's none.
+func loadCachedReadme(host, owner, repo string) *Source {
+	if hardened {
+		return nil
+	}
+	path, err := readmeCachePath(host, owner, repo)
+	if err != nil {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var c cachedReadme
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil
+	}
+	return &Source{
+		Reader: io.NopCloser(strings.NewReader(c.Body)),
+		URL:    c.URL,
+		Cached: c.Fetched,
+	}
+}
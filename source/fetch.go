@@ -0,0 +1,165 @@
+package source
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// Limits of the hardened mode, for when glow fetches URLs on behalf of
+// others.
+const (
+	hardenedMaxRedirects = 5
+	hardenedMaxSize      = 10 << 20 // 10 MiB
+	hardenedTimeout      = 30 * time.Second
+)
+
+// Content types remote documents may have in hardened mode.
+var hardenedContentTypes = []string{"text/markdown", "text/x-markdown", "text/plain"}
+
+// Addresses of carrier-grade NAT, which net/netip doesn't count as private.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+var (
+	// httpClient fetches remote sources. In hardened mode it's replaced by
+	// one that won't connect to local networks.
+	httpClient = &http.Client{CheckRedirect: dropTokens}
+	hardened   bool
+)
+
+// Harden switches to hardened mode, for when glow fetches URLs on behalf of
+// others: local network addresses are refused, redirects and document sizes
+// are limited, and URLs need to be given explicitly.
+func Harden() {
+	hardened = true
+	httpClient = newHardenedClient()
+}
+
+// newHardenedClient returns an HTTP client that refuses to connect to
+// loopback, private and link-local addresses, ignores proxy settings and
+// follows a limited number of redirects.
+func newHardenedClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: hardenedTimeout,
+		// checked once resolved, so DNS can't be used to sneak past
+		Control: func(_, address string, _ syscall.RawConn) error {
+			return checkAddress(address)
+		},
+	}
+	return &http.Client{
+		Timeout: hardenedTimeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: hardenedTimeout,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= hardenedMaxRedirects {
+				return fmt.Errorf("stopped after %d redirects", hardenedMaxRedirects)
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return fmt.Errorf("refusing to follow redirect to %s", req.URL.Scheme)
+			}
+			return dropTokens(req, via)
+		},
+	}
+}
+
+// dropTokens removes tokens from requests redirected to another host. Go
+// drops the Authorization header itself, but not GitLab's Private-Token.
+func dropTokens(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 { //nolint:mnd
+		return errors.New("stopped after 10 redirects")
+	}
+	if req.URL.Host != via[0].URL.Host {
+		req.Header.Del("Authorization")
+		req.Header.Del("Private-Token")
+	}
+	return nil
+}
+
+// checkAddress returns an error for addresses of local networks.
+func checkAddress(address string) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	ip = ip.Unmap()
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() || ip.IsUnspecified() || sharedAddressSpace.Contains(ip) {
+		return fmt.Errorf("refusing to connect to local network address %s", ip)
+	}
+	return nil
+}
+
+// fetchDocument fetches a remote document, with the given request headers, if
+// any, trying again if the network or server fail temporarily. The caller is
+// responsible for closing it. In hardened mode the document must be text of a
+// limited size.
+func fetchDocument(u string, header http.Header) (io.ReadCloser, error) {
+	resp, err := getWithRetry(u, header)
+	if err != nil {
+		return nil, err
+	}
+	return documentBody(resp)
+}
+
+// documentBody returns the body of a response for a remote document, or an
+// error if it wasn't found, or in hardened mode, isn't text of a limited size.
+func documentBody(resp *http.Response) (io.ReadCloser, error) {
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("HTTP status %d", resp.StatusCode)
+	}
+	if !hardened {
+		return resp.Body, nil
+	}
+
+	ct, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if !isHardenedContentType(ct) {
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("refusing to render content of type %q", ct)
+	}
+	if resp.ContentLength > hardenedMaxSize {
+		_ = resp.Body.Close()
+		return nil, errTooLarge
+	}
+	return &limitedBody{ReadCloser: resp.Body, left: hardenedMaxSize}, nil
+}
+
+func isHardenedContentType(ct string) bool {
+	for _, v := range hardenedContentTypes {
+		if ct == v {
+			return true
+		}
+	}
+	return false
+}
+
+var errTooLarge = fmt.Errorf("remote document is larger than %d MiB", hardenedMaxSize>>20)
+
+// limitedBody fails reading once more than the allowed number of bytes have
+// been read, rather than silently cutting the document short.
+type limitedBody struct {
+	io.ReadCloser
+	left int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.left -= int64(n)
+	if b.left < 0 {
+		return n, errTooLarge
+	}
+	return n, err
+}
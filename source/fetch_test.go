@@ -0,0 +1,72 @@
+package source
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCheckAddress(t *testing.T) {
+	for addr, blocked := range map[string]bool{
+		"127.0.0.1:80":          true,
+		"10.1.2.3:443":          true,
+		"172.16.0.1:443":        true,
+		"192.168.1.1:80":        true,
+		"169.254.169.254:80":    true,
+		"100.64.0.1:80":         true,
+		"0.0.0.0:80":            true,
+		"[::1]:443":             true,
+		"[fe80::1]:443":         true,
+		"[fd00::1]:443":         true,
+		"[::ffff:127.0.0.1]:80": true,
+		"140.82.121.4:443":      false,
+		"[2606:4700::1]:443":    false,
+	} {
+		t.Run(addr, func(t *testing.T) {
+			if err := checkAddress(addr); (err != nil) != blocked {
+				t.Errorf("expected blocked to be %v, got error %v", blocked, err)
+			}
+		})
+	}
+}
+
+func TestFetchDocumentHardened(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/doc.md":
+			w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+			_, _ = io.WriteString(w, "# Hi")
+		case "/big.md":
+			w.Header().Set("Content-Type", "text/plain")
+			_, _ = io.WriteString(w, strings.Repeat("a", hardenedMaxSize+1))
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = io.WriteString(w, "{}")
+		}
+	}))
+	defer srv.Close()
+
+	hardened = true
+	defer func() { hardened = false }()
+
+	body, err := fetchDocument(srv.URL+"/doc.md", nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	_ = body.Close()
+
+	if _, err := fetchDocument(srv.URL+"/data.json", nil); err == nil {
+		t.Error("expected JSON to be refused")
+	}
+
+	body, err = fetchDocument(srv.URL+"/big.md", nil)
+	if err == nil {
+		_, err = io.ReadAll(body)
+		_ = body.Close()
+	}
+	if err == nil {
+		t.Error("expected oversized document to be refused")
+	}
+}
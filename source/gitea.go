@@ -0,0 +1,107 @@
+package source
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// giteaHosts are the base URLs of self-hosted Gitea and Forgejo instances.
+var giteaHosts []*url.URL
+
+// SetGiteaHosts sets the base URLs of self-hosted Gitea and Forgejo
+// instances, such as https://git.example.com, whose repositories resolve to
+// their README like those on Codeberg. The protocol may be left out.
+func SetGiteaHosts(hosts []string) error {
+	giteaHosts = nil
+	for _, h := range hosts {
+		if !strings.Contains(h, "://") {
+			h = protoHTTPS + h
+		}
+		u, err := url.Parse(h)
+		if err != nil || u.Host == "" {
+			return fmt.Errorf("invalid Gitea base URL: %s", h)
+		}
+		u.Path = strings.TrimSuffix(u.Path, "/")
+		giteaHosts = append(giteaHosts, u)
+	}
+	return nil
+}
+
+// giteaBase returns the base URL of the self-hosted instance a repository
+// URL belongs to, if any.
+func giteaBase(u *url.URL) *url.URL {
+	for _, base := range giteaHosts {
+		if u.Host == base.Host && strings.HasPrefix(u.Path, base.Path+"/") {
+			return base
+		}
+	}
+	return nil
+}
+
+// findGiteaREADME finds the README of a repository on Codeberg or another
+// Gitea instance by listing the files at its root through the API.
+func findGiteaREADME(service string, base, u *url.URL) (*Source, error) {
+	owner, repo, ok := strings.Cut(strings.TrimPrefix(u.Path, base.Path+"/"), "/")
+	if !ok {
+		return nil, fmt.Errorf("invalid url: %s", u.String())
+	}
+	repo, _, _ = strings.Cut(repo, "/")
+
+	env := giteaTokenEnv
+	if base == codebergURL {
+		env = codebergTokenEnv
+	}
+	tok := token(env)
+	header := giteaHeader(tok)
+	res, err := getWithRetry(base.JoinPath("api/v1/repos", owner, repo, "contents").String(), header)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close() //nolint:errcheck
+	if err := checkAuth(service, env, owner+"/"+repo, res, tok != ""); err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("can't find README in %s repository", service)
+	}
+
+	var files []struct {
+		Name        string `json:"name"`
+		Type        string `json:"type"`
+		DownloadURL string `json:"download_url"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&files); err != nil {
+		return nil, err
+	}
+	for _, name := range ReadmeNames {
+		for _, f := range files {
+			if f.Type != "file" || f.Name != name {
+				continue
+			}
+			// raw files of private repositories can only be fetched
+			// through the API
+			fetchURL := f.DownloadURL
+			if tok != "" {
+				fetchURL = base.JoinPath("api/v1/repos", owner, repo, "raw", f.Name).String()
+			}
+			// it is closed on the caller
+			body, err := fetchDocument(fetchURL, header)
+			if err != nil {
+				return nil, err
+			}
+			return &Source{Reader: body, URL: f.DownloadURL}, nil
+		}
+	}
+	return nil, fmt.Errorf("can't find README in %s repository", service)
+}
+
+// giteaHeader returns the headers to authenticate with a token, if any.
+func giteaHeader(tok string) http.Header {
+	if tok == "" {
+		return nil
+	}
+	return http.Header{"Authorization": {"token " + tok}}
+}
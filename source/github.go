@@ -0,0 +1,106 @@
+package source
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// findGitHubREADME tries to find the correct README filename in a repository using GitHub API.
+// When GitHub can't be reached, the copy cached last time is used, if any.
+func findGitHubREADME(u *url.URL) (*Source, error) {
+	owner, repo, ok := strings.Cut(strings.TrimPrefix(u.Path, "/"), "/")
+	if !ok {
+		return nil, fmt.Errorf("invalid url: %s", u.String())
+	}
+
+	src, err := fetchGitHubREADME(u.Hostname(), owner, repo)
+	if err != nil {
+		var authErr *AuthError
+		if cached := loadCachedReadme(u.Hostname(), owner, repo); cached != nil && !errors.As(err, &authErr) {
+			return cached, nil
+		}
+		return nil, err
+	}
+	return cacheReadme(src, u.Hostname(), owner, repo)
+}
+
+func fetchGitHubREADME(host, owner, repo string) (*Source, error) {
+	type readme struct {
+		DownloadURL string `json:"download_url"`
+	}
+
+	apiURL := fmt.Sprintf("https://api.%s/repos/%s/%s/readme", host, owner, repo)
+
+	// private repositories are fetched with a token, which also raises the
+	// rate limit; the download URL GitHub answers with has one of its own
+	tok := token(githubTokenEnv)
+	res, err := getWithRetry(apiURL, githubHeader(tok))
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close() //nolint:errcheck
+	if err := checkAuth("GitHub", githubTokenEnv, owner+"/"+repo, res, tok != ""); err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var result readme
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode == http.StatusOK {
+		// it is closed on the caller
+		body, err := fetchDocument(result.DownloadURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		return &Source{Reader: body, URL: result.DownloadURL}, nil
+	}
+
+	return nil, errors.New("can't find README in GitHub repository")
+}
+
+// findGitHubFile fetches a file given by a blob or raw URL, or the README of
+// a directory given by a tree URL. The ref and path don't have to be told
+// apart for raw.githubusercontent.com, which takes a token as well.
+func findGitHubFile(f repoFile) (*Source, error) {
+	var urls []string
+	for _, p := range f.paths() {
+		u := url.URL{Scheme: "https", Host: "raw.githubusercontent.com", Path: "/" + f.project + "/" + p}
+		urls = append(urls, u.String())
+	}
+
+	tok := token(githubTokenEnv)
+	res, i, err := fetchFirst(urls, githubHeader(tok))
+	if err != nil {
+		return nil, err
+	}
+	if err := checkAuth("GitHub", githubTokenEnv, f.project, res, tok != ""); err != nil {
+		_ = res.Body.Close()
+		return nil, f.authError(err)
+	}
+	// it is closed on the caller
+	body, err := documentBody(res)
+	if err != nil {
+		return nil, err
+	}
+	return &Source{Reader: body, URL: urls[i], Line: f.line, Anchor: f.anchor}, nil
+}
+
+// githubHeader returns the headers to authenticate with a token, if any.
+func githubHeader(tok string) http.Header {
+	if tok == "" {
+		return nil
+	}
+	return http.Header{"Authorization": {"Bearer " + tok}}
+}
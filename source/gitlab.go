@@ -0,0 +1,121 @@
+package source
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// findGitLabREADME tries to find the correct README filename in a repository using GitLab API.
+func findGitLabREADME(u *url.URL) (*Source, error) {
+	owner, repo, ok := strings.Cut(strings.TrimPrefix(u.Path, "/"), "/")
+	if !ok {
+		return nil, fmt.Errorf("invalid url: %s", u.String())
+	}
+
+	projectPath := url.QueryEscape(owner + "/" + repo)
+
+	type readme struct {
+		ReadmeURL     string `json:"readme_url"`
+		DefaultBranch string `json:"default_branch"`
+	}
+
+	apiURL := fmt.Sprintf("https://%s/api/v4/projects/%s", u.Hostname(), projectPath)
+
+	tok := token(gitlabTokenEnv)
+	header := gitlabHeader(tok)
+	res, err := getWithRetry(apiURL, header)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close() //nolint:errcheck
+	if err := checkAuth("GitLab", gitlabTokenEnv, owner+"/"+repo, res, tok != ""); err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var result readme
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, err
+	}
+
+	readmeRawURL := strings.Replace(result.ReadmeURL, "blob", "raw", -1)
+
+	if res.StatusCode == http.StatusOK && result.ReadmeURL != "" {
+		// raw files of private projects can only be fetched through the API
+		fetchURL := readmeRawURL
+		if _, file, ok := strings.Cut(result.ReadmeURL, "/-/blob/"+result.DefaultBranch+"/"); ok && tok != "" {
+			fetchURL = fmt.Sprintf("https://%s/api/v4/projects/%s/repository/files/%s/raw?ref=%s",
+				u.Hostname(), projectPath, url.PathEscape(file), url.QueryEscape(result.DefaultBranch))
+		}
+
+		// it is closed on the caller
+		body, err := fetchDocument(fetchURL, header)
+		if err != nil {
+			return nil, err
+		}
+		return &Source{Reader: body, URL: readmeRawURL}, nil
+	}
+
+	return nil, errors.New("can't find README in GitLab repository")
+}
+
+// findGitLabFile fetches a file given by a blob or raw URL, or the README of
+// a directory given by a tree URL.
+func findGitLabFile(host string, f repoFile) (*Source, error) {
+	tok := token(gitlabTokenEnv)
+
+	// the URLs to try, and the raw URL each stands for
+	var urls, rawURLs []string
+	for _, p := range f.paths() {
+		raw := url.URL{Scheme: "https", Host: host, Path: "/" + f.project + "/-/raw/" + p}
+		if tok == "" {
+			urls = append(urls, raw.String())
+			rawURLs = append(rawURLs, raw.String())
+			continue
+		}
+		// raw files of private projects can only be fetched through the
+		// API, which wants the ref and the path apart: each place the ref
+		// could end is tried, shortest first
+		parts := strings.Split(p, "/")
+		for i := 1; i < len(parts); i++ {
+			urls = append(urls, fmt.Sprintf("https://%s/api/v4/projects/%s/repository/files/%s/raw?ref=%s",
+				host, url.QueryEscape(f.project), url.PathEscape(strings.Join(parts[i:], "/")), url.QueryEscape(strings.Join(parts[:i], "/"))))
+			rawURLs = append(rawURLs, raw.String())
+		}
+	}
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("invalid url: missing the path after %s", f.path)
+	}
+
+	res, i, err := fetchFirst(urls, gitlabHeader(tok))
+	if err != nil {
+		return nil, err
+	}
+	if err := checkAuth("GitLab", gitlabTokenEnv, f.project, res, tok != ""); err != nil {
+		_ = res.Body.Close()
+		return nil, f.authError(err)
+	}
+	// it is closed on the caller
+	body, err := documentBody(res)
+	if err != nil {
+		return nil, err
+	}
+	return &Source{Reader: body, URL: rawURLs[i], Line: f.line, Anchor: f.anchor}, nil
+}
+
+// gitlabHeader returns the headers to authenticate with a token, if any.
+func gitlabHeader(tok string) http.Header {
+	if tok == "" {
+		return nil
+	}
+	return http.Header{"Private-Token": {tok}}
+}
@@ -0,0 +1,163 @@
+package source
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/charmbracelet/glow/v2/utils"
+)
+
+// ReadmeNames are the file names looked for when a directory is given.
+var ReadmeNames = []string{"README.md", "README", "Readme.md", "Readme", "readme.md", "readme"}
+
+// Stdin resolves "-" to the standard input.
+type Stdin struct{}
+
+// Resolve implements Resolver.
+func (Stdin) Resolve(arg string) (*Source, error) {
+	if arg != "-" {
+		return nil, nil
+	}
+	return &Source{Reader: os.Stdin}, nil
+}
+
+// Readme resolves GitHub and GitLab repositories to their README, given as
+// github://owner/repo, gitlab://owner/repo or a repository URL. The blob, raw
+// and tree URLs of files and directories in them are resolved to the raw
+// file, or the directory's README. The protocol may be left out, unless in
+// hardened mode.
+type Readme struct{}
+
+// Resolve implements Resolver.
+func (Readme) Resolve(arg string) (*Source, error) {
+	if hardened && !strings.Contains(arg, "://") {
+		return nil, nil
+	}
+	src, err := readmeURL(arg)
+	if err != nil {
+		// not a repository after all, leave it to the next resolvers,
+		// unless it's one that can't be read without a token or a file in
+		// one, and not a local path either
+		var (
+			authErr *AuthError
+			fileErr *repoFileError
+		)
+		if _, statErr := os.Stat(arg); (errors.As(err, &authErr) || errors.As(err, &fileErr)) && statErr != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	return src, nil
+}
+
+// HTTP resolves http:// and https:// URLs.
+type HTTP struct{}
+
+// Resolve implements Resolver.
+func (HTTP) Resolve(arg string) (*Source, error) {
+	u, err := url.ParseRequestURI(arg)
+	if err != nil || !strings.Contains(arg, "://") || u.Scheme == "" || u.Scheme == "file" {
+		return nil, nil
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("%s is not a supported protocol", u.Scheme)
+	}
+	body, err := fetchDocument(u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Source{Reader: body, URL: u.String()}, nil
+}
+
+// Dir resolves a directory, or the current one for an empty argument, to the
+// first README found in it.
+type Dir struct{}
+
+// Resolve implements Resolver.
+func (Dir) Resolve(arg string) (*Source, error) {
+	if len(arg) == 0 {
+		arg = "."
+	}
+	st, err := os.Stat(arg)
+	if err != nil || !st.IsDir() {
+		return nil, nil
+	}
+
+	var src *Source
+	_ = filepath.Walk(arg, func(path string, _ os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		for _, v := range ReadmeNames {
+			if strings.EqualFold(filepath.Base(path), v) {
+				r, err := os.Open(path)
+				if err != nil {
+					continue
+				}
+
+				u, _ := filepath.Abs(path)
+				src = &Source{Reader: r, URL: u}
+
+				// abort filepath.Walk
+				return errors.New("source found")
+			}
+		}
+		return nil
+	})
+
+	if src != nil {
+		return src, nil
+	}
+	return nil, errors.New("missing markdown source")
+}
+
+// File resolves a path to a file, possibly followed by a line number or
+// anchor as in "file.md:42" or "file.md#install". Paths may be given as
+// file:// URLs, too.
+type File struct{}
+
+// Resolve implements Resolver.
+func (File) Resolve(arg string) (*Source, error) {
+	if p, ok := fileURLPath(arg); ok {
+		arg = p
+	}
+	var line int
+	var anchor string
+	if _, err := os.Stat(arg); err != nil {
+		if p, l, a := utils.ParseTarget(arg); p != arg {
+			if _, err := os.Stat(p); err == nil {
+				arg, line, anchor = p, l, a
+			}
+		}
+	}
+	r, err := os.Open(arg)
+	if err != nil {
+		return nil, err
+	}
+	u, _ := filepath.Abs(arg)
+	return &Source{Reader: r, URL: u, Line: line, Anchor: anchor}, nil
+}
+
+// fileURLPath returns the local path of a file:// URL, keeping an anchor.
+// Windows drive letters follow a slash in these, as in
+// file:///C:/docs/README.md.
+func fileURLPath(arg string) (string, bool) {
+	u, err := url.Parse(arg)
+	if err != nil || u.Scheme != "file" {
+		return "", false
+	}
+	p := u.Path
+	if runtime.GOOS == "windows" && len(p) > 2 && p[0] == '/' && p[2] == ':' {
+		p = p[1:]
+	}
+	p = filepath.FromSlash(p)
+	if u.Fragment != "" {
+		p += "#" + u.Fragment
+	}
+	return p, true
+}
@@ -0,0 +1,100 @@
+package source
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// How often fetches are attempted when the network is flaky or a server
+// asks to come back later, and how long to wait between attempts.
+const (
+	maxAttempts   = 3
+	retryBackoff  = time.Second
+	maxRetryAfter = 30 * time.Second
+)
+
+// sleep waits between attempts. Replaced in tests.
+var sleep = time.Sleep
+
+// getWithRetry fetches a URL, with the given request headers, if any, trying
+// again with exponential backoff when the network fails or the server is rate
+// limiting or temporarily unavailable. A Retry-After header is respected,
+// unless it asks to wait longer than maxRetryAfter, in which case the
+// response is returned as is.
+func getWithRetry(u string, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, u, nil) //nolint:noctx
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	backoff := retryBackoff
+	for attempt := 1; ; attempt++ {
+		resp, err := httpClient.Do(req)
+		if attempt == maxAttempts || !shouldRetry(resp, err) {
+			return resp, err
+		}
+
+		wait := backoff
+		if resp != nil {
+			if d, ok := retryAfter(resp.Header, time.Now()); ok {
+				if d > maxRetryAfter {
+					return resp, nil
+				}
+				wait = d
+			}
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+		sleep(wait)
+		backoff *= 2
+	}
+}
+
+// shouldRetry reports whether a failed fetch may succeed when tried again.
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		var ne net.Error
+		return errors.As(err, &ne) && ne.Timeout() ||
+			errors.Is(err, syscall.ECONNRESET) ||
+			errors.Is(err, syscall.ECONNREFUSED) ||
+			errors.Is(err, io.ErrUnexpectedEOF)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	case http.StatusForbidden:
+		// GitHub answers 403 once the rate limit is used up
+		return resp.Header.Get("X-RateLimit-Remaining") == "0"
+	}
+	return false
+}
+
+// retryAfter returns how long a response asks to wait before trying again,
+// going by Retry-After, or GitHub's X-RateLimit-Reset when the rate limit
+// is used up.
+func retryAfter(h http.Header, now time.Time) (time.Duration, bool) {
+	if v := h.Get("Retry-After"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil {
+			return time.Duration(max(0, secs)) * time.Second, true
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			return max(0, t.Sub(now)), true
+		}
+	}
+	if h.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			return max(0, time.Unix(reset, 0).Sub(now)), true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,139 @@
+package source
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetWithRetry(t *testing.T) {
+	var waits []time.Duration
+	sleep = func(d time.Duration) { waits = append(waits, d) }
+	defer func() { sleep = time.Sleep }()
+
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		switch r.URL.Path {
+		case "/flaky":
+			if calls < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+		case "/limited":
+			if calls < 2 {
+				w.Header().Set("Retry-After", "7")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+		case "/later":
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+	}))
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		path   string
+		status int
+		calls  int
+		waits  []time.Duration
+	}{
+		{"/flaky", http.StatusOK, 3, []time.Duration{time.Second, 2 * time.Second}},
+		{"/limited", http.StatusOK, 2, []time.Duration{7 * time.Second}},
+		{"/later", http.StatusTooManyRequests, 1, nil},
+		{"/missing", http.StatusNotFound, 1, nil},
+	} {
+		t.Run(tc.path, func(t *testing.T) {
+			calls, waits = 0, nil
+			resp, err := getWithRetry(srv.URL+tc.path, nil)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			_ = resp.Body.Close()
+			if resp.StatusCode != tc.status {
+				t.Errorf("expected status %d, got %d", tc.status, resp.StatusCode)
+			}
+			if calls != tc.calls {
+				t.Errorf("expected %d requests, got %d", tc.calls, calls)
+			}
+			if len(waits) != len(tc.waits) {
+				t.Fatalf("expected waits %v, got %v", tc.waits, waits)
+			}
+			for i := range waits {
+				if waits[i] != tc.waits[i] {
+					t.Errorf("expected waits %v, got %v", tc.waits, waits)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for name, tc := range map[string]struct {
+		header http.Header
+		want   time.Duration
+		ok     bool
+	}{
+		"seconds": {http.Header{"Retry-After": {"30"}}, 30 * time.Second, true},
+		"date":    {http.Header{"Retry-After": {"Wed, 01 May 2024 12:01:00 GMT"}}, time.Minute, true},
+		"reset":   {http.Header{"X-Ratelimit-Remaining": {"0"}, "X-Ratelimit-Reset": {"1714564810"}}, 10 * time.Second, true},
+		"none":    {http.Header{}, 0, false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			got, ok := retryAfter(tc.header, now)
+			if got != tc.want || ok != tc.ok {
+				t.Errorf("expected %v, %v, got %v, %v", tc.want, tc.ok, got, ok)
+			}
+		})
+	}
+}
+
+func TestCachedReadme(t *testing.T) {
+	dir := t.TempDir()
+	orig := cacheDir
+	cacheDir = func() (string, error) { return dir, nil }
+	defer func() { cacheDir = orig }()
+
+	if src := loadCachedReadme("github.com", "owner", "repo"); src != nil {
+		t.Fatal("expected no cached README")
+	}
+
+	src := &Source{Reader: io.NopCloser(strings.NewReader("# Hello")), URL: "https://example.com/README.md"}
+	src, err := cacheReadme(src, "github.com", "owner", "repo")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got := readAll(t, src); got != "# Hello" {
+		t.Errorf("expected the README to be readable still, got %q", got)
+	}
+
+	cached := loadCachedReadme("github.com", "Owner", "repo")
+	if cached == nil {
+		t.Fatal("expected a cached README")
+	}
+	if got := readAll(t, cached); got != "# Hello" {
+		t.Errorf("expected the cached README, got %q", got)
+	}
+	if cached.URL != src.URL || cached.Cached.IsZero() {
+		t.Errorf("expected URL %q and a fetch date, got %q and %v", src.URL, cached.URL, cached.Cached)
+	}
+}
+
+func readAll(t *testing.T, src *Source) string {
+	t.Helper()
+	defer src.Reader.Close() //nolint:errcheck
+	b, err := io.ReadAll(src.Reader)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	return string(b)
+}
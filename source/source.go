@@ -0,0 +1,79 @@
+// Package source resolves arguments, as given on the command line, into
+// readable markdown sources: stdin, files, directories, HTTP URLs and GitHub
+// or GitLab repositories. Other kinds of sources can be added with Register.
+package source
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Source provides a readable markdown source.
+type Source struct {
+	// Reader reads the markdown. Whoever resolved the source is responsible
+	// for closing it.
+	Reader io.ReadCloser
+	// URL is the absolute path or URL of the source, empty for stdin.
+	URL string
+
+	// Optional position to start rendering from, as given by a
+	// "file.md:42" or "file.md#anchor" reference.
+	Line   int
+	Anchor string
+
+	// When a cached copy of the source was fetched, if it's shown because
+	// fetching it failed. Zero otherwise.
+	Cached time.Time
+}
+
+// Resolver creates sources from arguments. Resolvers return a nil source
+// and no error for arguments they don't handle, so the next resolver gets a
+// chance.
+type Resolver interface {
+	Resolve(arg string) (*Source, error)
+}
+
+// ResolverFunc adapts a function to a Resolver.
+type ResolverFunc func(arg string) (*Source, error)
+
+// Resolve calls f(arg).
+func (f ResolverFunc) Resolve(arg string) (*Source, error) {
+	return f(arg)
+}
+
+var (
+	mu         sync.RWMutex
+	registered []Resolver
+)
+
+// Register adds a resolver. Registered resolvers are consulted before the
+// built-in ones, in the order they were registered.
+func Register(r Resolver) {
+	mu.Lock()
+	defer mu.Unlock()
+	registered = append(registered, r)
+}
+
+// Builtin returns the built-in resolvers in the order they're consulted.
+// The file resolver comes last and handles any argument.
+func Builtin() []Resolver {
+	return []Resolver{Stdin{}, Readme{}, HTTP{}, Dir{}, File{}}
+}
+
+// Resolve creates a source for an argument, trying the registered resolvers
+// first and then the built-in ones.
+func Resolve(arg string) (*Source, error) {
+	mu.RLock()
+	resolvers := append(append([]Resolver(nil), registered...), Builtin()...)
+	mu.RUnlock()
+
+	for _, r := range resolvers {
+		src, err := r.Resolve(arg)
+		if err != nil || src != nil {
+			return src, err
+		}
+	}
+	return nil, fmt.Errorf("no source for %s", arg)
+}
@@ -0,0 +1,105 @@
+package source
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveStdin(t *testing.T) {
+	src, err := Resolve("-")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if src.Reader != os.Stdin || src.URL != "" {
+		t.Errorf("expected stdin, got %+v", src)
+	}
+}
+
+func TestResolveFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "doc.md")
+	if err := os.WriteFile(path, []byte("# Doc\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	fileURL := "file://" + filepath.ToSlash(path)
+	if !strings.HasPrefix(filepath.ToSlash(path), "/") {
+		fileURL = "file:///" + filepath.ToSlash(path)
+	}
+
+	for arg, want := range map[string]Source{
+		path:                 {URL: path},
+		path + ":42":         {URL: path, Line: 42},
+		path + "#install":    {URL: path, Anchor: "install"},
+		fileURL:              {URL: path},
+		fileURL + "#install": {URL: path, Anchor: "install"},
+	} {
+		t.Run(arg, func(t *testing.T) {
+			src, err := Resolve(arg)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			_ = src.Reader.Close()
+			if src.URL != want.URL || src.Line != want.Line || src.Anchor != want.Anchor {
+				t.Errorf("expected %+v, got %+v", want, src)
+			}
+		})
+	}
+
+	if _, err := Resolve(filepath.Join(dir, "missing.md")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
+
+func TestResolveDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "docs"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	readme := filepath.Join(dir, "docs", "Readme.md")
+	if err := os.WriteFile(readme, []byte("# Readme\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := Resolve(dir)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	_ = src.Reader.Close()
+	if src.URL != readme {
+		t.Errorf("expected %s, got %s", readme, src.URL)
+	}
+
+	if _, err := Resolve(t.TempDir()); err == nil {
+		t.Error("expected an error for a directory without a README")
+	}
+}
+
+func TestRegister(t *testing.T) {
+	defer func(r []Resolver) { registered = r }(registered)
+
+	Register(ResolverFunc(func(arg string) (*Source, error) {
+		name, ok := strings.CutPrefix(arg, "mem://")
+		if !ok {
+			return nil, nil
+		}
+		return &Source{Reader: io.NopCloser(strings.NewReader("# " + name)), URL: arg}, nil
+	}))
+
+	src, err := Resolve("mem://notes")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	b, _ := io.ReadAll(src.Reader)
+	if string(b) != "# notes" {
+		t.Errorf("expected the registered resolver to be used, got %q", b)
+	}
+
+	// other arguments still reach the built-in resolvers
+	src, err = Resolve("-")
+	if err != nil || src.Reader != os.Stdin {
+		t.Errorf("expected stdin, got %+v, %v", src, err)
+	}
+}
@@ -0,0 +1,39 @@
+package source
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// findSourcehutREADME finds the README of a repository on sourcehut. Its API
+// wants a token even for public repositories, so each name a README may have
+// is tried on the default branch instead.
+func findSourcehutREADME(u *url.URL) (*Source, error) {
+	owner, repo, ok := strings.Cut(strings.TrimPrefix(u.Path, "/"), "/")
+	if !ok || !strings.HasPrefix(owner, "~") {
+		return nil, fmt.Errorf("invalid url: %s", u.String())
+	}
+	repo, _, _ = strings.Cut(repo, "/")
+
+	urls := make([]string, len(ReadmeNames))
+	for i, name := range ReadmeNames {
+		urls[i] = sourcehutURL.JoinPath(owner, repo, "blob/HEAD", name).String()
+	}
+	res, i, err := fetchFirst(urls, nil)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode == http.StatusNotFound {
+		_ = res.Body.Close()
+		return nil, errors.New("can't find README in sourcehut repository")
+	}
+	// it is closed on the caller
+	body, err := documentBody(res)
+	if err != nil {
+		return nil, err
+	}
+	return &Source{Reader: body, URL: urls[i]}, nil
+}
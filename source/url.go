@@ -0,0 +1,213 @@
+package source
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	protoGithub    = "github://"
+	protoGitlab    = "gitlab://"
+	protoCodeberg  = "codeberg://"
+	protoSourcehut = "sourcehut://"
+	protoHTTPS     = "https://"
+)
+
+var (
+	githubURL    *url.URL
+	gitlabURL    *url.URL
+	codebergURL  *url.URL
+	sourcehutURL *url.URL
+	urlsOnce     sync.Once
+)
+
+func init() {
+	urlsOnce.Do(func() {
+		githubURL, _ = url.Parse("https://github.com")
+		gitlabURL, _ = url.Parse("https://gitlab.com")
+		codebergURL, _ = url.Parse("https://codeberg.org")
+		sourcehutURL, _ = url.Parse("https://git.sr.ht")
+	})
+}
+
+func readmeURL(path string) (*Source, error) {
+	for proto, base := range map[string]*url.URL{
+		protoGithub:    githubURL,
+		protoGitlab:    gitlabURL,
+		protoCodeberg:  codebergURL,
+		protoSourcehut: sourcehutURL,
+	} {
+		if !strings.HasPrefix(path, proto) {
+			continue
+		}
+		if u := shortcutURL(strings.TrimPrefix(path, proto), base); u != nil {
+			return readmeURL(u.String())
+		}
+		return nil, nil
+	}
+
+	if !strings.Contains(path, "://") {
+		path = protoHTTPS + path
+	}
+	u, err := url.Parse(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case u.Hostname() == githubURL.Hostname():
+		if f, ok := parseGitHubFile(u); ok {
+			return fileSource(findGitHubFile(f))
+		}
+		return findGitHubREADME(u)
+	case u.Hostname() == gitlabURL.Hostname():
+		if f, ok := parseGitLabFile(u); ok {
+			return fileSource(findGitLabFile(u.Hostname(), f))
+		}
+		return findGitLabREADME(u)
+	case u.Hostname() == codebergURL.Hostname():
+		return findGiteaREADME("Codeberg", codebergURL, u)
+	case u.Hostname() == sourcehutURL.Hostname():
+		return findSourcehutREADME(u)
+	}
+	if base := giteaBase(u); base != nil {
+		return findGiteaREADME("Gitea", base, u)
+	}
+
+	return nil, nil
+}
+
+// repoFile is a file of a repository, as given by a blob or raw URL, or a
+// directory whose README is wanted, as given by a tree URL.
+type repoFile struct {
+	project string // owner/repo, with subgroups on GitLab
+	// The branch, tag or commit, followed by the path. Branches may contain
+	// slashes, so where the ref ends is left to the service.
+	path string
+	dir  bool
+
+	// Where to start rendering, from a #L42 or #heading fragment.
+	line   int
+	anchor string
+}
+
+// parseGitHubFile parses github.com/owner/repo/blob/ref/path URLs, and their
+// raw and tree counterparts.
+func parseGitHubFile(u *url.URL) (repoFile, bool) {
+	// owner, repo, the kind of URL and the rest
+	parts := strings.SplitN(strings.Trim(u.Path, "/"), "/", 4) //nolint:mnd
+
+	if len(parts) < 4 { //nolint:mnd
+		return repoFile{}, false
+	}
+	return newRepoFile(parts[0]+"/"+parts[1], parts[2], parts[3], u.Fragment)
+}
+
+// parseGitLabFile parses gitlab.com/group/project/-/blob/ref/path URLs, and
+// their raw and tree counterparts.
+func parseGitLabFile(u *url.URL) (repoFile, bool) {
+	project, rest, ok := strings.Cut(strings.Trim(u.Path, "/"), "/-/")
+	if !ok {
+		return repoFile{}, false
+	}
+	kind, path, ok := strings.Cut(rest, "/")
+	if !ok {
+		return repoFile{}, false
+	}
+	return newRepoFile(project, kind, path, u.Fragment)
+}
+
+func newRepoFile(project, kind, path, fragment string) (repoFile, bool) {
+	if kind != "blob" && kind != "raw" && kind != "tree" {
+		return repoFile{}, false
+	}
+	f := repoFile{project: project, path: strings.Trim(path, "/"), dir: kind == "tree"}
+	if f.path == "" {
+		return repoFile{}, false
+	}
+
+	// GitHub and GitLab link to lines as #L42, or #L42-L50 for a range
+	if l, ok := strings.CutPrefix(fragment, "L"); ok {
+		l, _, _ = strings.Cut(l, "-")
+		if n, err := strconv.Atoi(l); err == nil && n > 0 {
+			f.line = n
+			return f, true
+		}
+	}
+	f.anchor = fragment
+	return f, true
+}
+
+// paths returns the paths to try: the file's, or for a directory, that of
+// each README name.
+func (f repoFile) paths() []string {
+	if !f.dir {
+		return []string{f.path}
+	}
+	paths := make([]string, len(ReadmeNames))
+	for i, name := range ReadmeNames {
+		paths[i] = f.path + "/" + name
+	}
+	return paths
+}
+
+// authError describes a failure to fetch the file in an AuthError.
+func (f repoFile) authError(err error) error {
+	var authErr *AuthError
+	if errors.As(err, &authErr) {
+		authErr.File, authErr.Dir = f.path, f.dir
+	}
+	return err
+}
+
+// repoFileError is returned when a file of a repository couldn't be fetched.
+// Unlike a repository, which may turn out to be a web page after all, such
+// URLs aren't left to other resolvers.
+type repoFileError struct{ err error }
+
+func (e *repoFileError) Error() string { return e.err.Error() }
+func (e *repoFileError) Unwrap() error { return e.err }
+
+func fileSource(src *Source, err error) (*Source, error) {
+	if err != nil {
+		return nil, &repoFileError{err}
+	}
+	return src, nil
+}
+
+// fetchFirst fetches the first of the URLs that exists, and returns its
+// index. If none does, the answer for the last one is returned.
+func fetchFirst(urls []string, header http.Header) (*http.Response, int, error) {
+	for i, u := range urls {
+		res, err := getWithRetry(u, header)
+		if err != nil {
+			return nil, 0, err
+		}
+		if res.StatusCode == http.StatusNotFound && i < len(urls)-1 {
+			_ = res.Body.Close()
+			continue
+		}
+		return res, i, nil
+	}
+	return nil, 0, errors.New("nothing to fetch")
+}
+
+// shortcutURL returns the URL of a repository given by a shortcut, as in
+// github://owner/repo. Repositories of sourcehut users may be given without
+// the tilde.
+func shortcutURL(path string, base *url.URL) *url.URL {
+	parts := strings.Split(path, "/")
+	if len(parts) != 2 { //nolint:mnd
+		// custom hostnames are not supported yet
+		return nil
+	}
+	if base == sourcehutURL && !strings.HasPrefix(path, "~") {
+		path = "~" + path
+	}
+	u, _ := url.Parse(base.String())
+	return u.JoinPath(path)
+}
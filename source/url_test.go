@@ -0,0 +1,215 @@
+package source
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestURLParser(t *testing.T) {
+	for path, url := range map[string]string{
+		"github.com/charmbracelet/glow":             "https://raw.githubusercontent.com/charmbracelet/glow/master/README.md",
+		"github://charmbracelet/glow":               "https://raw.githubusercontent.com/charmbracelet/glow/master/README.md",
+		"github://caarlos0/dotfiles.fish":           "https://raw.githubusercontent.com/caarlos0/dotfiles.fish/main/README.md",
+		"github://tj/git-extras":                    "https://raw.githubusercontent.com/tj/git-extras/main/Readme.md",
+		"https://github.com/goreleaser/nfpm":        "https://raw.githubusercontent.com/goreleaser/nfpm/main/README.md",
+		"gitlab.com/caarlos0/test":                  "https://gitlab.com/caarlos0/test/-/raw/master/README.md",
+		"gitlab://caarlos0/test":                    "https://gitlab.com/caarlos0/test/-/raw/master/README.md",
+		"https://gitlab.com/terrakok/gitlab-client": "https://gitlab.com/terrakok/gitlab-client/-/raw/develop/Readme.md",
+	} {
+		t.Run(path, func(t *testing.T) {
+			t.Skip("test uses network, sometimes fails for no reason")
+			got, err := readmeURL(path)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if got == nil {
+				t.Fatalf("should not be nil")
+			}
+			if url != got.URL {
+				t.Errorf("expected url for %s to be %s, was %s", path, url, got.URL)
+			}
+		})
+	}
+}
+
+func TestParseRepoFile(t *testing.T) {
+	for in, want := range map[string]repoFile{
+		"https://github.com/owner/repo/blob/main/docs/guide.md":        {project: "owner/repo", path: "main/docs/guide.md"},
+		"https://github.com/owner/repo/raw/feature/x/guide.md#L42-L50": {project: "owner/repo", path: "feature/x/guide.md", line: 42},
+		"https://github.com/owner/repo/tree/v1.0/docs/":                {project: "owner/repo", path: "v1.0/docs", dir: true},
+		"https://github.com/owner/repo/blob/main/README.md#install":    {project: "owner/repo", path: "main/README.md", anchor: "install"},
+		"https://gitlab.com/group/sub/project/-/blob/main/guide.md":    {project: "group/sub/project", path: "main/guide.md"},
+		"https://gitlab.com/group/project/-/tree/main":                 {project: "group/project", path: "main", dir: true},
+	} {
+		u, _ := url.Parse(in)
+		parse := parseGitHubFile
+		if u.Hostname() == gitlabURL.Hostname() {
+			parse = parseGitLabFile
+		}
+		got, ok := parse(u)
+		if !ok || got != want {
+			t.Errorf("%s: got %+v, %v; want %+v", in, got, ok, want)
+		}
+	}
+
+	for _, in := range []string{
+		"https://github.com/owner/repo",
+		"https://github.com/owner/repo/issues/12",
+		"https://github.com/owner/repo/blob/",
+		"https://gitlab.com/group/project",
+		"https://gitlab.com/group/project/-/issues/12",
+	} {
+		u, _ := url.Parse(in)
+		parse := parseGitHubFile
+		if u.Hostname() == gitlabURL.Hostname() {
+			parse = parseGitLabFile
+		}
+		if f, ok := parse(u); ok {
+			t.Errorf("%s: expected no file, got %+v", in, f)
+		}
+	}
+}
+
+// rewriteTransport sends all requests to a test server.
+type rewriteTransport struct{ srv *httptest.Server }
+
+func (t rewriteTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	u, _ := url.Parse(t.srv.URL)
+	r = r.Clone(r.Context())
+	r.URL.Scheme, r.URL.Host = u.Scheme, u.Host
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func TestFindGitHubFile(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GH_TOKEN", "")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/owner/repo/feature/x/docs/guide.md":
+			_, _ = io.WriteString(w, "# Guide\n")
+		case "/owner/repo/main/docs/Readme.md":
+			_, _ = io.WriteString(w, "# Docs\n")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	saved := httpClient
+	httpClient = &http.Client{Transport: rewriteTransport{srv}}
+	defer func() { httpClient = saved }()
+
+	for in, want := range map[string]string{
+		"https://github.com/owner/repo/blob/feature/x/docs/guide.md": "# Guide\n",
+		"https://github.com/owner/repo/tree/main/docs":               "# Docs\n",
+	} {
+		src, err := readmeURL(in)
+		if err != nil {
+			t.Fatalf("%s: %v", in, err)
+		}
+		b, _ := io.ReadAll(src.Reader)
+		_ = src.Reader.Close()
+		if string(b) != want {
+			t.Errorf("%s: got %q, want %q", in, b, want)
+		}
+		if !strings.HasPrefix(src.URL, "https://raw.githubusercontent.com/owner/repo/") {
+			t.Errorf("%s: expected the raw URL as the source, got %s", in, src.URL)
+		}
+	}
+
+	_, err := readmeURL("https://github.com/owner/repo/blob/main/missing.md")
+	var authErr *AuthError
+	if !errors.As(err, &authErr) || !strings.Contains(err.Error(), "can't find main/missing.md in owner/repo") {
+		t.Errorf("expected an error about the missing file, got %v", err)
+	}
+}
+
+func TestShortcutURL(t *testing.T) {
+	for in, want := range map[string]string{
+		"github://owner/repo":       "https://github.com/owner/repo",
+		"codeberg://owner/repo":     "https://codeberg.org/owner/repo",
+		"sourcehut://~owner/repo":   "https://git.sr.ht/~owner/repo",
+		"sourcehut://owner/repo":    "https://git.sr.ht/~owner/repo",
+		"gitlab://group/sub/repo":   "",
+		"codeberg://host/owner/rep": "",
+	} {
+		proto, path, _ := strings.Cut(in, "://")
+		base := map[string]*url.URL{"github": githubURL, "gitlab": gitlabURL, "codeberg": codebergURL, "sourcehut": sourcehutURL}[proto]
+		got := ""
+		if u := shortcutURL(path, base); u != nil {
+			got = u.String()
+		}
+		if got != want {
+			t.Errorf("%s: got %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestFindGiteaREADME(t *testing.T) {
+	t.Setenv("GITEA_TOKEN", "")
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/gitea/api/v1/repos/owner/repo/contents":
+			_, _ = fmt.Fprintf(w, `[{"name":"docs","type":"dir"},{"name":"readme.md","type":"file","download_url":%q}]`,
+				srv.URL+"/gitea/owner/repo/raw/branch/main/readme.md")
+		case "/gitea/owner/repo/raw/branch/main/readme.md":
+			_, _ = io.WriteString(w, "# Repo\n")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	if err := SetGiteaHosts([]string{srv.URL + "/gitea/"}); err != nil {
+		t.Fatal(err)
+	}
+	defer SetGiteaHosts(nil) //nolint:errcheck
+
+	src, err := readmeURL(srv.URL + "/gitea/owner/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := io.ReadAll(src.Reader)
+	_ = src.Reader.Close()
+	if string(b) != "# Repo\n" || !strings.HasSuffix(src.URL, "/readme.md") {
+		t.Errorf("got %q from %s", b, src.URL)
+	}
+
+	_, err = readmeURL(srv.URL + "/gitea/owner/private")
+	var authErr *AuthError
+	if !errors.As(err, &authErr) || !strings.Contains(err.Error(), "set GITEA_TOKEN") {
+		t.Errorf("expected an error asking for a token, got %v", err)
+	}
+
+	if src, err := readmeURL(srv.URL + "/elsewhere/owner/repo"); src != nil || err != nil {
+		t.Errorf("expected URLs of other hosts to be left alone, got %v, %v", src, err)
+	}
+}
+
+func TestFindSourcehutREADME(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/~owner/repo/blob/HEAD/README" {
+			_, _ = io.WriteString(w, "plain readme\n")
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer srv.Close()
+	saved := httpClient
+	httpClient = &http.Client{Transport: rewriteTransport{srv}}
+	defer func() { httpClient = saved }()
+
+	src, err := readmeURL("sourcehut://owner/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = src.Reader.Close()
+	if src.URL != "https://git.sr.ht/~owner/repo/blob/HEAD/README" {
+		t.Errorf("expected the README without an extension, got %s", src.URL)
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/glow/v2/source"
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// streamCLI renders a source block by block as it comes in, rather than
+// once it ends: a block is rendered and written once the blank line or
+// closing fence after it arrives. With --stream-code, open code blocks are
+// drawn as their lines arrive too, redrawing the block each time, so that
+// slow producers aren't held back by long code blocks. Front matter is
+// dropped, and blocks that go on past a blank line, such as loose lists and
+// CriticMarkup edits, are held back until they end.
+func streamCLI(src *source.Source, w io.Writer) error {
+	watchdog.rendering(src, 0)
+	s := &streamer{src: src, w: w}
+	if f, ok := w.(*os.File); ok && streamCode && utils.Term.IsTerminal(f) {
+		s.live = true
+		s.cols, s.rows, _ = utils.Term.Size(f)
+	}
+
+	r := bufio.NewReader(src.Reader)
+	for {
+		l, err := r.ReadString('\n')
+		if l != "" {
+			if err := s.line(l); err != nil {
+				return err
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return s.end()
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// streamer writes blocks of a source as they're completed.
+type streamer struct {
+	src *source.Source
+	w   io.Writer
+
+	// whether open code blocks are drawn, on a terminal of the given size
+	live       bool
+	cols, rows int
+
+	block   strings.Builder
+	fence   utils.CodeFence
+	closing string   // fence that closes the open code block
+	shown   []string // lines of the open block drawn so far
+	written int      // blocks written
+	read    int      // lines read
+	front   bool     // whether front matter is being read
+	held    bool     // whether the block went on past a blank line
+}
+
+// streamListItem matches the first line of a list item.
+var streamListItem = regexp.MustCompile(`^ {0,3}([-*+]|\d{1,9}[.)])([ \t]|$)`)
+
+func (s *streamer) line(l string) error {
+	s.read++
+	if s.read == 1 && strings.TrimRight(l, "\r\n") == "---" {
+		s.front = true
+	}
+	if s.front {
+		s.block.WriteString(l)
+		if s.read > 1 && strings.TrimRight(l, "\r\n") == "---" {
+			s.front = false
+			s.block.Reset()
+		}
+		return nil
+	}
+	if s.held && strings.TrimSpace(l) != "" {
+		s.held = false
+		if !s.continues(l) {
+			if err := s.flush(); err != nil {
+				return err
+			}
+		}
+	}
+
+	wasOpen := s.fence.Open()
+	s.fence.Scan(l)
+	opening := !wasOpen && s.fence.Open()
+	switch {
+	case !wasOpen && !s.fence.Open() && strings.TrimSpace(l) == "":
+		if s.block.Len() > 0 && s.continues("") {
+			s.held = true
+			s.block.WriteString(l)
+			return nil
+		}
+		return s.flush()
+	case opening && s.block.Len() > 0:
+		// a code block right after a paragraph is a block of its own
+		if err := s.flush(); err != nil {
+			return err
+		}
+	}
+	if opening {
+		trimmed := strings.TrimSpace(l)
+		s.closing = trimmed[:len(trimmed)-len(strings.TrimLeft(trimmed, trimmed[:1]))]
+	}
+
+	s.block.WriteString(l)
+	switch {
+	case wasOpen && !s.fence.Open():
+		return s.flush()
+	case s.fence.Open() && s.live:
+		md := s.block.String()
+		if !strings.HasSuffix(md, "\n") {
+			md += "\n"
+		}
+		return s.draw(md + s.closing + "\n")
+	}
+	return nil
+}
+
+// continues reports whether the block goes on past a blank line with the
+// given one, or with any line if it's empty: a CriticMarkup edit that isn't
+// closed yet does, and so does a list with a line indented or starting
+// another item.
+func (s *streamer) continues(l string) bool {
+	md := s.block.String()
+	if critic && criticOpen(md) {
+		return true
+	}
+	if !streamListItem.MatchString(md) {
+		return false
+	}
+	return l == "" || streamListItem.MatchString(l) || strings.HasPrefix(l, " ") || strings.HasPrefix(l, "\t")
+}
+
+// flush renders and writes the block read so far.
+func (s *streamer) flush() error {
+	if strings.TrimSpace(s.block.String()) == "" {
+		s.block.Reset()
+		return nil
+	}
+	if err := s.draw(s.block.String()); err != nil {
+		return err
+	}
+	s.block.Reset()
+	s.shown = nil
+	s.written++
+	return nil
+}
+
+// end writes what's left once the source ends, such as a code block that
+// was never closed.
+func (s *streamer) end() error {
+	if err := s.flush(); err != nil {
+		return err
+	}
+	if s.written == 0 {
+		return nil
+	}
+	_, err := io.WriteString(s.w, "\n")
+	return err
+}
+
+// draw renders a block and shows it in place of what was drawn of it so far.
+func (s *streamer) draw(md string) error {
+	watchdog.rendering(s.src, s.written+1)
+	out, _, err := safeRenderCLI(s.src, []byte(md))
+	if err != nil {
+		return err
+	}
+	// glamour puts a blank line above and below each document; blocks only
+	// get one between them, unless they have margins of their own
+	out = strings.TrimPrefix(out, "\n")
+	out = strings.TrimSuffix(out, "\n")
+	if centered {
+		out = utils.Center(out, int(width), int(termWidth))
+	}
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	if s.written == 0 || len(lines) > 0 && strings.TrimSpace(ansi.Strip(lines[0])) != "" {
+		lines = append([]string{""}, lines...)
+	}
+	return s.show(lines)
+}
+
+// show replaces the lines of the open block on screen with the given ones,
+// redrawing from the first line that changed. Lines that have scrolled off
+// the screen can't be redrawn; new lines are only added below them then.
+func (s *streamer) show(lines []string) error {
+	same := 0
+	for same < len(s.shown) && same < len(lines) && s.shown[same] == lines[same] {
+		same++
+	}
+	var b strings.Builder
+	if up := s.height(s.shown[same:]); up > 0 {
+		if s.live && up < s.rows {
+			b.WriteString(ansi.CursorPreviousLine(up) + ansi.EraseDisplayRight)
+		} else {
+			same = min(len(s.shown), len(lines))
+		}
+	}
+	for _, l := range lines[same:] {
+		b.WriteString(l + "\n")
+	}
+	s.shown = lines
+	summary.output(b.String())
+	_, err := io.WriteString(s.w, b.String())
+	return err
+}
+
+// height returns the number of rows lines take up on the terminal, as long
+// ones wrap.
+func (s *streamer) height(lines []string) int {
+	rows := 0
+	for _, l := range lines {
+		w := ansi.StringWidth(l)
+		if s.cols <= 0 || w <= s.cols {
+			rows++
+			continue
+		}
+		rows += (w + s.cols - 1) / s.cols
+	}
+	return rows
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/glow/v2/source"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+func TestStreamCLI(t *testing.T) {
+	style, width = "notty", 40
+	md := "# Title\n\nSome text\nmore\n\n\n```go\nfunc main() {\n}\n```\nafter\n\n- a\n- b\n"
+	var b bytes.Buffer
+	if err := streamCLI(&source.Source{Reader: io.NopCloser(strings.NewReader(md))}, &b); err != nil {
+		t.Fatal(err)
+	}
+
+	// the same as rendering it all at once, bar spaces on blank lines
+	want, _, err := renderCLI(&source.Source{}, []byte(md))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := trimLines(b.String()); got != trimLines(want) {
+		t.Errorf("got:\n%s\nwant:\n%s", got, trimLines(want))
+	}
+}
+
+// trimLines drops spaces at the ends of lines.
+func trimLines(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimRight(l, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestStreamerShow(t *testing.T) {
+	var b bytes.Buffer
+	s := &streamer{w: &b, live: true, cols: 10, rows: 5}
+	for _, lines := range [][]string{
+		{"", "a"},
+		{"", "a", "b"},
+		{"", "a", "B", "c"},
+		{"", "A", "0123456789abc", "d"},
+	} {
+		if err := s.show(lines); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// lines are only redrawn from the first one that changed, and wrapped
+	// ones take up more than a row
+	want := "\na\n" + "b\n" + "\x1b[F\x1b[0JB\nc\n" + "\x1b[3F\x1b[0JA\n0123456789abc\nd\n"
+	if b.String() != want {
+		t.Errorf("got %q, want %q", b.String(), want)
+	}
+
+	// lines that have scrolled off the screen aren't redrawn
+	b.Reset()
+	s.rows = 3
+	if err := s.show([]string{"", "x", "y", "z", "w", "v"}); err != nil {
+		t.Fatal(err)
+	}
+	if want := "w\nv\n"; b.String() != want {
+		t.Errorf("got %q, want %q", b.String(), want)
+	}
+}
+
+func TestStreamCLIBlocks(t *testing.T) {
+	style, width = "notty", 40
+	defer func() { critic = false }()
+	for _, tc := range []struct {
+		name, md string
+		critic   bool
+	}{
+		{"front matter", "---\ntitle: x\n\ndate: y\n---\n# Title\n", false},
+		{"loose list", "- a\n\n- b\n\n  more of b\n\nafter\n", false},
+		{"critic", "Some {++added\n\ntext++} here\n\nafter\n", true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			critic = tc.critic
+			if tc.critic {
+				// edits are only marked in color
+				lipgloss.SetColorProfile(termenv.ANSI)
+				defer lipgloss.SetColorProfile(termenv.Ascii)
+			}
+			var b bytes.Buffer
+			if err := streamCLI(&source.Source{Reader: io.NopCloser(strings.NewReader(tc.md))}, &b); err != nil {
+				t.Fatal(err)
+			}
+			want, _, err := renderCLI(&source.Source{}, []byte(tc.md))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := trimLines(b.String()); got != trimLines(want) {
+				t.Errorf("got:\n%s\nwant:\n%s", got, trimLines(want))
+			}
+		})
+	}
+}
@@ -7,6 +7,17 @@ var (
 		Foreground(lipgloss.Color("#04B575")).
 		Render
 
+	separatorStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.AdaptiveColor{Light: "#DDDADA", Dark: "#3C3C3C"}).
+			Padding(0, 0, 0, 2)
+
+	filenameStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.AdaptiveColor{Light: "#1A1A1A", Dark: "#F1F1F1"}).
+			Bold(true)
+
+	errorStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.AdaptiveColor{Light: "#D1242F", Dark: "#F85149"})
+
 	paragraph = lipgloss.NewStyle().
 			Width(78).
 			Padding(0, 0, 0, 2).
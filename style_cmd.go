@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/glow/v2/ui"
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+)
+
+var (
+	styleSimulate string
+
+	styleCmd = &cobra.Command{
+		Use:   "style",
+		Short: "Work with glamour styles",
+		Args:  cobra.NoArgs,
+	}
+
+	styleEditCmd = &cobra.Command{
+		Use:     "edit STYLE.json",
+		Short:   "Edit a style with a live preview",
+		Long:    paragraph(fmt.Sprintf("\n%s a glamour style JSON file with a live preview of a sample document. If the file doesn't exist, it will be created from the dark style.", keyword("Edit"))),
+		Example: paragraph("glow style edit mystyle.json"),
+		Args:    cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			p, err := ui.NewStyleEditorProgram(utils.ExpandPath(args[0]))
+			if err != nil {
+				return err
+			}
+			_, err = p.Run()
+			return err
+		},
+	}
+
+	stylePreviewCmd = &cobra.Command{
+		Use:     "preview STYLE",
+		Short:   "Render a sample document in a style",
+		Long:    paragraph(fmt.Sprintf("\n%s a sample document in a glamour style, given by name or JSON file. With --simulate, its colors are shown the way they look with a color vision deficiency, to check that a style stays readable for color-blind readers.", keyword("Render"))),
+		Example: paragraph("glow style preview mystyle.json\nglow style preview --simulate deuteranopia mystyle.json"),
+		Args:    cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return previewStyle(os.Stdout, utils.ExpandPath(args[0]), styleSimulate)
+		},
+	}
+)
+
+// previewStyle renders the sample document in a style, with the colors it
+// has for a color vision deficiency, if one is given.
+func previewStyle(w io.Writer, style, deficiency string) error {
+	if err := validateStyle(style); err != nil {
+		return err
+	}
+	cfg, err := utils.LoadStyleConfig(style)
+	if err != nil {
+		return fmt.Errorf("could not load style %s: %w", style, err)
+	}
+	opt := glamour.WithStyles(cfg)
+	if deficiency != "" {
+		b, err := simulateStyle(cfg, deficiency)
+		if err != nil {
+			return err
+		}
+		opt = glamour.WithStylesFromJSONBytes(b)
+	}
+
+	r, err := glamour.NewTermRenderer(
+		glamour.WithColorProfile(lipgloss.ColorProfile()),
+		opt,
+		glamour.WithWordWrap(int(width)), //nolint:gosec
+	)
+	if err != nil {
+		return err
+	}
+	out, err := r.Render(ui.StyleSample)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, out)
+	return err
+}
+
+func init() {
+	stylePreviewCmd.Flags().StringVar(&styleSimulate, "simulate", "", "show colors as they look with a color vision deficiency: "+strings.Join(colorDeficiencyNames(), ", "))
+	styleCmd.AddCommand(styleEditCmd, stylePreviewCmd)
+}
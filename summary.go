@@ -0,0 +1,71 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"time"
+)
+
+// summary accounts for what the CLI rendered when --summary is set, and
+// prints it to stderr once done. All of its methods are safe to call on a
+// nil summary.
+var summary *renderSummary
+
+type renderSummary struct {
+	start    time.Time
+	sources  int
+	bytesIn  int
+	bytesOut int
+	blocks   int
+	hash     hash.Hash // of the output, as it's written
+}
+
+func newRenderSummary() *renderSummary {
+	return &renderSummary{start: time.Now(), hash: sha256.New()}
+}
+
+// read accounts for a source that has been read.
+func (s *renderSummary) read(size int) {
+	if s == nil {
+		return
+	}
+	s.sources++
+	s.bytesIn += size
+}
+
+// rendered accounts for the blocks of a rendered document.
+func (s *renderSummary) rendered(blocks int) {
+	if s == nil {
+		return
+	}
+	s.blocks += blocks
+}
+
+// output accounts for output that's been written, or paged.
+func (s *renderSummary) output(out string) {
+	if s == nil {
+		return
+	}
+	s.bytesOut += len(out)
+	_, _ = io.WriteString(s.hash, out)
+}
+
+// print writes the summary line, unless nothing was rendered.
+func (s *renderSummary) print(w io.Writer) {
+	if s == nil || s.sources == 0 {
+		return
+	}
+	fmt.Fprintf(w, "glow: %d %s, %d bytes in, %d bytes out, %d blocks, %s, sha256:%s\n",
+		s.sources, plural(s.sources, "source", "sources"), s.bytesIn, s.bytesOut, s.blocks,
+		time.Since(s.start).Round(time.Millisecond), hex.EncodeToString(s.hash.Sum(nil)))
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/glow/v2/utils"
+)
+
+func TestTerminalFromEnv(t *testing.T) {
+	env := func(kv ...string) func(string) string {
+		return func(k string) string {
+			for i := 0; i < len(kv); i += 2 {
+				if kv[i] == k {
+					return kv[i+1]
+				}
+			}
+			return ""
+		}
+	}
+
+	term, err := utils.TerminalFromEnv(env("GLOW_FORCE_TTY", "1", "GLOW_COLS", "72", "GLOW_BACKGROUND", "light"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !term.IsTerminal(os.Stdout) {
+		t.Error("stdout isn't a terminal with GLOW_FORCE_TTY=1")
+	}
+	if w, _, err := term.Size(os.Stdout); err != nil || w != 72 {
+		t.Errorf("expected 72 columns, got %d (%v)", w, err)
+	}
+	if term.HasDarkBackground() {
+		t.Error("expected a light background")
+	}
+
+	term, err = utils.TerminalFromEnv(env("GLOW_FORCE_TTY", "0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if term.IsTerminal(os.Stdout) {
+		t.Error("stdout is a terminal with GLOW_FORCE_TTY=0")
+	}
+
+	for _, kv := range [][]string{
+		{"GLOW_FORCE_TTY", "maybe"},
+		{"GLOW_COLS", "0"},
+		{"GLOW_ROWS", "tall"},
+		{"GLOW_BACKGROUND", "blue"},
+		{"GLOW_COLOR_PROFILE", "sepia"},
+	} {
+		if _, err := utils.TerminalFromEnv(env(kv...)); err == nil || !strings.Contains(err.Error(), kv[0]) {
+			t.Errorf("%s=%s: expected an error naming the variable, got %v", kv[0], kv[1], err)
+		}
+	}
+}
+
+func TestShouldPageFixedTerminal(t *testing.T) {
+	term, err := utils.TerminalFromEnv(func(k string) string {
+		return map[string]string{"GLOW_FORCE_TTY": "1", "GLOW_ROWS": "3"}[k]
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := utils.Term
+	utils.Term = term
+	t.Cleanup(func() { utils.Term = old })
+
+	if shouldPage(pagerAuto, "1\n2\n3\n") {
+		t.Error("paged output that fits the terminal")
+	}
+	if !shouldPage(pagerAuto, "1\n2\n3\n4\n") {
+		t.Error("didn't page output taller than the terminal")
+	}
+}
@@ -0,0 +1,64 @@
+package ui
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// How long after the last key press or mouse event a scheduled refresh waits,
+// so a document doesn't change while it's being read or acted on.
+const autoRefreshIdle = 5 * time.Second
+
+// autoRefreshMsg is sent when it's time to fetch a remote document again.
+type autoRefreshMsg struct {
+	gen uint64
+}
+
+// scheduleRefresh schedules fetching the document again after the
+// configured interval, if it's a remote one. Scheduled refreshes from
+// before are dropped.
+func (m *pagerModel) scheduleRefresh() tea.Cmd {
+	m.refreshGen++
+	interval := m.common.cfg.RefreshInterval
+	if interval <= 0 || m.currentDocument.remotePath == "" {
+		return nil
+	}
+	gen := m.refreshGen
+	return tea.Tick(interval, func(time.Time) tea.Msg {
+		return autoRefreshMsg{gen}
+	})
+}
+
+// autoRefresh fetches the document again, unless it's being interacted
+// with, in which case it waits some more.
+func (m *pagerModel) autoRefresh(msg autoRefreshMsg) tea.Cmd {
+	if msg.gen != m.refreshGen {
+		return nil
+	}
+	if m.refreshPaused() {
+		gen := m.refreshGen
+		return tea.Tick(autoRefreshIdle, func(time.Time) tea.Msg {
+			return autoRefreshMsg{gen}
+		})
+	}
+	return tea.Batch(loadLocalMarkdown(&m.currentDocument), m.scheduleRefresh())
+}
+
+// refreshPaused reports whether a scheduled refresh should wait: while
+// something is typed or about to be confirmed, a link preview, the table of
+// contents or link mode is open, the document is still streaming in, or there
+// was a key press or mouse event just now.
+func (m pagerModel) refreshPaused() bool {
+	return m.commanding || m.searching || m.marking || m.pendingRun != nil || m.preview != nil ||
+		m.toc != nil || m.links != nil || m.streaming || time.Since(m.lastInteraction) < autoRefreshIdle
+}
+
+// refreshedNote returns when the document was last refreshed, for the status
+// bar, if it's refreshed on a schedule.
+func (m pagerModel) refreshedNote() string {
+	if m.common.cfg.RefreshInterval <= 0 || m.currentDocument.remotePath == "" || m.refreshed.IsZero() {
+		return ""
+	}
+	return "refreshed " + m.refreshed.Format(time.Kitchen)
+}
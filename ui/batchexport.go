@@ -0,0 +1,153 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glow/v2/export"
+	"github.com/charmbracelet/glow/v2/utils"
+)
+
+// Directory filtered documents are exported to, unless another one is
+// given.
+const defaultExportDir = "glow-export"
+
+// exportBatch is an export of filtered documents in progress.
+type exportBatch struct {
+	dir    string
+	format string
+	mds    []*markdown
+	done   int
+	failed int
+}
+
+// exportedMsg reports that a document of the batch has been exported.
+type exportedMsg struct {
+	md  *markdown
+	err error
+}
+
+// startExportPrompt asks where to export the filtered documents to.
+func (m *stashModel) startExportPrompt() tea.Cmd {
+	m.hideStatusMessage()
+	m.exportPrompt = true
+	m.updateExportPrompt()
+	if m.exportInput.Value() == "" {
+		m.exportInput.SetValue(defaultExportDir)
+	}
+	m.exportInput.CursorEnd()
+	return tea.Batch(m.exportInput.Focus(), textinput.Blink)
+}
+
+func (m *stashModel) updateExportPrompt() {
+	m.exportInput.Prompt = fmt.Sprintf("Export %d as %s to:", len(m.filteredMarkdowns), export.Formats[m.exportFormat])
+}
+
+// handleExportPrompt handles keys while the export directory is entered.
+func (m *stashModel) handleExportPrompt(msg tea.Msg) tea.Cmd {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case keyEsc:
+			m.exportPrompt = false
+			m.exportInput.Blur()
+			return nil
+		case "tab":
+			m.exportFormat = (m.exportFormat + 1) % len(export.Formats)
+			m.updateExportPrompt()
+			return nil
+		case keyEnter:
+			m.exportPrompt = false
+			m.exportInput.Blur()
+			dir := m.exportInput.Value()
+			if dir == "" {
+				dir = defaultExportDir
+			}
+			m.exportBatch = &exportBatch{
+				dir:    utils.ExpandPath(dir),
+				format: export.Formats[m.exportFormat],
+				mds:    append([]*markdown(nil), m.filteredMarkdowns...),
+			}
+			return tea.Batch(m.spinner.Tick, exportNext(m.exportBatch))
+		}
+	}
+
+	var cmd tea.Cmd
+	m.exportInput, cmd = m.exportInput.Update(msg)
+	return cmd
+}
+
+// handleExported records the outcome of exporting a document and moves on to
+// the next one, or reports how the export went.
+func (m *stashModel) handleExported(msg exportedMsg) tea.Cmd {
+	b := m.exportBatch
+	if b == nil {
+		return nil
+	}
+	b.done++
+	if msg.err != nil {
+		b.failed++
+		m.common.notifications.add(fmt.Sprintf("Couldn't export %s: %v", msg.md.Note, msg.err), true)
+	}
+	if b.done < len(b.mds) {
+		return exportNext(b)
+	}
+
+	m.exportBatch = nil
+	if b.failed > 0 {
+		return m.newStatusMessage(statusMessage{
+			status:  errorStatusMessage,
+			message: fmt.Sprintf("Exported %d of %d documents to %s, %d failed", b.done-b.failed, b.done, b.dir, b.failed),
+		})
+	}
+	return m.newStatusMessage(statusMessage{
+		status:  normalStatusMessage,
+		message: fmt.Sprintf("Exported %d documents to %s", b.done, b.dir),
+	})
+}
+
+// exportNext exports the next document of a batch.
+func exportNext(b *exportBatch) tea.Cmd {
+	md := b.mds[b.done]
+	dir, format := b.dir, b.format
+	return func() tea.Msg {
+		return exportedMsg{md: md, err: exportMarkdown(md, dir, format)}
+	}
+}
+
+// exportMarkdown exports a document below dir, at the same relative path it
+// has in the stash.
+func exportMarkdown(md *markdown, dir, format string) error {
+	if md.remotePath != "" {
+		if err := fetchRemoteMarkdown(md); err != nil {
+			return err
+		}
+	}
+	b, err := os.ReadFile(md.localPath)
+	if err != nil {
+		return err
+	}
+	if md.remotePath == "" {
+		s, _, err := utils.ResolveIncludes(string(b), filepath.Dir(md.localPath))
+		if err != nil {
+			return err
+		}
+		b = []byte(s)
+	}
+
+	out := filepath.Join(dir, filepath.FromSlash(export.OutputPath(filepath.ToSlash(md.Note), format)))
+	if err := os.MkdirAll(filepath.Dir(out), 0o755); err != nil { //nolint:mnd
+		return err
+	}
+	f, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	if err := export.Document(f, b, filepath.Base(md.Note), format); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
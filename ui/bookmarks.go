@@ -0,0 +1,119 @@
+package ui
+
+import (
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Bookmarks are numbered positions in a document: m followed by a digit sets
+// one, the digit alone jumps to it. They're kept as source lines, so they
+// survive rendering at another width, and remembered with the document.
+
+const keyBookmark = "m"
+
+var bookmarkStyle = lipgloss.NewStyle().Foreground(fuchsia).Bold(true).Render
+
+// bookmarkNumber returns the number of a bookmark key, 1 to 9.
+func bookmarkNumber(key string) (int, bool) {
+	if len(key) != 1 || key[0] < '1' || key[0] > '9' {
+		return 0, false
+	}
+	return int(key[0] - '0'), true
+}
+
+// toggleBookmark sets a bookmark at the top of the viewport, or removes it if
+// it's already there.
+func (m *pagerModel) toggleBookmark(n int) tea.Cmd {
+	line := m.topSourceLine()
+	status := fmt.Sprintf("Bookmark %d set", n)
+	if m.bookmarks[n] == line {
+		delete(m.bookmarks, n)
+		status = fmt.Sprintf("Bookmark %d removed", n)
+	} else {
+		if m.bookmarks == nil {
+			m.bookmarks = make(map[int]int)
+		}
+		m.bookmarks[n] = line
+	}
+
+	path := m.currentDocument.localPath
+	state := m.common.docs.get(path)
+	state.Bookmarks = maps.Clone(m.bookmarks)
+	m.common.docs.set(path, state)
+
+	m.setContent(m.rendered)
+	cmds := []tea.Cmd{m.showStatusMessage(pagerStatusMessage{status, false})}
+	if m.viewport.HighPerformanceRendering {
+		cmds = append(cmds, viewport.Sync(m.viewport))
+	}
+	return tea.Batch(cmds...)
+}
+
+// gotoBookmark scrolls to a bookmark.
+func (m *pagerModel) gotoBookmark(n int) tea.Cmd {
+	line, ok := m.bookmarks[n]
+	if !ok {
+		return m.showStatusMessage(pagerStatusMessage{fmt.Sprintf("No bookmark %d, set one with %s%d", n, keyBookmark, n), false})
+	}
+	m.gotoSourceLine(line)
+	cmds := []tea.Cmd{m.scrollTables()}
+	if m.viewport.HighPerformanceRendering {
+		cmds = append(cmds, viewport.Sync(m.viewport))
+	}
+	return tea.Batch(cmds...)
+}
+
+// topSourceLine returns the source line at the top of the viewport, such that
+// gotoSourceLine scrolls back to it.
+func (m pagerModel) topSourceLine() int {
+	total := strings.Count(m.currentDocument.Body, "\n") + 1
+	lines := max(1, m.viewport.TotalLineCount())
+	return (m.viewport.YOffset*total+lines-1)/lines + 1
+}
+
+// markBookmarks puts the numbers of bookmarks into the left margin of the
+// rendered lines they point to.
+func (m pagerModel) markBookmarks(s string) string {
+	if len(m.bookmarks) == 0 {
+		return s
+	}
+	lines := strings.Split(s, "\n")
+	total := strings.Count(m.currentDocument.Body, "\n") + 1
+	for n, line := range m.bookmarks {
+		i := len(lines) * (min(line, total) - 1) / total
+		if i >= 0 && i < len(lines) {
+			lines[i] = replaceMargin(lines[i], bookmarkStyle(strconv.Itoa(n)))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// replaceMargin replaces the first character of a rendered line with a
+// marker, if it's a space. Escape sequences before it are kept, after the
+// marker, so the rest of the line is styled as before.
+func replaceMargin(line, marker string) string {
+	for i := 0; i < len(line); {
+		if line[i] != '\x1b' {
+			if line[i] != ' ' {
+				return line
+			}
+			return marker + line[:i] + line[i+1:]
+		}
+		// skip a CSI sequence: ESC [ parameters final
+		j := i + 1
+		if j < len(line) && line[j] == '[' {
+			j++
+			for j < len(line) && (line[j] < 0x40 || line[j] > 0x7e) {
+				j++
+			}
+		}
+		i = j + 1
+	}
+	return line
+}
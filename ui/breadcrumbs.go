@@ -0,0 +1,67 @@
+package ui
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/glow/v2/utils"
+)
+
+// contentMatch is where the text of a filter was found in a document by
+// content search: the line, and the headings it's under.
+type contentMatch struct {
+	line     int // 1-based
+	headings []string
+}
+
+// breadcrumb returns the headings a match is under, outermost first, such as
+// "Install > From source".
+func (c contentMatch) breadcrumb() string {
+	return strings.Join(c.headings, " > ")
+}
+
+// findContentMatch returns the line of a document that contains the most
+// words of a query, the first one if several do, along with the headings
+// it's under. It reports false if no word of the query is in the document.
+func findContentMatch(body, query string) (contentMatch, bool) {
+	words := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var match contentMatch
+	best := 0
+	for i, l := range strings.Split(strings.ToLower(body), "\n") {
+		n := 0
+		for _, w := range words {
+			if strings.Contains(l, w) {
+				n++
+			}
+		}
+		if n > best {
+			best, match.line = n, i+1
+		}
+		if best == len(words) {
+			break
+		}
+	}
+	if best == 0 {
+		return contentMatch{}, false
+	}
+
+	// the headings above the line, each one closing the ones at its level
+	// and below
+	var stack []utils.Heading
+	for _, h := range utils.Headings([]byte(body)) {
+		if h.Line > match.line {
+			break
+		}
+		for len(stack) > 0 && stack[len(stack)-1].Level >= h.Level {
+			stack = stack[:len(stack)-1]
+		}
+		stack = append(stack, h)
+	}
+	for _, h := range stack {
+		match.headings = append(match.headings, h.Text)
+	}
+	return match, true
+}
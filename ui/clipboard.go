@@ -0,0 +1,105 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/muesli/reflow/truncate"
+	"github.com/muesli/termenv"
+)
+
+// How many copied items are kept, one for each number key.
+const maxClipboardItems = 9
+
+// clipboardRing keeps what has been copied during the session, newest first,
+// so an earlier copy isn't lost when grabbing another snippet.
+type clipboardRing struct {
+	items []string
+}
+
+// add puts text at the front, moving it there if it's been copied before.
+func (r *clipboardRing) add(s string) {
+	r.items = slices.DeleteFunc(r.items, func(v string) bool { return v == s })
+	r.items = append([]string{s}, r.items...)
+	if len(r.items) > maxClipboardItems {
+		r.items = r.items[:maxClipboardItems]
+	}
+}
+
+// view lists the copied items, a line each.
+func (r clipboardRing) view(width int) string {
+	if len(r.items) == 0 {
+		return subtleStyle.Render("Nothing copied yet")
+	}
+	lines := make([]string, 0, len(r.items)+2) //nolint:mnd
+	for i, s := range r.items {
+		first, rest, _ := strings.Cut(strings.TrimSpace(s), "\n")
+		var more string
+		if rest != "" {
+			more = fmt.Sprintf(" (%d lines)", strings.Count(rest, "\n")+2) //nolint:mnd
+		}
+		first = truncate.StringWithTail(first, uint(max(0, width-3-len(more))), ellipsis) //nolint:mnd
+		lines = append(lines, fmt.Sprintf("%s  %s%s", fuchsiaFg(fmt.Sprint(i+1)), first, subtleStyle.Render(more)))
+	}
+	lines = append(lines, "", subtleStyle.Render("1-9 copy again • C/esc close"))
+	return strings.Join(lines, "\n")
+}
+
+// updateClipboard handles keys while the copied items are shown: a number
+// copies that item again and esc closes the list. Other keys aren't handled.
+func (m *pagerModel) updateClipboard(msg tea.KeyMsg) (tea.Cmd, bool) {
+	if msg.String() == keyEsc {
+		m.showClipboard = false
+		m.setSize(m.screenWidth, m.screenHeight)
+		return nil, true
+	}
+	n, ok := bookmarkNumber(msg.String())
+	if !ok {
+		return nil, false
+	}
+	if n > len(m.common.clipboard.items) {
+		return nil, true
+	}
+	s := m.common.clipboard.items[n-1]
+	m.copyText(s)
+	m.showClipboard = false
+	m.setSize(m.screenWidth, m.screenHeight)
+	return m.showStatusMessage(pagerStatusMessage{fmt.Sprintf("Copied item %d again", n), false}), true
+}
+
+// copyText copies text to the clipboard, both with OSC 52 and the system
+// clipboard, and keeps it in the ring. The system clipboard gets the line
+// endings of the platform.
+func (m *pagerModel) copyText(s string) {
+	termenv.Copy(s)
+	_ = clipboard.WriteAll(utils.NativeLineEndings(s))
+	m.common.clipboard.add(s)
+}
+
+// codeBlockAt returns the contents of the fenced code block at a source
+// line, or else the first one after it.
+func codeBlockAt(body string, line int) (string, bool) {
+	var (
+		fence utils.CodeFence
+		code  []string
+	)
+	for i, l := range strings.Split(body, "\n") {
+		wasOpen := fence.Open()
+		fence.Scan(l)
+		switch {
+		case !wasOpen && fence.Open():
+			code = nil
+		case wasOpen && !fence.Open():
+			if i+1 >= line {
+				return strings.Join(code, "\n"), true
+			}
+		case wasOpen:
+			code = append(code, l)
+		}
+	}
+	return "", false
+}
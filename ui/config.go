@@ -1,5 +1,11 @@
 package ui
 
+import (
+	"time"
+
+	"github.com/charmbracelet/glow/v2/utils"
+)
+
 // Config contains TUI-specific configuration.
 type Config struct {
 	ShowAllFiles     bool
@@ -10,10 +16,85 @@ type Config struct {
 	GlamourStyle     string `env:"GLAMOUR_STYLE"`
 	EnableMouse      bool
 	PreserveNewLines bool
+	KeyProfile       string
+	FilterMatcher    string
+	Sort             string // field the stash is sorted by until another is chosen
+	MaxCodeLines     int
+	Decorations      utils.Decorations
+
+	// Whether to guess the language of code blocks without one.
+	GuessCodeLanguage bool
+
+	// Charset mermaid diagrams are drawn in, or "" to show their source.
+	Mermaid string
+
+	// Whether to show placeholders for videos, iframes and other embeds, and
+	// notes on large images, rather than leaving them out silently.
+	EmbedWarnings bool
+
+	// Columns tabs in code blocks are expanded to, or 0 to go by the
+	// .editorconfig files of each document.
+	TabWidth int
+
+	// Commands code blocks can be run with, by language. Code blocks can't
+	// be run unless their language has one.
+	CodeRunners map[string]string
+
+	// Limits of the commands code blocks are run with: how long they may
+	// take, how much of their output is kept and which environment
+	// variables they see.
+	Commands utils.CommandPolicy
+
+	// How long status messages are shown, or 0 for the default.
+	StatusMessageDuration time.Duration
+
+	// How often remote documents are fetched again while they're open, or 0
+	// not to.
+	RefreshInterval time.Duration
+
+	// Whether documents are centered on screens wider than their word-wrap
+	// width.
+	Centered bool
+
+	// Whether the open document is rendered again when its file changes.
+	Watch bool
+
+	// Whether up and down scroll by block, such as a paragraph or code
+	// block, rather than by line.
+	SnapScroll bool
+
+	// Whether to ask before quitting while a document is still streaming in.
+	ConfirmQuitWhileStreaming bool
+
+	// How to tell that a document that took a while to stream in has been
+	// rendered: one of Notifies. Empty means off.
+	Notify string
+
+	// Whether to show the author and age of the last commit of git-tracked
+	// documents.
+	GitMetadata bool
+
+	// Layout of absolute dates, as for time.Format, and the IANA time zone
+	// they're shown in. An empty time zone means the local one.
+	DateFormat string
+	Timezone   string
+
+	// Name of the config profile in use, shown next to the logo, if any.
+	Profile string
+
+	// Called with the value and stack of a panic anywhere in the TUI,
+	// instead of Bubble Tea's own panic handling. It's expected not to
+	// return.
+	OnPanic func(r any, stack []byte)
 
 	// Which directory should we start from?
 	WorkingDirectory string
 
+	// Remote stash root, e.g. sftp://host/docs or s3://bucket/docs, or the
+	// path of a documentation bundle. When set it's listed instead of the
+	// working directory.
+	Remote string
+
 	// For debugging the UI
 	HighPerformancePager bool `env:"GLOW_HIGH_PERFORMANCE_PAGER" envDefault:"true"`
 	GlamourEnabled       bool `env:"GLOW_ENABLE_GLAMOUR"         envDefault:"true"`
@@ -0,0 +1,32 @@
+package ui
+
+import (
+	"runtime/debug"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// guard wraps a command so that a panic while it runs reaches the configured
+// panic handler. Commands run in goroutines of their own, where a panic
+// would otherwise take down glow with the terminal still in raw mode.
+// Batched commands are guarded in turn.
+func guard(cmd tea.Cmd) tea.Cmd {
+	if cmd == nil || config.OnPanic == nil {
+		return cmd
+	}
+	return func() tea.Msg {
+		defer func() {
+			if r := recover(); r != nil {
+				config.OnPanic(r, debug.Stack())
+			}
+		}()
+
+		msg := cmd()
+		if batch, ok := msg.(tea.BatchMsg); ok {
+			for i, c := range batch {
+				batch[i] = guard(c)
+			}
+		}
+		return msg
+	}
+}
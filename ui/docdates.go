@@ -0,0 +1,70 @@
+package ui
+
+import (
+	"time"
+
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+	"github.com/dustin/go-humanize"
+)
+
+// Layout of absolute dates in the document footer, unless configured
+// otherwise.
+const defaultDateFormat = "02 Jan 2006"
+
+var dateFooterStyle = lipgloss.NewStyle().
+	Foreground(statusBarNoteFg).
+	Italic(true).
+	Render
+
+// docDates holds the dates a document's front matter gives.
+type docDates struct {
+	updated time.Time
+	written time.Time
+}
+
+func newDocDates(body string) docDates {
+	updated, written := utils.FrontmatterDates([]byte(body), dateLocation())
+	return docDates{updated: updated, written: written}
+}
+
+func (d docDates) empty() bool {
+	return d.updated.IsZero() && d.written.IsZero()
+}
+
+// footer describes the most recent date, relative to now or as an absolute
+// date in the configured format and time zone.
+func (d docDates) footer(absolute bool) string {
+	t, label := d.updated, "Last updated"
+	if t.IsZero() {
+		t, label = d.written, "Written"
+	}
+	if t.IsZero() {
+		return ""
+	}
+
+	when := humanize.Time(t)
+	if absolute {
+		layout := config.DateFormat
+		if layout == "" {
+			layout = defaultDateFormat
+		}
+		when = "on " + t.In(dateLocation()).Format(layout)
+	}
+	return "  " + dateFooterStyle(label+" "+when) + "\n"
+}
+
+// dateLocation returns the configured time zone for dates, or the local
+// one.
+func dateLocation() *time.Location {
+	if config.Timezone == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(config.Timezone)
+	if err != nil {
+		log.Error("invalid timezone", "timezone", config.Timezone, "error", err)
+		return time.Local
+	}
+	return loc
+}
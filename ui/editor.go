@@ -1,19 +1,104 @@
 package ui
 
 import (
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/x/editor"
 )
 
-type editorFinishedMsg struct{ err error }
+// editorFinishedMsg is sent when the editor exits: whether the file of the
+// document changed while it was being edited, and whether another program
+// had already changed it since the document was loaded.
+type editorFinishedMsg struct {
+	err     error
+	path    string
+	changed bool
+	stale   bool
+}
 
-func openEditor(path string, lineno int) tea.Cmd {
-	cb := func(err error) tea.Msg {
-		return editorFinishedMsg{err}
-	}
-	cmd, err := editor.Cmd("Glow", path, editor.OpenAtLine(uint(lineno)))
+// editMarkdown opens a document in the editor. Remote documents are edited
+// as a local copy which is uploaded again when the editor exits.
+func editMarkdown(md *markdown, lineno int) tea.Cmd {
+	cmd, err := editor.Cmd("Glow", md.localPath, editor.OpenAtLine(uint(lineno)))
 	if err != nil {
-		return func() tea.Msg { return cb(err) }
+		return func() tea.Msg { return editorFinishedMsg{err: err, path: md.localPath} }
+	}
+	c := &editCmd{Cmd: cmd, md: *md}
+	return tea.Exec(c, func(err error) tea.Msg {
+		return editorFinishedMsg{err: err, path: md.localPath, changed: c.changed, stale: c.stale}
+	})
+}
+
+// editCmd runs the editor on a document, and tells whether its file changed.
+// A remote document is fetched first and its local copy uploaded afterwards.
+type editCmd struct {
+	*exec.Cmd
+	md markdown
+
+	changed bool
+	stale   bool
+}
+
+func (c *editCmd) Run() error {
+	md := &c.md
+	if md.remotePath != "" {
+		if err := fetchRemoteMarkdown(md); err != nil {
+			return err
+		}
+	}
+
+	before, err := os.Stat(md.localPath)
+	if err == nil && md.remotePath == "" && !md.Modtime.IsZero() {
+		c.stale = !before.ModTime().Equal(md.Modtime) || before.Size() != md.Size
+	}
+	err = c.Cmd.Run()
+	after, statErr := os.Stat(md.localPath)
+	c.changed = statErr != nil || before == nil ||
+		!after.ModTime().Equal(before.ModTime()) || after.Size() != before.Size()
+	if err != nil || md.remotePath == "" || !c.changed && !unsynced.has(md.localPath) {
+		return err
+	}
+
+	// changes that couldn't be uploaded are kept in the local copy, and
+	// uploaded along with the next edit
+	if err := uploadRemoteMarkdown(md); err != nil {
+		unsynced.set(md.localPath, true)
+		return err
+	}
+	unsynced.set(md.localPath, false)
+	return nil
+}
+
+func (c *editCmd) SetStdin(r io.Reader)  { c.Stdin = r }
+func (c *editCmd) SetStdout(w io.Writer) { c.Stdout = w }
+func (c *editCmd) SetStderr(w io.Writer) { c.Stderr = w }
+
+// unsynced holds the local copies of remote documents with edits that
+// couldn't be uploaded, which aren't to be replaced by fetching the document
+// again.
+var unsynced = &pathSet{paths: make(map[string]bool)}
+
+type pathSet struct {
+	mu    sync.Mutex
+	paths map[string]bool
+}
+
+func (s *pathSet) has(path string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.paths[path]
+}
+
+func (s *pathSet) set(path string, in bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if in {
+		s.paths[path] = true
+	} else {
+		delete(s.paths, path)
 	}
-	return tea.ExecProcess(cmd, cb)
 }
@@ -0,0 +1,182 @@
+package ui
+
+import (
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+)
+
+// filterQuery is a parsed stash filter. Besides the text that's fuzzy
+// matched against document names, a filter may hold operators that narrow
+// down the documents first:
+//
+//	ext:md          extension, several can be given as ext:md,txt
+//	dir:docs/       documents in a directory or below it
+//	mtime:<7d       modified less than 7 days ago; > for longer ago
+//	size:>100k      bigger than 100 kilobytes; < for smaller
+//	is:unread       documents that haven't been read yet; is:read for others
+//
+// Operators with a value that can't be parsed, as happens while they're
+// being typed, are ignored.
+type filterQuery struct {
+	text  string
+	conds []func(*markdown) bool
+}
+
+// parseFilterQuery splits a filter into operators and free text. Documents
+// that have been read are given by path.
+func parseFilterQuery(s string, read map[string]bool) filterQuery {
+	var (
+		q    filterQuery
+		text []string
+	)
+	for _, f := range strings.Fields(s) {
+		key, val, ok := strings.Cut(f, ":")
+		if !ok {
+			text = append(text, f)
+			continue
+		}
+
+		var cond func(*markdown) bool
+		switch strings.ToLower(key) {
+		case "ext":
+			cond = extCond(val)
+		case "dir":
+			cond = dirCond(val)
+		case "mtime":
+			cond = mtimeCond(val)
+		case "size":
+			cond = sizeCond(val)
+		case "is":
+			cond = isCond(val, read)
+		default:
+			text = append(text, f)
+			continue
+		}
+		if cond != nil {
+			q.conds = append(q.conds, cond)
+		}
+	}
+	q.text = strings.Join(text, " ")
+	return q
+}
+
+// match reports whether a document satisfies all operators.
+func (q filterQuery) match(md *markdown) bool {
+	for _, c := range q.conds {
+		if !c(md) {
+			return false
+		}
+	}
+	return true
+}
+
+func extCond(val string) func(*markdown) bool {
+	var exts []string
+	for _, e := range strings.Split(val, ",") {
+		if e = strings.TrimPrefix(strings.ToLower(e), "."); e != "" {
+			exts = append(exts, "."+e)
+		}
+	}
+	if len(exts) == 0 {
+		return nil
+	}
+	return func(md *markdown) bool {
+		ext := strings.ToLower(path.Ext(md.Note))
+		for _, e := range exts {
+			if ext == e {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func dirCond(val string) func(*markdown) bool {
+	dir := strings.Trim(filepath.ToSlash(val), "/")
+	if dir == "" {
+		return nil
+	}
+	return func(md *markdown) bool {
+		d := path.Dir(filepath.ToSlash(md.Note))
+		return d == dir || strings.HasPrefix(d, dir+"/")
+	}
+}
+
+func mtimeCond(val string) func(*markdown) bool {
+	less, rest, ok := cutComparison(val)
+	if !ok {
+		return nil
+	}
+	d, err := parseAge(rest)
+	if err != nil {
+		return nil
+	}
+	return func(md *markdown) bool {
+		age := time.Since(md.Modtime)
+		if less {
+			return age < d
+		}
+		return age > d
+	}
+}
+
+func sizeCond(val string) func(*markdown) bool {
+	less, rest, ok := cutComparison(val)
+	if !ok {
+		return nil
+	}
+	n, err := humanize.ParseBytes(rest)
+	if err != nil {
+		return nil
+	}
+	return func(md *markdown) bool {
+		if less {
+			return uint64(md.Size) < n //nolint:gosec
+		}
+		return uint64(md.Size) > n //nolint:gosec
+	}
+}
+
+func isCond(val string, read map[string]bool) func(*markdown) bool {
+	switch strings.ToLower(val) {
+	case "read":
+		return func(md *markdown) bool { return read[md.localPath] }
+	case "unread":
+		return func(md *markdown) bool { return !read[md.localPath] }
+	default:
+		return nil
+	}
+}
+
+// cutComparison splits a leading < or > off an operator value.
+func cutComparison(val string) (less bool, rest string, ok bool) {
+	if rest, ok := strings.CutPrefix(val, "<"); ok && rest != "" {
+		return true, rest, true
+	}
+	if rest, ok := strings.CutPrefix(val, ">"); ok && rest != "" {
+		return false, rest, true
+	}
+	return false, "", false
+}
+
+// parseAge parses durations like 7d or 2w on top of what time.ParseDuration
+// understands.
+func parseAge(s string) (time.Duration, error) {
+	units := map[byte]time.Duration{
+		'd': 24 * time.Hour,     //nolint:mnd
+		'w': 7 * 24 * time.Hour, //nolint:mnd
+	}
+	if u, ok := units[s[len(s)-1]]; ok {
+		n, err := strconv.ParseFloat(s[:len(s)-1], 64)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(n * float64(u)), nil
+	}
+	return time.ParseDuration(s)
+}
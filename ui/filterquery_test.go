@@ -0,0 +1,76 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseFilterQuery(t *testing.T) {
+	now := time.Now()
+	docs := map[string]*markdown{
+		"readme":  {localPath: "/r/README.md", Note: "README.md", Modtime: now.Add(-time.Hour), Size: 2_000},
+		"guide":   {localPath: "/r/docs/guide.MD", Note: "docs/guide.MD", Modtime: now.Add(-10 * 24 * time.Hour), Size: 200_000},
+		"deep":    {localPath: "/r/docs/api/ref.txt", Note: "docs/api/ref.txt", Modtime: now.Add(-3 * 24 * time.Hour), Size: 50_000},
+		"docsish": {localPath: "/r/docs2/x.md", Note: "docs2/x.md", Modtime: now.Add(-30 * 24 * time.Hour), Size: 10},
+	}
+	read := map[string]bool{"/r/README.md": true}
+
+	for _, tc := range []struct {
+		filter string
+		text   string
+		match  []string
+	}{
+		{"readme", "readme", []string{"deep", "docsish", "guide", "readme"}},
+		{"ext:md", "", []string{"docsish", "guide", "readme"}},
+		{"EXT:.txt,md", "", []string{"deep", "docsish", "guide", "readme"}},
+		{"dir:docs/", "", []string{"deep", "guide"}},
+		{"dir:docs/api", "", []string{"deep"}},
+		{"mtime:<7d", "", []string{"deep", "readme"}},
+		{"mtime:>1w", "", []string{"docsish", "guide"}},
+		{"mtime:<2h", "", []string{"readme"}},
+		{"size:>100k", "", []string{"guide"}},
+		{"size:<1k", "", []string{"docsish"}},
+		{"is:read", "", []string{"readme"}},
+		{"is:unread", "", []string{"deep", "docsish", "guide"}},
+		{"dir:docs ext:md guide", "guide", []string{"guide"}},
+		// unknown operators are text, unparseable values are ignored
+		{"foo:bar baz", "foo:bar baz", []string{"deep", "docsish", "guide", "readme"}},
+		{"mtime:< size:>lots ext: dir:/ is:maybe", "", []string{"deep", "docsish", "guide", "readme"}},
+		{"mtime:7d size:100k", "", []string{"deep", "docsish", "guide", "readme"}},
+	} {
+		q := parseFilterQuery(tc.filter, read)
+		if q.text != tc.text {
+			t.Errorf("%q: got text %q, want %q", tc.filter, q.text, tc.text)
+		}
+		var got []string
+		for _, name := range []string{"deep", "docsish", "guide", "readme"} {
+			if q.match(docs[name]) {
+				got = append(got, name)
+			}
+		}
+		if strings.Join(got, ",") != strings.Join(tc.match, ",") {
+			t.Errorf("%q: got matches %q, want %q", tc.filter, got, tc.match)
+		}
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want time.Duration
+		err  bool
+	}{
+		{"7d", 7 * 24 * time.Hour, false},
+		{"2w", 14 * 24 * time.Hour, false},
+		{"1.5d", 36 * time.Hour, false},
+		{"90m", 90 * time.Minute, false},
+		{"xd", 0, true},
+		{"soon", 0, true},
+	} {
+		got, err := parseAge(tc.in)
+		if (err != nil) != tc.err || got != tc.want {
+			t.Errorf("parseAge(%q) = %v, %v, want %v (error: %v)", tc.in, got, err, tc.want, tc.err)
+		}
+	}
+}
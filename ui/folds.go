@@ -0,0 +1,159 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glow/v2/utils"
+)
+
+// Code blocks can be folded in the pager, one at a time, leaving a line that
+// says what was folded. Folds are kept by the index of the block, until the
+// document is closed.
+
+const keyFold = "Z"
+
+// codeBlock is a fenced code block in a markdown document.
+type codeBlock struct {
+	lang string
+	code []string // lines between the fences
+}
+
+// findCodeBlocks returns the fenced code blocks of a markdown document.
+func findCodeBlocks(md string) []codeBlock {
+	var (
+		blocks []codeBlock
+		fence  utils.CodeFence
+	)
+	for _, l := range strings.Split(md, "\n") {
+		wasOpen := fence.Open()
+		fence.Scan(l)
+		switch {
+		case !wasOpen && fence.Open():
+			info := strings.TrimLeft(strings.TrimSpace(l), "`~")
+			lang, _, _ := strings.Cut(strings.TrimSpace(info), " ")
+			blocks = append(blocks, codeBlock{lang: lang})
+		case wasOpen && fence.Open():
+			blocks[len(blocks)-1].code = append(blocks[len(blocks)-1].code, l)
+		}
+	}
+	return blocks
+}
+
+func codeFoldMarker(i int) string {
+	return fmt.Sprintf("Code block %d folded", i+1)
+}
+
+// foldCodeBlocks replaces folded code blocks with a line naming their
+// language and how many lines they have.
+func foldCodeBlocks(md string, folded map[int]bool, guessLang bool) string {
+	if len(folded) == 0 {
+		return md
+	}
+	if guessLang {
+		md = utils.GuessCodeLanguages(md)
+	}
+	blocks := findCodeBlocks(md)
+
+	var (
+		out   []string
+		fence utils.CodeFence
+		n     = -1
+	)
+	for _, l := range strings.Split(md, "\n") {
+		wasOpen := fence.Open()
+		fence.Scan(l)
+		if !wasOpen && fence.Open() {
+			n++
+		}
+		if !folded[n] || !wasOpen && !fence.Open() {
+			out = append(out, l)
+			continue
+		}
+		if wasOpen && !fence.Open() {
+			// the closing fence: put the summary in place of the block
+			b := blocks[n]
+			lang := b.lang
+			if lang == "" {
+				lang = "code"
+			}
+			out = append(out, "", fmt.Sprintf("*▸ %s · %s, %d lines, press %s to unfold*", codeFoldMarker(n), lang, len(b.code), keyFold), "")
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+// codeBlockInView returns the index of the first code block that's at least
+// partly in the viewport, folded or not.
+func (m pagerModel) codeBlockInView() (int, bool) {
+	top, bottom := m.viewport.YOffset, m.viewport.YOffset+m.viewport.Height
+	for _, c := range m.renderedCodeBlocks(strings.Split(m.rendered, "\n"), bottom) {
+		if c.start+c.height > top {
+			return c.block, true
+		}
+	}
+	return 0, false
+}
+
+// renderedCodeBlock is where a code block ended up in the rendered document.
+type renderedCodeBlock struct {
+	block  int // index, as returned by findCodeBlocks
+	start  int // rendered line of the fold, or the first line of code
+	height int
+}
+
+// renderedCodeBlocks finds the code blocks in the rendered lines that start
+// before the given one.
+func (m pagerModel) renderedCodeBlocks(lines []string, until int) []renderedCodeBlock {
+	var (
+		blocks []renderedCodeBlock
+		from   int
+	)
+	for i, b := range findCodeBlocks(m.currentDocument.Body) {
+		// look for the fold, or the first line of code, which isn't wrapped
+		text, first := codeFoldMarker(i), 0
+		height := 1
+		if !m.folded[i] {
+			for first < len(b.code) && strings.TrimSpace(b.code[first]) == "" {
+				first++
+			}
+			if first == len(b.code) {
+				continue
+			}
+			text, _, _ = strings.Cut(strings.TrimSpace(b.code[first]), "\t")
+			height = len(b.code) - first
+			if limit := m.common.cfg.MaxCodeLines; limit > 0 && !m.expandCode {
+				height = min(height, limit)
+			}
+		}
+
+		start := renderedLineFrom(lines, text, from)
+		if start < 0 {
+			continue
+		}
+		if start >= until {
+			break
+		}
+		blocks = append(blocks, renderedCodeBlock{i, start, height})
+		from = start + 1
+	}
+	return blocks
+}
+
+// toggleFold folds the code block in view, or unfolds it.
+func (m *pagerModel) toggleFold() tea.Cmd {
+	i, ok := m.codeBlockInView()
+	if !ok {
+		return m.showStatusMessage(pagerStatusMessage{"No code block in view", false})
+	}
+	if m.folded[i] {
+		delete(m.folded, i)
+	} else {
+		if m.folded == nil {
+			m.folded = make(map[int]bool)
+		}
+		m.folded[i] = true
+	}
+	return renderWithGlamour(*m, m.currentDocument.Body)
+}
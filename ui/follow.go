@@ -0,0 +1,382 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/muesli/reflow/truncate"
+)
+
+const (
+	keyLinkMode = "o"
+	keyBack     = "<"
+	keyForward  = ">"
+
+	// How many links link mode lists at once.
+	linkMenuHeight = 10
+)
+
+var errNoBrowser = errors.New("no browser to open links with")
+
+// linkMenu is link mode: the links of the open document, numbered, where
+// they were found in the rendered document, the selected one, and the number
+// typed so far to select one.
+type linkMenu struct {
+	links  []docLink
+	locs   []searchMatch // line is -1 for links that weren't found
+	cursor int
+	offset int // of the first link listed
+	number string
+}
+
+// linkVisit is a document left by following a link, and the source line it
+// was left at.
+type linkVisit struct {
+	doc  markdown
+	line int
+}
+
+// openLinkedMsg asks for a document to be shown in the pager in place of the
+// current one, keeping the history of followed links.
+type openLinkedMsg struct {
+	md *markdown
+}
+
+// linkOpenedMsg is sent once a web link has been handed to the browser, or
+// couldn't be.
+type linkOpenedMsg struct {
+	url string
+	err error
+}
+
+// toggleLinkMode enters link mode, with the first link on screen selected,
+// or leaves it.
+func (m *pagerModel) toggleLinkMode() tea.Cmd {
+	if m.links != nil {
+		m.closeLinkMode()
+		return m.syncViewport()
+	}
+	links := findLinks(m.currentDocument.Body)
+	if len(links) == 0 {
+		return m.showStatusMessage(pagerStatusMessage{"No links in this document", false})
+	}
+
+	menu := &linkMenu{links: links}
+	for i, loc := range linkLocations(strings.Split(m.rendered, "\n"), links) {
+		if loc.line >= m.viewport.YOffset {
+			menu.cursor = i
+			break
+		}
+	}
+	menu.scroll()
+	m.links = menu
+	m.preview, m.toc = nil, nil
+	m.showClipboard, m.showNotifications = false, false
+	m.setSize(m.screenWidth, m.screenHeight)
+	m.setContent(m.rendered)
+	m.showSelectedLink()
+	return m.syncViewport()
+}
+
+// closeLinkMode leaves link mode, if in it, keeping the link that was
+// selected for tab and shift+tab to go on from.
+func (m *pagerModel) closeLinkMode() {
+	if m.links == nil {
+		return
+	}
+	m.linkIndex = m.links.cursor
+	m.links = nil
+	m.setSize(m.screenWidth, m.screenHeight)
+	m.setContent(m.rendered)
+}
+
+// updateLinkMode handles keys in link mode: up and down, or the number of a
+// link, select one, enter follows it, y copies it, and esc leaves link mode.
+// Other keys aren't handled.
+func (m *pagerModel) updateLinkMode(msg tea.KeyMsg) (tea.Cmd, bool) {
+	menu := m.links
+	k := msg.String()
+	if !isDigit(k) {
+		menu.number = ""
+	}
+	switch {
+	case k == "up" || k == "k" || k == keyPrevLink:
+		menu.cursor = max(0, menu.cursor-1)
+	case k == "down" || k == "j" || k == keyNextLink:
+		menu.cursor = min(len(menu.links)-1, menu.cursor+1)
+	case k == "home" || k == "g":
+		menu.cursor = 0
+	case k == "end" || k == "G":
+		menu.cursor = len(menu.links) - 1
+	case isDigit(k):
+		// numbers are typed a digit at a time, starting over when there's
+		// no link with the number typed so far
+		n, _ := strconv.Atoi(menu.number + k)
+		if n < 1 || n > len(menu.links) {
+			n, _ = strconv.Atoi(k)
+			menu.number = ""
+		}
+		if n < 1 || n > len(menu.links) {
+			return nil, true
+		}
+		menu.number += k
+		menu.cursor = n - 1
+	case k == "y":
+		dest := menu.links[menu.cursor].dest
+		m.copyText(dest)
+		return m.showStatusMessage(pagerStatusMessage{"Copied " + dest, false}), true
+	case k == keyEsc || k == keyLinkMode:
+		m.closeLinkMode()
+		return m.syncViewport(), true
+	case k == keyEnter:
+		l := menu.links[menu.cursor]
+		m.closeLinkMode()
+		return tea.Batch(m.followLink(l), m.syncViewport()), true
+	default:
+		return nil, false
+	}
+	menu.scroll()
+	m.setContent(m.rendered)
+	m.showSelectedLink()
+	return m.syncViewport(), true
+}
+
+func isDigit(k string) bool {
+	return len(k) == 1 && k[0] >= '0' && k[0] <= '9'
+}
+
+// showSelectedLink scrolls to the selected link if it's off screen.
+func (m *pagerModel) showSelectedLink() {
+	loc := m.links.locs[m.links.cursor]
+	if loc.line >= 0 && (loc.line < m.viewport.YOffset || loc.line >= m.viewport.YOffset+m.viewport.Height) {
+		m.viewport.SetYOffset(max(0, loc.line-m.viewport.Height/2))
+	}
+}
+
+// highlightLinks finds the links of link mode in the rendered document and
+// highlights them, the selected one standing out.
+func (m *pagerModel) highlightLinks(s string) string {
+	if m.links == nil {
+		return s
+	}
+	lines := strings.Split(s, "\n")
+	m.links.locs = linkLocations(lines, m.links.links)
+
+	byLine := make(map[int][]searchMatch)
+	current := make(map[int]int)
+	for i, loc := range m.links.locs {
+		if loc.line < 0 {
+			continue
+		}
+		if i == m.links.cursor {
+			current[loc.line] = len(byLine[loc.line])
+		} else if _, ok := current[loc.line]; !ok {
+			current[loc.line] = -1
+		}
+		byLine[loc.line] = append(byLine[loc.line], loc)
+	}
+	for l, locs := range byLine {
+		lines[l] = highlightLine(lines[l], locs, current[l])
+	}
+	return strings.Join(lines, "\n")
+}
+
+// linkLocations finds the text of links in rendered lines, in order, each
+// after the one before. Links whose text was wrapped onto another line are
+// found by their first word.
+func linkLocations(lines []string, links []docLink) []searchMatch {
+	text := make([]string, len(lines))
+	for i, l := range lines {
+		text[i] = ansi.Strip(l)
+	}
+
+	locs := make([]searchMatch, len(links))
+	line, pos := 0, 0
+	for i, l := range links {
+		locs[i] = searchMatch{line: -1}
+		want := strings.TrimSpace(l.text)
+		for _, w := range []string{want, strings.SplitN(want, " ", 2)[0]} { //nolint:mnd
+			if loc, ok := findFrom(text, w, line, pos); ok {
+				locs[i] = loc
+				line, pos = loc.line, loc.end
+				break
+			}
+		}
+	}
+	return locs
+}
+
+// findFrom finds text in lines, from a position in one of them on.
+func findFrom(lines []string, s string, line, pos int) (searchMatch, bool) {
+	if s == "" {
+		return searchMatch{}, false
+	}
+	for i := line; i < len(lines); i++ {
+		from := 0
+		if i == line {
+			from = min(pos, len(lines[i]))
+		}
+		if j := strings.Index(lines[i][from:], s); j >= 0 {
+			return searchMatch{i, from + j, from + j + len(s)}, true
+		}
+	}
+	return searchMatch{}, false
+}
+
+// followLink follows a link: web links are opened in the browser, links to
+// other local documents open them in the pager, and links to headings scroll
+// to them. Other links are copied.
+func (m *pagerModel) followLink(l docLink) tea.Cmd {
+	u, err := url.Parse(l.dest)
+	if err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		return openURL(l.dest)
+	}
+	if err == nil && u.Scheme == "" && u.Host == "" && u.Path == "" && u.Fragment != "" {
+		m.currentDocument.anchor = u.Fragment
+		m.scrollToTarget()
+		return nil
+	}
+
+	path, ok := m.localLinkTarget(l.dest)
+	if !ok {
+		m.copyText(l.dest)
+		return m.showStatusMessage(pagerStatusMessage{"Copied " + l.dest, false})
+	}
+	if _, err := os.Stat(path); err != nil {
+		return m.showStatusMessage(pagerStatusMessage{"Couldn't open " + l.dest + ": " + err.Error(), true})
+	}
+	m.history = append(m.history, m.visit())
+	m.future = nil
+	return openLinked(&markdown{
+		localPath: path,
+		Note:      stripAbsolutePath(path, m.common.cwd),
+		anchor:    u.Fragment,
+	})
+}
+
+// travel goes back to the document a link was followed from, or forward to
+// one gone back from.
+func (m *pagerModel) travel(back bool) tea.Cmd {
+	from, to := &m.future, &m.history
+	if back {
+		from, to = &m.history, &m.future
+	}
+	if len(*from) == 0 {
+		if back {
+			return m.showStatusMessage(pagerStatusMessage{"No document to go back to", false})
+		}
+		return m.showStatusMessage(pagerStatusMessage{"No document to go forward to", false})
+	}
+	v := (*from)[len(*from)-1]
+	*from = (*from)[:len(*from)-1]
+	*to = append(*to, m.visit())
+
+	md := v.doc
+	md.line, md.anchor, md.breadcrumb = max(1, v.line), "", ""
+	return openLinked(&md)
+}
+
+// visit returns the current document and where it's been read to, to come
+// back to.
+func (m pagerModel) visit() linkVisit {
+	return linkVisit{doc: m.currentDocument, line: m.sourceLine()}
+}
+
+func openLinked(md *markdown) tea.Cmd {
+	return func() tea.Msg {
+		return openLinkedMsg{md}
+	}
+}
+
+// openURL opens a web link in the browser $BROWSER names, or else the
+// system's default one.
+func openURL(u string) tea.Cmd {
+	return func() tea.Msg {
+		args, err := browserCommand()
+		if err != nil {
+			return linkOpenedMsg{u, err}
+		}
+		cmd := exec.Command(args[0], append(args[1:], u)...) //nolint:gosec
+		if err := cmd.Start(); err != nil {
+			return linkOpenedMsg{u, err}
+		}
+		go cmd.Wait() //nolint:errcheck
+		return linkOpenedMsg{url: u}
+	}
+}
+
+// browserCommand returns the command links are opened with. Over SSH, or
+// without a display, there's no browser to open unless $BROWSER names one.
+func browserCommand() ([]string, error) {
+	if b := os.Getenv("BROWSER"); b != "" {
+		if args := strings.Fields(strings.Split(b, string(os.PathListSeparator))[0]); len(args) > 0 {
+			return args, nil
+		}
+	}
+	if os.Getenv("SSH_CONNECTION") != "" || os.Getenv("SSH_TTY") != "" {
+		return nil, errNoBrowser
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		return []string{"open"}, nil
+	case "windows":
+		return []string{"rundll32", "url.dll,FileProtocolHandler"}, nil
+	default:
+		if os.Getenv("DISPLAY") == "" && os.Getenv("WAYLAND_DISPLAY") == "" {
+			return nil, errNoBrowser
+		}
+		return []string{"xdg-open"}, nil
+	}
+}
+
+// linkOpened reports whether a web link was opened, copying it instead if it
+// couldn't be.
+func (m *pagerModel) linkOpened(msg linkOpenedMsg) tea.Cmd {
+	if msg.err != nil {
+		m.copyText(msg.url)
+		return m.showStatusMessage(pagerStatusMessage{fmt.Sprintf("Copied %s (couldn't open it: %v)", msg.url, msg.err), false})
+	}
+	return m.showStatusMessage(pagerStatusMessage{"Opened " + msg.url, false})
+}
+
+// scroll keeps the selected link in the listed ones.
+func (l *linkMenu) scroll() {
+	if l.cursor < l.offset {
+		l.offset = l.cursor
+	}
+	if l.cursor >= l.offset+linkMenuHeight {
+		l.offset = l.cursor - linkMenuHeight + 1
+	}
+}
+
+// view lists the links, numbered, with where they point.
+func (l linkMenu) view(width int) string {
+	digits := len(strconv.Itoa(len(l.links)))
+	end := min(len(l.links), l.offset+linkMenuHeight)
+	lines := make([]string, 0, linkMenuHeight+2) //nolint:mnd
+	for i := l.offset; i < end; i++ {
+		link := l.links[i]
+		text := link.dest
+		if t := strings.TrimSpace(link.text); t != "" && t != link.dest {
+			text = t + " → " + link.dest
+		}
+		text = truncate.StringWithTail(fmt.Sprintf("%*d  %s", digits, i+1, text), uint(max(0, width-2)), ellipsis) //nolint:mnd
+		if i == l.cursor {
+			lines = append(lines, fuchsiaFg("│ "+text))
+		} else {
+			lines = append(lines, "  "+text)
+		}
+	}
+	lines = append(lines, "", subtleStyle.Render(fmt.Sprintf(
+		"%d/%d • ↑/↓ or number choose • enter follow • y copy • o/esc close", l.cursor+1, len(l.links),
+	)))
+	return strings.Join(lines, "\n")
+}
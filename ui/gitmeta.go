@@ -0,0 +1,65 @@
+package ui
+
+import (
+	"bytes"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/log"
+)
+
+// How many git processes may run at once while looking up metadata.
+const gitMetadataWorkers = 4
+
+var gitMetadataSlots = make(chan struct{}, gitMetadataWorkers)
+
+// gitMetadata describes the last commit that touched a document.
+type gitMetadata struct {
+	author string
+	date   time.Time
+}
+
+func (g gitMetadata) String() string {
+	return g.author + ", " + relativeTime(g.date)
+}
+
+// gitMetadataMsg carries the git metadata of a local document. Documents
+// git doesn't track get an empty one, so they aren't looked up again.
+type gitMetadataMsg struct {
+	path string
+	meta gitMetadata
+}
+
+// fetchGitMetadata looks up the last commit of a local document with git
+// log.
+func fetchGitMetadata(path string) tea.Cmd {
+	return func() tea.Msg {
+		gitMetadataSlots <- struct{}{}
+		defer func() { <-gitMetadataSlots }()
+
+		cmd := exec.Command("git", "log", "-1", "--format=%an%x00%ct", "--", filepath.Base(path))
+		cmd.Dir = filepath.Dir(path)
+		out, err := cmd.Output()
+		if err != nil {
+			log.Debug("could not get git metadata", "path", path, "error", err)
+			return gitMetadataMsg{path: path}
+		}
+
+		author, ts, ok := bytes.Cut(bytes.TrimSpace(out), []byte{0})
+		if !ok {
+			// not committed yet
+			return gitMetadataMsg{path: path}
+		}
+		secs, err := strconv.ParseInt(string(ts), 10, 64)
+		if err != nil {
+			return gitMetadataMsg{path: path}
+		}
+		return gitMetadataMsg{path: path, meta: gitMetadata{
+			author: string(author),
+			date:   time.Unix(secs, 0),
+		}}
+	}
+}
@@ -0,0 +1,72 @@
+package ui
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/sahilm/fuzzy"
+)
+
+// runCommand runs a command entered after pressing : in the pager:
+//
+//	:42                 go to line 42 of the document
+//	:50%                go halfway through the document
+//	:heading Install    go to the heading best matching "Install"
+//
+// "h" is short for "heading".
+func (m *pagerModel) runCommand(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "", true
+	}
+
+	if p, ok := strings.CutSuffix(s, "%"); ok {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 || n > 100 {
+			return "Not a percentage: " + s, false
+		}
+		m.viewport.SetYOffset(m.viewport.TotalLineCount() * n / 100) //nolint:mnd
+		return "", true
+	}
+
+	if n, err := strconv.Atoi(s); err == nil {
+		if n < 1 {
+			return "Not a line number: " + s, false
+		}
+		m.gotoSourceLine(n)
+		return "", true
+	}
+
+	name, query, _ := strings.Cut(s, " ")
+	switch name {
+	case "heading", "h":
+		query = strings.TrimSpace(query)
+		if query == "" {
+			return "Which heading? Try :heading Installation", false
+		}
+		headings := m.documentHeadings()
+		texts := make([]string, len(headings))
+		for i, h := range headings {
+			texts[i] = h.Text
+		}
+		matches := fuzzy.Find(query, texts)
+		if len(matches) == 0 {
+			return "No heading matches “" + query + "”", false
+		}
+		m.gotoHeading(headings, matches[0].Index)
+		return headings[matches[0].Index].Text, true
+	}
+	return "Unknown command: " + s, false
+}
+
+// gotoHeading scrolls to one of the headings of the document, where it's
+// found in the rendered document, or else to where its line is estimated to
+// have ended up.
+func (m *pagerModel) gotoHeading(headings []utils.Heading, i int) {
+	if line := m.headingLines(headings)[i]; line >= 0 {
+		m.viewport.SetYOffset(line)
+		return
+	}
+	m.gotoSourceLine(headings[i].Line)
+}
@@ -0,0 +1,256 @@
+package ui
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/reflow/ansi"
+	"github.com/muesli/reflow/truncate"
+)
+
+const (
+	keyHelp    = "?"
+	keyHelpAlt = "f1" // for the vim profile, where ? searches backward in documents
+)
+
+// Categories of the help overlay, in the order they're listed.
+const (
+	helpNavigation = "Navigation"
+	helpFiltering  = "Finding documents"
+	helpActions    = "Document actions"
+	helpReading    = "Reading"
+	helpApp        = "Application"
+)
+
+var helpCategories = []string{helpNavigation, helpFiltering, helpActions, helpReading, helpApp}
+
+// Where a binding works.
+const (
+	helpFiles    = "files"
+	helpDocument = "document"
+)
+
+var helpCategoryStyle = lipgloss.NewStyle().Foreground(fuchsia).Bold(true)
+
+// helpBinding is a keybinding listed in the help overlay, with the config
+// key that changes how it behaves, if there is one.
+type helpBinding struct {
+	keys     []string
+	action   string
+	category string
+	where    string // helpFiles, helpDocument, or empty for both
+	config   string
+}
+
+var helpBindings = []helpBinding{
+	{[]string{"k", "up"}, "up", helpNavigation, "", ""},
+	{[]string{"j", "down"}, "down", helpNavigation, "", ""},
+	{[]string{"g", "home"}, "go to top", helpNavigation, "", ""},
+	{[]string{"G", "end"}, "go to bottom", helpNavigation, "", ""},
+	{[]string{"b", "pgup"}, "page up", helpNavigation, helpDocument, ""},
+	{[]string{"f", "pgdown"}, "page down", helpNavigation, helpDocument, ""},
+	{[]string{"u"}, "½ page up", helpNavigation, helpDocument, ""},
+	{[]string{"d"}, "½ page down", helpNavigation, helpDocument, ""},
+	{[]string{"h", "left"}, "previous page", helpNavigation, helpFiles, ""},
+	{[]string{"l", "right"}, "next page", helpNavigation, helpFiles, ""},
+	{[]string{"tab", "L"}, "next section", helpNavigation, helpFiles, ""},
+	{[]string{"shift+tab", "H"}, "previous section", helpNavigation, helpFiles, ""},
+	{[]string{":"}, "go to line, N% or heading", helpNavigation, helpDocument, ""},
+	{[]string{"t"}, "table of contents", helpNavigation, helpDocument, ""},
+	{[]string{"m1-9"}, "set or remove bookmark", helpNavigation, helpDocument, ""},
+	{[]string{"1-9"}, "go to bookmark", helpNavigation, helpDocument, ""},
+	{[]string{"NG"}, "go to line N, with vim keys", helpNavigation, helpDocument, "keyProfile"},
+	{[]string{"S"}, "scroll by block or line", helpNavigation, helpDocument, "snapScroll"},
+	{[]string{"tab"}, "select next link", helpNavigation, helpDocument, ""},
+	{[]string{"shift+tab"}, "select previous link", helpNavigation, helpDocument, ""},
+	{[]string{"enter"}, "preview link", helpNavigation, helpDocument, ""},
+	{[]string{"o"}, "follow links", helpNavigation, helpDocument, ""},
+	{[]string{"<", ">"}, "back or forward after following a link", helpNavigation, helpDocument, ""},
+	{[]string{"w"}, "switch between documents side by side", helpNavigation, helpDocument, ""},
+	{[]string{"W"}, "scroll documents side by side together", helpNavigation, helpDocument, ""},
+
+	{[]string{"/"}, "find documents", helpFiltering, helpFiles, "filterMatcher"},
+	{[]string{"esc"}, "clear filter", helpFiltering, helpFiles, ""},
+	{[]string{"ctrl+k", "ctrl+j"}, "choose while finding", helpFiltering, helpFiles, ""},
+	{[]string{"x"}, "export matching documents", helpFiltering, helpFiles, ""},
+	{[]string{"/"}, "search in document", helpFiltering, helpDocument, ""},
+	{[]string{"?"}, "search backward, with vim keys", helpFiltering, helpDocument, "keyProfile"},
+	{[]string{"n", "N"}, "next or previous match", helpFiltering, helpDocument, ""},
+	{[]string{"esc"}, "clear search", helpFiltering, helpDocument, ""},
+	{[]string{"r", "F"}, "look for new and removed documents", helpFiltering, helpFiles, "all"},
+	{[]string{"y"}, "sort by title, date or size", helpFiltering, helpFiles, "sort"},
+	{[]string{"t"}, "reverse sort order", helpFiltering, helpFiles, ""},
+
+	{[]string{"enter"}, "open document", helpActions, helpFiles, ""},
+	{[]string{"e"}, "edit document", helpActions, "", ""},
+	{[]string{"r"}, "reload document", helpActions, helpDocument, ""},
+	{[]string{"R"}, "mark read or unread", helpActions, "", ""},
+	{[]string{"c"}, "copy contents", helpActions, helpDocument, ""},
+	{[]string{"y"}, "copy link to line", helpActions, helpDocument, ""},
+	{[]string{"Y"}, "copy code block", helpActions, helpDocument, ""},
+	{[]string{"C"}, "copied items", helpActions, helpDocument, ""},
+	{[]string{"[", "]"}, "select task", helpActions, helpDocument, ""},
+	{[]string{"space"}, "toggle task", helpActions, helpDocument, ""},
+	{[]string{"x"}, "run code block", helpActions, helpDocument, "codeRunners"},
+
+	{[]string{"s"}, "switch style", helpReading, helpDocument, "style"},
+	{[]string{"+", "-"}, "wider or narrower", helpReading, helpDocument, "width"},
+	{[]string{"="}, "reset style and width", helpReading, helpDocument, ""},
+	{[]string{"z"}, "expand or collapse code", helpReading, helpDocument, "maxCodeLines"},
+	{[]string{"Z"}, "fold or unfold code block", helpReading, helpDocument, ""},
+	{[]string{"L"}, "load more of long lines", helpReading, helpDocument, ""},
+	{[]string{"T"}, "relative or absolute dates", helpReading, helpDocument, "dateFormat"},
+	{[]string{"V"}, "show a document beside, or close it", helpReading, helpDocument, ""},
+
+	{[]string{"p"}, "pin status message", helpApp, helpDocument, "statusMessageDuration"},
+	{[]string{"N"}, "message log, when not searching", helpApp, helpDocument, ""},
+	{[]string{"!"}, "errors", helpApp, helpFiles, ""},
+	{[]string{"Q", "@"}, "record or replay macro", helpApp, "", ""},
+	{[]string{"?", "f1"}, "this help", helpApp, "", ""},
+	{[]string{"esc"}, "back to files", helpApp, helpDocument, ""},
+	{[]string{"q"}, "quit", helpApp, "", "confirmQuitWhileStreaming"},
+}
+
+// helpOverlay lists every keybinding by category, and can be searched.
+type helpOverlay struct {
+	bindings []helpBinding
+	input    textinput.Model
+	offset   int
+}
+
+// newHelpOverlay returns the help overlay, with the keys of the given key
+// profile listed next to the default keys they stand for.
+func newHelpOverlay(keys keyProfile) helpOverlay {
+	aliases := make(map[string][]string)
+	for from, to := range keys.aliases {
+		aliases[to] = append(aliases[to], from)
+	}
+	for _, a := range aliases {
+		sort.Strings(a)
+	}
+
+	bindings := make([]helpBinding, len(helpBindings))
+	for i, b := range helpBindings {
+		b.keys = slices.Clone(b.keys)
+		for _, k := range b.keys {
+			for _, alias := range aliases[k] {
+				if !slices.Contains(b.keys, alias) {
+					b.keys = append(b.keys, alias)
+				}
+			}
+		}
+		bindings[i] = b
+	}
+
+	input := textinput.New()
+	input.Prompt = "Find: "
+	input.PromptStyle = stashInputPromptStyle
+	input.Cursor.Style = stashInputCursorStyle
+	input.Placeholder = "key, action or config key"
+	input.Focus()
+
+	return helpOverlay{bindings: bindings, input: input}
+}
+
+// matches returns the bindings matching the search, in category order.
+func (h helpOverlay) matches() []helpBinding {
+	q := strings.ToLower(strings.TrimSpace(h.input.Value()))
+	var matched []helpBinding
+	for _, c := range helpCategories {
+		for _, b := range h.bindings {
+			if b.category != c {
+				continue
+			}
+			text := strings.ToLower(strings.Join(b.keys, " ") + " " + b.action + " " + b.config + " " + b.category)
+			if q == "" || strings.Contains(text, q) {
+				matched = append(matched, b)
+			}
+		}
+	}
+	return matched
+}
+
+// update handles keys while the overlay is shown. It reports whether the
+// overlay was closed.
+func (h helpOverlay) update(msg tea.KeyMsg) (helpOverlay, bool, tea.Cmd) {
+	switch msg.String() {
+	case keyEsc:
+		return h, true, nil
+	case keyHelp:
+		if h.input.Value() == "" {
+			return h, true, nil
+		}
+	case "up", "ctrl+k", "ctrl+p":
+		h.offset = max(0, h.offset-1)
+		return h, false, nil
+	case "down", "ctrl+j", "ctrl+n":
+		h.offset = min(h.offset+1, len(h.lines())-1)
+		return h, false, nil
+	case "pgup":
+		h.offset = max(0, h.offset-10) //nolint:mnd
+		return h, false, nil
+	case "pgdown":
+		h.offset = min(h.offset+10, len(h.lines())-1) //nolint:mnd
+		return h, false, nil
+	}
+
+	var cmd tea.Cmd
+	value := h.input.Value()
+	h.input, cmd = h.input.Update(msg)
+	if h.input.Value() != value {
+		h.offset = 0
+	}
+	return h, false, cmd
+}
+
+// lines renders the matching bindings under the names of their categories.
+func (h helpOverlay) lines() []string {
+	var lines []string
+	category := ""
+	for _, b := range h.matches() {
+		if b.category != category {
+			if category != "" {
+				lines = append(lines, "")
+			}
+			category = b.category
+			lines = append(lines, helpCategoryStyle.Render(category))
+		}
+		keys := strings.Join(b.keys, "/")
+		line := grayFg(keys) + strings.Repeat(" ", max(1, 18-ansi.PrintableRuneWidth(keys))) + midGrayFg(b.action) //nolint:mnd
+		if b.where != "" {
+			line += subtleStyle.Render(" · " + b.where)
+		}
+		if b.config != "" {
+			line += subtleStyle.Render(" · config: ") + fuchsiaFg(b.config)
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		lines = append(lines, subtleStyle.Render("No keys match"))
+	}
+	return lines
+}
+
+// view renders the overlay to fill the screen.
+func (h helpOverlay) view(width, height int) string {
+	lines := h.lines()
+
+	// title, search, blank lines around the list and the footer
+	listHeight := max(1, height-6) //nolint:mnd
+	offset := min(h.offset, max(0, len(lines)-listHeight))
+	lines = lines[offset:min(len(lines), offset+listHeight)]
+
+	var b strings.Builder
+	b.WriteString("\n" + glowLogoView("") + "  " + midGrayFg("Keys") + "\n\n")
+	b.WriteString(h.input.View() + "\n\n")
+	for _, l := range lines {
+		b.WriteString(truncate.StringWithTail(l, uint(max(0, width-4)), ellipsis) + "\n") //nolint:mnd
+	}
+	b.WriteString(strings.Repeat("\n", max(0, listHeight-len(lines))))
+	b.WriteString(subtleStyle.Render("↑/↓ scroll • type to search • esc close"))
+	return indent(b.String(), 2) //nolint:mnd
+}
@@ -1,6 +1,155 @@
 package ui
 
+import (
+	"strconv"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
 const (
 	keyEnter = "enter"
 	keyEsc   = "esc"
 )
+
+// Names of the available key profiles.
+const (
+	KeyProfileDefault = "default"
+	KeyProfileVim     = "vim"
+	KeyProfileEmacs   = "emacs"
+)
+
+// KeyProfiles lists the valid key profile names.
+var KeyProfiles = []string{KeyProfileDefault, KeyProfileVim, KeyProfileEmacs}
+
+// Keys an alternate profile may translate to. Anything not listed here is
+// treated as a plain rune.
+var namedKeys = map[string]tea.Key{
+	"up":     {Type: tea.KeyUp},
+	"down":   {Type: tea.KeyDown},
+	"pgup":   {Type: tea.KeyPgUp},
+	"pgdown": {Type: tea.KeyPgDown},
+	"home":   {Type: tea.KeyHome},
+	"end":    {Type: tea.KeyEnd},
+	keyEsc:   {Type: tea.KeyEscape},
+	keyEnter: {Type: tea.KeyEnter},
+}
+
+// keyProfile translates keystrokes of an alternate keymap into the default
+// bindings that the stash and pager handle. The default profile passes keys
+// through untouched.
+type keyProfile struct {
+	name    string
+	aliases map[string]string
+
+	// Vim-style counts ("10j") and pending multi-key sequences ("gg").
+	counts  bool
+	count   int
+	pending string
+}
+
+func newKeyProfile(name string) keyProfile {
+	switch name {
+	case KeyProfileVim:
+		return keyProfile{
+			name:   name,
+			counts: true,
+			aliases: map[string]string{
+				"ctrl+f": "pgdown",
+				"ctrl+b": "pgup",
+				"ctrl+d": "d",
+				"ctrl+u": "u",
+				"ctrl+e": "down",
+				"ctrl+y": "up",
+				"G":      "end",
+				"gg":     "home",
+			},
+		}
+	case KeyProfileEmacs:
+		return keyProfile{
+			name: name,
+			aliases: map[string]string{
+				"ctrl+n": "down",
+				"ctrl+p": "up",
+				"ctrl+v": "pgdown",
+				"alt+v":  "pgup",
+				"alt+<":  "home",
+				"alt+>":  "end",
+				"ctrl+s": "/",
+				"ctrl+g": keyEsc,
+			},
+		}
+	default:
+		return keyProfile{name: KeyProfileDefault}
+	}
+}
+
+// active reports whether the profile translates keys at all.
+func (p keyProfile) active() bool {
+	return p.name != KeyProfileDefault && p.name != ""
+}
+
+// gotoLineMsg asks to go to a line of the document, for vim's "10G" and
+// "10gg".
+type gotoLineMsg int
+
+// translate returns the default keystrokes for the given key, which may be
+// none (when the key starts a sequence or count) or several (when a count
+// was given). A count before a jump to the top or bottom goes to that line
+// instead.
+func (p keyProfile) translate(msg tea.KeyMsg) ([]tea.Msg, keyProfile) {
+	k := msg.String()
+
+	if p.counts && msg.Type == tea.KeyRunes && len(msg.Runes) == 1 {
+		if n, err := strconv.Atoi(k); err == nil && (n > 0 || p.count > 0) {
+			p.count = min(p.count*10+n, 9999) //nolint:mnd
+			return nil, p
+		}
+	}
+
+	switch {
+	case p.pending != "":
+		seq := p.pending + k
+		p.pending = ""
+		alias, ok := p.aliases[seq]
+		if !ok {
+			// an unfinished sequence is dropped, as in vim
+			p.count = 0
+			return nil, p
+		}
+		if alias == "home" && p.count > 0 {
+			return p.gotoLine()
+		}
+		msg = keyMsg(alias)
+	case p.aliases[k] == "end" && k == "G" && p.count > 0:
+		return p.gotoLine()
+	case p.aliases[k+k] != "":
+		// first key of a sequence such as "gg"
+		p.pending = k
+		return nil, p
+	case p.aliases[k] != "":
+		msg = keyMsg(p.aliases[k])
+	}
+
+	n := max(1, p.count)
+	p.count = 0
+	keys := make([]tea.Msg, n)
+	for i := range keys {
+		keys[i] = msg
+	}
+	return keys, p
+}
+
+// gotoLine returns a jump to the line of the count given.
+func (p keyProfile) gotoLine() ([]tea.Msg, keyProfile) {
+	n := p.count
+	p.count = 0
+	return []tea.Msg{gotoLineMsg(n)}, p
+}
+
+// keyMsg builds a key message that stringifies to the given key.
+func keyMsg(k string) tea.KeyMsg {
+	if key, ok := namedKeys[k]; ok {
+		return tea.KeyMsg(key)
+	}
+	return tea.KeyMsg(tea.Key{Type: tea.KeyRunes, Runes: []rune(k)})
+}
@@ -0,0 +1,93 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// typeKeys feeds keys to a profile one at a time, and returns what they
+// were translated to.
+func typeKeys(p keyProfile, keys ...string) (string, keyProfile) {
+	var out []string
+	for _, k := range keys {
+		var msgs []tea.Msg
+		msgs, p = p.translate(keyMsg(k))
+		for _, msg := range msgs {
+			switch msg := msg.(type) {
+			case tea.KeyMsg:
+				out = append(out, msg.String())
+			case gotoLineMsg:
+				out = append(out, fmt.Sprintf("line %d", int(msg)))
+			}
+		}
+	}
+	return strings.Join(out, " "), p
+}
+
+func TestKeyProfileTranslate(t *testing.T) {
+	for _, tc := range []struct {
+		profile string
+		keys    []string
+		want    string
+	}{
+		{KeyProfileDefault, []string{"3", "j"}, "3 j"},
+		{KeyProfileVim, []string{"j"}, "j"},
+		{KeyProfileVim, []string{"3", "j"}, "j j j"},
+		{KeyProfileVim, []string{"1", "0", "G"}, "line 10"},
+		{KeyProfileVim, []string{"G"}, "end"},
+		{KeyProfileVim, []string{"g", "g"}, "home"},
+		{KeyProfileVim, []string{"5", "g", "g"}, "line 5"},
+		{KeyProfileVim, []string{"g", "x", "j"}, "j"},
+		{KeyProfileVim, []string{"0"}, "0"},
+		{KeyProfileVim, []string{"ctrl+f"}, "pgdown"},
+		{KeyProfileEmacs, []string{"ctrl+n", "alt+>"}, "down end"},
+	} {
+		got, p := typeKeys(newKeyProfile(tc.profile), tc.keys...)
+		if got != tc.want {
+			t.Errorf("%s %v: got %q, want %q", tc.profile, tc.keys, got, tc.want)
+		}
+		if p.count != 0 || p.pending != "" {
+			t.Errorf("%s %v: left count %d and pending %q", tc.profile, tc.keys, p.count, p.pending)
+		}
+	}
+}
+
+func TestVimKeysReachBookmarks(t *testing.T) {
+	m := model{state: stateShowDocument, keys: newKeyProfile(KeyProfileVim)}
+	m.pager.bookmarks = map[int]int{1: 12}
+
+	if !m.bookmarkKey(keyMsg("1")) {
+		t.Error("1 didn't go to bookmark 1")
+	}
+	if m.bookmarkKey(keyMsg("2")) {
+		t.Error("2 went to a bookmark that isn't set")
+	}
+	_, m.keys = typeKeys(m.keys, "2")
+	if m.bookmarkKey(keyMsg("1")) {
+		t.Error("1 went to a bookmark while a count was typed")
+	}
+
+	m.keys = newKeyProfile(KeyProfileVim)
+	m.pager.marking = true
+	if !m.editingText() {
+		t.Error("the number after m was taken for a count")
+	}
+}
+
+func TestQuitPromptNeedsTwoQs(t *testing.T) {
+	common := &commonModel{cfg: Config{ConfirmQuitWhileStreaming: true}}
+	m := model{common: common, state: stateShowDocument, pager: newPagerModel(common)}
+	m.pager.streaming = true
+
+	m, _ = m.update(keyMsg("q"))
+	if !m.pager.quitPrompt {
+		t.Fatal("q quit without asking while streaming")
+	}
+	m, _ = m.update(keyMsg("j"))
+	if m.pager.quitPrompt {
+		t.Error("the prompt stayed after another key, so a later q would quit")
+	}
+}
@@ -0,0 +1,188 @@
+package ui
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/glow/v2/markup"
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/reflow/truncate"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/text"
+)
+
+const (
+	keyNextLink    = "tab"
+	keyPrevLink    = "shift+tab"
+	keyPreviewLink = keyEnter
+
+	// rendered lines of the target document shown in a link preview
+	linkPreviewHeight = 8
+)
+
+// docLink is a link in a markdown document.
+type docLink struct {
+	text string
+	dest string
+}
+
+// findLinks returns the links in a markdown document, in order.
+func findLinks(body string) []docLink {
+	src := utils.RemoveFrontmatter([]byte(body))
+	doc := goldmark.New(goldmark.WithExtensions(extension.GFM)).Parser().Parse(text.NewReader(src))
+
+	var links []docLink
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n := n.(type) {
+		case *ast.Link:
+			links = append(links, docLink{string(n.Text(src)), string(n.Destination)}) //nolint:staticcheck
+		case *ast.AutoLink:
+			u := string(n.URL(src))
+			links = append(links, docLink{u, u})
+		}
+		return ast.WalkContinue, nil
+	})
+	return links
+}
+
+// linkPreview is the target of a link, shown below the status bar. For links
+// to other local documents, their first lines are rendered once loaded.
+type linkPreview struct {
+	link    docLink
+	loading bool
+	content string
+	err     error
+}
+
+type linkPreviewMsg struct {
+	dest    string
+	content string
+	err     error
+}
+
+// selectLink selects the next or previous link, scrolling to it if it's off
+// screen.
+func (m *pagerModel) selectLink(delta int) tea.Cmd {
+	links := findLinks(m.currentDocument.Body)
+	if len(links) == 0 {
+		return m.showStatusMessage(pagerStatusMessage{"No links in this document", false})
+	}
+
+	if m.linkIndex < 0 && delta < 0 {
+		m.linkIndex = len(links) - 1
+	} else {
+		m.linkIndex = (m.linkIndex + delta + len(links)) % len(links)
+	}
+	l := links[m.linkIndex]
+
+	if line := renderedLineOf(m.rendered, l.text); line >= 0 &&
+		(line < m.viewport.YOffset || line >= m.viewport.YOffset+m.viewport.Height) {
+		m.viewport.SetYOffset(max(0, line-m.viewport.Height/2))
+	}
+
+	return m.showStatusMessage(pagerStatusMessage{
+		fmt.Sprintf("Link %d/%d %s → %s (enter to preview)", m.linkIndex+1, len(links), l.text, l.dest),
+		false,
+	})
+}
+
+// togglePreview shows the target of the selected link, or hides it.
+func (m *pagerModel) togglePreview() tea.Cmd {
+	if m.preview != nil {
+		m.closePreview()
+		return nil
+	}
+	links := findLinks(m.currentDocument.Body)
+	if m.linkIndex < 0 || m.linkIndex >= len(links) {
+		return m.showStatusMessage(pagerStatusMessage{"Press tab to select a link first", false})
+	}
+
+	l := links[m.linkIndex]
+	path, ok := m.localLinkTarget(l.dest)
+	m.preview = &linkPreview{link: l, loading: ok}
+	m.toc = nil
+	m.closeLinkMode()
+	m.showClipboard, m.showNotifications = false, false
+	m.setSize(m.screenWidth, m.screenHeight)
+	if !ok {
+		return nil
+	}
+	return loadLinkPreview(l.dest, path, m.glamourStyle(), m.common.cfg.Decorations, max(0, m.screenWidth-4)) //nolint:mnd
+}
+
+func (m *pagerModel) closePreview() {
+	m.preview = nil
+	m.setSize(m.screenWidth, m.screenHeight)
+}
+
+// localLinkTarget returns the path of the local document a link points to,
+// if it does.
+func (m pagerModel) localLinkTarget(dest string) (string, bool) {
+	u, err := url.Parse(dest)
+	if err != nil || u.Scheme != "" || u.Host != "" || u.Path == "" || m.documentDir() == "" {
+		return "", false
+	}
+	p := filepath.FromSlash(u.Path)
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(m.documentDir(), p)
+	}
+	if filepath.Ext(p) == "" || !utils.IsMarkdownFile(p) {
+		return "", false
+	}
+	return p, true
+}
+
+// loadLinkPreview renders the first lines of a linked document.
+func loadLinkPreview(dest, path, style string, decorations utils.Decorations, width int) tea.Cmd {
+	return func() tea.Msg {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return linkPreviewMsg{dest: dest, err: err}
+		}
+		b = utils.RemoveFrontmatter(markup.ToMarkdown(path, b))
+
+		// there's no need to render more than fits
+		lines := strings.SplitAfter(string(b), "\n")
+		md := strings.Join(lines[:min(len(lines), linkPreviewHeight*4)], "") //nolint:mnd
+
+		r, err := glamour.NewTermRenderer(
+			glamour.WithColorProfile(lipgloss.ColorProfile()),
+			utils.GlamourStyle(style, false, decorations),
+			glamour.WithWordWrap(width),
+		)
+		if err != nil {
+			return linkPreviewMsg{dest: dest, err: err}
+		}
+		out, err := r.Render(md)
+		if err != nil {
+			return linkPreviewMsg{dest: dest, err: err}
+		}
+		rendered := strings.Split(strings.Trim(out, "\n"), "\n")
+		return linkPreviewMsg{dest: dest, content: strings.Join(rendered[:min(len(rendered), linkPreviewHeight)], "\n")}
+	}
+}
+
+func (p linkPreview) view(width int) string {
+	lines := []string{fuchsiaFg(truncate.StringWithTail(p.link.dest, uint(max(0, width)), ellipsis))}
+	switch {
+	case p.loading:
+		lines = append(lines, "", subtleStyle.Render("Loading…"))
+	case p.err != nil:
+		lines = append(lines, "", subtleStyle.Render("Couldn't load preview: "+p.err.Error()))
+	case p.content != "":
+		lines = append(lines, "", p.content)
+	}
+	lines = append(lines, "", subtleStyle.Render("tab/shift+tab select link • enter/esc close"))
+	return strings.Join(lines, "\n")
+}
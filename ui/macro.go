@@ -0,0 +1,70 @@
+package ui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Keys for recording and replaying macros.
+const (
+	keyRecordMacro = "Q"
+	keyReplayMacro = "@"
+)
+
+// macro records keystrokes so they can be replayed later on. The recorded
+// keys are kept for the rest of the session.
+type macro struct {
+	recording bool
+	keys      []tea.KeyMsg
+}
+
+// macroKeyMsg is a replayed keystroke. It's handled like any other key, but
+// not recorded again.
+type macroKeyMsg tea.KeyMsg
+
+// replay returns a command sending the recorded keys in order.
+func (mc macro) replay() tea.Cmd {
+	cmds := make([]tea.Cmd, len(mc.keys))
+	for i, k := range mc.keys {
+		cmds[i] = func() tea.Msg { return macroKeyMsg(k) }
+	}
+	return tea.Sequence(cmds...)
+}
+
+// updateMacro handles the macro keys and records keystrokes while recording.
+// It reports whether the key has been handled.
+func (m *model) updateMacro(msg tea.KeyMsg) (bool, tea.Cmd) {
+	if !m.editingText() {
+		switch msg.String() {
+		case keyRecordMacro:
+			m.macro.recording = !m.macro.recording
+			if m.macro.recording {
+				m.macro.keys = nil
+				return true, m.showMacroStatus("Recording macro, press Q to stop")
+			}
+			return true, m.showMacroStatus(fmt.Sprintf("Recorded %d keys, press @ to replay", len(m.macro.keys)))
+
+		case keyReplayMacro:
+			if m.macro.recording {
+				return true, m.showMacroStatus("Can't replay a macro while recording")
+			}
+			if len(m.macro.keys) == 0 {
+				return true, m.showMacroStatus("No macro recorded, press Q to record one")
+			}
+			return true, m.macro.replay()
+		}
+	}
+
+	if m.macro.recording {
+		m.macro.keys = append(m.macro.keys, msg)
+	}
+	return false, nil
+}
+
+func (m *model) showMacroStatus(s string) tea.Cmd {
+	if m.state == stateShowDocument {
+		return m.pager.showStatusMessage(pagerStatusMessage{s, false})
+	}
+	return m.stash.newStatusMessage(statusMessage{subtleStatusMessage, s})
+}
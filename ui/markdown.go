@@ -10,6 +10,7 @@ import (
 	"golang.org/x/text/runes"
 	"golang.org/x/text/transform"
 	"golang.org/x/text/unicode/norm"
+	"golang.org/x/text/width"
 )
 
 type markdown struct {
@@ -17,14 +18,32 @@ type markdown struct {
 	// those that have been stashed in this session.
 	localPath string
 
+	// Path relative to the remote stash root, for documents listed from a
+	// remote stash. The local path then points at the cached copy.
+	remotePath string
+
 	// Value we filter against. This exists so that we can maintain positions
 	// of filtered items if notes are edited while a filter is active. This
 	// field is ephemeral, and should only be referenced during filtering.
 	filterValue string
 
+	// Line or heading anchor the pager should scroll to once the document
+	// has been rendered. Set when opening a "file.md:42" style reference.
+	line   int
+	anchor string
+
+	// Headings above where content search found the filter text, shown in
+	// the pager's status bar.
+	breadcrumb string
+
 	Body    string
 	Note    string
 	Modtime time.Time
+	Size    int64 // in bytes
+
+	// Whether the body was converted to markdown from another markup
+	// language, so it can't be written back.
+	converted bool
 }
 
 // Generate the value we're doing to filter against.
@@ -43,14 +62,26 @@ func (m markdown) relativeTime() string {
 }
 
 // Normalize text to aid in the filtering process. In particular, we remove
-// diacritics, "ö" becomes "o". Note that Mn is the unicode key for nonspacing
-// marks.
+// diacritics, "ö" becomes "o", and fold full-width characters to their ASCII
+// equivalents, "Ａ" becomes "A". Note that Mn is the unicode key for
+// nonspacing marks. Both names and filter text are normalized, so "é" and
+// "e" match either way.
 func normalize(in string) (string, error) {
-	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), width.Fold, norm.NFC)
 	out, _, err := transform.String(t, in)
 	return out, err
 }
 
+// normalizeQuery normalizes filter text like the names it's matched against.
+func normalizeQuery(q string) string {
+	n, err := normalize(q)
+	if err != nil {
+		log.Error("error normalizing", "query", q, "error", err)
+		return q
+	}
+	return n
+}
+
 // Return the time in a human-readable format relative to the current time.
 func relativeTime(then time.Time) string {
 	now := time.Now()
@@ -0,0 +1,216 @@
+package ui
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/sahilm/fuzzy"
+)
+
+// Names of the available filter matchers.
+const (
+	MatcherFuzzy     = "fuzzy"
+	MatcherSmartCase = "smartcase"
+	MatcherSubstring = "substring"
+	MatcherTokens    = "tokens"
+)
+
+// Matchers lists the valid filter matcher names.
+var Matchers = []string{MatcherFuzzy, MatcherSmartCase, MatcherSubstring, MatcherTokens}
+
+// A matcher decides which document names match the text of a filter, and
+// which of their characters did, so they can be highlighted.
+type matcher interface {
+	// find returns the targets that match the query, best first.
+	find(query string, targets []string) []filterMatch
+}
+
+// filterMatch is a target that matched, by index, along with the indexes of
+// the runes that matched.
+type filterMatch struct {
+	index   int
+	matched []int
+}
+
+func newMatcher(name string) matcher {
+	switch name {
+	case MatcherSmartCase:
+		return smartCaseMatcher{}
+	case MatcherSubstring:
+		return substringMatcher{}
+	case MatcherTokens:
+		return tokenMatcher{}
+	default:
+		return fuzzyMatcher{}
+	}
+}
+
+// fuzzyMatcher matches the characters of the query in order, ignoring case,
+// ranking matches at word boundaries and in a row higher.
+type fuzzyMatcher struct{}
+
+func (fuzzyMatcher) find(query string, targets []string) []filterMatch {
+	ranks := fuzzy.Find(query, targets)
+	sort.Stable(ranks)
+
+	matches := make([]filterMatch, 0, len(ranks))
+	for _, r := range ranks {
+		matches = append(matches, filterMatch{r.Index, runeIndexes(r.Str, r.MatchedIndexes)})
+	}
+	return matches
+}
+
+// smartCaseMatcher matches like fuzzyMatcher, but queries with uppercase
+// letters only match with the same case.
+type smartCaseMatcher struct{}
+
+func (smartCaseMatcher) find(query string, targets []string) []filterMatch {
+	matches := fuzzyMatcher{}.find(query, targets)
+	if strings.ToLower(query) == query {
+		return matches
+	}
+
+	q := []rune(query)
+	kept := matches[:0]
+	for _, m := range matches {
+		// find the characters again, case sensitively this time
+		var matched []int
+		i := 0
+		for j, r := range []rune(targets[m.index]) {
+			if i < len(q) && r == q[i] {
+				matched = append(matched, j)
+				i++
+			}
+		}
+		if i == len(q) {
+			kept = append(kept, filterMatch{m.index, matched})
+		}
+	}
+	return kept
+}
+
+// substringMatcher matches the query as a whole, ignoring case. Names where
+// it comes earlier rank higher.
+type substringMatcher struct{}
+
+func (substringMatcher) find(query string, targets []string) []filterMatch {
+	q := []rune(query)
+	var (
+		matches []filterMatch
+		pos     []int
+	)
+	for i, t := range targets {
+		j := indexFold([]rune(t), q)
+		if j < 0 {
+			continue
+		}
+		matches = append(matches, filterMatch{i, span(j, len(q))})
+		pos = append(pos, j)
+	}
+	sortMatches(matches, pos)
+	return matches
+}
+
+// tokenMatcher matches each word of the query on its own, ignoring case
+// and in any order. Names where the words come earlier rank higher.
+type tokenMatcher struct{}
+
+func (tokenMatcher) find(query string, targets []string) []filterMatch {
+	tokens := strings.Fields(query)
+	var (
+		matches []filterMatch
+		pos     []int
+	)
+targets:
+	for i, t := range targets {
+		hay := []rune(t)
+		var (
+			matched []int
+			sum     int
+		)
+		for _, tok := range tokens {
+			q := []rune(tok)
+			j := indexFold(hay, q)
+			if j < 0 {
+				continue targets
+			}
+			matched = append(matched, span(j, len(q))...)
+			sum += j
+		}
+		sort.Ints(matched)
+		matches = append(matches, filterMatch{i, matched})
+		pos = append(pos, sum)
+	}
+	sortMatches(matches, pos)
+	return matches
+}
+
+// sortMatches sorts matches by position, keeping the order of matches at
+// the same position.
+func sortMatches(matches []filterMatch, pos []int) {
+	sort.Stable(byPosition{matches, pos})
+}
+
+type byPosition struct {
+	matches []filterMatch
+	pos     []int
+}
+
+func (p byPosition) Len() int           { return len(p.matches) }
+func (p byPosition) Less(i, j int) bool { return p.pos[i] < p.pos[j] }
+func (p byPosition) Swap(i, j int) {
+	p.matches[i], p.matches[j] = p.matches[j], p.matches[i]
+	p.pos[i], p.pos[j] = p.pos[j], p.pos[i]
+}
+
+// indexFold returns the index of the first occurrence of needle in hay,
+// ignoring case, or -1.
+func indexFold(hay, needle []rune) int {
+	if len(needle) == 0 {
+		return -1
+	}
+outer:
+	for i := 0; i+len(needle) <= len(hay); i++ {
+		for j, r := range needle {
+			if !foldEqual(hay[i+j], r) {
+				continue outer
+			}
+		}
+		return i
+	}
+	return -1
+}
+
+// foldEqual reports whether two runes are the same under Unicode case
+// folding, such as "K" and the Kelvin sign.
+func foldEqual(a, b rune) bool {
+	if a == b {
+		return true
+	}
+	for f := unicode.SimpleFold(a); f != a; f = unicode.SimpleFold(f) {
+		if f == b {
+			return true
+		}
+	}
+	return false
+}
+
+// span returns the indexes from i on, n of them.
+func span(i, n int) []int {
+	s := make([]int, n)
+	for j := range s {
+		s[j] = i + j
+	}
+	return s
+}
+
+// runeIndexes turns byte offsets into s into rune indexes.
+func runeIndexes(s string, offsets []int) []int {
+	idx := make([]int, len(offsets))
+	for i, o := range offsets {
+		idx[i] = utf8.RuneCountInString(s[:min(o, len(s))])
+	}
+	return idx
+}
@@ -0,0 +1,47 @@
+package ui
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestMatchers(t *testing.T) {
+	targets := []string{"docs/Getting Started.md", "README.md", "notes/read later.md", "CHANGELOG.md"}
+
+	for _, tc := range []struct {
+		matcher string
+		query   string
+		want    string // matches as index:matched runes, best first
+	}{
+		{MatcherFuzzy, "rdme", "[{1 [0 3 4 5]}]"},
+		{MatcherFuzzy, "READ", "[{1 [0 1 2 3]} {2 [6 7 8 9]}]"},
+		{MatcherSmartCase, "read", "[{1 [0 1 2 3]} {2 [6 7 8 9]}]"},
+		{MatcherSmartCase, "READ", "[{1 [0 1 2 3]}]"},
+		{MatcherSmartCase, "GS", "[{0 [5 13]}]"},
+		{MatcherSubstring, "read", "[{1 [0 1 2 3]} {2 [6 7 8 9]}]"},
+		{MatcherSubstring, "ed.md", "[{0 [18 19 20 21 22]}]"},
+		{MatcherSubstring, "ed md", "[]"},
+		{MatcherSubstring, "LOG", "[{3 [6 7 8]}]"},
+		{MatcherTokens, "later read", "[{2 [6 7 8 9 11 12 13 14 15]}]"},
+		{MatcherTokens, "md started", "[{0 [13 14 15 16 17 18 19 21 22]}]"},
+		{MatcherTokens, "md nope", "[]"},
+		// unknown names fall back to fuzzy matching
+		{"bogus", "rdme", "[{1 [0 3 4 5]}]"},
+	} {
+		got := newMatcher(tc.matcher).find(tc.query, targets)
+		if s := fmt.Sprint(got); s != tc.want {
+			t.Errorf("%s %q: got %s, want %s", tc.matcher, tc.query, s, tc.want)
+		}
+	}
+}
+
+func TestMatchersFoldCase(t *testing.T) {
+	// the Kelvin sign folds to k, and the highlighted indexes are of runes
+	targets := []string{"Kelvin.md", "kelvin.md"}
+	for _, name := range []string{MatcherSubstring, MatcherTokens} {
+		got := fmt.Sprint(newMatcher(name).find("kel", targets))
+		if want := "[{0 [0 1 2]} {1 [0 1 2]}]"; got != want {
+			t.Errorf("%s: got %s, want %s", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// How many status messages are kept in the log.
+const maxNotifications = 100
+
+// notification is a status message that has been shown.
+type notification struct {
+	time    time.Time
+	message string
+	isError bool
+}
+
+// notificationLog records status messages so they can be reviewed after
+// they've disappeared.
+type notificationLog struct {
+	entries []notification
+}
+
+func (l *notificationLog) add(message string, isError bool) {
+	l.entries = append(l.entries, notification{time.Now(), message, isError})
+	if len(l.entries) > maxNotifications {
+		l.entries = l.entries[len(l.entries)-maxNotifications:]
+	}
+}
+
+// view returns the most recent n messages, newest last.
+func (l notificationLog) view(n int) string {
+	if len(l.entries) == 0 {
+		return subtleStyle.Render("No messages yet")
+	}
+	var lines []string
+	for _, e := range l.entries[max(0, len(l.entries)-n):] {
+		msg := e.message
+		if e.isError {
+			msg = redFg(msg)
+		}
+		lines = append(lines, fmt.Sprintf("%s  %s", subtleStyle.Render(e.time.Format(time.TimeOnly)), msg))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// statusMessageDuration returns how long status messages are shown.
+func statusMessageDuration() time.Duration {
+	if config.StatusMessageDuration > 0 {
+		return config.StatusMessageDuration
+	}
+	return statusMessageTimeout
+}
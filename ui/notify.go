@@ -0,0 +1,54 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Ways of telling that a long render has finished.
+const (
+	NotifyOff  = "off"
+	NotifyBell = "bell"
+	NotifyOSC  = "osc" // OSC 9, shown as a desktop notification by many terminals
+)
+
+// Notifies lists the valid notification settings.
+var Notifies = []string{NotifyOff, NotifyBell, NotifyOSC}
+
+// Renders taking less than this are over before there's time to switch away,
+// so they don't need a notification.
+const notifyAfter = 2 * time.Second
+
+// notifyRendered rings the bell, or sends a notification, as configured,
+// once a document that took a while to stream in has been rendered.
+func (m pagerModel) notifyRendered(started time.Time) tea.Cmd {
+	if m.common.cfg.Notify == NotifyOff || m.common.cfg.Notify == "" || time.Since(started) < notifyAfter {
+		return nil
+	}
+	name := m.currentDocument.Note
+	if name == "" {
+		name = filepath.Base(m.currentDocument.localPath)
+	}
+	notify := m.common.cfg.Notify
+	return func() tea.Msg {
+		switch notify {
+		case NotifyBell:
+			fmt.Fprint(os.Stdout, "\a")
+		case NotifyOSC:
+			// keep control characters from ending the sequence early
+			msg := strings.Map(func(r rune) rune {
+				if r < ' ' || r == 0x7f {
+					return -1
+				}
+				return r
+			}, "Glow: finished rendering "+name)
+			fmt.Fprint(os.Stdout, "\x1b]9;"+msg+"\a")
+		}
+		return nil
+	}
+}
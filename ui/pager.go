@@ -5,28 +5,52 @@ import (
 	"math"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
-	"github.com/atotto/clipboard"
+	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/glamour/styles"
 	"github.com/charmbracelet/glow/v2/utils"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/log"
-	runewidth "github.com/mattn/go-runewidth"
 	"github.com/muesli/reflow/ansi"
 	"github.com/muesli/reflow/truncate"
-	"github.com/muesli/termenv"
+	"github.com/muesli/reflow/wordwrap"
 )
 
 const (
 	statusBarHeight = 1
 	lineNumberWidth = 4
+
+	// How many recent status messages the message log shows.
+	notificationLogHeight = 8
+
+	// Word-wrap width adjustments with + and -.
+	overrideWidthStep = 10
+	minOverrideWidth  = 20
+
+	// Lines longer than this many characters are cut, and L loads this many
+	// more. Runs of characters without spaces longer than longWordLimit are
+	// broken up, as word-wrapping can't cope with them.
+	longLineLimit = 10000
+	longWordLimit = 500
 )
 
 var (
-	pagerHelpHeight int
+	// Styles to cycle through with s.
+	pagerStyles = []string{
+		styles.DarkStyle,
+		styles.LightStyle,
+		styles.DraculaStyle,
+		styles.TokyoNightStyle,
+		styles.PinkStyle,
+		styles.AsciiStyle,
+		utils.MonoStyle,
+	}
 
 	mintGreen = lipgloss.AdaptiveColor{Light: "#89F0CB", Dark: "#89F0CB"}
 	darkGreen = lipgloss.AdaptiveColor{Light: "#1C8760", Dark: "#1C8760"}
@@ -91,14 +115,133 @@ type pagerModel struct {
 	common   *commonModel
 	viewport viewport.Model
 	state    pagerState
-	showHelp bool
+
+	// Identifies the pager, so messages for it can be told apart from
+	// messages for a document shown beside it.
+	pane uint64
+
+	// Size of the pager: the whole window, unless it's shared with a
+	// document shown beside this one.
+	screenWidth  int
+	screenHeight int
+
+	// Whether a document shown beside this one has the focus.
+	blurred bool
 
 	statusMessage      string
 	statusMessageTimer *time.Timer
 
+	// Whether the status message is pinned and shown in full, and whether
+	// the log of recent status messages is shown.
+	statusPinned      bool
+	showNotifications bool
+
+	// Whether the items copied during the session are shown.
+	showClipboard bool
+
 	// Current document being rendered, sans-glamour rendering. We cache
 	// it here so we can re-render it on resize.
 	currentDocument markdown
+
+	// Rendered version of the current document.
+	rendered string
+
+	// Index of the selected task list item, or -1 if none is selected.
+	taskIndex int
+
+	// Generation of the pager's latest render, shared by its copies, the
+	// chunks of a progressively rendered document, whether they're still
+	// streaming in, whether quitting has been requested while they do, and
+	// when the render started.
+	renders     *atomic.Uint64
+	ahead       *renderAheadState
+	streaming   bool
+	quitPrompt  bool
+	renderStart time.Time
+
+	// Whether code blocks longer than the configured maximum are shown in
+	// full.
+	expandCode bool
+
+	// How many more chunks of overly long lines have been loaded.
+	longLines int
+
+	// Dates from the document's front matter, shown below it, and whether
+	// they're shown as absolute dates rather than relative ones.
+	dates         docDates
+	absoluteDates bool
+
+	// First row shown of each virtualized table, and the scroll adjustment
+	// to apply once the table has been re-rendered with a new window.
+	tableOffsets map[int]int
+	tableScroll  int
+
+	// Style and word-wrap width chosen for this document, overriding the
+	// configured ones.
+	style string
+	width uint
+
+	// Command line opened with :, and whether it's being typed in.
+	command    textinput.Model
+	commanding bool
+
+	// Search line opened with /, whether it's being typed in, the text
+	// searched for, where it's found in the document and which match is
+	// selected.
+	search        textinput.Model
+	searching     bool
+	searchQuery   string
+	searchMatches []searchMatch
+	searchIndex   int
+
+	// Whether the search goes up the document, as vim's ?, and the line it
+	// started from.
+	searchBackward bool
+	searchFrom     int
+
+	// Source lines of the bookmarks set in this document, by number, and
+	// whether the number of a bookmark to set is awaited.
+	bookmarks map[int]int
+	marking   bool
+
+	// Indexes of the code blocks that are folded.
+	folded map[int]bool
+
+	// Whether up and down scroll by block rather than by line.
+	snap bool
+
+	// Whether the document has been marked as read or unread by hand, so
+	// it isn't marked as read when scrolled to the end.
+	readToggled bool
+
+	// Generation of the scheduled refresh of a remote document, when it was
+	// last fetched, and when a key was last pressed or the mouse last used.
+	refreshGen      uint64
+	refreshed       time.Time
+	lastInteraction time.Time
+
+	// Index of the selected link, or -1 if none is selected, and the
+	// preview of its target, if shown.
+	linkIndex int
+	preview   *linkPreview
+
+	// Table of contents, if shown.
+	toc *tocMenu
+
+	// Link mode, if in it, and the documents followed links came from and
+	// those gone back from, most recent last.
+	links   *linkMenu
+	history []linkVisit
+	future  []linkVisit
+
+	// Whether a document is being opened by following a link or going back
+	// or forward, rather than reloaded.
+	following bool
+
+	// Code block awaiting confirmation to be run, and the code blocks that
+	// have been run, by index.
+	pendingRun *codeRun
+	runs       map[int]codeRun
 }
 
 func newPagerModel(common *commonModel) pagerModel {
@@ -107,35 +250,40 @@ func newPagerModel(common *commonModel) pagerModel {
 	vp.YPosition = 0
 	vp.HighPerformanceRendering = config.HighPerformancePager
 
+	ci := textinput.New()
+	ci.Prompt = ":"
+	ci.PromptStyle = stashInputPromptStyle
+	ci.Cursor.Style = stashInputCursorStyle
+
 	return pagerModel{
-		common:   common,
-		state:    pagerStateBrowse,
-		viewport: vp,
+		common:    common,
+		pane:      paneID.Add(1),
+		renders:   &atomic.Uint64{},
+		state:     pagerStateBrowse,
+		viewport:  vp,
+		taskIndex: -1,
+		linkIndex: -1,
+		command:   ci,
+		search:    newSearchInput(),
+		snap:      common.cfg.SnapScroll,
 	}
 }
 
 func (m *pagerModel) setSize(w, h int) {
+	m.screenWidth, m.screenHeight = w, h
 	m.viewport.Width = w
 	m.viewport.Height = h - statusBarHeight
 
-	if m.showHelp {
-		if pagerHelpHeight == 0 {
-			pagerHelpHeight = strings.Count(m.helpView(), "\n")
-		}
-		m.viewport.Height -= (statusBarHeight + pagerHelpHeight)
+	if v := m.notificationsView(); v != "" {
+		m.viewport.Height -= (statusBarHeight + strings.Count(v, "\n"))
 	}
 }
 
 func (m *pagerModel) setContent(s string) {
-	m.viewport.SetContent(s)
-}
-
-func (m *pagerModel) toggleHelp() {
-	m.showHelp = !m.showHelp
-	m.setSize(m.common.width, m.common.height)
-	if m.viewport.PastBottom() {
-		m.viewport.GotoBottom()
+	if !m.streaming {
+		s += m.dates.footer(m.absoluteDates)
 	}
+	m.viewport.SetContent(m.highlightMatches(m.markBookmarks(m.highlightLinks(s))))
 }
 
 type pagerStatusMessage struct {
@@ -150,24 +298,249 @@ func (m *pagerModel) showStatusMessage(msg pagerStatusMessage) tea.Cmd {
 	// Show a success message to the user
 	m.state = pagerStateStatusMessage
 	m.statusMessage = msg.message
+	m.common.notifications.add(msg.message, msg.isError)
 	if m.statusMessageTimer != nil {
 		m.statusMessageTimer.Stop()
 	}
-	m.statusMessageTimer = time.NewTimer(statusMessageTimeout)
+	m.statusMessageTimer = time.NewTimer(statusMessageDuration())
+	if m.statusPinned || m.showNotifications {
+		m.statusPinned = false
+		m.setSize(m.screenWidth, m.screenHeight)
+	}
 
 	return waitForStatusMessageTimeout(pagerContext, m.statusMessageTimer)
 }
 
 func (m *pagerModel) unload() {
-	if m.showHelp {
-		m.toggleHelp()
-	}
 	if m.statusMessageTimer != nil {
 		m.statusMessageTimer.Stop()
 	}
 	m.state = pagerStateBrowse
+	m.statusPinned = false
+	m.showNotifications = false
+	m.showClipboard = false
+	m.setSize(m.screenWidth, m.screenHeight)
 	m.viewport.SetContent("")
 	m.viewport.YOffset = 0
+	m.rendered = ""
+	m.ahead = nil
+	m.taskIndex = -1
+	m.linkIndex = -1
+	m.preview = nil
+	m.toc = nil
+	m.links = nil
+	m.history = nil
+	m.future = nil
+	m.following = false
+	m.expandCode = false
+	m.longLines = 0
+	m.dates = docDates{}
+	m.absoluteDates = false
+	m.tableOffsets = nil
+	m.tableScroll = 0
+	m.style = ""
+	m.width = 0
+	m.streaming = false
+	m.quitPrompt = false
+	m.commanding = false
+	m.command.Blur()
+	m.searching = false
+	m.search.Blur()
+	m.searchQuery = ""
+	m.searchMatches = nil
+	m.searchIndex = 0
+	m.searchBackward = false
+	m.bookmarks = nil
+	m.marking = false
+	m.folded = nil
+	m.readToggled = false
+	m.refreshGen++
+	m.refreshed = time.Time{}
+	m.pendingRun = nil
+	m.runs = nil
+}
+
+// selectTask selects the next or previous task list item and scrolls to it.
+func (m *pagerModel) selectTask(delta int) tea.Cmd {
+	tasks := findTasks(m.currentDocument.Body)
+	if len(tasks) == 0 {
+		return m.showStatusMessage(pagerStatusMessage{"No tasks in this document", false})
+	}
+
+	if m.taskIndex < 0 && delta < 0 {
+		m.taskIndex = len(tasks) - 1
+	} else {
+		m.taskIndex = (m.taskIndex + delta + len(tasks)) % len(tasks)
+	}
+	t := tasks[m.taskIndex]
+
+	if line := renderedLineOf(m.rendered, t.text); line >= 0 &&
+		(line < m.viewport.YOffset || line >= m.viewport.YOffset+m.viewport.Height) {
+		m.viewport.SetYOffset(max(0, line-m.viewport.Height/2))
+	}
+
+	mark := "[ ]"
+	if t.checked {
+		mark = "[x]"
+	}
+	return m.showStatusMessage(pagerStatusMessage{
+		fmt.Sprintf("Task %d/%d %s %s (space to toggle)", m.taskIndex+1, len(tasks), mark, t.text),
+		false,
+	})
+}
+
+// sourceLine estimates the line in the source document that corresponds to
+// the current scroll position.
+func (m pagerModel) sourceLine() int {
+	if m.viewport.AtTop() {
+		return 0
+	}
+	total := strings.Count(m.currentDocument.Body, "\n") + 1
+	return int(math.RoundToEven(float64(total) * m.viewport.ScrollPercent()))
+}
+
+// scrollToTarget scrolls to the line or anchor the current document was
+// opened with, if any. The target is cleared so it's only applied once and
+// not again when re-rendering on resize.
+func (m *pagerModel) scrollToTarget() {
+	md := &m.currentDocument
+	line := md.line
+	if md.anchor != "" {
+		line = utils.AnchorLine([]byte(md.Body), md.anchor)
+	}
+	md.line, md.anchor = 0, ""
+	if line <= 1 {
+		return
+	}
+	m.gotoSourceLine(line)
+}
+
+// gotoSourceLine scrolls to an estimate of where a line of the source
+// document ended up once rendered.
+func (m *pagerModel) gotoSourceLine(line int) {
+	total := strings.Count(m.currentDocument.Body, "\n") + 1
+	m.viewport.SetYOffset(m.viewport.TotalLineCount() * (min(line, total) - 1) / total)
+}
+
+// scrollTables moves the window of a virtualized table along when the
+// viewport reaches either end of it. The viewport is shifted by the same
+// number of rows once re-rendered, so the visible rows stay put.
+func (m *pagerModel) scrollTables() tea.Cmd {
+	if m.tableScroll != 0 {
+		return nil // still re-rendering
+	}
+
+	top, bottom := m.viewport.YOffset, m.viewport.YOffset+m.viewport.Height
+	visible := func(line int) bool {
+		return line >= top && line < bottom
+	}
+
+	tables := findLargeTables(strings.Split(m.currentDocument.Body, "\n"))
+	for i, t := range tables {
+		o := tableOffset(m.tableOffsets, i, t)
+		n := 0
+		if more := t.rows - o - virtualTableRows; more > 0 && visible(renderedLineOf(m.rendered, tableMoreMarker(i, more))) {
+			n = min(more, virtualTableRows/2)
+		} else if o > 0 && visible(renderedLineOf(m.rendered, tableRowsMarker(i, o, t.rows))) {
+			n = -min(o, virtualTableRows/2)
+		}
+		if n == 0 {
+			continue
+		}
+
+		if m.tableOffsets == nil {
+			m.tableOffsets = make(map[int]int)
+		}
+		m.tableOffsets[i] = o + n
+		m.tableScroll = -n
+		return renderWithGlamour(*m, m.currentDocument.Body)
+	}
+	return nil
+}
+
+// confirmQuit reports whether quitting should wait for a confirmation, as
+// the document is still streaming in. If so the user is asked for one.
+func (m *pagerModel) confirmQuit() (bool, tea.Cmd) {
+	if !m.streaming || !m.common.cfg.ConfirmQuitWhileStreaming || m.quitPrompt {
+		return false, nil
+	}
+	m.quitPrompt = true
+	return true, m.showStatusMessage(pagerStatusMessage{"Document still streaming in, press q again to quit", false})
+}
+
+// glamourStyle returns the style to render the current document with.
+func (m pagerModel) glamourStyle() string {
+	if m.style != "" {
+		return m.style
+	}
+	return m.common.cfg.GlamourStyle
+}
+
+// glamourWidth returns the word-wrap width for the current document.
+func (m pagerModel) glamourWidth() uint {
+	if m.width > 0 {
+		return m.width
+	}
+	return m.common.cfg.GlamourMaxWidth
+}
+
+// rememberOverrides stores the style and width chosen for the current
+// document, so they're used again next time it's opened, and re-renders.
+func (m *pagerModel) rememberOverrides(status string) tea.Cmd {
+	path := m.currentDocument.localPath
+	state := m.common.docs.get(path)
+	state.Style, state.Width = m.style, m.width
+	m.common.docs.set(path, state)
+
+	return tea.Batch(
+		m.showStatusMessage(pagerStatusMessage{status, false}),
+		renderWithGlamour(*m, m.currentDocument.Body),
+	)
+}
+
+// nextStyle returns the built-in style following the given one.
+func nextStyle(style string) string {
+	for i, s := range pagerStyles {
+		if s == style {
+			return pagerStyles[(i+1)%len(pagerStyles)]
+		}
+	}
+	return pagerStyles[0]
+}
+
+// documentDir returns the directory of the current document, which relative
+// paths in it are resolved against.
+func (m pagerModel) documentDir() string {
+	if m.currentDocument.localPath == "" {
+		return ""
+	}
+	return filepath.Dir(m.currentDocument.localPath)
+}
+
+// longLineLimit returns how many characters of a line are shown.
+func (m pagerModel) longLineLimit() int {
+	return longLineLimit * (1 + m.longLines)
+}
+
+// linesCut reports whether any line of the document is too long to be shown
+// in full.
+func (m pagerModel) linesCut() bool {
+	limit := m.longLineLimit()
+	for _, l := range strings.Split(m.currentDocument.Body, "\n") {
+		if len(l) > limit && utf8.RuneCountInString(l) > limit {
+			return true
+		}
+	}
+	return false
+}
+
+// applyTableScroll shifts the viewport after a virtualized table has been
+// re-rendered with a new window.
+func (m *pagerModel) applyTableScroll() {
+	if m.tableScroll != 0 {
+		m.viewport.SetYOffset(m.viewport.YOffset + m.tableScroll)
+		m.tableScroll = 0
+	}
 }
 
 func (m pagerModel) update(msg tea.Msg) (pagerModel, tea.Cmd) {
@@ -177,11 +550,85 @@ func (m pagerModel) update(msg tea.Msg) (pagerModel, tea.Cmd) {
 	)
 
 	switch msg := msg.(type) {
+	case gotoLineMsg:
+		m.gotoSourceLine(int(msg))
+		return m, tea.Batch(m.scrollTables(), m.syncViewport())
+
+	case tea.MouseMsg:
+		m.lastInteraction = time.Now()
+
 	case tea.KeyMsg:
+		m.lastInteraction = time.Now()
+		if m.commanding {
+			return m.updateCommand(msg)
+		}
+		if m.searching {
+			return m.updateSearch(msg)
+		}
+		if m.pendingRun != nil {
+			return m, m.confirmRun(msg)
+		}
+		if m.marking {
+			m.marking = false
+			if n, ok := bookmarkNumber(msg.String()); ok {
+				return m, m.toggleBookmark(n)
+			}
+			return m, nil
+		}
+		if m.showClipboard {
+			if cmd, ok := m.updateClipboard(msg); ok {
+				return m, cmd
+			}
+		}
+		if m.toc != nil {
+			if cmd, ok := m.updateTOC(msg); ok {
+				return m, cmd
+			}
+		}
+		if m.links != nil {
+			if cmd, ok := m.updateLinkMode(msg); ok {
+				return m, cmd
+			}
+		}
+		if m.preview != nil && msg.String() == keyEsc {
+			m.closePreview()
+			return m, nil
+		}
+		if n, ok := bookmarkNumber(msg.String()); ok {
+			return m, m.gotoBookmark(n)
+		}
+
 		switch msg.String() {
-		case "q", keyEsc:
+		case keyBookmark:
+			m.marking = true
+			return m, nil
+
+		case ":":
+			m.commanding = true
+			m.command.Reset()
+			return m, m.command.Focus()
+
+		case "/":
+			return m, m.startSearch(false)
+
+		case "n":
+			if m.searchQuery != "" {
+				return m, m.nextMatch(m.searchDirection())
+			}
+
+		case keyEsc:
+			if m.searchQuery != "" {
+				return m, m.clearSearch()
+			}
+			fallthrough
+
+		case "q":
 			if m.state != pagerStateBrowse {
 				m.state = pagerStateBrowse
+				if m.statusPinned {
+					m.statusPinned = false
+					m.setSize(m.screenWidth, m.screenHeight)
+				}
 				return m, nil
 			}
 		case "home", "g":
@@ -189,6 +636,10 @@ func (m pagerModel) update(msg tea.Msg) (pagerModel, tea.Cmd) {
 			if m.viewport.HighPerformanceRendering {
 				cmds = append(cmds, viewport.Sync(m.viewport))
 			}
+			if len(m.tableOffsets) > 0 {
+				m.tableOffsets = nil
+				return m, renderWithGlamour(m, m.currentDocument.Body)
+			}
 		case "end", "G":
 			m.viewport.GotoBottom()
 			if m.viewport.HighPerformanceRendering {
@@ -196,45 +647,253 @@ func (m pagerModel) update(msg tea.Msg) (pagerModel, tea.Cmd) {
 			}
 
 		case "e":
-			lineno := int(math.RoundToEven(float64(m.viewport.TotalLineCount()) * m.viewport.ScrollPercent()))
-			if m.viewport.AtTop() {
-				lineno = 0
-			}
+			lineno := m.sourceLine()
 			log.Info(
 				"opening editor",
 				"file", m.currentDocument.localPath,
-				"line", fmt.Sprintf("%d/%d", lineno, m.viewport.TotalLineCount()),
+				"line", lineno,
 			)
-			return m, openEditor(m.currentDocument.localPath, lineno)
+			return m, editMarkdown(&m.currentDocument, lineno)
+
+		case "y":
+			// Copy a "file.md:42" reference to the current position
+			ref := fmt.Sprintf("%s:%d", m.currentDocument.Note, max(1, m.sourceLine()))
+			m.copyText(ref)
+			cmds = append(cmds, m.showStatusMessage(pagerStatusMessage{"Copied " + ref, false}))
+
+		case "Y":
+			// Copy the code block at the top of the viewport, or the next one
+			code, ok := codeBlockAt(m.currentDocument.Body, m.topSourceLine())
+			if !ok {
+				cmds = append(cmds, m.showStatusMessage(pagerStatusMessage{"No code block here or below", false}))
+				break
+			}
+			m.copyText(code)
+			status := "Copied code block"
+			if n := strings.Count(code, "\n") + 1; n > 1 {
+				status += fmt.Sprintf(" (%d lines)", n)
+			}
+			cmds = append(cmds, m.showStatusMessage(pagerStatusMessage{status, false}))
 
 		case "c":
-			// Copy using OSC 52
-			termenv.Copy(m.currentDocument.Body)
-			// Copy using native system clipboard
-			_ = clipboard.WriteAll(m.currentDocument.Body)
+			m.copyText(m.currentDocument.Body)
 			cmds = append(cmds, m.showStatusMessage(pagerStatusMessage{"Copied contents", false}))
 
+		case "C":
+			m.showClipboard = !m.showClipboard
+			m.showNotifications = false
+			m.preview = nil
+			m.toc = nil
+			m.closeLinkMode()
+			m.setSize(m.screenWidth, m.screenHeight)
+
 		case "r":
 			return m, loadLocalMarkdown(&m.currentDocument)
 
-		case "?":
-			m.toggleHelp()
-			if m.viewport.HighPerformanceRendering {
-				cmds = append(cmds, viewport.Sync(m.viewport))
+		case keyToggleRead:
+			cmds = append(cmds, m.toggleRead())
+
+		case keyNextLink:
+			cmds = append(cmds, m.selectLink(1))
+		case keyPrevLink:
+			cmds = append(cmds, m.selectLink(-1))
+		case keyPreviewLink:
+			return m, m.togglePreview()
+		case keyLinkMode:
+			return m, m.toggleLinkMode()
+		case keyBack:
+			return m, m.travel(true)
+		case keyForward:
+			return m, m.travel(false)
+
+		case "]":
+			cmds = append(cmds, m.selectTask(1))
+		case "[":
+			cmds = append(cmds, m.selectTask(-1))
+		case " ":
+			tasks := findTasks(m.currentDocument.Body)
+			if m.currentDocument.converted && m.taskIndex >= 0 {
+				// the task's offset is in the converted document, not the file
+				return m, m.showStatusMessage(pagerStatusMessage{"Tasks of converted documents can't be toggled", true})
+			}
+			if m.taskIndex >= 0 && m.taskIndex < len(tasks) {
+				return m, toggleTask(m.currentDocument, tasks[m.taskIndex])
 			}
+
+		case "z":
+			if m.common.cfg.MaxCodeLines > 0 {
+				m.expandCode = !m.expandCode
+				return m, renderWithGlamour(m, m.currentDocument.Body)
+			}
+
+		case keyRun:
+			if utils.IsMarkdownFile(m.currentDocument.Note) {
+				return m, m.startRun()
+			}
+
+		case keyFold:
+			if utils.IsMarkdownFile(m.currentDocument.Note) {
+				return m, m.toggleFold()
+			}
+
+		case keySnap:
+			return m, m.toggleSnap()
+
+		case "up", "k", "down", "j":
+			if m.snap {
+				return m, m.snapScroll(msg.String() == "down" || msg.String() == "j")
+			}
+
+		case "L":
+			if m.linesCut() {
+				m.longLines++
+				return m, tea.Batch(
+					renderWithGlamour(m, m.currentDocument.Body),
+					m.showStatusMessage(pagerStatusMessage{fmt.Sprintf("Showing up to %d characters per line", m.longLineLimit()), false}),
+				)
+			}
+
+		case keyTOC:
+			return m, m.toggleTOC()
+
+		case "T":
+			if !m.dates.empty() {
+				m.absoluteDates = !m.absoluteDates
+				m.setContent(m.rendered)
+				if m.viewport.HighPerformanceRendering {
+					cmds = append(cmds, viewport.Sync(m.viewport))
+				}
+			}
+
+		case "p":
+			// Pin the status message, showing it in full until dismissed
+			if m.statusPinned {
+				m.statusPinned = false
+				m.state = pagerStateBrowse
+			} else if m.state == pagerStateStatusMessage {
+				m.statusPinned = true
+				m.statusMessageTimer.Stop()
+			}
+			m.setSize(m.screenWidth, m.screenHeight)
+
+		case "N":
+			// previous match while searching, otherwise the message log
+			if m.searchQuery != "" {
+				return m, m.nextMatch(-m.searchDirection())
+			}
+			m.showNotifications = !m.showNotifications
+			m.showClipboard = false
+			m.preview = nil
+			m.toc = nil
+			m.closeLinkMode()
+			m.setSize(m.screenWidth, m.screenHeight)
+
+		case "s":
+			m.style = nextStyle(m.glamourStyle())
+			return m, m.rememberOverrides("Style: " + m.style)
+		case "+", "-":
+			step := overrideWidthStep
+			if msg.String() == "-" {
+				step = -step
+			}
+			w := max(0, min(int(m.glamourWidth()), m.viewport.Width))
+			m.width = uint(max(minOverrideWidth, w+step))
+			return m, m.rememberOverrides(fmt.Sprintf("Width: %d", m.width))
+		case "=":
+			m.style, m.width = "", 0
+			return m, m.rememberOverrides("Using configured style and width")
 		}
 
 	// Glow has rendered the content
 	case contentRenderedMsg:
+		m.ahead = nil
+		m.streaming = false
+		m.rendered = string(msg)
 		m.setContent(string(msg))
+		m.applyTableScroll()
+		m.scrollToTarget()
 		if m.viewport.HighPerformanceRendering {
 			cmds = append(cmds, viewport.Sync(m.viewport))
 		}
 
-	// We've finished editing the document, potentially making changes. Let's
-	// retrieve the latest version of the document so that we display
-	// up-to-date contents.
+	// A chunk of a large document has been rendered
+	case renderAheadMsg:
+		if m.renders == nil || msg.gen != m.renders.Load() {
+			break // stale
+		}
+		first := msg.chunks != nil
+		if first {
+			m.ahead = newRenderAheadState(msg.gen, msg.chunks)
+			m.renderStart = time.Now()
+		} else if m.ahead == nil || m.ahead.gen != msg.gen {
+			break
+		}
+		wasStreaming := first || m.streaming
+		m.ahead.add(msg.index, msg.content, m.viewport.YOffset, m.viewport.Height)
+		m.streaming = m.ahead.streaming()
+		m.rendered = m.ahead.content()
+		m.setContent(m.rendered)
+		if first {
+			m.applyTableScroll()
+		}
+		if wasStreaming && !m.streaming {
+			m.scrollToTarget()
+			cmds = append(cmds, m.notifyRendered(m.renderStart))
+		}
+		cmds = append(cmds, m.renderAround())
+		if m.viewport.HighPerformanceRendering {
+			cmds = append(cmds, viewport.Sync(m.viewport))
+		}
+
+	// We've finished editing the document, potentially making changes. If
+	// the file changed, while it was edited or before, let's retrieve the
+	// latest version of the document so that we display up-to-date contents.
 	case editorFinishedMsg:
+		if msg.path != m.currentDocument.localPath {
+			return m, nil
+		}
+		if msg.changed || msg.stale {
+			cmds = append(cmds, loadLocalMarkdown(&m.currentDocument))
+		}
+		switch {
+		case msg.err != nil:
+			cmds = append(cmds, m.showStatusMessage(pagerStatusMessage{"Couldn't edit: " + msg.err.Error(), true}))
+		case msg.stale:
+			cmds = append(cmds, m.showStatusMessage(pagerStatusMessage{
+				m.currentDocument.Note + " was changed by another program while open", true,
+			}))
+		}
+		return m, tea.Batch(cmds...)
+
+	// A task has been toggled and written back to disk.
+	case codeRunMsg:
+		if m.runs == nil {
+			m.runs = make(map[int]codeRun)
+		}
+		m.runs[msg.block] = codeRun(msg)
+		return m, tea.Batch(
+			m.showStatusMessage(pagerStatusMessage{"Ran " + msg.command + ": " + msg.status, false}),
+			renderWithGlamour(m, m.currentDocument.Body),
+		)
+
+	case autoRefreshMsg:
+		return m, m.autoRefresh(msg)
+
+	case linkPreviewMsg:
+		if m.preview != nil && m.preview.link.dest == msg.dest {
+			m.preview.loading = false
+			m.preview.content, m.preview.err = msg.content, msg.err
+			m.setSize(m.screenWidth, m.screenHeight)
+		}
+		return m, nil
+
+	case linkOpenedMsg:
+		return m, m.linkOpened(msg)
+
+	case taskToggledMsg:
+		if msg.err != nil {
+			return m, m.showStatusMessage(pagerStatusMessage{"Couldn't update task: " + msg.err.Error(), true})
+		}
 		return m, loadLocalMarkdown(&m.currentDocument)
 
 	// We've received terminal dimensions, either for the first time or
@@ -243,15 +902,50 @@ func (m pagerModel) update(msg tea.Msg) (pagerModel, tea.Cmd) {
 		return m, renderWithGlamour(m, m.currentDocument.Body)
 
 	case statusMessageTimeoutMsg:
-		m.state = pagerStateBrowse
+		if !m.statusPinned {
+			m.state = pagerStateBrowse
+		}
 	}
 
 	m.viewport, cmd = m.viewport.Update(msg)
 	cmds = append(cmds, cmd)
 
+	switch msg.(type) {
+	case tea.KeyMsg, tea.MouseMsg:
+		cmds = append(cmds, m.scrollTables(), m.renderAround())
+	}
+	m.markReadAtEnd()
+
 	return m, tea.Batch(cmds...)
 }
 
+// updateCommand handles keys while a command is typed in.
+func (m pagerModel) updateCommand(msg tea.KeyMsg) (pagerModel, tea.Cmd) {
+	switch msg.String() {
+	case keyEsc:
+		m.commanding = false
+		m.command.Blur()
+		return m, nil
+	case keyEnter:
+		m.commanding = false
+		m.command.Blur()
+		status, ok := m.runCommand(m.command.Value())
+		var cmds []tea.Cmd
+		if status != "" {
+			cmds = append(cmds, m.showStatusMessage(pagerStatusMessage{status, !ok}))
+		}
+		if m.viewport.HighPerformanceRendering {
+			cmds = append(cmds, viewport.Sync(m.viewport))
+		}
+		cmds = append(cmds, m.scrollTables())
+		return m, tea.Batch(cmds...)
+	}
+
+	var cmd tea.Cmd
+	m.command, cmd = m.command.Update(msg)
+	return m, cmd
+}
+
 func (m pagerModel) View() string {
 	var b strings.Builder
 	fmt.Fprint(&b, m.viewport.View()+"\n")
@@ -259,8 +953,8 @@ func (m pagerModel) View() string {
 	// Footer
 	m.statusBarView(&b)
 
-	if m.showHelp {
-		fmt.Fprint(&b, "\n"+m.helpView())
+	if v := m.notificationsView(); v != "" {
+		fmt.Fprint(&b, "\n"+v)
 	}
 
 	return b.String()
@@ -273,10 +967,26 @@ func (m pagerModel) statusBarView(b *strings.Builder) {
 		percentToStringMagnitude float64 = 100.0
 	)
 
-	showStatusMessage := m.state == pagerStateStatusMessage
+	if m.commanding {
+		fmt.Fprint(b, truncate.String(m.command.View(), uint(max(0, m.screenWidth))))
+		return
+	}
+	if m.searching {
+		line := m.search.View()
+		if s := m.searchStatus(); s != "" {
+			line += "  " + statusBarNoteStyle(s)
+		}
+		fmt.Fprint(b, truncate.String(line, uint(max(0, m.screenWidth))))
+		return
+	}
+
+	showStatusMessage := m.state == pagerStateStatusMessage || m.marking || m.pendingRun != nil
 
-	// Logo
-	logo := glowLogoView()
+	// Logo, only shown for the document with the focus
+	logo := glowLogoView(m.common.cfg.Profile)
+	if m.blurred {
+		logo = ""
+	}
 
 	// Scroll percent
 	percent := math.Max(minPercent, math.Min(maxPercent, m.viewport.ScrollPercent()))
@@ -297,13 +1007,29 @@ func (m pagerModel) statusBarView(b *strings.Builder) {
 
 	// Note
 	var note string
-	if showStatusMessage {
+	if m.marking {
+		note = "Set or remove bookmark 1-9"
+	} else if m.pendingRun != nil {
+		note = m.runPrompt()
+	} else if showStatusMessage {
 		note = m.statusMessage
 	} else {
 		note = m.currentDocument.Note
+		if bc := m.currentDocument.breadcrumb; bc != "" {
+			note += " · " + bc
+		}
+		if meta := m.common.gitMetadata[m.currentDocument.localPath]; meta.author != "" {
+			note += " · " + meta.String()
+		}
+		if refreshed := m.refreshedNote(); refreshed != "" {
+			note += " · " + refreshed
+		}
+		if s := m.searchStatus(); s != "" {
+			note += " · " + s
+		}
 	}
 	note = truncate.StringWithTail(" "+note+" ", uint(max(0,
-		m.common.width-
+		m.screenWidth-
 			ansi.PrintableRuneWidth(logo)-
 			ansi.PrintableRuneWidth(scrollPercent)-
 			ansi.PrintableRuneWidth(helpNote),
@@ -316,7 +1042,7 @@ func (m pagerModel) statusBarView(b *strings.Builder) {
 
 	// Empty space
 	padding := max(0,
-		m.common.width-
+		m.screenWidth-
 			ansi.PrintableRuneWidth(logo)-
 			ansi.PrintableRuneWidth(note)-
 			ansi.PrintableRuneWidth(scrollPercent)-
@@ -338,37 +1064,37 @@ func (m pagerModel) statusBarView(b *strings.Builder) {
 	)
 }
 
-func (m pagerModel) helpView() (s string) {
-	col1 := []string{
-		"g/home  go to top",
-		"G/end   go to bottom",
-		"c       copy contents",
-		"e       edit this document",
-		"r       reload this document",
-		"esc     back to files",
-		"q       quit",
-	}
-
-	s += "\n"
-	s += "k/↑      up                  " + col1[0] + "\n"
-	s += "j/↓      down                " + col1[1] + "\n"
-	s += "b/pgup   page up             " + col1[2] + "\n"
-	s += "f/pgdn   page down           " + col1[3] + "\n"
-	s += "u        ½ page up           " + col1[4] + "\n"
-	s += "d        ½ page down         "
-
-	if len(col1) > 5 {
-		s += col1[5]
+// notificationsView returns the pinned status message in full, the log of
+// recent status messages, or the items copied so far, if any is shown.
+func (m pagerModel) notificationsView() string {
+	switch {
+	case m.preview != nil:
+		return m.panelView("\n" + m.preview.view(m.screenWidth-4))
+	case m.toc != nil:
+		return m.panelView("\n" + m.toc.view(m.screenWidth-4))
+	case m.links != nil:
+		return m.panelView("\n" + m.links.view(m.screenWidth-4))
+	case m.showClipboard:
+		return m.panelView("\n" + m.common.clipboard.view(m.screenWidth-4))
+	case m.showNotifications:
+		return m.panelView("\n" + m.common.notifications.view(notificationLogHeight))
+	case m.statusPinned:
+		return m.panelView("\n" + wordwrap.String(m.statusMessage, max(0, m.screenWidth-4)))
+	default:
+		return ""
 	}
+}
 
+// panelView styles content shown below the status bar.
+func (m pagerModel) panelView(s string) string {
 	s = indent(s, 2)
 
 	// Fill up empty cells with spaces for background coloring
-	if m.common.width > 0 {
+	if m.screenWidth > 0 {
 		lines := strings.Split(s, "\n")
 		for i := 0; i < len(lines); i++ {
-			l := runewidth.StringWidth(lines[i])
-			n := max(m.common.width-l, 0)
+			l := ansi.PrintableRuneWidth(lines[i])
+			n := max(m.screenWidth-l, 0)
 			lines[i] += strings.Repeat(" ", n)
 		}
 
@@ -381,6 +1107,19 @@ func (m pagerModel) helpView() (s string) {
 // COMMANDS
 
 func renderWithGlamour(m pagerModel, md string) tea.Cmd {
+	gen := renderGeneration.Add(1)
+	if m.renders != nil {
+		m.renders.Store(gen)
+	}
+	if config.GlamourEnabled && utils.IsMarkdownFile(m.currentDocument.Note) {
+		md = virtualizeTables(md, m.tableOffsets)
+		md = appendRunOutputs(md, m.runs)
+		md = foldCodeBlocks(md, m.folded, m.common.cfg.GuessCodeLanguage)
+	}
+	if shouldRenderAhead(m, md) {
+		return renderChunk(m, splitForRenderAhead(md, renderAheadLines), 0, gen, true)
+	}
+
 	return func() tea.Msg {
 		s, err := glamourRender(m, md)
 		if err != nil {
@@ -400,13 +1139,14 @@ func glamourRender(m pagerModel, markdown string) (string, error) {
 	}
 
 	isCode := !utils.IsMarkdownFile(m.currentDocument.Note)
-	width := max(0, min(int(m.common.cfg.GlamourMaxWidth), m.viewport.Width))
+	width := max(0, min(int(m.glamourWidth()), m.viewport.Width))
 	if isCode {
 		width = 0
 	}
 
 	options := []glamour.TermRendererOption{
-		utils.GlamourStyle(m.common.cfg.GlamourStyle, isCode),
+		glamour.WithColorProfile(lipgloss.ColorProfile()),
+		utils.GlamourStyle(m.glamourStyle(), isCode, m.common.cfg.Decorations),
 		glamour.WithWordWrap(width),
 	}
 
@@ -418,9 +1158,36 @@ func glamourRender(m pagerModel, markdown string) (string, error) {
 		return "", err
 	}
 
+	markdown = utils.TruncateLongLines(markdown, m.longLineLimit(), ", press L to load more")
 	if isCode {
 		markdown = utils.WrapCodeBlock(markdown, filepath.Ext(m.currentDocument.Note))
+	} else {
+		chunk := width
+		if chunk == 0 {
+			chunk = longWordLimit
+		}
+		markdown = utils.BreakLongWords(markdown, longWordLimit, chunk)
+		if m.common.cfg.Mermaid != "" {
+			markdown = utils.RenderMermaid(markdown, m.common.cfg.Mermaid, width)
+		}
+		if !m.expandCode {
+			markdown = utils.TruncateCodeBlocks(markdown, m.common.cfg.MaxCodeLines, ", press z to expand")
+		}
+		if m.common.cfg.GuessCodeLanguage {
+			markdown = utils.GuessCodeLanguages(markdown)
+		}
+		if m.common.cfg.EmbedWarnings {
+			markdown, _ = utils.MarkEmbeds(markdown, m.documentDir())
+		}
+	}
+	tabWidth := m.common.cfg.TabWidth
+	if tabWidth == 0 {
+		tabWidth = utils.EditorConfigTabWidth(m.currentDocument.localPath)
+	}
+	if !isCode {
+		markdown = utils.NormalizeListIndentation(markdown, tabWidth)
 	}
+	markdown = utils.ExpandTabs(markdown, tabWidth)
 
 	out, err := r.Render(markdown)
 	if err != nil {
@@ -429,6 +1196,8 @@ func glamourRender(m pagerModel, markdown string) (string, error) {
 
 	if isCode {
 		out = strings.TrimSpace(out)
+	} else if m.common.cfg.Centered && !m.common.cfg.ShowLineNumbers {
+		out = utils.Center(out, width, m.viewport.Width)
 	}
 
 	// trim lines
@@ -0,0 +1,86 @@
+package ui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const keyToggleRead = "R"
+
+// setRead marks a document as read or unread.
+func (s *documentStore) setRead(path string, read bool) {
+	state := s.get(path)
+	state.Read = read
+	s.set(path, state)
+}
+
+// readPaths returns the paths of the documents that have been read.
+func (s *documentStore) readPaths() map[string]bool {
+	read := make(map[string]bool)
+	for path, state := range s.docs {
+		if state.Read {
+			read[path] = true
+		}
+	}
+	return read
+}
+
+// readStatus returns the status message for a document marked as read or
+// unread.
+func readStatus(read bool) string {
+	if read {
+		return "Marked as read"
+	}
+	return "Marked as unread"
+}
+
+// markReadAtEnd marks the document as read once it has been scrolled to the
+// end, unless it has been marked as read or unread by hand.
+func (m *pagerModel) markReadAtEnd() {
+	if m.readToggled || m.streaming || m.rendered == "" || !m.viewport.AtBottom() {
+		return
+	}
+	if path := m.currentDocument.localPath; !m.common.docs.get(path).Read {
+		m.common.docs.setRead(path, true)
+	}
+}
+
+// toggleRead marks the document as read or unread by hand.
+func (m *pagerModel) toggleRead() tea.Cmd {
+	path := m.currentDocument.localPath
+	read := !m.common.docs.get(path).Read
+	m.common.docs.setRead(path, read)
+	m.readToggled = true
+	return m.showStatusMessage(pagerStatusMessage{readStatus(read), false})
+}
+
+// toggleRead marks the selected document as read or unread. When only
+// unread or read documents are listed, the list is updated.
+func (m *stashModel) toggleRead() tea.Cmd {
+	md := m.selectedMarkdown()
+	if md == nil {
+		return nil
+	}
+	read := !m.common.docs.get(md.localPath).Read
+	m.common.docs.setRead(md.localPath, read)
+
+	if m.filterApplied() {
+		index := m.markdownIndex()
+		if msg, ok := filterMarkdowns(*m)().(filteredMarkdownMsg); ok {
+			m.filteredMarkdowns, m.contentMatches = msg.mds, msg.matches
+		}
+		m.updatePagination()
+		m.selectIndex(min(index, len(m.getVisibleMarkdowns())-1))
+	}
+	return m.newStatusMessage(statusMessage{normalStatusMessage, readStatus(read)})
+}
+
+// unreadCount returns how many documents haven't been read yet.
+func (m stashModel) unreadCount() int {
+	n := 0
+	for _, md := range m.markdowns {
+		if !m.common.docs.get(md.localPath).Read {
+			n++
+		}
+	}
+	return n
+}
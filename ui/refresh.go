@@ -0,0 +1,125 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"slices"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// stashRefresh tracks a search for documents that updates the stash in
+// place, rather than starting over.
+type stashRefresh struct {
+	// documents already listed that were found again, by key
+	seen map[string]bool
+
+	// documents that weren't listed yet
+	added []*markdown
+}
+
+// key identifies a document across searches.
+func (m markdown) key() string {
+	if m.remotePath != "" {
+		return m.remotePath
+	}
+	return m.localPath
+}
+
+// startRefresh searches for documents again. What's found is compared with
+// the documents already listed once the search is done, so the cursor and
+// filter survive.
+func (m *stashModel) startRefresh() tea.Cmd {
+	if !m.loaded || m.refresh != nil {
+		return nil
+	}
+	m.loaded = false
+	m.refresh = &stashRefresh{seen: make(map[string]bool)}
+	return tea.Batch(m.spinner.Tick, findLocalFiles(*m.common))
+}
+
+// refreshFound records documents found while refreshing. Documents that were
+// already listed get their size and modification time updated.
+func (m *stashModel) refreshFound(mds ...*markdown) {
+	listed := make(map[string]*markdown, len(m.markdowns))
+	for _, md := range m.markdowns {
+		listed[md.key()] = md
+	}
+	for _, md := range mds {
+		if old, ok := listed[md.key()]; ok {
+			old.Modtime, old.Size = md.Modtime, md.Size
+			m.refresh.seen[md.key()] = true
+			continue
+		}
+		m.refresh.added = append(m.refresh.added, md)
+	}
+}
+
+// refreshEdited updates the size and modification time of a document that
+// was edited.
+func (m *stashModel) refreshEdited(path string) {
+	i := slices.IndexFunc(m.markdowns, func(md *markdown) bool { return md.localPath == path })
+	if i < 0 {
+		return
+	}
+	if info, err := os.Stat(path); err == nil {
+		m.markdowns[i].Modtime, m.markdowns[i].Size = info.ModTime(), info.Size()
+	}
+}
+
+// finishRefresh adds the documents that are new and removes those that are
+// gone, keeping the cursor on the selected document, and says what changed.
+func (m *stashModel) finishRefresh() tea.Cmd {
+	r := m.refresh
+	m.refresh = nil
+
+	var selected string
+	if md := m.selectedMarkdown(); md != nil {
+		selected = md.key()
+	}
+	index := m.markdownIndex()
+
+	before := len(m.markdowns)
+	m.markdowns = slices.DeleteFunc(m.markdowns, func(md *markdown) bool {
+		return !r.seen[md.key()]
+	})
+	removed := before - len(m.markdowns)
+	m.markdowns = append(m.markdowns, r.added...)
+	sortMarkdowns(m.markdowns, m.order)
+
+	if m.filterApplied() {
+		for _, md := range r.added {
+			md.buildFilterValue()
+		}
+		if msg, ok := filterMarkdowns(*m)().(filteredMarkdownMsg); ok {
+			m.filteredMarkdowns, m.contentMatches = msg.mds, msg.matches
+		}
+	}
+	m.updatePagination()
+
+	mds := m.getVisibleMarkdowns()
+	if i := slices.IndexFunc(mds, func(md *markdown) bool { return md.key() == selected }); i >= 0 {
+		index = i
+	}
+	m.selectIndex(min(index, len(mds)-1))
+
+	if len(r.added) == 0 && removed == 0 {
+		return m.newStatusMessage(statusMessage{normalStatusMessage, "No changes"})
+	}
+	return m.newStatusMessage(statusMessage{
+		normalStatusMessage,
+		fmt.Sprintf("+%d new, -%d removed", len(r.added), removed),
+	})
+}
+
+// selectIndex moves the cursor to a visible document, turning the page if
+// need be.
+func (m *stashModel) selectIndex(i int) {
+	if i < 0 || m.paginator().PerPage == 0 {
+		m.paginator().Page = 0
+		m.setCursor(0)
+		return
+	}
+	m.paginator().Page = i / m.paginator().PerPage
+	m.setCursor(i % m.paginator().PerPage)
+}
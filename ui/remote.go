@@ -0,0 +1,536 @@
+package ui
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glow/v2/bundle"
+	"github.com/charmbracelet/glow/v2/markup"
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/charmbracelet/log"
+	gap "github.com/muesli/go-app-paths"
+)
+
+// remoteFile is a markdown document found on a remote stash.
+type remoteFile struct {
+	path    string // relative to the remote root
+	modtime time.Time
+	size    int64
+}
+
+// remoteBackend lists, fetches and uploads documents below a remote root.
+type remoteBackend interface {
+	list() ([]remoteFile, error)
+	fetch(path string) ([]byte, error)
+	upload(path string, data []byte) error
+}
+
+// newRemoteBackend returns a backend for a remote root such as
+// sftp://host/docs, s3://bucket/prefix or webdavs://host/dav/docs, or for a
+// documentation bundle.
+func newRemoteBackend(root string) (remoteBackend, error) {
+	if bundle.IsBundle(root) {
+		b, err := openBundle(root)
+		if err != nil {
+			return nil, err
+		}
+		return bundleBackend{b}, nil
+	}
+
+	u, err := url.Parse(root)
+	if err != nil {
+		return nil, err
+	}
+
+	switch u.Scheme {
+	case "ssh", "sftp":
+		host := u.Host
+		if u.User != nil {
+			host = u.User.Username() + "@" + host
+		}
+		if strings.HasPrefix(host, "-") {
+			return nil, fmt.Errorf("invalid ssh host: %s", host)
+		}
+		return sshBackend{host: host, root: u.Path}, nil
+	case "s3":
+		return s3Backend{bucket: u.Host, prefix: strings.TrimPrefix(u.Path, "/")}, nil
+	case "webdav", "webdavs", "http", "https":
+		u.Scheme = strings.Replace(u.Scheme, "webdav", "http", 1)
+		if !strings.HasSuffix(u.Path, "/") {
+			u.Path += "/"
+		}
+		return webdavBackend{root: u}, nil
+	default:
+		return nil, fmt.Errorf("unsupported remote stash: %s", root)
+	}
+}
+
+// isMarkdownPath reports whether a remote path has a markdown extension.
+func isMarkdownPath(p string) bool {
+	for _, v := range MarkdownExtensions {
+		if ok, _ := path.Match(v, strings.ToLower(path.Base(p))); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// safeRemotePath reports whether a path listed by a remote stays below its
+// root, so that it can't be used to write outside of the cache.
+func safeRemotePath(p string) bool {
+	if p == "" || strings.HasPrefix(p, "/") || strings.HasPrefix(p, `\`) || filepath.IsAbs(p) {
+		return false
+	}
+	for _, seg := range strings.FieldsFunc(p, func(r rune) bool { return r == '/' || r == '\\' }) {
+		if seg == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+// remoteCachePath returns where a remote document is cached locally.
+func remoteCachePath(root, p string) (string, error) {
+	if !safeRemotePath(p) {
+		return "", fmt.Errorf("refusing remote path outside of its root: %s", p)
+	}
+	dir, err := gap.NewScope(gap.User, "glow").CacheDir()
+	if err != nil {
+		return "", err
+	}
+	u, err := url.Parse(root)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "remote", u.Scheme, u.Host, filepath.FromSlash(path.Join(u.Path, p))), nil
+}
+
+// SSH
+
+// sshBackend uses the ssh command, so the user's ssh config and agent apply.
+type sshBackend struct {
+	host string
+	root string
+}
+
+func (b sshBackend) run(stdin io.Reader, script string) ([]byte, error) {
+	cmd := exec.Command("ssh", "-o", "BatchMode=yes", "--", b.host, script) //nolint:gosec
+	cmd.Stdin = stdin
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("ssh %s: %w: %s", b.host, err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// sshListScript lists the files below the working directory with their
+// modification times and sizes, as "seconds size ./path" lines. GNU find
+// can print those itself; elsewhere stat does, which takes different flags
+// on BSD and macOS than on busybox.
+const sshListScript = `if find . -prune -printf '' >/dev/null 2>&1; then
+	find . -type f -printf '%T@ %s %p\n'
+elif stat -f %m . >/dev/null 2>&1; then
+	find . -type f -exec stat -f '%m %z %N' {} +
+else
+	find . -type f -exec stat -c '%Y %s %n' {} +
+fi`
+
+func (b sshBackend) list() ([]remoteFile, error) {
+	root := b.root
+	if root == "" {
+		root = "."
+	}
+	out, err := b.run(nil, "cd -- "+shellQuote(root)+" && "+sshListScript)
+	if err != nil {
+		return nil, err
+	}
+	return parseSSHList(out)
+}
+
+// parseSSHList reads the markdown files of the output of sshListScript.
+func parseSSHList(out []byte) ([]remoteFile, error) {
+	var files []remoteFile
+	s := bufio.NewScanner(bytes.NewReader(out))
+	for s.Scan() {
+		f := strings.SplitN(s.Text(), " ", 3) //nolint:mnd
+		if len(f) < 3 {                       //nolint:mnd
+			continue
+		}
+		p := strings.TrimPrefix(f[2], "./")
+		if !isMarkdownPath(p) || !safeRemotePath(p) {
+			continue
+		}
+		secs, _ := strconv.ParseFloat(f[0], 64)
+		size, _ := strconv.ParseInt(f[1], 10, 64)
+		files = append(files, remoteFile{path: p, modtime: time.Unix(int64(secs), 0), size: size})
+	}
+	return files, s.Err()
+}
+
+func (b sshBackend) fetch(p string) ([]byte, error) {
+	return b.run(nil, "cat -- "+shellQuote(path.Join(b.root, p)))
+}
+
+func (b sshBackend) upload(p string, data []byte) error {
+	_, err := b.run(bytes.NewReader(data), "cat > "+shellQuote(path.Join(b.root, p)))
+	return err
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// S3
+
+// s3Backend uses the aws command, so the user's credentials and profiles
+// apply.
+type s3Backend struct {
+	bucket string
+	prefix string
+}
+
+func (b s3Backend) uri(p string) string {
+	return "s3://" + path.Join(b.bucket, b.prefix, p)
+}
+
+func (b s3Backend) run(stdin io.Reader, args ...string) ([]byte, error) {
+	cmd := exec.Command("aws", append([]string{"s3"}, args...)...) //nolint:gosec
+	cmd.Stdin = stdin
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("aws s3: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+func (b s3Backend) list() ([]remoteFile, error) {
+	out, err := b.run(nil, "ls", "--recursive", "s3://"+path.Join(b.bucket, b.prefix)+"/")
+	if err != nil {
+		return nil, err
+	}
+	return parseS3List(out, b.prefix)
+}
+
+// parseS3List reads the markdown files of the output of aws s3 ls, with
+// paths relative to the prefix listed. Keys are free-form, so those that
+// would lead out of the prefix are left out.
+func parseS3List(out []byte, prefix string) ([]remoteFile, error) {
+	// Lines look like: 2024-01-02 15:04:05       1234 prefix/file.md
+	var files []remoteFile
+	s := bufio.NewScanner(bytes.NewReader(out))
+	for s.Scan() {
+		f := strings.Fields(s.Text())
+		if len(f) < 4 { //nolint:mnd
+			continue
+		}
+		key := strings.Join(f[3:], " ")
+		rel := strings.TrimPrefix(strings.TrimPrefix(key, prefix), "/")
+		if !isMarkdownPath(key) || !safeRemotePath(rel) {
+			continue
+		}
+		t, _ := time.ParseInLocation("2006-01-02 15:04:05", f[0]+" "+f[1], time.Local)
+		size, _ := strconv.ParseInt(f[2], 10, 64)
+		files = append(files, remoteFile{path: rel, modtime: t, size: size})
+	}
+	return files, s.Err()
+}
+
+func (b s3Backend) fetch(p string) ([]byte, error) {
+	return b.run(nil, "cp", b.uri(p), "-")
+}
+
+func (b s3Backend) upload(p string, data []byte) error {
+	_, err := b.run(bytes.NewReader(data), "cp", "-", b.uri(p))
+	return err
+}
+
+// WebDAV
+
+type webdavBackend struct {
+	root *url.URL
+}
+
+type davMultistatus struct {
+	Responses []struct {
+		Href     string `xml:"href"`
+		Propstat []struct {
+			Prop struct {
+				LastModified string `xml:"getlastmodified"`
+				Length       int64  `xml:"getcontentlength"`
+				ResourceType struct {
+					Collection *struct{} `xml:"collection"`
+				} `xml:"resourcetype"`
+			} `xml:"prop"`
+		} `xml:"propstat"`
+	} `xml:"response"`
+}
+
+func (b webdavBackend) do(method string, u *url.URL, body io.Reader, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequest(method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if u.User != nil {
+		pass, _ := u.User.Password()
+		req.SetBasicAuth(u.User.Username(), pass)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode >= http.StatusBadRequest {
+		_ = res.Body.Close()
+		return nil, fmt.Errorf("%s %s: %s", method, u.Redacted(), res.Status)
+	}
+	return res, nil
+}
+
+func (b webdavBackend) list() ([]remoteFile, error) {
+	var files []remoteFile
+	dirs := []string{b.root.Path}
+
+	// Walk collections one level at a time, as many servers refuse
+	// "Depth: infinity".
+	for len(dirs) > 0 {
+		dir := dirs[0]
+		dirs = dirs[1:]
+
+		u := *b.root
+		u.Path = dir
+		res, err := b.do("PROPFIND", &u, nil, http.Header{"Depth": {"1"}})
+		if err != nil {
+			return nil, err
+		}
+		var ms davMultistatus
+		err = xml.NewDecoder(res.Body).Decode(&ms)
+		_ = res.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, r := range ms.Responses {
+			href, err := url.PathUnescape(r.Href)
+			if err != nil {
+				continue
+			}
+			if h, err := url.Parse(href); err == nil {
+				href = h.Path
+			}
+			if strings.TrimSuffix(href, "/") == strings.TrimSuffix(dir, "/") || len(r.Propstat) == 0 {
+				continue
+			}
+
+			prop := r.Propstat[0].Prop
+			if prop.ResourceType.Collection != nil {
+				dirs = append(dirs, href)
+				continue
+			}
+			rel, ok := strings.CutPrefix(href, b.root.Path)
+			if !isMarkdownPath(href) || !ok || !safeRemotePath(rel) {
+				continue
+			}
+			t, _ := http.ParseTime(prop.LastModified)
+			files = append(files, remoteFile{
+				path:    rel,
+				modtime: t,
+				size:    prop.Length,
+			})
+		}
+	}
+	return files, nil
+}
+
+func (b webdavBackend) fetch(p string) ([]byte, error) {
+	res, err := b.do(http.MethodGet, b.root.JoinPath(p), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close() //nolint:errcheck
+	return io.ReadAll(res.Body)
+}
+
+func (b webdavBackend) upload(p string, data []byte) error {
+	res, err := b.do(http.MethodPut, b.root.JoinPath(p), bytes.NewReader(data), nil)
+	if err != nil {
+		return err
+	}
+	return res.Body.Close()
+}
+
+// Bundles
+
+var (
+	bundlesMu sync.Mutex
+	bundles   = map[string]*bundle.Bundle{}
+)
+
+// openBundle opens a bundle once and keeps it open, as its index is needed
+// on every search.
+func openBundle(p string) (*bundle.Bundle, error) {
+	bundlesMu.Lock()
+	defer bundlesMu.Unlock()
+	if b, ok := bundles[p]; ok {
+		return b, nil
+	}
+	b, err := bundle.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	bundles[p] = b
+	return b, nil
+}
+
+// bundleBackend reads documents from a bundle, which is read-only.
+type bundleBackend struct {
+	*bundle.Bundle
+}
+
+func (b bundleBackend) list() ([]remoteFile, error) {
+	files := make([]remoteFile, 0, len(b.Files()))
+	for _, f := range b.Files() {
+		files = append(files, remoteFile{path: f.Path, modtime: f.Modtime, size: f.Size})
+	}
+	return files, nil
+}
+
+func (b bundleBackend) fetch(p string) ([]byte, error) {
+	return b.ReadFile(p)
+}
+
+func (b bundleBackend) upload(string, []byte) error {
+	return errors.New("bundles are read-only")
+}
+
+// searchBundle returns the documents of the bundle being browsed that
+// contain all words of the query, using the bundle's search index, along
+// with where in them the query was found.
+func searchBundle(root string, mds []*markdown, query string) ([]*markdown, map[*markdown]contentMatch) {
+	if !bundle.IsBundle(root) {
+		return nil, nil
+	}
+	b, err := openBundle(root)
+	if err != nil {
+		return nil, nil
+	}
+	found := map[string]bool{}
+	for _, p := range b.Search(query) {
+		found[p] = true
+	}
+	var (
+		matches []*markdown
+		where   = map[*markdown]contentMatch{}
+	)
+	for _, md := range mds {
+		if !found[md.remotePath] {
+			continue
+		}
+		matches = append(matches, md)
+		if data, err := b.ReadFile(md.remotePath); err == nil {
+			if c, ok := findContentMatch(string(markup.ToMarkdown(md.Note, data)), query); ok {
+				where[md] = c
+			}
+		}
+	}
+	return matches, where
+}
+
+// COMMANDS
+
+type foundRemoteFilesMsg []*markdown
+
+func findRemoteFiles(m commonModel) tea.Cmd {
+	return func() tea.Msg {
+		log.Info("findRemoteFiles", "root", m.cfg.Remote)
+		backend, err := newRemoteBackend(m.cfg.Remote)
+		if err != nil {
+			return errMsg{err}
+		}
+
+		files, err := backend.list()
+		if err != nil {
+			log.Error("error listing remote files", "error", err)
+			return errMsg{err}
+		}
+
+		mds := make([]*markdown, 0, len(files))
+		for _, f := range files {
+			cachePath, err := remoteCachePath(m.cfg.Remote, f.path)
+			if err != nil {
+				return errMsg{err}
+			}
+			mds = append(mds, &markdown{
+				localPath:  cachePath,
+				remotePath: f.path,
+				Note:       f.path,
+				Modtime:    f.modtime,
+				Size:       f.size,
+			})
+		}
+		return foundRemoteFilesMsg(mds)
+	}
+}
+
+// fetchRemoteMarkdown downloads a remote document into its local cache
+// path. If the remote can't be reached a previously cached copy is used.
+func fetchRemoteMarkdown(md *markdown) error {
+	if unsynced.has(md.localPath) {
+		log.Warn("keeping local copy with edits not uploaded yet", "path", md.remotePath)
+		return nil
+	}
+	backend, err := newRemoteBackend(config.Remote)
+	if err != nil {
+		return err
+	}
+
+	data, err := backend.fetch(md.remotePath)
+	if err != nil {
+		if _, statErr := os.Stat(md.localPath); statErr == nil {
+			log.Warn("using cached copy of remote file", "path", md.remotePath, "error", err)
+			return nil
+		}
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(md.localPath), 0o700); err != nil {
+		return err
+	}
+	return utils.WriteFileAtomic(md.localPath, data, 0o600)
+}
+
+// uploadRemoteMarkdown uploads the local copy of a remote document.
+func uploadRemoteMarkdown(md *markdown) error {
+	backend, err := newRemoteBackend(config.Remote)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(md.localPath)
+	if err != nil {
+		return err
+	}
+	if err := backend.upload(md.remotePath, data); err != nil {
+		return errors.New("could not upload changes: " + err.Error())
+	}
+	return nil
+}
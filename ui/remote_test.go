@@ -0,0 +1,59 @@
+package ui
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSSHList(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		out  string
+	}{
+		{"gnu find", "1700000000.5 12 ./notes/a.md\n1700000000.0 3 ./b.txt\n"},
+		{"stat", "1700000000 12 ./notes/a.md\n1700000000 3 ./b.txt\n"},
+	} {
+		files, err := parseSSHList([]byte(tc.out + "1700000000 1 ./../c.md\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := remoteFile{path: "notes/a.md", modtime: time.Unix(1700000000, 0), size: 12}
+		if len(files) != 1 || files[0] != want {
+			t.Errorf("%s: got %+v, want [%+v]", tc.name, files, want)
+		}
+	}
+}
+
+func TestParseS3List(t *testing.T) {
+	out := "2024-01-02 15:04:05       1234 docs/my notes.md\n" +
+		"2024-01-02 15:04:05         10 docs/../../escape.md\n" +
+		"2024-01-02 15:04:05         10 docs/image.png\n"
+	files, err := parseS3List([]byte(out), "docs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0].path != "my notes.md" || files[0].size != 1234 {
+		t.Errorf("got %+v, want only my notes.md", files)
+	}
+}
+
+func TestRemoteCachePath(t *testing.T) {
+	for _, tc := range []struct {
+		path string
+		ok   bool
+	}{
+		{"a.md", true},
+		{"notes/a.md", true},
+		{"notes/..md", true},
+		{"../a.md", false},
+		{"notes/../../a.md", false},
+		{`notes\..\..\a.md`, false},
+		{"/etc/a.md", false},
+		{"", false},
+	} {
+		_, err := remoteCachePath("s3://bucket/docs", tc.path)
+		if got := err == nil; got != tc.ok {
+			t.Errorf("remoteCachePath(%q): got ok %v, want %v (%v)", tc.path, got, tc.ok, err)
+		}
+	}
+}
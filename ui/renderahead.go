@@ -0,0 +1,247 @@
+package ui
+
+import (
+	"regexp"
+	"strings"
+	"sync/atomic"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/charmbracelet/log"
+)
+
+// Documents longer than this many source lines are rendered progressively:
+// the first chunk is shown right away while the rest is rendered ahead of
+// the viewport in the background.
+const renderAheadLines = 1000
+
+// At most this many chunks of a progressively rendered document are kept
+// rendered. Beyond that, those farthest from the viewport are evicted, and
+// rendered again once it gets within renderAheadScreens screens of them.
+const (
+	renderAheadCached  = 16
+	renderAheadScreens = 3
+)
+
+// Incremented for every render so chunks of a stale render (e.g. before a
+// resize or reload) can be told apart and dropped.
+var renderGeneration atomic.Uint64
+
+// renderAheadMsg carries a rendered chunk of a large document. The first one
+// rendered also carries all of the document's chunks.
+type renderAheadMsg struct {
+	gen     uint64
+	index   int
+	content string
+	chunks  []string
+}
+
+// renderAheadState keeps track of the chunks of a progressively rendered
+// document. Evicted chunks are stood in for by as many blank lines as they
+// took up, so the rest of the document stays where it is.
+type renderAheadState struct {
+	gen      uint64
+	chunks   []string
+	rendered []string // "" until rendered, and once evicted
+	heights  []int    // -1 until rendered
+	pending  int      // chunk being rendered, or -1
+}
+
+func newRenderAheadState(gen uint64, chunks []string) *renderAheadState {
+	a := &renderAheadState{
+		gen:      gen,
+		chunks:   chunks,
+		rendered: make([]string, len(chunks)),
+		heights:  make([]int, len(chunks)),
+		pending:  -1,
+	}
+	for i := range a.heights {
+		a.heights[i] = -1
+	}
+	return a
+}
+
+// content returns the rendered document, up to the first chunk that hasn't
+// been rendered yet.
+func (a *renderAheadState) content() string {
+	var b strings.Builder
+	for i, h := range a.heights {
+		if h < 0 {
+			break
+		}
+		if a.rendered[i] == "" {
+			b.WriteString(strings.Repeat("\n", h))
+			continue
+		}
+		b.WriteString(a.rendered[i])
+	}
+	return b.String()
+}
+
+// streaming reports whether some chunks haven't been rendered even once.
+func (a *renderAheadState) streaming() bool {
+	return a.heights[len(a.heights)-1] < 0
+}
+
+// window returns the range of chunks within renderAheadScreens screens of
+// the given lines.
+func (a *renderAheadState) window(top, height int) (int, int) {
+	from, to := top-renderAheadScreens*height, top+(renderAheadScreens+1)*height
+	first, last := -1, -1
+	line := 0
+	for i, h := range a.heights {
+		if h < 0 {
+			break
+		}
+		if line+h > from && line < to {
+			if first < 0 {
+				first = i
+			}
+			last = i
+		}
+		line += h
+	}
+	return first, last
+}
+
+// add stores a rendered chunk and evicts the ones farthest from the
+// viewport if too many are kept.
+func (a *renderAheadState) add(i int, s string, top, height int) {
+	a.pending = -1
+	a.rendered[i] = s
+	a.heights[i] = strings.Count(s, "\n")
+
+	first, last := a.window(top, height)
+	for kept := a.kept(); kept > renderAheadCached; kept-- {
+		far, dist := -1, 0
+		for j, r := range a.rendered {
+			if r == "" || (j >= first && j <= last) {
+				continue
+			}
+			if d := max(first-j, j-last); d > dist {
+				far, dist = j, d
+			}
+		}
+		if far < 0 {
+			return
+		}
+		a.rendered[far] = ""
+	}
+}
+
+func (a *renderAheadState) kept() int {
+	n := 0
+	for _, r := range a.rendered {
+		if r != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// next returns the chunk to render next: an evicted one near the viewport,
+// or else the first one that hasn't been rendered yet. It returns -1 if
+// there's none, or one is being rendered already.
+func (a *renderAheadState) next(top, height int) int {
+	if a.pending >= 0 {
+		return -1
+	}
+	if first, last := a.window(top, height); first >= 0 {
+		for i := first; i <= last; i++ {
+			if a.rendered[i] == "" {
+				return i
+			}
+		}
+	}
+	for i, h := range a.heights {
+		if h < 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+var linkDefinitionPattern = regexp.MustCompile(`(?m)^ {0,3}\[[^\]]+\]:\s*\S+.*$`)
+
+// splitForRenderAhead splits a markdown document into chunks of at least n
+// lines, breaking only at blank lines outside of code fences. Link reference
+// definitions are appended to every chunk so references keep resolving.
+func splitForRenderAhead(md string, n int) []string {
+	defs := strings.Join(linkDefinitionPattern.FindAllString(md, -1), "\n")
+
+	var (
+		chunks []string
+		b      strings.Builder
+		lines  int
+		fence  utils.CodeFence
+	)
+	flush := func() {
+		if b.Len() == 0 {
+			return
+		}
+		chunk := b.String()
+		if defs != "" {
+			chunk += "\n\n" + defs + "\n"
+		}
+		chunks = append(chunks, chunk)
+		b.Reset()
+		lines = 0
+	}
+
+	for _, l := range strings.SplitAfter(md, "\n") {
+		if !fence.Scan(l) && strings.TrimSpace(l) == "" && lines >= n {
+			flush()
+			continue
+		}
+		b.WriteString(l)
+		lines++
+	}
+	flush()
+	return chunks
+}
+
+// renderChunk renders a chunk of a large document in the background. The
+// first one is handed to the pager along with all of the chunks, which it
+// then keeps rendering as needed.
+func renderChunk(m pagerModel, chunks []string, i int, gen uint64, first bool) tea.Cmd {
+	return func() tea.Msg {
+		s, err := glamourRender(m, chunks[i])
+		if err != nil {
+			log.Error("error rendering with Glamour", "error", err)
+			return errMsg{err}
+		}
+		if i > 0 {
+			// glamour pads every render with a blank line at the top
+			s = strings.TrimPrefix(s, "\n")
+		}
+		msg := renderAheadMsg{gen: gen, index: i, content: s}
+		if first {
+			msg.chunks = chunks
+		}
+		return msg
+	}
+}
+
+// renderAround renders the next chunk of a large document that's needed
+// around the viewport, if any.
+func (m *pagerModel) renderAround() tea.Cmd {
+	if m.ahead == nil {
+		return nil
+	}
+	i := m.ahead.next(m.viewport.YOffset, m.viewport.Height)
+	if i < 0 {
+		return nil
+	}
+	m.ahead.pending = i
+	return renderChunk(*m, m.ahead.chunks, i, m.ahead.gen, false)
+}
+
+// shouldRenderAhead reports whether a document is large enough to render
+// progressively. Code files and line numbers need a single render so line
+// numbering stays continuous.
+func shouldRenderAhead(m pagerModel, md string) bool {
+	return config.GlamourEnabled &&
+		!m.common.cfg.ShowLineNumbers &&
+		utils.IsMarkdownFile(m.currentDocument.Note) &&
+		strings.Count(md, "\n") > renderAheadLines*2
+}
@@ -0,0 +1,46 @@
+package ui
+
+import (
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRenderAheadEviction(t *testing.T) {
+	const chunks, lines = 40, 10
+	a := newRenderAheadState(1, make([]string, chunks))
+	chunk := strings.Repeat("x\n", lines)
+	for i := 0; i < chunks; i++ {
+		if got := a.next(0, 5); got != i {
+			t.Fatalf("next() = %d, want %d", got, i)
+		}
+		a.pending = i
+		a.add(i, chunk, 0, 5)
+	}
+	if a.streaming() {
+		t.Error("still streaming with all chunks rendered")
+	}
+	if got := a.kept(); got != renderAheadCached {
+		t.Errorf("kept %d chunks, want %d", got, renderAheadCached)
+	}
+	if a.rendered[0] == "" || a.rendered[chunks-1] != "" {
+		t.Error("kept chunks far from the viewport over those near it")
+	}
+	if got := strings.Count(a.content(), "\n"); got != chunks*lines {
+		t.Errorf("content has %d lines, want %d", got, chunks*lines)
+	}
+
+	// scrolling to the end renders the chunks there again
+	if got := a.next((chunks-1)*lines, 5); got < chunks-renderAheadScreens || a.rendered[got] != "" {
+		t.Errorf("next() at the end = %d, want an evicted chunk near the end", got)
+	}
+}
+
+func TestRenderAheadDropsStaleChunks(t *testing.T) {
+	m := pagerModel{renders: &atomic.Uint64{}}
+	m.renders.Store(2)
+	m, _ = m.update(renderAheadMsg{gen: 1, content: "old\n", chunks: []string{"old"}})
+	if m.ahead != nil || m.rendered != "" {
+		t.Error("first chunk of a stale render was shown")
+	}
+}
@@ -0,0 +1,170 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Code blocks can be run with x, if a runner is configured for their
+// language. Nothing is run without a runner in the config file, and each run
+// is confirmed first. The output is shown below the block.
+
+const keyRun = "x"
+
+// At most this much of the output of a run is kept, however much the
+// commands section of the config file lets commands write.
+const runMaxOutput = 64 << 10 // 64 KiB
+
+// codeRun is a code block about to be run, or that has been.
+type codeRun struct {
+	block   int
+	command string
+	output  string
+	status  string
+}
+
+type codeRunMsg codeRun
+
+// startRun asks for confirmation to run the code block in view.
+func (m *pagerModel) startRun() tea.Cmd {
+	i, ok := m.codeBlockInView()
+	if !ok {
+		return m.showStatusMessage(pagerStatusMessage{"No code block in view", false})
+	}
+	b := findCodeBlocks(m.currentDocument.Body)[i]
+	if b.lang == "" {
+		return m.showStatusMessage(pagerStatusMessage{"Code block doesn't name its language", false})
+	}
+	command := m.common.cfg.CodeRunners[strings.ToLower(b.lang)]
+	if command == "" {
+		return m.showStatusMessage(pagerStatusMessage{"No runner configured for " + b.lang, false})
+	}
+	m.pendingRun = &codeRun{block: i, command: command}
+	return nil
+}
+
+// confirmRun runs the code block awaiting confirmation if the key is y.
+func (m *pagerModel) confirmRun(msg tea.KeyMsg) tea.Cmd {
+	run := *m.pendingRun
+	m.pendingRun = nil
+	if msg.String() != "y" {
+		return nil
+	}
+	code := strings.Join(findCodeBlocks(m.currentDocument.Body)[run.block].code, "\n")
+	return tea.Batch(
+		m.showStatusMessage(pagerStatusMessage{"Running " + run.command + "…", false}),
+		runCode(run, code, m.documentDir(), m.common.cfg.Commands),
+	)
+}
+
+// runPrompt is shown in the status bar while a run awaits confirmation.
+func (m pagerModel) runPrompt() string {
+	b := findCodeBlocks(m.currentDocument.Body)[m.pendingRun.block]
+	return fmt.Sprintf("Run this %s block with %q? y/n", b.lang, m.pendingRun.command)
+}
+
+// runCode runs a code block with a runner command, if the command policy
+// allows it, and within its limits. The code is passed as the last argument
+// if the command ends in -c, as with "bash -c", and on stdin otherwise, as
+// with "python -".
+func runCode(run codeRun, code, dir string, policy utils.CommandPolicy) tea.Cmd {
+	return func() tea.Msg {
+		args := strings.Fields(run.command)
+		if len(args) == 0 {
+			run.status = "no command given"
+			return codeRunMsg(run)
+		}
+		if err := policy.Check(args[0]); err != nil {
+			run.status = err.Error()
+			return codeRunMsg(run)
+		}
+		var stdin string
+		if args[len(args)-1] == "-c" {
+			args = append(args, code)
+		} else {
+			stdin = code
+		}
+
+		ctx, cancel := policy.Context()
+		defer cancel()
+		cmd := exec.CommandContext(ctx, args[0], args[1:]...) //nolint:gosec
+		cmd.Dir = dir
+		cmd.Env = policy.Environ()
+		cmd.WaitDelay = utils.CommandWaitDelay
+		cmd.Stdin = strings.NewReader(stdin)
+		limit := runMaxOutput
+		if policy.MaxOutput > 0 {
+			limit = min(limit, policy.MaxOutput)
+		}
+		out := &utils.LimitedBuffer{Max: limit, Exceeded: cancel}
+		cmd.Stdout = out
+		cmd.Stderr = out
+
+		start := time.Now()
+		err := cmd.Run()
+		took := time.Since(start).Round(time.Millisecond)
+		switch {
+		case out.Over:
+			run.status = fmt.Sprintf("stopped after writing more than %d bytes", limit)
+		case errors.Is(ctx.Err(), context.DeadlineExceeded):
+			run.status = fmt.Sprintf("stopped after %s", policy.Timeout)
+		case err != nil && cmd.ProcessState == nil:
+			run.status = err.Error()
+		default:
+			run.status = fmt.Sprintf("exit status %d, %s", cmd.ProcessState.ExitCode(), took)
+		}
+
+		output := out.String()
+		if out.Over {
+			output += "\n…"
+		}
+		run.output = ansi.Strip(output)
+		return codeRunMsg(run)
+	}
+}
+
+// appendRunOutputs puts the output of code blocks that have been run below
+// them, as indented code so they're not mistaken for code blocks of the
+// document.
+func appendRunOutputs(md string, runs map[int]codeRun) string {
+	if len(runs) == 0 {
+		return md
+	}
+
+	var (
+		out   []string
+		fence utils.CodeFence
+		n     = -1
+	)
+	for _, l := range strings.Split(md, "\n") {
+		wasOpen := fence.Open()
+		fence.Scan(l)
+		out = append(out, l)
+		if !wasOpen && fence.Open() {
+			n++
+		}
+		run, ok := runs[n]
+		if !ok || !wasOpen || fence.Open() {
+			continue
+		}
+
+		out = append(out, "", fmt.Sprintf("> **Output of `%s`** · %s", run.command, run.status), "")
+		output := strings.TrimRight(run.output, "\n")
+		if output == "" {
+			output = "(no output)"
+		}
+		for _, o := range strings.Split(output, "\n") {
+			out = append(out, "    "+o)
+		}
+		out = append(out, "")
+	}
+	return strings.Join(out, "\n")
+}
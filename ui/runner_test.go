@@ -0,0 +1,39 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/glow/v2/utils"
+)
+
+func TestRunCodeFollowsCommandPolicy(t *testing.T) {
+	t.Setenv("GLOW_SECRET", "hunter2")
+	policy := utils.CommandPolicy{Allow: []string{"sh"}, Timeout: time.Minute, MaxOutput: 100, Env: []string{"PATH"}}
+
+	runWith := func(command, code string, p utils.CommandPolicy) codeRun {
+		return codeRun(runCode(codeRun{command: command}, code, t.TempDir(), p)().(codeRunMsg))
+	}
+	run := func(code string, p utils.CommandPolicy) codeRun {
+		return runWith("sh -c", code, p)
+	}
+
+	if r := runWith("sh -c", "echo ran", utils.CommandPolicy{}); r.output != "" || !strings.Contains(r.status, "isn't allowed") {
+		t.Errorf("got output %q and status %q, want the runner not allowed", r.output, r.status)
+	}
+	if r := runWith("  ", "echo ran", policy); r.status != "no command given" {
+		t.Errorf("got status %q for an empty runner", r.status)
+	}
+
+	if r := run("echo ${GLOW_SECRET:-scrubbed}", policy); r.output != "scrubbed\n" {
+		t.Errorf("got output %q, want the secret scrubbed", r.output)
+	}
+	if r := run("yes", policy); !strings.Contains(r.status, "more than 100 bytes") || len(r.output) > 110 {
+		t.Errorf("got status %q and %d bytes of output, want it cut off", r.status, len(r.output))
+	}
+	policy.Timeout = 100 * time.Millisecond
+	if r := run("sleep 5", policy); r.status != "stopped after 100ms" {
+		t.Errorf("got status %q, want a timeout", r.status)
+	}
+}
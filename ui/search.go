@@ -0,0 +1,321 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Escape sequences that highlight search matches. Only reverse video, bold
+// and underline are switched, so the colors of the rendered document show
+// through.
+const (
+	searchMatchOn    = "\x1b[7m"
+	searchMatchOff   = "\x1b[27m"
+	searchCurrentOn  = "\x1b[7;1;4m"
+	searchCurrentOff = "\x1b[27;22;24m"
+)
+
+// searchMatch is where the search text is found in the rendered document:
+// the line, and the start and end of the match in bytes of the line's
+// printable text.
+type searchMatch struct {
+	line, start, end int
+}
+
+func newSearchInput() textinput.Model {
+	si := textinput.New()
+	si.Prompt = "/"
+	si.PromptStyle = stashInputPromptStyle
+	si.Cursor.Style = stashInputCursorStyle
+	return si
+}
+
+// startSearch opens the search line, for a search down the document or,
+// as vim's ?, up it.
+func (m *pagerModel) startSearch(backward bool) tea.Cmd {
+	m.searching = true
+	m.searchBackward = backward
+	m.searchFrom = m.viewport.YOffset
+	m.search.Prompt = "/"
+	if backward {
+		m.search.Prompt = "?"
+	}
+	m.search.Reset()
+	m.searchIndex = 0
+	m.setSearch("")
+	return m.search.Focus()
+}
+
+// clearSearch drops the search and its highlights.
+func (m *pagerModel) clearSearch() tea.Cmd {
+	m.searching = false
+	m.search.Blur()
+	m.setSearch("")
+	return m.syncViewport()
+}
+
+// updateSearch handles keys while the search text is typed in. Matches are
+// highlighted and scrolled to as it changes; enter keeps the search for n and
+// N, and esc drops it.
+func (m pagerModel) updateSearch(msg tea.KeyMsg) (pagerModel, tea.Cmd) {
+	switch msg.String() {
+	case keyEsc:
+		return m, m.clearSearch()
+	case keyEnter:
+		m.searching = false
+		m.search.Blur()
+		if m.searchQuery == "" {
+			return m, nil
+		}
+		if len(m.searchMatches) == 0 {
+			return m, m.showStatusMessage(pagerStatusMessage{"No matches for " + m.searchQuery, false})
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.search, cmd = m.search.Update(msg)
+	if q := m.search.Value(); q != m.searchQuery {
+		m.setSearch(q)
+		m.searchIndex = m.firstMatchFrom(m.searchFrom)
+		if m.searchBackward {
+			m.searchIndex = m.lastMatchAbove(m.searchFrom)
+		}
+		m.setContent(m.rendered)
+		m.scrollToMatch()
+	}
+	return m, tea.Batch(cmd, m.syncViewport())
+}
+
+// setSearch sets the text searched for, and highlights its matches.
+func (m *pagerModel) setSearch(q string) {
+	m.searchQuery = q
+	m.setContent(m.rendered)
+}
+
+// nextMatch selects the next match, or the previous one for a negative delta,
+// wrapping around at the end of the document.
+func (m *pagerModel) nextMatch(delta int) tea.Cmd {
+	if len(m.searchMatches) == 0 {
+		return m.showStatusMessage(pagerStatusMessage{"No matches for " + m.searchQuery, false})
+	}
+	n := len(m.searchMatches)
+	m.searchIndex = ((m.searchIndex+delta)%n + n) % n
+	m.setContent(m.rendered)
+	m.scrollToMatch()
+	return m.syncViewport()
+}
+
+// firstMatchFrom returns the index of the first match on the given line or
+// below, or of the first one if there's none.
+func (m pagerModel) firstMatchFrom(line int) int {
+	for i, sm := range m.searchMatches {
+		if sm.line >= line {
+			return i
+		}
+	}
+	return 0
+}
+
+// lastMatchAbove returns the index of the last match above the given line,
+// or of the last one if there's none.
+func (m pagerModel) lastMatchAbove(line int) int {
+	for i := len(m.searchMatches) - 1; i >= 0; i-- {
+		if m.searchMatches[i].line < line {
+			return i
+		}
+	}
+	return max(0, len(m.searchMatches)-1)
+}
+
+// searchDirection returns which way n goes through matches: down, or up
+// after a backward search.
+func (m pagerModel) searchDirection() int {
+	if m.searchBackward {
+		return -1
+	}
+	return 1
+}
+
+// scrollToMatch scrolls the selected match into view, a third of the way
+// down the screen, unless it's visible already.
+func (m *pagerModel) scrollToMatch() {
+	if m.searchIndex >= len(m.searchMatches) {
+		return
+	}
+	line := m.searchMatches[m.searchIndex].line
+	if line < m.viewport.YOffset || line >= m.viewport.YOffset+m.viewport.Height {
+		m.viewport.SetYOffset(max(0, line-m.viewport.Height/3)) //nolint:mnd
+	}
+}
+
+func (m pagerModel) syncViewport() tea.Cmd {
+	if m.viewport.HighPerformanceRendering {
+		return viewport.Sync(m.viewport)
+	}
+	return nil
+}
+
+// searchStatus returns the position of the selected match among all of
+// them, for the status bar.
+func (m pagerModel) searchStatus() string {
+	switch {
+	case m.searchQuery == "":
+		return ""
+	case len(m.searchMatches) == 0:
+		return "no matches"
+	default:
+		return fmt.Sprintf("match %d/%d", m.searchIndex+1, len(m.searchMatches))
+	}
+}
+
+// highlightMatches finds the matches of the search in rendered content, and
+// highlights them, the selected one standing out.
+func (m *pagerModel) highlightMatches(s string) string {
+	m.searchMatches = nil
+	if m.searchQuery == "" {
+		return s
+	}
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		var found []searchMatch
+		for _, loc := range findAll(ansi.Strip(l), m.searchQuery) {
+			found = append(found, searchMatch{i, loc[0], loc[1]})
+		}
+		if len(found) == 0 {
+			continue
+		}
+		current := -1
+		if j := m.searchIndex - len(m.searchMatches); j >= 0 && j < len(found) {
+			current = j
+		}
+		m.searchMatches = append(m.searchMatches, found...)
+		lines[i] = highlightLine(l, found, current)
+	}
+	m.searchIndex = min(m.searchIndex, max(0, len(m.searchMatches)-1))
+	return strings.Join(lines, "\n")
+}
+
+// findAll returns where the query is found in text, ignoring case unless the
+// query has capitals in it.
+func findAll(text, query string) [][2]int {
+	var locs [][2]int
+	if strings.ContainsFunc(query, unicode.IsUpper) {
+		for i := 0; ; {
+			j := strings.Index(text[i:], query)
+			if j < 0 {
+				return locs
+			}
+			locs = append(locs, [2]int{i + j, i + j + len(query)})
+			i += j + len(query)
+		}
+	}
+
+	// lowercasing changes the length of some characters, such as the Kelvin
+	// sign, so matches are compared a character at a time in the text itself
+	for i := 0; i < len(text); {
+		if n := foldedPrefixLen(text[i:], query); n > 0 {
+			locs = append(locs, [2]int{i, i + n})
+			i += n
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(text[i:])
+		i += size
+	}
+	return locs
+}
+
+// foldedPrefixLen returns the length in bytes of the start of s that equals
+// the query, ignoring case, or 0 if s doesn't start with it.
+func foldedPrefixLen(s, query string) int {
+	n := 0
+	for _, q := range query {
+		r, size := utf8.DecodeRuneInString(s[n:])
+		if size == 0 || (r != q && !strings.EqualFold(string(r), string(q))) {
+			return 0
+		}
+		n += size
+	}
+	return n
+}
+
+// highlightLine highlights matches in a rendered line, given in bytes of its
+// printable text, leaving its escape sequences intact. Highlights are
+// switched on again after every escape sequence inside a match, as glamour
+// resets styles between words.
+func highlightLine(line string, matches []searchMatch, current int) string {
+	var (
+		b     strings.Builder
+		pos   int // in printable text
+		match int // the next or current match
+		in    bool
+	)
+	on := func() string {
+		if match == current {
+			return searchCurrentOn
+		}
+		return searchMatchOn
+	}
+	off := func() string {
+		if match == current {
+			return searchCurrentOff
+		}
+		return searchMatchOff
+	}
+	for i := 0; i < len(line); {
+		if n := escapeLen(line[i:]); n > 0 {
+			b.WriteString(line[i : i+n])
+			if in {
+				b.WriteString(on())
+			}
+			i += n
+			continue
+		}
+		if match < len(matches) && !in && pos == matches[match].start {
+			b.WriteString(on())
+			in = true
+		}
+		b.WriteByte(line[i])
+		i++
+		pos++
+		if in && pos == matches[match].end {
+			b.WriteString(off())
+			in = false
+			match++
+		}
+	}
+	return b.String()
+}
+
+// escapeLen returns the length of the CSI or OSC escape sequence s starts
+// with, or 0 if it doesn't start with one.
+func escapeLen(s string) int {
+	if len(s) < 2 || s[0] != '\x1b' {
+		return 0
+	}
+	switch s[1] {
+	case '[':
+		for j := 2; j < len(s); j++ {
+			if s[j] >= 0x40 && s[j] <= 0x7e {
+				return j + 1
+			}
+		}
+	case ']':
+		for j := 2; j < len(s); j++ {
+			if s[j] == '\a' {
+				return j + 1
+			}
+			if s[j] == '\x1b' && j+1 < len(s) && s[j+1] == '\\' {
+				return j + 2
+			}
+		}
+	}
+	return len(s)
+}
@@ -0,0 +1,84 @@
+package ui
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSearchBackward(t *testing.T) {
+	m := pagerModel{searchMatches: []searchMatch{{line: 2}, {line: 5}, {line: 9}}}
+	for _, tc := range []struct{ line, want int }{
+		{10, 2},
+		{9, 1},
+		{3, 0},
+		{2, 2}, // wraps around to the last
+	} {
+		if got := m.lastMatchAbove(tc.line); got != tc.want {
+			t.Errorf("lastMatchAbove(%d) = %d, want %d", tc.line, got, tc.want)
+		}
+	}
+
+	m.searchBackward = true
+	if m.searchDirection() != -1 {
+		t.Error("n doesn't go up after a backward search")
+	}
+}
+
+func TestFindAll(t *testing.T) {
+	for _, tc := range []struct {
+		text, query string
+		want        [][2]int
+	}{
+		{"Go go GO", "go", [][2]int{{0, 2}, {3, 5}, {6, 8}}},
+		{"Go go GO", "Go", [][2]int{{0, 2}}},
+		{"aaaa", "aa", [][2]int{{0, 2}, {2, 4}}},
+		// characters whose lowercase is longer or shorter in bytes
+		{"K9 k9", "k9", [][2]int{{0, 4}, {5, 7}}},
+		{"Ⱥx ⱥx", "ⱥx", [][2]int{{0, 3}, {4, 8}}},
+		{"İi x", "x", [][2]int{{4, 5}}},
+		{"", "x", nil},
+	} {
+		got := findAll(tc.text, tc.query)
+		if fmt.Sprint(got) != fmt.Sprint(tc.want) {
+			t.Errorf("findAll(%q, %q) = %v, want %v", tc.text, tc.query, got, tc.want)
+		}
+	}
+}
+
+func TestHighlightLine(t *testing.T) {
+	const on, off = searchMatchOn, searchMatchOff
+	const cur, curOff = searchCurrentOn, searchCurrentOff
+	for _, tc := range []struct {
+		line    string
+		matches []searchMatch
+		current int
+		want    string
+	}{
+		{"a go b", []searchMatch{{0, 2, 4}}, -1, "a " + on + "go" + off + " b"},
+		{"go go", []searchMatch{{0, 0, 2}, {0, 3, 5}}, 1, on + "go" + off + " " + cur + "go" + curOff},
+		// escape sequences are kept, and the highlight switched on again
+		{"\x1b[1mg\x1b[0mo", []searchMatch{{0, 0, 2}}, -1, "\x1b[1m" + on + "g\x1b[0m" + on + "o" + off},
+		{"\x1b]8;;http://x\x07go\x1b]8;;\x07", []searchMatch{{0, 0, 2}}, -1, "\x1b]8;;http://x\x07" + on + "go" + off + "\x1b]8;;\x07"},
+		{"K9", []searchMatch{{0, 0, 4}}, -1, on + "K9" + off},
+	} {
+		if got := highlightLine(tc.line, tc.matches, tc.current); got != tc.want {
+			t.Errorf("highlightLine(%q) = %q, want %q", tc.line, got, tc.want)
+		}
+	}
+}
+
+func TestEscapeLen(t *testing.T) {
+	for s, want := range map[string]int{
+		"text":               0,
+		"\x1b":               0,
+		"\x1b[1mtext":        4,
+		"\x1b[38;5;12mtext":  10,
+		"\x1b]8;;url\x07x":   9,
+		"\x1b]8;;url\x1b\\x": 10,
+		"\x1b[12":            4, // unterminated, so the rest of the line
+	} {
+		if got := escapeLen(s); got != want {
+			t.Errorf("escapeLen(%q) = %d, want %d", s, got, want)
+		}
+	}
+}
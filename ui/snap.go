@@ -0,0 +1,74 @@
+package ui
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+const keySnap = "S"
+
+// blockStarts returns the rendered lines that blocks, such as headings,
+// paragraphs, lists and code blocks, start on. glamour separates blocks with
+// blank lines, which code blocks can have too, so those are left out.
+func (m pagerModel) blockStarts() []int {
+	lines := strings.Split(m.rendered, "\n")
+	inCode := make([]bool, len(lines))
+	for _, c := range m.renderedCodeBlocks(lines, len(lines)) {
+		for i := c.start + 1; i < min(c.start+c.height, len(lines)); i++ {
+			inCode[i] = true
+		}
+	}
+
+	var starts []int
+	blank := true
+	for i, l := range lines {
+		empty := strings.TrimSpace(ansi.Strip(l)) == ""
+		if !empty && blank && !inCode[i] {
+			starts = append(starts, i)
+		}
+		blank = empty
+	}
+	return starts
+}
+
+// snapScroll scrolls up or down to the start of the previous or next block,
+// so the top of the screen never cuts a block in half. Blocks taller than
+// the screen are scrolled through a line at a time.
+func (m *pagerModel) snapScroll(down bool) tea.Cmd {
+	top := m.viewport.YOffset
+	starts := m.blockStarts()
+	if down {
+		i := sort.SearchInts(starts, top+1)
+		if i < len(starts) && starts[i]-top <= m.viewport.Height {
+			m.viewport.SetYOffset(starts[i])
+		} else {
+			m.viewport.SetYOffset(top + 1)
+		}
+	} else {
+		i := sort.SearchInts(starts, top) - 1
+		if i >= 0 && top-starts[i] <= m.viewport.Height {
+			m.viewport.SetYOffset(starts[i])
+		} else {
+			m.viewport.SetYOffset(top - 1)
+		}
+	}
+
+	var cmds []tea.Cmd
+	if m.viewport.HighPerformanceRendering {
+		cmds = append(cmds, viewport.Sync(m.viewport))
+	}
+	return tea.Batch(append(cmds, m.scrollTables())...)
+}
+
+// toggleSnap turns scrolling by block on or off.
+func (m *pagerModel) toggleSnap() tea.Cmd {
+	m.snap = !m.snap
+	if m.snap {
+		return m.showStatusMessage(pagerStatusMessage{"Scrolling by block", false})
+	}
+	return m.showStatusMessage(pagerStatusMessage{"Scrolling by line", false})
+}
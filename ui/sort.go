@@ -2,11 +2,187 @@ package ui
 
 import (
 	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
 	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/charmbracelet/log"
+	gap "github.com/muesli/go-app-paths"
+
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+// Keys that change how the stash is sorted: by another field, or the other
+// way around.
+const (
+	keySortField   = "y"
+	keySortReverse = "t"
 )
 
-func sortMarkdowns(mds []*markdown) {
+// Fields the stash can be sorted by.
+const (
+	SortTitle = "title"
+	SortDate  = "date"
+	SortSize  = "size"
+)
+
+// SortFields lists the valid sort fields, in the order they're switched
+// through.
+var SortFields = []string{SortTitle, SortDate, SortSize}
+
+// sortOrder is how the stash is sorted: by a field, in the direction that
+// comes naturally for it (A to Z, newest first, largest first) or reversed.
+type sortOrder struct {
+	Field   string `json:"field"`
+	Reverse bool   `json:"reverse,omitempty"`
+}
+
+// savedSortOrder is the order the stash was last sorted in, along with the
+// sort setting it was chosen over, so that changing the setting wins over
+// it.
+type savedSortOrder struct {
+	sortOrder
+	Default string `json:"default"`
+}
+
+// next returns the order by the following field, in its natural direction.
+func (o sortOrder) next() sortOrder {
+	i := slices.Index(SortFields, o.Field)
+	return sortOrder{Field: SortFields[(i+1)%len(SortFields)]}
+}
+
+// String describes the order for the stash's header.
+func (o sortOrder) String() string {
+	directions := map[string][2]string{
+		SortTitle: {"A–Z", "Z–A"},
+		SortDate:  {"newest first", "oldest first"},
+		SortSize:  {"largest first", "smallest first"},
+	}[o.Field]
+	d := directions[0]
+	if o.Reverse {
+		d = directions[1]
+	}
+	return fmt.Sprintf("by %s, %s", o.Field, d)
+}
+
+// sortMarkdowns sorts documents in the given order. Names are compared in
+// the order of the user's locale, so accented and non-Latin names end up
+// where a reader expects them rather than by their bytes; they also settle
+// ties between dates and sizes.
+func sortMarkdowns(mds []*markdown, order sortOrder) {
+	c := collate.New(userLanguage())
 	slices.SortStableFunc(mds, func(a, b *markdown) int {
-		return cmp.Compare(a.Note, b.Note)
+		var n int
+		switch order.Field {
+		case SortDate:
+			n = b.Modtime.Compare(a.Modtime)
+		case SortSize:
+			n = cmp.Compare(b.Size, a.Size)
+		}
+		if n == 0 {
+			n = c.CompareString(a.Note, b.Note)
+		}
+		if order.Reverse {
+			return -n
+		}
+		return n
 	})
 }
+
+// sortBy sorts the stash in another order, keeping the selected document
+// selected, and remembers it for the next session.
+func (m *stashModel) sortBy(order sortOrder) tea.Cmd {
+	selected := m.selectedMarkdown()
+	m.order = order
+	sortMarkdowns(m.markdowns, order)
+	m.selectIndex(slices.Index(m.markdowns, selected))
+	return tea.Batch(
+		saveSortOrder(order, m.common.cfg.Sort),
+		m.newStatusMessage(statusMessage{normalStatusMessage, "Sorted " + order.String()}),
+	)
+}
+
+// loadSortOrder reads the order the stash was last sorted in from the data
+// dir, or returns the given default if there is none. An order that was
+// saved while the default was different is forgotten, since the default
+// has been changed since.
+func loadSortOrder(def string) sortOrder {
+	order := sortOrder{Field: def}
+	p, err := sortOrderPath()
+	if err != nil {
+		return order
+	}
+	b, err := os.ReadFile(p)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			log.Error("could not read sort order", "error", err)
+		}
+		return order
+	}
+	var saved savedSortOrder
+	if err := json.Unmarshal(b, &saved); err != nil || !slices.Contains(SortFields, saved.Field) {
+		log.Error("could not parse sort order", "path", p, "error", err)
+		return order
+	}
+	if saved.Default != def {
+		if err := os.Remove(p); err != nil {
+			log.Error("could not clear sort order", "error", err)
+		}
+		return order
+	}
+	return saved.sortOrder
+}
+
+// saveSortOrder returns a command that remembers the order the stash is
+// sorted in for the next session, along with the default it was chosen
+// over.
+func saveSortOrder(order sortOrder, def string) tea.Cmd {
+	return func() tea.Msg {
+		p, err := sortOrderPath()
+		if err != nil {
+			log.Error("could not locate sort order", "error", err)
+			return nil
+		}
+		err = utils.UpdateFile(p, 0o600, func([]byte) ([]byte, error) { //nolint:mnd
+			return json.Marshal(savedSortOrder{order, def})
+		})
+		if err != nil {
+			log.Error("could not save sort order", "error", err)
+		}
+		return nil
+	}
+}
+
+func sortOrderPath() (string, error) {
+	return gap.NewScope(gap.User, "glow").DataPath("sort.json")
+}
+
+// userLanguage returns the language names are collated in, as set by the
+// locale environment variables. Without one, or for the C locale, it's the
+// Unicode default order.
+func userLanguage() language.Tag {
+	for _, v := range []string{"LC_ALL", "LC_COLLATE", "LANG"} {
+		l := os.Getenv(v)
+		if l == "" {
+			continue
+		}
+		// e.g. de_DE.UTF-8 or sr_RS@latin
+		l, _, _ = strings.Cut(l, ".")
+		l, _, _ = strings.Cut(l, "@")
+		if l == "C" || l == "POSIX" {
+			return language.Und
+		}
+		if t, err := language.Parse(strings.ReplaceAll(l, "_", "-")); err == nil {
+			return t
+		}
+		return language.Und
+	}
+	return language.Und
+}
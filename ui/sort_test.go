@@ -0,0 +1,35 @@
+package ui
+
+import (
+	"os"
+	"testing"
+)
+
+func TestSortOrderFollowsDefault(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	p, err := sortOrderPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := loadSortOrder(SortTitle); got != (sortOrder{Field: SortTitle}) {
+		t.Errorf("without a saved order, got %v", got)
+	}
+
+	saved := sortOrder{Field: SortSize, Reverse: true}
+	saveSortOrder(saved, SortTitle)()
+	if got := loadSortOrder(SortTitle); got != saved {
+		t.Errorf("with the same default, got %v, want %v", got, saved)
+	}
+
+	// a new default wins over the order saved before, which is forgotten
+	if got := loadSortOrder(SortDate); got != (sortOrder{Field: SortDate}) {
+		t.Errorf("with a new default, got %v, want the default", got)
+	}
+	if _, err := os.Stat(p); !os.IsNotExist(err) {
+		t.Errorf("saved order not cleared: %v", err)
+	}
+	if got := loadSortOrder(SortTitle); got != (sortOrder{Field: SortTitle}) {
+		t.Errorf("after changing the default back, got %v, want the default", got)
+	}
+}
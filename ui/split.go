@@ -0,0 +1,196 @@
+package ui
+
+import (
+	"math"
+	"strings"
+	"sync/atomic"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	keySplit      = "V"
+	keySplitFocus = "w"
+	keySyncScroll = "W"
+)
+
+// Numbers pagers, so there are never two with the same number.
+var paneID atomic.Uint64
+
+var splitDividerStyle = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#DCDCDC", Dark: "#3C3C3C"})
+
+// splitView is a second document, shown beside the one in the pager. The
+// pager always holds the document with the focus; switching the focus swaps
+// the two.
+type splitView struct {
+	pager pagerModel
+
+	// Whether the document with the focus is the one on the right.
+	focusRight bool
+
+	// Whether scrolling the document with the focus scrolls the other one to
+	// the same percentage.
+	syncScroll bool
+}
+
+// paneMsg is a message for one of the pagers, as there can be two.
+type paneMsg struct {
+	pane uint64
+	msg  tea.Msg
+}
+
+// forPane addresses the messages a pager's command results in to that pager.
+// Other messages, such as those for Bubble Tea itself, are left alone.
+func forPane(pane uint64, cmd tea.Cmd) tea.Cmd {
+	if cmd == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		switch msg := cmd().(type) {
+		case tea.BatchMsg:
+			cmds := make([]tea.Cmd, len(msg))
+			for i, c := range msg {
+				cmds[i] = forPane(pane, c)
+			}
+			return tea.BatchMsg(cmds)
+		case contentRenderedMsg, renderAheadMsg, fetchedMarkdownMsg,
+			statusMessageTimeoutMsg, linkPreviewMsg, linkOpenedMsg, codeRunMsg, taskToggledMsg,
+			autoRefreshMsg:
+			return paneMsg{pane, msg}
+		default:
+			return msg
+		}
+	}
+}
+
+// updatePane hands a message to the pager it's for. Messages for a pager
+// that has been closed are dropped.
+func (m model) updatePane(msg paneMsg) (model, tea.Cmd) {
+	if msg.pane == m.pager.pane {
+		return m.update(msg.msg)
+	}
+	if m.split == nil || msg.pane != m.split.pager.pane {
+		return m, nil
+	}
+
+	p := &m.split.pager
+	if md, ok := msg.msg.(fetchedMarkdownMsg); ok {
+		return m, forPane(p.pane, m.loadDocument(p, md, false))
+	}
+	var cmd tea.Cmd
+	*p, cmd = p.update(msg.msg)
+	return m, forPane(p.pane, cmd)
+}
+
+// chooseBeside goes back to the files to choose a document to show beside
+// the one that's open, or closes the document shown beside it.
+func (m *model) chooseBeside() tea.Cmd {
+	if m.split != nil {
+		return m.closeSplit()
+	}
+	if m.pager.viewport.HighPerformanceRendering {
+		return forPane(m.pager.pane, m.pager.showStatusMessage(pagerStatusMessage{
+			"Documents can't be shown side by side with the high-performance pager", true,
+		}))
+	}
+
+	m.saveReadingPosition()
+	m.stash.besideNote = m.pager.currentDocument.Note
+	m.state = stateShowStash
+	m.stash.viewState = stashStateReady
+	if !m.stash.shouldSpin() {
+		return m.stash.spinner.Tick
+	}
+	return nil
+}
+
+// cancelBeside goes back to the open document without choosing one to show
+// beside it.
+func (m *model) cancelBeside() {
+	m.stash.besideNote = ""
+	m.state = stateShowDocument
+}
+
+// openSplit shows a document beside the open one, and gives it the focus.
+func (m *model) openSplit(md *markdown) tea.Cmd {
+	m.stash.besideNote = ""
+	m.stash.viewState = stashStateReady
+	m.state = stateShowDocument
+
+	m.split = &splitView{pager: m.pager, focusRight: true}
+	m.split.pager.blurred = true
+	m.pager = newPagerModel(m.common)
+	m.layoutSplit()
+
+	return tea.Batch(
+		forPane(m.pager.pane, m.loadDocument(&m.pager, md, true)),
+		forPane(m.split.pager.pane, renderDocument(m.split.pager)),
+	)
+}
+
+// closeSplit closes the document without the focus, leaving the window to
+// the other one.
+func (m *model) closeSplit() tea.Cmd {
+	m.savePosition(m.split.pager)
+	m.split.pager.unload()
+	m.split = nil
+	m.pager.blurred = false
+	m.pager.setSize(m.common.width, m.common.height)
+	return forPane(m.pager.pane, renderDocument(m.pager))
+}
+
+// switchFocus gives the focus to the other document.
+func (m *model) switchFocus() {
+	m.pager, m.split.pager = m.split.pager, m.pager
+	m.pager.blurred, m.split.pager.blurred = false, true
+	m.split.focusRight = !m.split.focusRight
+}
+
+// toggleSyncScroll turns scrolling both documents together on or off.
+func (m *model) toggleSyncScroll() tea.Cmd {
+	m.split.syncScroll = !m.split.syncScroll
+	status := "Scrolling documents separately"
+	if m.split.syncScroll {
+		m.split.follow(m.pager)
+		status = "Scrolling documents together"
+	}
+	return forPane(m.pager.pane, m.pager.showStatusMessage(pagerStatusMessage{status, false}))
+}
+
+// layoutSplit has the documents share the window, with a column between
+// them.
+func (m *model) layoutSplit() {
+	left := max(0, m.common.width-1) / 2 //nolint:mnd
+	right := max(0, m.common.width-1-left)
+	if m.split.focusRight {
+		left, right = right, left
+	}
+	m.pager.setSize(left, m.common.height)
+	m.split.pager.setSize(right, m.common.height)
+}
+
+// follow scrolls the document without the focus to the same percentage as
+// the given one.
+func (s *splitView) follow(p pagerModel) {
+	vp := &s.pager.viewport
+	vp.SetYOffset(int(math.Round(p.viewport.ScrollPercent() * float64(max(0, vp.TotalLineCount()-vp.Height)))))
+}
+
+// view renders both documents next to each other.
+func (s splitView) view(focused pagerModel) string {
+	left, right := focused.View(), s.pager.View()
+	if s.focusRight {
+		left, right = right, left
+	}
+	height := max(lipgloss.Height(left), lipgloss.Height(right))
+	divider := strings.TrimSuffix(strings.Repeat(splitDividerStyle.Render("│")+"\n", height), "\n")
+	return lipgloss.JoinHorizontal(lipgloss.Top, left, divider, right)
+}
+
+// renderDocument renders the document in a pager again, e.g. after it has
+// been resized.
+func renderDocument(m pagerModel) tea.Cmd {
+	return renderWithGlamour(m, string(utils.RemoveFrontmatter([]byte(m.currentDocument.Body))))
+}
@@ -4,7 +4,9 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"sort"
+	"path"
+	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
@@ -12,11 +14,12 @@ import (
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glow/v2/markup"
+	"github.com/charmbracelet/glow/v2/utils"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/log"
 	"github.com/muesli/reflow/ansi"
 	"github.com/muesli/reflow/truncate"
-	"github.com/sahilm/fuzzy"
 )
 
 const (
@@ -38,6 +41,10 @@ var (
 			Background(fuchsia).
 			Bold(true)
 
+	profileStyle = lipgloss.NewStyle().
+			Foreground(cream).
+			Background(dullFuchsia)
+
 	stashSpinnerStyle = lipgloss.NewStyle().
 				Foreground(gray)
 	stashInputPromptStyle = lipgloss.NewStyle().
@@ -51,8 +58,11 @@ var (
 // MSG
 
 type (
-	filteredMarkdownMsg []*markdown
-	fetchedMarkdownMsg  *markdown
+	filteredMarkdownMsg struct {
+		mds     []*markdown
+		matches map[*markdown]contentMatch // of content search
+	}
+	fetchedMarkdownMsg *markdown
 )
 
 // MODEL
@@ -143,7 +153,6 @@ type stashModel struct {
 	filterInput        textinput.Model
 	viewState          stashViewState
 	filterState        filterState
-	showFullHelp       bool
 	showStatusMessage  bool
 	statusMessage      statusMessage
 	statusMessageTimer *time.Timer
@@ -158,6 +167,12 @@ type stashModel struct {
 	// Tracks if docs were loaded
 	loaded bool
 
+	// Search for documents updating the list in place, if one is running
+	refresh *stashRefresh
+
+	// Document that a document to show beside is being chosen for, if any
+	besideNote string
+
 	// The master set of markdown documents we're working with.
 	markdowns []*markdown
 
@@ -166,6 +181,22 @@ type stashModel struct {
 	// reason, this field should be considered ephemeral.
 	filteredMarkdowns []*markdown
 
+	// Where content search found the filter text in the filtered documents
+	contentMatches map[*markdown]contentMatch
+
+	// How the filter text is matched against document names
+	matcher matcher
+
+	// How documents are sorted when no filter is applied
+	order sortOrder
+
+	// Prompt for the directory to export filtered documents to, the index
+	// of the chosen export format, and the export in progress, if any.
+	exportInput  textinput.Model
+	exportPrompt bool
+	exportFormat int
+	exportBatch  *exportBatch
+
 	// Page we're fetching stash items from on the server, which is different
 	// from the local pagination. Generally, the server will return more items
 	// than we can display at a time so we can paginate locally without having
@@ -173,6 +204,11 @@ type stashModel struct {
 	serverPage int64
 }
 
+// typing reports whether keystrokes go to the filter or the export prompt.
+func (m stashModel) typing() bool {
+	return m.filterState == filtering || m.exportPrompt
+}
+
 func (m stashModel) loadingDone() bool {
 	return m.loaded
 }
@@ -201,7 +237,7 @@ func (m *stashModel) setCursor(i int) {
 func (m stashModel) shouldSpin() bool {
 	loading := !m.loadingDone()
 	openingDocument := m.viewState == stashStateLoadingDocument
-	return loading || openingDocument
+	return loading || openingDocument || m.exportBatch != nil
 }
 
 func (m *stashModel) setSize(width, height int) {
@@ -219,8 +255,9 @@ func (m *stashModel) resetFiltering() {
 	m.filterState = unfiltered
 	m.filterInput.Reset()
 	m.filteredMarkdowns = nil
+	m.contentMatches = nil
 
-	sortMarkdowns(m.markdowns)
+	sortMarkdowns(m.markdowns, m.order)
 
 	// If the filtered section is present (it's always at the end) slice it out
 	// of the sections slice to remove it from the UI.
@@ -299,7 +336,7 @@ func (m *stashModel) addMarkdowns(mds ...*markdown) {
 
 	m.markdowns = append(m.markdowns, mds...)
 	if !m.filterApplied() {
-		sortMarkdowns(m.markdowns)
+		sortMarkdowns(m.markdowns, m.order)
 	}
 
 	m.updatePagination()
@@ -322,6 +359,62 @@ func (m *stashModel) openMarkdown(md *markdown) tea.Cmd {
 	return tea.Batch(cmd, m.spinner.Tick)
 }
 
+// Command for opening a markdown document at the line or anchor referenced in
+// the filter, e.g. "README.md:42" or "README.md#usage".
+func (m *stashModel) openMarkdownAtTarget(md *markdown) tea.Cmd {
+	_, line, anchor := m.filterTarget()
+	if line == 0 && anchor == "" {
+		// documents found by content search open where the text is
+		if c, ok := m.contentMatches[md]; ok {
+			target := *md
+			target.line, target.breadcrumb = c.line, c.breadcrumb()
+			return m.openMarkdown(&target)
+		}
+		return m.openMarkdown(md)
+	}
+	target := *md
+	target.line, target.anchor = line, anchor
+	return m.openMarkdown(&target)
+}
+
+// filterTarget splits the filter value into the text to filter by and an
+// optional line or anchor to open the document at. That's only split off when
+// what's before it names a document, so filters such as "C#" or "notes:2"
+// are left alone.
+func (m stashModel) filterTarget() (query string, line int, anchor string) {
+	v := m.filterInput.Value()
+	name, line, anchor := utils.ParseTarget(v)
+	if line == 0 && anchor == "" || !m.namesDocument(name) {
+		return v, 0, ""
+	}
+	return name, line, anchor
+}
+
+// namesDocument reports whether a name is the path or the file name of a
+// document in the stash, ignoring case.
+func (m stashModel) namesDocument(name string) bool {
+	name = filepath.ToSlash(name)
+	for _, md := range m.markdowns {
+		note := filepath.ToSlash(md.Note)
+		if strings.EqualFold(note, name) || strings.EqualFold(path.Base(note), name) {
+			return true
+		}
+	}
+	return false
+}
+
+// newStatusMessage shows a status message for a little while.
+func (m *stashModel) newStatusMessage(sm statusMessage) tea.Cmd {
+	m.showStatusMessage = true
+	m.statusMessage = sm
+	m.common.notifications.add(sm.message, sm.status == errorStatusMessage)
+	if m.statusMessageTimer != nil {
+		m.statusMessageTimer.Stop()
+	}
+	m.statusMessageTimer = time.NewTimer(statusMessageDuration())
+	return waitForStatusMessageTimeout(stashContext, m.statusMessageTimer)
+}
+
 func (m *stashModel) hideStatusMessage() {
 	m.showStatusMessage = false
 	m.statusMessage = statusMessage{}
@@ -384,6 +477,10 @@ func newStashModel(common *commonModel) stashModel {
 	si.Cursor.Style = stashInputCursorStyle
 	si.Focus()
 
+	ei := textinput.New()
+	ei.PromptStyle = stashInputPromptStyle
+	ei.Cursor.Style = stashInputCursorStyle
+
 	s := []section{
 		sections[documentsSection],
 	}
@@ -392,8 +489,11 @@ func newStashModel(common *commonModel) stashModel {
 		common:      common,
 		spinner:     sp,
 		filterInput: si,
+		exportInput: ei,
 		serverPage:  1,
 		sections:    s,
+		matcher:     newMatcher(common.cfg.FilterMatcher),
+		order:       loadSortOrder(common.cfg.Sort),
 	}
 
 	return m
@@ -416,12 +516,20 @@ func (m stashModel) update(msg tea.Msg) (stashModel, tea.Cmd) {
 	case errMsg:
 		m.err = msg
 
+	case editorFinishedMsg:
+		if msg.err != nil {
+			cmds = append(cmds, m.newStatusMessage(statusMessage{errorStatusMessage, "Couldn't edit: " + msg.err.Error()}))
+		}
+
 	case localFileSearchFinished:
 		// We're finished searching for local files
 		m.loaded = true
+		if m.refresh != nil {
+			cmds = append(cmds, m.finishRefresh())
+		}
 
 	case filteredMarkdownMsg:
-		m.filteredMarkdowns = msg
+		m.filteredMarkdowns, m.contentMatches = msg.mds, msg.matches
 		m.setCursor(0)
 		return m, nil
 
@@ -438,6 +546,15 @@ func (m stashModel) update(msg tea.Msg) (stashModel, tea.Cmd) {
 		}
 	}
 
+	if msg, ok := msg.(exportedMsg); ok {
+		return m, m.handleExported(msg)
+	}
+
+	if m.exportPrompt {
+		cmds = append(cmds, m.handleExportPrompt(msg))
+		return m, tea.Batch(cmds...)
+	}
+
 	if m.filterState == filtering {
 		cmds = append(cmds, m.handleFiltering(msg))
 		return m, tea.Batch(cmds...)
@@ -511,14 +628,35 @@ func (m *stashModel) handleDocumentBrowsing(msg tea.Msg) tea.Cmd {
 			}
 			m.updatePagination()
 
+		// Export the filtered documents
+		case "x":
+			if m.filterApplied() && numDocs > 0 && m.exportBatch == nil {
+				return m.startExportPrompt()
+			}
+
 		case "F":
-			m.loaded = false
-			return findLocalFiles(*m.common)
+			return m.startRefresh()
+
+		case keyToggleRead:
+			return m.toggleRead()
+
+		case keySortField, keySortReverse:
+			if m.filterApplied() {
+				break
+			}
+			order := m.order.next()
+			if msg.String() == keySortReverse {
+				order = sortOrder{Field: m.order.Field, Reverse: !m.order.Reverse}
+			}
+			return m.sortBy(order)
 
 		// Edit document in EDITOR
 		case "e":
 			md := m.selectedMarkdown()
-			return openEditor(md.localPath, 0)
+			if md == nil {
+				break
+			}
+			return editMarkdown(md, 0)
 
 		// Open document
 		case keyEnter:
@@ -531,7 +669,11 @@ func (m *stashModel) handleDocumentBrowsing(msg tea.Msg) tea.Cmd {
 			// Load the document from the server. We'll handle the message
 			// that comes back in the main update function.
 			md := m.selectedMarkdown()
-			cmds = append(cmds, m.openMarkdown(md))
+			if m.filterApplied() {
+				cmds = append(cmds, m.openMarkdownAtTarget(md))
+			} else {
+				cmds = append(cmds, m.openMarkdown(md))
+			}
 
 		// Filter your notes
 		case "/":
@@ -551,11 +693,6 @@ func (m *stashModel) handleDocumentBrowsing(msg tea.Msg) tea.Cmd {
 			m.filterInput.Focus()
 			return textinput.Blink
 
-		// Toggle full help
-		case "?":
-			m.showFullHelp = !m.showFullHelp
-			m.updatePagination()
-
 		// Show errors
 		case "!":
 			if m.err != nil && m.viewState == stashStateReady {
@@ -621,8 +758,9 @@ func (m *stashModel) handleFiltering(msg tea.Msg) tea.Cmd {
 			// "open" it directly
 			if len(h) == 1 {
 				m.viewState = stashStateReady
+				cmd := m.openMarkdownAtTarget(h[0])
 				m.resetFiltering()
-				cmds = append(cmds, m.openMarkdown(h[0]))
+				cmds = append(cmds, cmd)
 				break
 			}
 
@@ -680,14 +818,20 @@ func (m stashModel) view() string {
 
 		// Rules for the logo, filter and status message.
 		logoOrFilter := " "
-		if m.showStatusMessage && m.filterState == filtering {
+		if m.exportPrompt {
+			logoOrFilter += m.exportInput.View()
+		} else if b := m.exportBatch; b != nil {
+			logoOrFilter += glowLogoView(m.common.cfg.Profile) + "  " + grayFg(fmt.Sprintf("Exporting %d/%d…", b.done+1, len(b.mds)))
+		} else if m.showStatusMessage && m.filterState == filtering {
 			logoOrFilter += m.statusMessage.String()
 		} else if m.filterState == filtering {
 			logoOrFilter += m.filterInput.View()
 		} else {
-			logoOrFilter += glowLogoView()
+			logoOrFilter += glowLogoView(m.common.cfg.Profile)
 			if m.showStatusMessage {
 				logoOrFilter += "  " + m.statusMessage.String()
+			} else if m.besideNote != "" {
+				logoOrFilter += "  " + grayFg("Choose a document to show beside "+m.besideNote)
 			}
 		}
 		logoOrFilter = truncate.StringWithTail(logoOrFilter, uint(m.common.width-1), ellipsis)
@@ -741,8 +885,14 @@ func (m stashModel) view() string {
 	return "\n" + indent(s, stashIndent)
 }
 
-func glowLogoView() string {
-	return logoStyle.Render(" Glow ")
+// glowLogoView renders the logo, followed by the name of the config profile
+// in use, if any.
+func glowLogoView(profile string) string {
+	logo := logoStyle.Render(" Glow ")
+	if profile != "" {
+		logo += profileStyle.Render(" " + profile + " ")
+	}
+	return logo
 }
 
 func (m stashModel) headerView() string {
@@ -773,6 +923,10 @@ func (m stashModel) headerView() string {
 		switch v.key {
 		case documentsSection:
 			s = fmt.Sprintf("%d documents", localCount)
+			if unread := m.unreadCount(); unread > 0 {
+				s += fmt.Sprintf(", %d unread", unread)
+			}
+			s += ", " + m.order.String()
 
 		case filterSection:
 			s = fmt.Sprintf("%d “%s”", len(m.filteredMarkdowns), m.filterInput.Value())
@@ -849,37 +1003,67 @@ func loadLocalMarkdown(md *markdown) tea.Cmd {
 			return errMsg{errors.New("could not load file: missing path")}
 		}
 
+		if md.remotePath != "" {
+			if err := fetchRemoteMarkdown(md); err != nil {
+				log.Debug("error fetching remote file", "error", err)
+				return errMsg{err}
+			}
+		}
+
 		data, err := os.ReadFile(md.localPath)
 		if err != nil {
 			log.Debug("error reading local file", "error", err)
 			return errMsg{err}
 		}
-		md.Body = string(data)
+		// what's read is the version edits are compared against
+		if info, err := os.Stat(md.localPath); err == nil && md.remotePath == "" {
+			md.Modtime, md.Size = info.ModTime(), info.Size()
+		}
+		md.Body = string(markup.ToMarkdown(md.Note, data))
+		md.converted = markup.Converts(md.Note)
 		return fetchedMarkdownMsg(md)
 	}
 }
 
 func filterMarkdowns(m stashModel) tea.Cmd {
+	read := m.common.docs.readPaths()
 	return func() tea.Msg {
-		if m.filterInput.Value() == "" || !m.filterApplied() {
-			return filteredMarkdownMsg(m.markdowns) // return everything
+		target, _, _ := m.filterTarget()
+		if target == "" || !m.filterApplied() {
+			return filteredMarkdownMsg{mds: m.markdowns} // return everything
 		}
 
-		targets := []string{}
+		q := parseFilterQuery(target, read)
 		mds := m.markdowns
+		if len(q.conds) > 0 {
+			mds = slices.DeleteFunc(slices.Clone(mds), func(md *markdown) bool {
+				return !q.match(md)
+			})
+		}
+		if q.text == "" {
+			return filteredMarkdownMsg{mds: mds}
+		}
+		query := normalizeQuery(q.text)
 
+		targets := []string{}
 		for _, t := range mds {
 			targets = append(targets, t.filterValue)
 		}
 
-		ranks := fuzzy.Find(m.filterInput.Value(), targets)
-		sort.Stable(ranks)
-
 		filtered := []*markdown{}
-		for _, r := range ranks {
-			filtered = append(filtered, mds[r.Index])
+		for _, r := range m.matcher.find(query, targets) {
+			filtered = append(filtered, mds[r.index])
+		}
+
+		// when browsing a bundle, follow up with documents containing the
+		// query
+		found, matches := searchBundle(m.common.cfg.Remote, mds, query)
+		for _, md := range found {
+			if !slices.Contains(filtered, md) {
+				filtered = append(filtered, md)
+			}
 		}
 
-		return filteredMarkdownMsg(filtered)
+		return filteredMarkdownMsg{filtered, matches}
 	}
 }
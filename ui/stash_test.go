@@ -0,0 +1,34 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/bubbles/textinput"
+)
+
+func TestFilterTarget(t *testing.T) {
+	m := stashModel{
+		markdowns:   []*markdown{{Note: "README.md"}, {Note: "docs/notes.md"}},
+		filterInput: textinput.New(),
+	}
+	for _, tc := range []struct {
+		filter, query, anchor string
+		line                  int
+	}{
+		{"README.md:12", "README.md", "", 12},
+		{"readme.md#install", "readme.md", "install", 0},
+		{"docs/notes.md:3", "docs/notes.md", "", 3},
+		{"notes.md#todo", "notes.md", "todo", 0},
+		// plain filter text that only looks like a target
+		{"C#", "C#", "", 0},
+		{"C# tips", "C# tips", "", 0},
+		{"notes:2", "notes:2", "", 0},
+		{"README", "README", "", 0},
+	} {
+		m.filterInput.SetValue(tc.filter)
+		query, line, anchor := m.filterTarget()
+		if query != tc.query || line != tc.line || anchor != tc.anchor {
+			t.Errorf("%q: got %q, %d, %q, want %q, %d, %q", tc.filter, query, line, anchor, tc.query, tc.line, tc.anchor)
+		}
+	}
+}
@@ -2,87 +2,19 @@ package ui
 
 import (
 	"fmt"
-	"strings"
 
 	"github.com/muesli/reflow/ansi"
 )
 
-// helpEntry is a entry in a help menu containing values for a keystroke and
-// it's associated action.
-type helpEntry struct{ key, val string }
-
-// helpColumn is a group of helpEntries which will be rendered into a column.
-type helpColumn []helpEntry
-
-// newHelpColumn creates a help column from pairs of string arguments
-// representing keys and values. If the arguments are not even (and therein
-// not every key has a matching value) the function will panic.
-func newHelpColumn(pairs ...string) (h helpColumn) {
-	if len(pairs)%2 != 0 {
-		panic("help text group must have an even number of items")
-	}
-
-	for i := 0; i < len(pairs); i = i + 2 {
-		h = append(h, helpEntry{key: pairs[i], val: pairs[i+1]})
-	}
-
-	return
-}
-
-// render returns styled and formatted rows from keys and values.
-func (h helpColumn) render(height int) (rows []string) {
-	keyWidth, valWidth := h.maxWidths()
-
-	for i := 0; i < height; i++ {
-		var (
-			b    = strings.Builder{}
-			k, v string
-		)
-		if i < len(h) {
-			k = h[i].key
-			v = h[i].val
-
-			switch k {
-			case "s":
-				k = greenFg(k)
-				v = semiDimGreenFg(v)
-			default:
-				k = grayFg(k)
-				v = midGrayFg(v)
-			}
-		}
-		b.WriteString(k)
-		b.WriteString(strings.Repeat(" ", keyWidth-ansi.PrintableRuneWidth(k))) // pad keys
-		b.WriteString("  ")                                                     // gap
-		b.WriteString(v)
-		b.WriteString(strings.Repeat(" ", valWidth-ansi.PrintableRuneWidth(v))) // pad vals
-		rows = append(rows, b.String())
-	}
-
-	return
-}
-
-// maxWidths returns the widest key and values in the column, respectively.
-func (h helpColumn) maxWidths() (maxKey int, maxVal int) {
-	for _, v := range h {
-		kw := ansi.PrintableRuneWidth(v.key)
-		vw := ansi.PrintableRuneWidth(v.val)
-		if kw > maxKey {
-			maxKey = kw
-		}
-		if vw > maxVal {
-			maxVal = vw
-		}
-	}
-
-	return
-}
-
-// helpView returns either the mini or full help view depending on the state of
-// the model, as well as the total height of the help view.
+// helpView returns the help line for the state of the model, as well as its
+// height.
 func (m stashModel) helpView() (string, int) {
 	numDocs := len(m.getVisibleMarkdowns())
 
+	if m.exportPrompt {
+		return m.renderHelp([]string{"enter", "export", "tab", "format", "esc", "cancel"})
+	}
+
 	// Help for when we're filtering
 	if m.filterState == filtering {
 		var h []string
@@ -108,16 +40,8 @@ func (m stashModel) helpView() (string, int) {
 		appHelp       []string
 	)
 
-	if numDocs > 0 && m.showFullHelp {
-		navHelp = []string{"enter", "open", "j/k ↑/↓", "choose"}
-	}
-
 	if len(m.sections) > 1 {
-		if m.showFullHelp {
-			navHelp = append(navHelp, "tab/shift+tab", "section")
-		} else {
-			navHelp = append(navHelp, "tab", "section")
-		}
+		navHelp = append(navHelp, "tab", "section")
 	}
 
 	if m.paginator().TotalPages > 1 {
@@ -126,9 +50,17 @@ func (m stashModel) helpView() (string, int) {
 
 	// If we're browsing a filtered set
 	if m.filterApplied() {
-		filterHelp = []string{"/", "edit search", "esc", "clear filter"}
+		filterHelp = []string{"/", "edit search", "esc", "clear filter", "x", "export"}
 	} else {
-		filterHelp = []string{"/", "find"}
+		filterHelp = []string{"/", "find", "y/t", "sort"}
+	}
+
+	// If we're choosing a document to show beside the open one
+	if m.besideNote != "" {
+		selectionHelp = []string{"enter", "show beside"}
+		if !m.filterApplied() {
+			selectionHelp = append(selectionHelp, "esc", "back")
+		}
 	}
 
 	// If there are errors
@@ -140,31 +72,13 @@ func (m stashModel) helpView() (string, int) {
 	appHelp = append(appHelp, "e", "edit")
 	appHelp = append(appHelp, "q", "quit")
 
-	// Detailed help
-	if m.showFullHelp {
-		if m.filterState != filtering {
-			appHelp = append(appHelp, "?", "close help")
-		}
-		return m.renderHelp(navHelp, filterHelp, append(selectionHelp, editHelp...), sectionHelp, appHelp)
-	}
-
-	// Mini help
-	if m.filterState != filtering {
-		appHelp = append(appHelp, "?", "more")
-	}
+	appHelp = append(appHelp, "?", "all keys")
 	return m.renderHelp(navHelp, filterHelp, selectionHelp, editHelp, sectionHelp, appHelp)
 }
 
-const minHelpViewHeight = 5
-
-// renderHelp returns the rendered help view and associated line height for
-// the given groups of help items.
+// renderHelp returns the rendered help line and its height for the given
+// groups of help items.
 func (m stashModel) renderHelp(groups ...[]string) (string, int) {
-	if m.showFullHelp {
-		str := m.fullHelpView(groups...)
-		numLines := strings.Count(str, "\n") + 1
-		return str, max(numLines, minHelpViewHeight)
-	}
 	return m.miniHelpView(concatStringSlices(groups...)...), 1
 }
 
@@ -216,75 +130,6 @@ func (m stashModel) miniHelpView(entries ...string) string {
 	return s
 }
 
-func (m stashModel) fullHelpView(groups ...[]string) string {
-	var tallestCol int
-	columns := make([]helpColumn, 0, len(groups))
-	renderedCols := make([][]string, 0, len(groups)) // final rows grouped by column
-
-	// Get key/value pairs
-	for _, g := range groups {
-		if len(g) == 0 {
-			continue // ignore empty columns
-		}
-
-		columns = append(columns, newHelpColumn(g...))
-	}
-
-	// Find the tallest column
-	for _, c := range columns {
-		if len(c) > tallestCol {
-			tallestCol = len(c)
-		}
-	}
-
-	// Build columns
-	for _, c := range columns {
-		renderedCols = append(renderedCols, c.render(tallestCol))
-	}
-
-	// Merge columns
-	return mergeColumns(renderedCols...)
-}
-
-// Merge columns together to build the help view.
-func mergeColumns(cols ...[]string) string {
-	const minimumHeight = 3
-
-	// Find the tallest column
-	var tallestCol int
-	for _, v := range cols {
-		n := len(v)
-		if n > tallestCol {
-			tallestCol = n
-		}
-	}
-
-	// Make sure the tallest column meets the minimum height
-	if tallestCol < minimumHeight {
-		tallestCol = minimumHeight
-	}
-
-	b := strings.Builder{}
-	for i := 0; i < tallestCol; i++ {
-		for j, col := range cols {
-			if i >= len(col) {
-				continue // skip if we're past the length of this column
-			}
-			if j == 0 {
-				b.WriteString("  ") // gutter
-			} else if j > 0 {
-				b.WriteString("    ") // gap
-			}
-			b.WriteString(col[i])
-		}
-		if i < tallestCol-1 {
-			b.WriteRune('\n')
-		}
-	}
-
-	return b.String()
-}
-
 func concatStringSlices(s ...[]string) (agg []string) {
 	for _, v := range s {
 		agg = append(agg, v...)
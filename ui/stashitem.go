@@ -2,12 +2,12 @@ package ui
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/log"
 	"github.com/muesli/reflow/truncate"
-	"github.com/sahilm/fuzzy"
 )
 
 const (
@@ -23,10 +23,35 @@ func stashItemView(b *strings.Builder, m stashModel, index int, md *markdown) {
 		date        = md.relativeTime()
 		editedBy    = ""
 		hasEditedBy = false
+		crumb       = ""
+		hasCrumb    = false
 		icon        = ""
 		separator   = ""
 	)
 
+	// documents that haven't been read yet are marked
+	if !m.common.docs.get(md.localPath).Read {
+		icon = fileListingStashIcon
+		title = truncate.StringWithTail(md.Note, uint(max(0, int(truncateTo)-lipgloss.Width(icon))), ellipsis)
+	}
+
+	if meta := m.common.gitMetadata[md.localPath]; meta.author != "" {
+		editedBy = "· " + meta.String()
+		hasEditedBy = true
+	}
+
+	// where content search found the filter text, as far as it fits
+	if c, ok := m.contentMatches[md]; ok && len(c.headings) > 0 {
+		used := lipgloss.Width(date) + 1
+		if hasEditedBy {
+			used += lipgloss.Width(editedBy) + 1
+		}
+		crumb = truncate.StringWithTail("· "+c.breadcrumb(), uint(max(0, int(truncateTo)-used)), ellipsis)
+		hasCrumb = true
+	}
+
+	target, _, _ := m.filterTarget()
+	query := parseFilterQuery(target, nil).text
 	isSelected := index == m.cursor()
 	isFiltering := m.filterState == filtering
 	singleFilteredItem := isFiltering && len(m.getVisibleMarkdowns()) == 1
@@ -42,19 +67,21 @@ func stashItemView(b *strings.Builder, m stashModel, index int, md *markdown) {
 			title = greenFg(title)
 			date = semiDimGreenFg(date)
 			editedBy = semiDimGreenFg(editedBy)
+			crumb = semiDimGreenFg(crumb)
 			separator = semiDimGreenFg(separator)
 		} else {
 			gutter = dullFuchsiaFg(verticalLine)
 			if m.currentSection().key == filterSection &&
 				m.filterState == filterApplied || singleFilteredItem {
 				s := lipgloss.NewStyle().Foreground(fuchsia)
-				title = styleFilteredText(title, m.filterInput.Value(), s, s.Underline(true))
+				title = styleFilteredText(title, m.matchedRunes(title, query), s, s.Underline(true))
 			} else {
 				title = fuchsiaFg(title)
 				icon = fuchsiaFg(icon)
 			}
 			date = dimFuchsiaFg(date)
 			editedBy = dimDullFuchsiaFg(editedBy)
+			crumb = dimDullFuchsiaFg(crumb)
 			separator = dullFuchsiaFg(separator)
 		}
 	} else {
@@ -64,20 +91,23 @@ func stashItemView(b *strings.Builder, m stashModel, index int, md *markdown) {
 			title = greenFg(title)
 			date = semiDimGreenFg(date)
 			editedBy = semiDimGreenFg(editedBy)
+			crumb = semiDimGreenFg(crumb)
 			separator = semiDimGreenFg(separator)
 		} else if isFiltering && m.filterInput.Value() == "" {
 			icon = dimGreenFg(icon)
 			title = dimNormalFg(title)
 			date = dimBrightGrayFg(date)
 			editedBy = dimBrightGrayFg(editedBy)
+			crumb = dimBrightGrayFg(crumb)
 			separator = dimBrightGrayFg(separator)
 		} else {
 			icon = greenFg(icon)
 
 			s := lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#1a1a1a", Dark: "#dddddd"})
-			title = styleFilteredText(title, m.filterInput.Value(), s, s.Underline(true))
+			title = styleFilteredText(title, m.matchedRunes(title, query), s, s.Underline(true))
 			date = grayFg(date)
 			editedBy = midGrayFg(editedBy)
+			crumb = midGrayFg(crumb)
 			separator = brightGrayFg(separator)
 		}
 	}
@@ -87,32 +117,39 @@ func stashItemView(b *strings.Builder, m stashModel, index int, md *markdown) {
 	if hasEditedBy {
 		fmt.Fprintf(b, " %s", editedBy)
 	}
+	if hasCrumb {
+		fmt.Fprintf(b, " %s", crumb)
+	}
 }
 
-func styleFilteredText(haystack, needles string, defaultStyle, matchedStyle lipgloss.Style) string {
-	b := strings.Builder{}
-
-	normalizedHay, err := normalize(haystack)
+// matchedRunes returns the indexes of the runes of a title that match the
+// filter text, for highlighting.
+func (m stashModel) matchedRunes(title, query string) []int {
+	if query == "" {
+		return nil
+	}
+	normalized, err := normalize(title)
 	if err != nil {
-		log.Error("error normalizing", "haystack", haystack, "error", err)
+		log.Error("error normalizing", "title", title, "error", err)
 	}
-
-	matches := fuzzy.Find(needles, []string{normalizedHay})
+	matches := m.matcher.find(normalizeQuery(query), []string{normalized})
 	if len(matches) == 0 {
+		return nil
+	}
+	return matches[0].matched
+}
+
+func styleFilteredText(haystack string, matched []int, defaultStyle, matchedStyle lipgloss.Style) string {
+	if len(matched) == 0 {
 		return defaultStyle.Render(haystack)
 	}
 
-	m := matches[0] // only one match exists
-	for i, rune := range []rune(haystack) {
-		styled := false
-		for _, mi := range m.MatchedIndexes {
-			if i == mi {
-				b.WriteString(matchedStyle.Render(string(rune)))
-				styled = true
-			}
-		}
-		if !styled {
-			b.WriteString(defaultStyle.Render(string(rune)))
+	b := strings.Builder{}
+	for i, r := range []rune(haystack) {
+		if slices.Contains(matched, i) {
+			b.WriteString(matchedStyle.Render(string(r)))
+		} else {
+			b.WriteString(defaultStyle.Render(string(r)))
 		}
 	}
 
@@ -0,0 +1,131 @@
+package ui
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"maps"
+	"os"
+
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/charmbracelet/log"
+	gap "github.com/muesli/go-app-paths"
+)
+
+// documentState is what we remember about a document between sessions.
+type documentState struct {
+	Line  int    `json:"line,omitempty"`  // reading position, as a source line
+	Style string `json:"style,omitempty"` // style chosen in the pager
+	Width uint   `json:"width,omitempty"` // word-wrap width chosen in the pager
+
+	// Source lines of bookmarks set in the pager, by number.
+	Bookmarks map[int]int `json:"bookmarks,omitempty"`
+
+	// Whether the document has been read to the end, or marked as read.
+	Read bool `json:"read,omitempty"`
+}
+
+func (d documentState) equal(o documentState) bool {
+	return d.Line == o.Line && d.Style == o.Style && d.Width == o.Width &&
+		maps.Equal(d.Bookmarks, o.Bookmarks) && d.Read == o.Read
+}
+
+// documentStore keeps the state of documents in the data dir, keyed by
+// path.
+type documentStore struct {
+	path string
+	docs map[string]documentState
+}
+
+// loadDocumentStore reads the document state from the data dir. Failing to
+// do so isn't fatal: we just start out without any state.
+func loadDocumentStore() *documentStore {
+	s := &documentStore{docs: make(map[string]documentState)}
+
+	p, err := gap.NewScope(gap.User, "glow").DataPath("documents.json")
+	if err != nil {
+		log.Error("could not locate document state", "error", err)
+		return s
+	}
+	s.path = p
+
+	b, err := os.ReadFile(p)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			log.Error("could not read document state", "error", err)
+		}
+		return s
+	}
+	if err := json.Unmarshal(b, &s.docs); err != nil {
+		log.Error("could not parse document state", "path", p, "error", err)
+	}
+	return s
+}
+
+func (s *documentStore) get(path string) documentState {
+	return s.docs[path]
+}
+
+// set updates the state of a document and writes the store to disk. Other
+// glow instances may have changed the store in the meantime: their changes
+// are kept, and so are theirs to this document, where they don't conflict
+// with this one.
+func (s *documentStore) set(path string, state documentState) {
+	old := s.docs[path]
+	if path == "" || old.equal(state) {
+		return
+	}
+	s.docs[path] = state
+	if err := s.save(path, old, state); err != nil {
+		log.Error("could not save document state", "error", err)
+	}
+	if s.docs[path].equal(documentState{}) {
+		delete(s.docs, path)
+	}
+}
+
+// save writes a change of a document's state to disk, merged into what's
+// there, and reloads the store.
+func (s *documentStore) save(path string, old, state documentState) error {
+	if s.path == "" {
+		return nil
+	}
+	return utils.UpdateFile(s.path, 0o600, func(b []byte) ([]byte, error) {
+		docs := make(map[string]documentState)
+		if len(b) > 0 {
+			if err := json.Unmarshal(b, &docs); err != nil {
+				// don't let a broken file keep us from saving
+				log.Error("could not parse document state", "path", s.path, "error", err)
+			}
+		}
+		merged := mergeState(docs[path], old, state)
+		if merged.equal(documentState{}) {
+			delete(docs, path)
+		} else {
+			docs[path] = merged
+		}
+		s.docs = docs
+		return json.MarshalIndent(docs, "", "  ")
+	})
+}
+
+// mergeState applies the fields that changed from old to state to the
+// state of a document as another instance may have saved it.
+func mergeState(saved, old, state documentState) documentState {
+	if state.Line != old.Line {
+		saved.Line = state.Line
+	}
+	if state.Style != old.Style {
+		saved.Style = state.Style
+	}
+	if state.Width != old.Width {
+		saved.Width = state.Width
+	}
+	if !maps.Equal(state.Bookmarks, old.Bookmarks) {
+		saved.Bookmarks = state.Bookmarks
+	}
+	if state.Read != old.Read {
+		saved.Read = state.Read
+	}
+	return saved
+}
@@ -0,0 +1,386 @@
+package ui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/glamour/ansi"
+	"github.com/charmbracelet/glamour/styles"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/reflow/truncate"
+)
+
+// StyleSample is the document styles are previewed with.
+const StyleSample = `# Heading
+
+Some **bold**, *italic* and ~~struck~~ text with ` + "`inline code`" + ` and a
+[link](https://github.com/charmbracelet/glow).
+
+## Lists
+
+- First item
+- Second item
+  1. Nested
+  2. Items
+
+> A blockquote with some wisdom.
+
+---
+
+` + "```go" + `
+func main() {
+	fmt.Println("Hello, Glow!")
+}
+` + "```" + `
+
+| Column | Value |
+|--------|-------|
+| Style  | Live  |
+`
+
+var (
+	styleEditorPaneStyle = lipgloss.NewStyle().
+				Border(lipgloss.NormalBorder(), false, true, false, false).
+				BorderForeground(darkGray)
+	styleEditorKeyStyle      = lipgloss.NewStyle().Foreground(gray)
+	styleEditorSelectedStyle = lipgloss.NewStyle().Foreground(fuchsia)
+)
+
+// styleField is an editable leaf value in a glamour style, such as
+// heading.color, by its path of JSON names and the index of its struct
+// field in ansi.StyleConfig.
+type styleField struct {
+	path  []string
+	index []int
+}
+
+func (f styleField) key() string {
+	return strings.Join(f.path, ".")
+}
+
+type styleSavedMsg struct{ err error }
+
+type styleEditorModel struct {
+	path     string
+	style    ansi.StyleConfig
+	fields   []styleField
+	cursor   int
+	offset   int
+	editing  bool
+	dirty    bool
+	quitting bool
+	status   string
+	input    textinput.Model
+	preview  viewport.Model
+	width    int
+	height   int
+}
+
+// NewStyleEditorProgram returns a program for editing the glamour style at
+// the given path with a live preview. If the file doesn't exist yet it's
+// seeded with the dark style.
+func NewStyleEditorProgram(path string) (*tea.Program, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		b, err = json.Marshal(styles.DarkStyleConfig)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var style ansi.StyleConfig
+	if err := json.Unmarshal(b, &style); err != nil {
+		return nil, fmt.Errorf("could not parse style %s: %w", path, err)
+	}
+
+	ti := textinput.New()
+	ti.Prompt = "> "
+	ti.PromptStyle = stashInputPromptStyle
+	ti.Cursor.Style = stashInputCursorStyle
+
+	m := styleEditorModel{
+		path:    path,
+		style:   style,
+		fields:  styleFields(nil, nil, reflect.TypeOf(style)),
+		input:   ti,
+		preview: viewport.New(0, 0),
+	}
+	return tea.NewProgram(m, tea.WithAltScreen()), nil
+}
+
+// styleFields returns all leaf values of a style type, sorted by path. They
+// come from the type rather than from a style, so settings a style leaves
+// out can be added too.
+func styleFields(prefix []string, index []int, t reflect.Type) []styleField {
+	var fields []styleField
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if name == "-" || !sf.IsExported() {
+			continue
+		}
+		path := prefix
+		if !sf.Anonymous || name != "" {
+			path = append(append([]string{}, prefix...), name)
+		}
+		idx := append(append([]int{}, index...), i)
+
+		ft := sf.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		switch ft.Kind() {
+		case reflect.Struct:
+			fields = append(fields, styleFields(path, idx, ft)...)
+		case reflect.String, reflect.Bool, reflect.Uint:
+			fields = append(fields, styleField{path: path, index: idx})
+		}
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].key() < fields[j].key()
+	})
+	return fields
+}
+
+// field returns the value of a field in the style, and whether it's set.
+// With alloc, nil structs on the way to it are allocated, so it can be set;
+// otherwise the first nil pointer on the way is returned.
+func (m *styleEditorModel) field(f styleField, alloc bool) (reflect.Value, bool) {
+	v := reflect.ValueOf(&m.style).Elem()
+	for _, i := range f.index {
+		if v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !alloc {
+					return v, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+	return v, v.Kind() != reflect.Pointer || !v.IsNil()
+}
+
+// value returns the field's value as it's shown and edited, which is empty
+// if it isn't set.
+func (m *styleEditorModel) value(i int) string {
+	v, ok := m.field(m.fields[i], false)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprint(reflect.Indirect(v).Interface())
+}
+
+// setValue parses s according to the type of the field and stores it in the
+// style. Settings that are optional are unset by an empty value.
+func (m *styleEditorModel) setValue(i int, s string) error {
+	f := m.fields[i]
+	if s == "" {
+		if v, _ := m.field(f, false); v.Kind() == reflect.Pointer {
+			v.Set(reflect.Zero(v.Type()))
+			m.dirty = true
+			return nil
+		}
+	}
+
+	v, _ := m.field(f, true)
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() { //nolint:exhaustive
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("%s must be true or false", f.key())
+		}
+		v.SetBool(b)
+	case reflect.Uint:
+		n, err := strconv.ParseUint(s, 10, 0)
+		if err != nil {
+			return fmt.Errorf("%s must be a whole number", f.key())
+		}
+		v.SetUint(n)
+	default:
+		v.SetString(s)
+	}
+	m.dirty = true
+	return nil
+}
+
+func (m *styleEditorModel) renderPreview() {
+	r, err := glamour.NewTermRenderer(
+		glamour.WithColorProfile(lipgloss.ColorProfile()),
+		glamour.WithStyles(m.style),
+		glamour.WithWordWrap(max(0, m.preview.Width-4)),
+	)
+	if err != nil {
+		m.status = redFg(err.Error())
+		return
+	}
+	out, err := r.Render(StyleSample)
+	if err != nil {
+		m.status = redFg(err.Error())
+		return
+	}
+	m.preview.SetContent(out)
+}
+
+func (m styleEditorModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m styleEditorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.preview.Width = msg.Width - msg.Width/2 - 1
+		m.preview.Height = msg.Height - statusBarHeight
+		m.renderPreview()
+
+	case styleSavedMsg:
+		if msg.err != nil {
+			m.status = redFg(msg.err.Error())
+			break
+		}
+		m.dirty = false
+		m.status = greenFg("Saved " + m.path)
+
+	case tea.KeyMsg:
+		if m.editing {
+			return m.updateInput(msg)
+		}
+		if k := msg.String(); k != "q" && k != keyEsc {
+			m.quitting = false
+		}
+
+		switch msg.String() {
+		case "ctrl+c":
+			return m, tea.Quit
+		case "q", keyEsc:
+			if m.dirty && !m.quitting {
+				m.quitting = true
+				m.status = redFg("Unsaved changes, press q again to quit")
+				return m, nil
+			}
+			return m, tea.Quit
+		case "k", "up":
+			m.cursor = max(0, m.cursor-1)
+		case "j", "down":
+			m.cursor = min(len(m.fields)-1, m.cursor+1)
+		case "g", "home":
+			m.cursor = 0
+		case "G", "end":
+			m.cursor = len(m.fields) - 1
+		case "ctrl+s", "w":
+			return m, m.save()
+		case keyEnter:
+			if len(m.fields) == 0 {
+				break
+			}
+			m.editing = true
+			m.input.SetValue(m.value(m.cursor))
+			m.input.CursorEnd()
+			m.input.Focus()
+			return m, textinput.Blink
+		case "pgdown", "f", "pgup", "b":
+			var cmd tea.Cmd
+			m.preview, cmd = m.preview.Update(msg)
+			return m, cmd
+		}
+
+		// keep the cursor in view
+		rows := m.height - statusBarHeight
+		if m.cursor < m.offset {
+			m.offset = m.cursor
+		} else if m.cursor >= m.offset+rows {
+			m.offset = m.cursor - rows + 1
+		}
+	}
+
+	return m, nil
+}
+
+func (m styleEditorModel) updateInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case keyEsc:
+		m.editing = false
+		m.input.Blur()
+		return m, nil
+	case keyEnter:
+		m.editing = false
+		m.input.Blur()
+		if err := m.setValue(m.cursor, m.input.Value()); err != nil {
+			m.status = redFg(err.Error())
+			return m, nil
+		}
+		m.status = ""
+		m.renderPreview()
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+func (m styleEditorModel) save() tea.Cmd {
+	b, err := json.MarshalIndent(m.style, "", "  ")
+	return func() tea.Msg {
+		if err != nil {
+			return styleSavedMsg{err}
+		}
+		return styleSavedMsg{os.WriteFile(m.path, append(b, '\n'), 0o644)} //nolint:gosec
+	}
+}
+
+func (m styleEditorModel) View() string {
+	leftWidth := m.width / 2
+	rows := m.height - statusBarHeight
+
+	var b strings.Builder
+	for i := m.offset; i < min(len(m.fields), m.offset+rows); i++ {
+		f := m.fields[i]
+		line := styleEditorKeyStyle.Render(f.key()) + " " + m.value(i)
+		if i == m.cursor {
+			line = styleEditorSelectedStyle.Render("│ "+f.key()) + " " + m.value(i)
+			if m.editing {
+				line = styleEditorSelectedStyle.Render("│ "+f.key()) + " " + m.input.View()
+			}
+		} else {
+			line = "  " + line
+		}
+		b.WriteString(truncate.StringWithTail(line, uint(max(0, leftWidth-1)), ellipsis))
+		if i < min(len(m.fields), m.offset+rows)-1 {
+			b.WriteRune('\n')
+		}
+	}
+
+	left := styleEditorPaneStyle.Width(leftWidth - 1).Height(rows).Render(b.String())
+	body := lipgloss.JoinHorizontal(lipgloss.Top, left, m.preview.View())
+
+	help := "j/k choose • enter edit • ctrl+s save • pgup/pgdn scroll preview • q quit"
+	if m.editing {
+		help = "enter apply • empty value unsets • esc cancel"
+	}
+	status := m.status
+	if status == "" {
+		status = grayFg(help)
+	}
+	return body + "\n" + truncate.StringWithTail(" "+status, uint(max(0, m.width)), ellipsis)
+}
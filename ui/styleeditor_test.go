@@ -0,0 +1,83 @@
+package ui
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/glamour/ansi"
+	"github.com/charmbracelet/glamour/styles"
+)
+
+func TestStyleEditorFields(t *testing.T) {
+	b, err := json.Marshal(styles.DarkStyleConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := styleEditorModel{fields: styleFields(nil, nil, reflect.TypeOf(ansi.StyleConfig{}))}
+	if err := json.Unmarshal(b, &m.style); err != nil {
+		t.Fatal(err)
+	}
+
+	find := func(key string) int {
+		t.Helper()
+		for i, f := range m.fields {
+			if f.key() == key {
+				return i
+			}
+		}
+		t.Fatalf("no field %s", key)
+		return -1
+	}
+
+	// fields of embedded structs are flattened, and settings the dark style
+	// leaves out are listed too
+	for key, want := range map[string]string{
+		"h1.color":                     "228",
+		"h1.prefix":                    " ",
+		"h6.italic":                    "",
+		"list.level_indent":            "2",
+		"code_block.chroma.text.color": "#C4C4C4",
+		"table.center_separator":       "",
+	} {
+		if got := m.value(find(key)); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+
+	for _, tc := range []struct {
+		key, value, want string
+		err              bool
+	}{
+		{"h6.italic", "true", "true", false},
+		{"h6.italic", "maybe", "true", true},
+		{"list.level_indent", "4", "4", false},
+		{"list.level_indent", "-1", "4", true},
+		{"table.center_separator", "+", "+", false},
+		{"code_block.chroma.text.color", "", "", false},
+	} {
+		err := m.setValue(find(tc.key), tc.value)
+		if (err != nil) != tc.err {
+			t.Errorf("setting %s to %q: got error %v", tc.key, tc.value, err)
+		}
+		if got := m.value(find(tc.key)); got != tc.want {
+			t.Errorf("after setting %s to %q, it's %q, want %q", tc.key, tc.value, got, tc.want)
+		}
+	}
+
+	// settings that were added are saved, and unset ones left out
+	b, err = json.Marshal(m.style.H6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"italic":true`) {
+		t.Errorf("h6 saved as %s, without italic", b)
+	}
+	if err := m.setValue(find("h6.italic"), ""); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := json.Marshal(m.style.H6); strings.Contains(string(b), "italic") {
+		t.Errorf("h6 saved as %s, with italic unset", b)
+	}
+}
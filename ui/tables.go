@@ -0,0 +1,92 @@
+package ui
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/glow/v2/utils"
+)
+
+// Tables with more rows than this are virtualized in the pager: only the
+// header and a window of rows around the viewport are rendered, and the
+// window moves along as the viewport reaches either end of it. Exports
+// always render tables in full.
+const virtualTableRows = 200
+
+var tableDelimiterPattern = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?\s*$`)
+
+// table is a table in a markdown document.
+type table struct {
+	start int // line of the header row
+	rows  int // number of body rows
+}
+
+// findLargeTables returns the tables with more than virtualTableRows rows,
+// ignoring code blocks.
+func findLargeTables(lines []string) []table {
+	var (
+		tables []table
+		fence  utils.CodeFence
+	)
+	for i := 0; i < len(lines)-1; i++ {
+		if fence.Scan(lines[i]) ||
+			!strings.Contains(lines[i], "|") ||
+			!strings.Contains(lines[i+1], "|") ||
+			!tableDelimiterPattern.MatchString(lines[i+1]) {
+			continue
+		}
+		end := i + 2
+		for end < len(lines) && strings.TrimSpace(lines[end]) != "" {
+			end++
+		}
+		if rows := end - i - 2; rows > virtualTableRows {
+			tables = append(tables, table{start: i, rows: rows})
+		}
+		i = end - 1
+	}
+	return tables
+}
+
+// tableOffset returns the first row shown of the given large table.
+func tableOffset(offsets map[int]int, i int, t table) int {
+	return max(0, min(offsets[i], t.rows-virtualTableRows))
+}
+
+func tableRowsMarker(i, offset, rows int) string {
+	return fmt.Sprintf("Table %d · rows %d–%d of %d", i+1, offset+1, offset+virtualTableRows, rows)
+}
+
+func tableMoreMarker(i, more int) string {
+	return fmt.Sprintf("Table %d · %d more rows below", i+1, more)
+}
+
+// virtualizeTables replaces the body of large tables with the window of rows
+// starting at their offset, surrounded by row count indicators.
+func virtualizeTables(md string, offsets map[int]int) string {
+	lines := strings.Split(md, "\n")
+	tables := findLargeTables(lines)
+	if len(tables) == 0 {
+		return md
+	}
+
+	var (
+		out  []string
+		prev int
+	)
+	for i, t := range tables {
+		o := tableOffset(offsets, i, t)
+		body := t.start + 2 //nolint:mnd
+
+		out = append(out, lines[prev:t.start]...)
+		out = append(out, "", "*"+tableRowsMarker(i, o, t.rows)+"*", "")
+		out = append(out, lines[t.start:body]...)
+		out = append(out, lines[body+o:body+o+virtualTableRows]...)
+		if more := t.rows - o - virtualTableRows; more > 0 {
+			out = append(out, "", "*"+tableMoreMarker(i, more)+"*")
+		}
+		prev = body + t.rows
+	}
+	out = append(out, lines[prev:]...)
+	return strings.Join(out, "\n")
+}
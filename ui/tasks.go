@@ -0,0 +1,105 @@
+package ui
+
+import (
+	"errors"
+	"os"
+	"regexp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/charmbracelet/x/ansi"
+)
+
+var taskPattern = regexp.MustCompile(`^(\s*(?:[-*+]|\d+[.)])\s+\[)([ xX])\]\s+(.*?)\s*$`)
+
+// task is a task list item ("- [ ] do something") in a markdown document.
+type task struct {
+	offset  int // byte offset of the check mark in the source
+	checked bool
+	text    string
+}
+
+// findTasks returns the task list items in a markdown document, ignoring
+// those in code blocks.
+func findTasks(body string) []task {
+	var (
+		tasks  []task
+		fence  utils.CodeFence
+		offset int
+	)
+	for _, l := range strings.SplitAfter(body, "\n") {
+		line := strings.TrimRight(l, "\r\n")
+		if !fence.Scan(line) {
+			if m := taskPattern.FindStringSubmatchIndex(line); m != nil {
+				tasks = append(tasks, task{
+					offset:  offset + m[4],
+					checked: line[m[4]] != ' ',
+					text:    line[m[6]:m[7]],
+				})
+			}
+		}
+		offset += len(l)
+	}
+	return tasks
+}
+
+type taskToggledMsg struct{ err error }
+
+// toggleTask flips the check mark of a task and writes the document back to
+// disk. Only the check mark itself is changed, so encoding and line endings
+// are preserved. If the file changed on disk in the meantime we bail.
+func toggleTask(md markdown, t task) tea.Cmd {
+	return func() tea.Msg {
+		info, err := os.Stat(md.localPath)
+		if err != nil {
+			return taskToggledMsg{err}
+		}
+		data, err := os.ReadFile(md.localPath)
+		if err != nil {
+			return taskToggledMsg{err}
+		}
+		if t.offset >= len(data) || (data[t.offset] != ' ') != t.checked {
+			return taskToggledMsg{errors.New("file changed on disk, reload and try again")}
+		}
+
+		if t.checked {
+			data[t.offset] = ' '
+		} else {
+			data[t.offset] = 'x'
+		}
+		if err := utils.WriteFileAtomic(md.localPath, data, info.Mode().Perm()); err != nil {
+			return taskToggledMsg{err}
+		}
+		if md.remotePath != "" {
+			if err := uploadRemoteMarkdown(&md); err != nil {
+				return taskToggledMsg{err}
+			}
+		}
+		return taskToggledMsg{}
+	}
+}
+
+// renderedLineOf returns the index of the first rendered line containing the
+// given text, or -1.
+func renderedLineOf(rendered, text string) int {
+	return renderedLineFrom(strings.Split(rendered, "\n"), text, 0)
+}
+
+// renderedLineFrom is like renderedLineOf, but only looks at the rendered
+// lines from the given one on.
+func renderedLineFrom(lines []string, text string, from int) int {
+	text = strings.TrimSpace(text)
+	if len(text) > 24 { //nolint:mnd
+		text = text[:24]
+	}
+	if text == "" {
+		return -1
+	}
+	for i := from; i < len(lines); i++ {
+		if strings.Contains(ansi.Strip(lines[i]), text) {
+			return i
+		}
+	}
+	return -1
+}
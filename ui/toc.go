@@ -0,0 +1,211 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/muesli/reflow/truncate"
+)
+
+const keyTOC = "t"
+
+// How many headings the table of contents lists at once.
+const tocHeight = 10
+
+// tocMenu is the table of contents of the open document: its headings and
+// the selected one.
+type tocMenu struct {
+	headings []utils.Heading
+	cursor   int
+	offset   int // of the first heading listed
+}
+
+// documentHeadings returns the headings of the open document, leaving out
+// comments in its frontmatter that look like headings.
+func (m pagerModel) documentHeadings() []utils.Heading {
+	body := []byte(m.currentDocument.Body)
+	front := strings.Count(string(body[:len(body)-len(utils.RemoveFrontmatter(body))]), "\n")
+	headings := utils.Headings(body)
+	for len(headings) > 0 && headings[0].Line <= front {
+		headings = headings[1:]
+	}
+	return headings
+}
+
+// headingLines finds the rendered lines headings start on. Where a source
+// line ends up can only be estimated, so the headings are looked for in the
+// rendered document instead, in order, each below the one before: a heading
+// is the block that ends with its words, starting on its first line, even if
+// it was wrapped onto more lines. Decorations and line numbers before them
+// don't count, and neither do code blocks.
+func (m pagerModel) headingLines(headings []utils.Heading) []int {
+	lines := strings.Split(m.rendered, "\n")
+	inCode := make([]bool, len(lines))
+	for _, c := range m.renderedCodeBlocks(lines, len(lines)) {
+		for i := c.start; i < min(c.start+c.height, len(lines)); i++ {
+			inCode[i] = true
+		}
+	}
+	lineWords := make([][]string, len(lines))
+	for i, l := range lines {
+		lineWords[i] = words(ansi.Strip(l))
+		if m.common.cfg.ShowLineNumbers && len(lineWords[i]) > 0 {
+			lineWords[i] = lineWords[i][1:]
+		}
+	}
+
+	found := make([]int, len(headings))
+	next := 0
+	for i, h := range headings {
+		found[i] = -1
+		want := words(h.Text)
+		if len(want) == 0 {
+			continue
+		}
+		for j := next; j < len(lines); j++ {
+			start := !inCode[j] && len(lineWords[j]) > 0 && (j == 0 || len(lineWords[j-1]) == 0)
+			if start && blockIsHeading(lineWords[j:], want) {
+				found[i] = j
+				next = j + 1
+				break
+			}
+		}
+	}
+	return found
+}
+
+// blockIsHeading reports whether the block of rendered lines, given by their
+// words, ends with the words of a heading, which start on its first line.
+func blockIsHeading(lines [][]string, heading []string) bool {
+	var block []string
+	first := len(lines[0])
+	for _, w := range lines {
+		if len(w) == 0 {
+			break
+		}
+		block = append(block, w...)
+		if len(block) > first+len(heading) {
+			return false
+		}
+	}
+	start := len(block) - len(heading)
+	if start < 0 || start >= first {
+		return false
+	}
+	for i, w := range heading {
+		if !strings.EqualFold(block[start+i], w) {
+			return false
+		}
+	}
+	return true
+}
+
+// words splits text into its words, leaving out markup and punctuation.
+func words(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// toggleTOC shows the table of contents, with the section being read
+// selected, or hides it.
+func (m *pagerModel) toggleTOC() tea.Cmd {
+	if m.toc != nil {
+		m.closeTOC()
+		return nil
+	}
+	headings := m.documentHeadings()
+	if len(headings) == 0 {
+		return m.showStatusMessage(pagerStatusMessage{"This document has no headings", false})
+	}
+
+	toc := &tocMenu{headings: headings}
+	for i, l := range m.headingLines(headings) {
+		if l >= 0 && l <= m.viewport.YOffset {
+			toc.cursor = i
+		}
+	}
+	toc.scroll()
+	m.toc = toc
+	m.preview = nil
+	m.closeLinkMode()
+	m.showClipboard, m.showNotifications = false, false
+	m.setSize(m.screenWidth, m.screenHeight)
+	return nil
+}
+
+func (m *pagerModel) closeTOC() {
+	m.toc = nil
+	m.setSize(m.screenWidth, m.screenHeight)
+}
+
+// updateTOC handles keys while the table of contents is shown: up and down
+// choose a heading, enter jumps to it, and esc closes the table. Other keys
+// aren't handled.
+func (m *pagerModel) updateTOC(msg tea.KeyMsg) (tea.Cmd, bool) {
+	toc := m.toc
+	switch msg.String() {
+	case "up", "k":
+		toc.cursor = max(0, toc.cursor-1)
+	case "down", "j":
+		toc.cursor = min(len(toc.headings)-1, toc.cursor+1)
+	case "home", "g":
+		toc.cursor = 0
+	case "end", "G":
+		toc.cursor = len(toc.headings) - 1
+	case keyEsc:
+		m.closeTOC()
+	case keyEnter:
+		m.closeTOC()
+		m.gotoHeading(toc.headings, toc.cursor)
+		var cmds []tea.Cmd
+		if m.viewport.HighPerformanceRendering {
+			cmds = append(cmds, viewport.Sync(m.viewport))
+		}
+		return tea.Batch(append(cmds, m.scrollTables())...), true
+	default:
+		return nil, false
+	}
+	toc.scroll()
+	return nil, true
+}
+
+// scroll keeps the selected heading in the listed ones.
+func (t *tocMenu) scroll() {
+	if t.cursor < t.offset {
+		t.offset = t.cursor
+	}
+	if t.cursor >= t.offset+tocHeight {
+		t.offset = t.cursor - tocHeight + 1
+	}
+}
+
+// view lists the headings, indented by level.
+func (t tocMenu) view(width int) string {
+	top := t.headings[0].Level
+	for _, h := range t.headings {
+		top = min(top, h.Level)
+	}
+
+	end := min(len(t.headings), t.offset+tocHeight)
+	lines := make([]string, 0, tocHeight+2) //nolint:mnd
+	for i := t.offset; i < end; i++ {
+		h := t.headings[i]
+		text := strings.Repeat("  ", h.Level-top) + h.Text
+		text = truncate.StringWithTail(text, uint(max(0, width-2)), ellipsis) //nolint:mnd
+		if i == t.cursor {
+			lines = append(lines, fuchsiaFg("│ "+text))
+		} else {
+			lines = append(lines, "  "+text)
+		}
+	}
+	lines = append(lines, "", subtleStyle.Render(fmt.Sprintf(
+		"%d/%d • ↑/↓ choose • enter jump • t/esc close", t.cursor+1, len(t.headings),
+	)))
+	return strings.Join(lines, "\n")
+}
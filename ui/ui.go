@@ -2,17 +2,19 @@ package ui
 
 import (
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
 
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/glamour/styles"
 	"github.com/charmbracelet/glow/v2/utils"
 	"github.com/charmbracelet/log"
 	"github.com/muesli/gitcha"
-	te "github.com/muesli/termenv"
 )
 
 const (
@@ -23,8 +25,10 @@ const (
 var (
 	config Config
 
-	markdownExtensions = []string{
+	// MarkdownExtensions are the patterns of the files listed as documents.
+	MarkdownExtensions = []string{
 		"*.md", "*.mdown", "*.mkdn", "*.mkd", "*.markdown",
+		"*.rst", "*.rest", "*.adoc", "*.asciidoc", "*.asc",
 	}
 )
 
@@ -40,6 +44,9 @@ func NewProgram(cfg Config) *tea.Program {
 
 	config = cfg
 	opts := []tea.ProgramOption{tea.WithAltScreen()}
+	if cfg.OnPanic != nil {
+		opts = append(opts, tea.WithoutCatchPanics())
+	}
 	if cfg.EnableMouse {
 		opts = append(opts, tea.WithMouseCellMotion())
 	}
@@ -94,6 +101,19 @@ type commonModel struct {
 	cwd    string
 	width  int
 	height int
+
+	// Status messages shown so far
+	notifications notificationLog
+
+	// Items copied to the clipboard so far
+	clipboard clipboardRing
+
+	// What we remember about documents between sessions
+	docs *documentStore
+
+	// Last commits of local documents, by path, once looked up. Untracked
+	// documents have an empty entry.
+	gitMetadata map[string]gitMetadata
 }
 
 type model struct {
@@ -105,18 +125,60 @@ type model struct {
 	stash stashModel
 	pager pagerModel
 
+	// Alternate keymap, if any
+	keys keyProfile
+
+	// Recorded keystrokes
+	macro macro
+
+	// Keybinding reference, while it's shown
+	help *helpOverlay
+
+	// Document shown beside the one in the pager, if any
+	split *splitView
+
 	// Channel that receives paths to local markdown files
 	// (via the github.com/muesli/gitcha package)
 	localFileFinder chan gitcha.SearchResult
+
+	// Watches the file of the open document, with --watch
+	watcher *fileWatcher
 }
 
-// unloadDocument unloads a document from the pager. Note that while this
-// method alters the model we also need to send along any commands returned.
+// saveReadingPosition remembers where we are in the current documents.
+func (m *model) saveReadingPosition() {
+	if m.state != stateShowDocument {
+		return
+	}
+	m.savePosition(m.pager)
+	if m.split != nil {
+		m.savePosition(m.split.pager)
+	}
+}
+
+// savePosition remembers where we are in the document of a pager.
+func (m *model) savePosition(p pagerModel) {
+	path := p.currentDocument.localPath
+	state := m.common.docs.get(path)
+	state.Line = p.sourceLine()
+	m.common.docs.set(path, state)
+}
+
+// unloadDocument unloads a document from the pager, along with the document
+// shown beside it, if any. Note that while this method alters the model we
+// also need to send along any commands returned.
 func (m *model) unloadDocument() []tea.Cmd {
+	m.saveReadingPosition()
 	m.state = stateShowStash
 	m.stash.viewState = stashStateReady
+	m.stash.besideNote = ""
+	if m.split != nil {
+		m.split.pager.unload()
+		m.split = nil
+		m.pager.blurred = false
+		m.pager.setSize(m.common.width, m.common.height)
+	}
 	m.pager.unload()
-	m.pager.showHelp = false
 
 	var batch []tea.Cmd
 	if m.pager.viewport.HighPerformanceRendering {
@@ -133,7 +195,7 @@ func newModel(cfg Config) tea.Model {
 	initSections()
 
 	if cfg.GlamourStyle == styles.AutoStyle {
-		if te.HasDarkBackground() {
+		if utils.Term.HasDarkBackground() {
 			cfg.GlamourStyle = styles.DarkStyle
 		} else {
 			cfg.GlamourStyle = styles.LightStyle
@@ -141,24 +203,88 @@ func newModel(cfg Config) tea.Model {
 	}
 
 	common := commonModel{
-		cfg: cfg,
+		cfg:         cfg,
+		docs:        loadDocumentStore(),
+		gitMetadata: make(map[string]gitMetadata),
 	}
 
-	return model{
+	m := model{
 		common: &common,
 		state:  stateShowStash,
 		pager:  newPagerModel(&common),
 		stash:  newStashModel(&common),
+		keys:   newKeyProfile(cfg.KeyProfile),
+	}
+	if cfg.Watch {
+		m.watcher = newFileWatcher()
 	}
+	return m
 }
 
 func (m model) Init() tea.Cmd {
 	cmds := []tea.Cmd{m.stash.spinner.Tick}
-	cmds = append(cmds, findLocalFiles(*m.common))
-	return tea.Batch(cmds...)
+	cmds = append(cmds, findLocalFiles(*m.common), m.watcher.wait())
+	return guard(tea.Batch(cmds...))
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	m, cmd := m.dispatch(msg)
+	return m, guard(cmd)
+}
+
+// dispatch handles macros and alternate key profiles before updating the
+// model.
+func (m model) dispatch(msg tea.Msg) (model, tea.Cmd) {
+	switch key := msg.(type) {
+	case tea.KeyMsg:
+		if handled, cmd := m.updateMacro(key); handled {
+			return m, cmd
+		}
+	case macroKeyMsg:
+		msg = tea.KeyMsg(key)
+	}
+
+	// Translate keys from alternate key profiles, unless we're entering text
+	// or going to a bookmark, which vim's counts would take the digits of
+	if key, ok := msg.(tea.KeyMsg); ok && m.keys.active() && !m.editingText() && !m.bookmarkKey(key) {
+		var keys []tea.Msg
+		keys, m.keys = m.keys.translate(key)
+
+		var cmds []tea.Cmd
+		for _, k := range keys {
+			if _, ok := k.(gotoLineMsg); ok && m.state != stateShowDocument {
+				k = keyMsg("end")
+			}
+			var cmd tea.Cmd
+			m, cmd = m.update(k)
+			cmds = append(cmds, cmd)
+		}
+		return m, tea.Batch(cmds...)
+	}
+
+	return m.update(msg)
+}
+
+// editingText reports whether keystrokes are currently going to a text
+// input, or to a prompt such as the one for the number of a bookmark.
+func (m model) editingText() bool {
+	return m.help != nil ||
+		m.state == stateShowStash && m.stash.typing() ||
+		m.state == stateShowDocument && (m.pager.commanding || m.pager.searching || m.pager.marking)
+}
+
+// bookmarkKey reports whether a key goes to a bookmark of the document: a
+// digit of a bookmark that's set, unless a count is being typed.
+func (m model) bookmarkKey(key tea.KeyMsg) bool {
+	if m.state != stateShowDocument || m.keys.count > 0 || m.keys.pending != "" {
+		return false
+	}
+	n, ok := bookmarkNumber(key.String())
+	_, set := m.pager.bookmarks[n]
+	return ok && set
+}
+
+func (m model) update(msg tea.Msg) (model, tea.Cmd) {
 	// If there's been an error, any key exits
 	if m.fatalErr != nil {
 		if _, ok := msg.(tea.KeyMsg); ok {
@@ -170,8 +296,46 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		// quitting while streaming takes a second q right after the first
+		if msg.String() != "q" {
+			m.pager.quitPrompt = false
+		}
+		if m.help != nil && msg.String() != "ctrl+c" {
+			help, closed, cmd := m.help.update(msg)
+			m.help = &help
+			if closed {
+				m.help = nil
+				if m.state == stateShowDocument && m.pager.viewport.HighPerformanceRendering {
+					cmd = viewport.Sync(m.pager.viewport)
+				}
+			}
+			return m, cmd
+		}
+
+		// pass through all keys but ctrl+c while a pager command or search is
+		// typed in or a code block is about to be run
+		if m.state == stateShowDocument && (m.pager.commanding || m.pager.searching || m.pager.pendingRun != nil) && msg.String() != "ctrl+c" {
+			var cmd tea.Cmd
+			m.pager, cmd = m.pager.update(msg)
+			return m, forPane(m.pager.pane, cmd)
+		}
+
 		switch msg.String() {
 		case "esc":
+			// esc closes the list of copied items, a link preview, the
+			// table of contents or link mode, or clears a search, before the
+			// document
+			if m.state == stateShowDocument && (m.pager.showClipboard || m.pager.preview != nil || m.pager.toc != nil || m.pager.links != nil || m.pager.searchQuery != "") {
+				var cmd tea.Cmd
+				m.pager, cmd = m.pager.update(msg)
+				return m, forPane(m.pager.pane, cmd)
+			}
+			// and goes back to the document when choosing one to show
+			// beside it
+			if m.state == stateShowStash && m.stash.besideNote != "" && !m.stash.typing() && !m.stash.filterApplied() {
+				m.cancelBeside()
+				return m, nil
+			}
 			if m.state == stateShowDocument || m.stash.viewState == stashStateLoadingDocument {
 				batch := m.unloadDocument()
 				return m, tea.Batch(batch...)
@@ -180,12 +344,11 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			var cmd tea.Cmd
 			if m.state == stateShowStash {
 				// pass through all keys if we're editing the filter
-				if m.stash.filterState == filtering {
+				if m.stash.typing() {
 					m.stash, cmd = m.stash.update(msg)
 					return m, cmd
 				}
-				m.stash.markdowns = nil
-				return m, m.Init()
+				return m, m.stash.startRefresh()
 			}
 
 		case "q":
@@ -194,12 +357,17 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			switch m.state {
 			case stateShowStash:
 				// pass through all keys if we're editing the filter
-				if m.stash.filterState == filtering {
+				if m.stash.typing() {
 					m.stash, cmd = m.stash.update(msg)
 					return m, cmd
 				}
+			case stateShowDocument:
+				if confirm, cmd := m.pager.confirmQuit(); confirm {
+					return m, cmd
+				}
 			}
 
+			m.saveReadingPosition()
 			return m, tea.Quit
 
 		case "left", "h", "delete":
@@ -208,11 +376,37 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, tea.Batch(cmds...)
 			}
 
+		case keySplit:
+			if m.state == stateShowDocument {
+				return m, m.chooseBeside()
+			}
+		case keySplitFocus:
+			if m.state == stateShowDocument && m.split != nil {
+				m.switchFocus()
+				return m, nil
+			}
+		case keySyncScroll:
+			if m.state == stateShowDocument && m.split != nil {
+				return m, m.toggleSyncScroll()
+			}
+
+		case keyHelp, keyHelpAlt:
+			if m.keys.name == KeyProfileVim && m.state == stateShowDocument && msg.String() == keyHelp {
+				// vim's backward search
+				return m, forPane(m.pager.pane, m.pager.startSearch(true))
+			}
+			if m.state == stateShowDocument || !m.stash.typing() {
+				help := newHelpOverlay(m.keys)
+				m.help = &help
+				return m, textinput.Blink
+			}
+
 		case "ctrl+z":
 			return m, tea.Suspend
 
 		// Ctrl+C always quits no matter where in the application you are.
 		case "ctrl+c":
+			m.saveReadingPosition()
 			return m, tea.Quit
 		}
 
@@ -221,7 +415,15 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.common.width = msg.Width
 		m.common.height = msg.Height
 		m.stash.setSize(msg.Width, msg.Height)
-		m.pager.setSize(msg.Width, msg.Height)
+		if m.split != nil {
+			m.layoutSplit()
+			cmds = append(cmds, forPane(m.split.pager.pane, renderDocument(m.split.pager)))
+		} else {
+			m.pager.setSize(msg.Width, msg.Height)
+		}
+
+	case paneMsg:
+		return m.updatePane(msg)
 
 	case initLocalFileSearchMsg:
 		m.localFileFinder = msg.ch
@@ -230,14 +432,47 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case fetchedMarkdownMsg:
 		// We've loaded a markdown file's contents for rendering
-		m.pager.currentDocument = *msg
-		body := string(utils.RemoveFrontmatter([]byte(msg.Body)))
-		cmds = append(cmds, renderWithGlamour(m.pager, body))
+		if m.state == stateShowStash && m.stash.besideNote != "" {
+			return m, m.openSplit(msg)
+		}
+		opening := m.state != stateShowDocument || m.pager.following
+		m.pager.following = false
+		if opening && msg.remotePath == "" {
+			m.watcher.watch(msg.localPath)
+		}
+		cmds = append(cmds, forPane(m.pager.pane, m.loadDocument(&m.pager, msg, opening)))
+
+	case openLinkedMsg:
+		// a followed link replaces the document in the pager, which keeps
+		// the history of followed links
+		if m.state != stateShowDocument {
+			return m, nil
+		}
+		m.savePosition(m.pager)
+		history, future := m.pager.history, m.pager.future
+		m.pager.unload()
+		m.pager.history, m.pager.future = history, future
+		m.pager.following = true
+		return m, forPane(m.pager.pane, loadLocalMarkdown(msg.md))
+
+	case fileChangedMsg:
+		// the document is loaded again like a refreshed remote one, which
+		// keeps the scroll position
+		cmds = append(cmds, m.watcher.wait())
+		doc := &m.pager.currentDocument
+		if m.state == stateShowDocument && doc.remotePath == "" && filepath.Clean(doc.localPath) == msg.path {
+			cmds = append(cmds, loadLocalMarkdown(doc))
+		}
 
 	case contentRenderedMsg:
 		m.state = stateShowDocument
 
-	case localFileSearchFinished:
+	case renderAheadMsg:
+		if msg.chunks != nil {
+			m.state = stateShowDocument
+		}
+
+	case localFileSearchFinished, exportedMsg:
 		// Always pass these messages to the stash so we can keep it updated
 		// about network activity, even if the user isn't currently viewing
 		// the stash.
@@ -247,15 +482,48 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case foundLocalFileMsg:
 		newMd := localFileToMarkdown(m.common.cwd, gitcha.SearchResult(msg))
-		m.stash.addMarkdowns(newMd)
-		if m.stash.filterApplied() {
-			newMd.buildFilterValue()
+		if m.stash.refresh != nil {
+			m.stash.refreshFound(newMd)
+		} else {
+			m.stash.addMarkdowns(newMd)
+			if m.stash.filterApplied() {
+				newMd.buildFilterValue()
+			}
+			if m.stash.shouldUpdateFilter() {
+				cmds = append(cmds, filterMarkdowns(m.stash))
+			}
 		}
-		if m.stash.shouldUpdateFilter() {
-			cmds = append(cmds, filterMarkdowns(m.stash))
+		if m.common.cfg.GitMetadata {
+			if _, ok := m.common.gitMetadata[newMd.localPath]; !ok {
+				cmds = append(cmds, fetchGitMetadata(newMd.localPath))
+			}
 		}
 		cmds = append(cmds, findNextLocalFile(m))
 
+	case gitMetadataMsg:
+		m.common.gitMetadata[msg.path] = msg.meta
+
+	case foundRemoteFilesMsg:
+		if m.stash.refresh != nil {
+			m.stash.refreshFound(msg...)
+		} else {
+			m.stash.addMarkdowns(msg...)
+			if m.stash.filterApplied() {
+				for _, md := range msg {
+					md.buildFilterValue()
+				}
+			}
+			if m.stash.shouldUpdateFilter() {
+				cmds = append(cmds, filterMarkdowns(m.stash))
+			}
+		}
+		cmds = append(cmds, func() tea.Msg { return localFileSearchFinished{} })
+
+	case editorFinishedMsg:
+		if msg.changed {
+			m.stash.refreshEdited(msg.path)
+		}
+
 	case filteredMarkdownMsg:
 		if m.state == stateShowDocument {
 			newStashModel, cmd := m.stash.update(msg)
@@ -272,21 +540,53 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		cmds = append(cmds, cmd)
 
 	case stateShowDocument:
+		offset := m.pager.viewport.YOffset
 		newPagerModel, cmd := m.pager.update(msg)
 		m.pager = newPagerModel
-		cmds = append(cmds, cmd)
+		cmds = append(cmds, forPane(m.pager.pane, cmd))
+		if m.split != nil && m.split.syncScroll && m.pager.viewport.YOffset != offset {
+			m.split.follow(m.pager)
+		}
 	}
 
 	return m, tea.Batch(cmds...)
 }
 
+// loadDocument shows a fetched document in a pager. When opening a
+// document, rather than reloading it, what we remember about it is restored.
+func (m model) loadDocument(p *pagerModel, md *markdown, opening bool) tea.Cmd {
+	p.currentDocument = *md
+	if opening {
+		doc := &p.currentDocument
+		state := m.common.docs.get(doc.localPath)
+		p.style, p.width = state.Style, state.Width
+		p.bookmarks = maps.Clone(state.Bookmarks)
+		if doc.line == 0 && doc.anchor == "" {
+			doc.line = state.Line
+		}
+	}
+	p.dates = newDocDates(md.Body)
+	p.refreshed = time.Now()
+	body := string(utils.RemoveFrontmatter([]byte(md.Body)))
+	if opening {
+		return tea.Batch(renderWithGlamour(*p, body), p.scheduleRefresh())
+	}
+	return renderWithGlamour(*p, body)
+}
+
 func (m model) View() string {
 	if m.fatalErr != nil {
 		return errorView(m.fatalErr, true)
 	}
+	if m.help != nil {
+		return m.help.view(m.common.width, m.common.height)
+	}
 
 	switch m.state {
 	case stateShowDocument:
+		if m.split != nil {
+			return m.split.view(m.pager)
+		}
 		return m.pager.View()
 	default:
 		return m.stash.view()
@@ -311,6 +611,10 @@ func errorView(err error, fatal bool) string {
 // COMMANDS
 
 func findLocalFiles(m commonModel) tea.Cmd {
+	if m.cfg.Remote != "" {
+		return findRemoteFiles(m)
+	}
+
 	return func() tea.Msg {
 		log.Info("findLocalFiles")
 		var (
@@ -339,9 +643,9 @@ func findLocalFiles(m commonModel) tea.Cmd {
 		// Switch between FindFiles and FindAllFiles to bypass .gitignore rules
 		var ch chan gitcha.SearchResult
 		if m.cfg.ShowAllFiles {
-			ch, err = gitcha.FindAllFilesExcept(cwd, markdownExtensions, nil)
+			ch, err = gitcha.FindAllFilesExcept(cwd, MarkdownExtensions, nil)
 		} else {
-			ch, err = gitcha.FindFilesExcept(cwd, markdownExtensions, ignorePatterns(m))
+			ch, err = gitcha.FindFilesExcept(cwd, MarkdownExtensions, ignorePatterns(m))
 		}
 
 		if err != nil {
@@ -384,6 +688,7 @@ func localFileToMarkdown(cwd string, res gitcha.SearchResult) *markdown {
 		localPath: res.Path,
 		Note:      stripAbsolutePath(res.Path, cwd),
 		Modtime:   res.Info.ModTime(),
+		Size:      res.Info.Size(),
 	}
 
 	return md
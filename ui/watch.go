@@ -0,0 +1,105 @@
+package ui
+
+import (
+	"path/filepath"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/log"
+	"github.com/fsnotify/fsnotify"
+)
+
+// How long to wait for more changes after the open document's file changed,
+// as editors tend to save in several steps.
+const watchSettle = 100 * time.Millisecond
+
+// fileChangedMsg is sent when the file of the open document changed.
+type fileChangedMsg struct {
+	path string
+}
+
+// fileWatcher watches the file of the open document. Editors often replace
+// files rather than write to them, which would end a watch on the file
+// itself, so its directory is watched instead.
+type fileWatcher struct {
+	w *fsnotify.Watcher
+
+	mu   sync.Mutex
+	dir  string
+	path string
+}
+
+func newFileWatcher() *fileWatcher {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Error("could not watch files", "error", err)
+		return nil
+	}
+	return &fileWatcher{w: w}
+}
+
+// watch switches to watching another file, or none for "".
+func (fw *fileWatcher) watch(path string) {
+	if fw == nil {
+		return
+	}
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+	fw.path = filepath.Clean(path)
+	dir := filepath.Dir(fw.path)
+	if path == "" {
+		dir = ""
+	}
+	if dir == fw.dir {
+		return
+	}
+	if fw.dir != "" {
+		_ = fw.w.Remove(fw.dir)
+	}
+	fw.dir = dir
+	if dir != "" {
+		if err := fw.w.Add(dir); err != nil {
+			log.Error("could not watch file", "path", path, "error", err)
+		}
+	}
+}
+
+func (fw *fileWatcher) watching(path string) bool {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+	return path == fw.path
+}
+
+// wait waits until the watched file changes, and then until no more changes
+// come in for a moment.
+func (fw *fileWatcher) wait() tea.Cmd {
+	if fw == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		var (
+			settle  <-chan time.Time
+			changed string
+		)
+		for {
+			select {
+			case e, ok := <-fw.w.Events:
+				if !ok {
+					return nil
+				}
+				if p := filepath.Clean(e.Name); fw.watching(p) && !e.Has(fsnotify.Chmod) {
+					changed = p
+					settle = time.After(watchSettle)
+				}
+			case err, ok := <-fw.w.Errors:
+				if !ok {
+					return nil
+				}
+				log.Error("error watching file", "error", err)
+			case <-settle:
+				return fileChangedMsg{changed}
+			}
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/muesli/termenv"
+)
+
+// ColorDecision is the color profile glow renders with, and why.
+type ColorDecision struct {
+	Profile termenv.Profile
+	Reason  string
+}
+
+// DecideColor picks the color profile for output to f. The environment is
+// consulted in this order, the first variable that's set deciding:
+//
+//   - GLOW_COLOR_PROFILE sets the profile by name, as ParseColorProfile
+//     reads it, no matter what the terminal supports.
+//   - NO_COLOR, when not empty, turns colors off.
+//   - FORCE_COLOR turns colors off with "0" or "false", and on otherwise,
+//     even when f isn't a terminal. "1", "2" and "3" ask for 16, 256 and
+//     16 million colors; any other value uses what the terminal supports.
+//   - CLICOLOR_FORCE, when not "0", turns colors on even when f isn't a
+//     terminal.
+//   - CLICOLOR set to "0" turns colors off.
+//
+// Without any of them, the profile is detected from Term, and output that
+// doesn't go to a terminal isn't colored.
+func DecideColor(f *os.File) ColorDecision {
+	// what the terminal would support, whether or not f is one; profiles
+	// are ordered from most to fewest colors
+	supported := func() termenv.Profile {
+		return min(termenv.ANSI, Term.ColorProfile(f))
+	}
+
+	if v := os.Getenv("GLOW_COLOR_PROFILE"); v != "" {
+		if p, err := ParseColorProfile(v); err == nil {
+			return ColorDecision{p, "GLOW_COLOR_PROFILE=" + v}
+		}
+	}
+
+	if os.Getenv("NO_COLOR") != "" {
+		return ColorDecision{termenv.Ascii, "NO_COLOR is set"}
+	}
+
+	if v, ok := os.LookupEnv("FORCE_COLOR"); ok {
+		switch strings.ToLower(v) {
+		case "0", "false":
+			return ColorDecision{termenv.Ascii, "FORCE_COLOR=" + v}
+		case "1":
+			return ColorDecision{termenv.ANSI, "FORCE_COLOR=1"}
+		case "2":
+			return ColorDecision{termenv.ANSI256, "FORCE_COLOR=2"}
+		case "3":
+			return ColorDecision{termenv.TrueColor, "FORCE_COLOR=3"}
+		default:
+			return ColorDecision{supported(), "FORCE_COLOR is set"}
+		}
+	}
+
+	if v := os.Getenv("CLICOLOR_FORCE"); v != "" && v != "0" {
+		return ColorDecision{supported(), "CLICOLOR_FORCE is set"}
+	}
+
+	if os.Getenv("CLICOLOR") == "0" {
+		return ColorDecision{termenv.Ascii, "CLICOLOR=0"}
+	}
+
+	if Term.IsTerminal(f) {
+		if p := Term.ColorProfile(f); p != termenv.Ascii {
+			return ColorDecision{p, "detected from TERM and COLORTERM"}
+		}
+	}
+	return ColorDecision{termenv.Ascii, "output is not a color terminal"}
+}
+
+// ColorVariables are the environment variables DecideColor looks at, in
+// order, followed by the ones terminal detection relies on.
+var ColorVariables = []string{"GLOW_COLOR_PROFILE", "NO_COLOR", "FORCE_COLOR", "CLICOLOR_FORCE", "CLICOLOR", "TERM", "COLORTERM"}
+
+// colorProfiles are the color profiles by name.
+var colorProfiles = map[string]termenv.Profile{
+	"ascii":     termenv.Ascii,
+	"ansi":      termenv.ANSI,
+	"ansi256":   termenv.ANSI256,
+	"truecolor": termenv.TrueColor,
+}
+
+// ParseColorProfile returns the color profile of a name: ascii, ansi,
+// ansi256 or truecolor.
+func ParseColorProfile(name string) (termenv.Profile, error) {
+	p, ok := colorProfiles[strings.ToLower(name)]
+	if !ok {
+		return termenv.Ascii, fmt.Errorf("invalid color profile %q: must be one of ascii, ansi, ansi256, truecolor", name)
+	}
+	return p, nil
+}
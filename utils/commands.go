@@ -0,0 +1,135 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+)
+
+// How much of what a failing command writes to stderr is kept for its error.
+const commandMaxStderr = 4 << 10 // 4 KiB
+
+// How long to wait for the output of a command that has been stopped, which
+// its children may still hold open.
+const CommandWaitDelay = time.Second
+
+var errCommandOutput = errors.New("too much output")
+
+// CommandPolicy is what external commands glow runs on documents, such as
+// output filters and code runners, may do: which programs may be run at all,
+// how long they may take, how much they may write, and which environment
+// variables they see. It's set up from the commands section of the config
+// file.
+type CommandPolicy struct {
+	Allow     []string
+	Timeout   time.Duration
+	MaxOutput int
+	Env       []string
+}
+
+// Check makes sure a program can be found, and is on the allowlist. Programs
+// are compared by the executables they resolve to, so "sed" allows
+// "/usr/bin/sed" and the other way around.
+func (p CommandPolicy) Check(name string) error {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return err
+	}
+	for _, a := range p.Allow {
+		if allowed, err := exec.LookPath(a); err == nil && allowed == path {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s isn't allowed to run: add it to allow in the commands section of the config file", name)
+}
+
+// Environ returns the environment of a command: the variables of glow's own
+// environment the policy lets through, and the given ones.
+func (p CommandPolicy) Environ(extra ...string) []string {
+	var env []string
+	for _, kv := range os.Environ() {
+		k, _, _ := strings.Cut(kv, "=")
+		if slices.ContainsFunc(p.Env, func(name string) bool { return strings.EqualFold(name, k) }) {
+			env = append(env, kv)
+		}
+	}
+	return append(env, extra...)
+}
+
+// Context returns a context that ends once a command has taken as long as
+// the policy allows.
+func (p CommandPolicy) Context() (context.Context, context.CancelFunc) {
+	if p.Timeout > 0 {
+		return context.WithTimeout(context.Background(), p.Timeout)
+	}
+	return context.WithCancel(context.Background())
+}
+
+// Run runs a command with the given input, and returns what it writes to
+// stdout. It's stopped once it takes longer or writes more than the policy
+// allows. Errors include the start of what it wrote to stderr.
+func (p CommandPolicy) Run(args []string, stdin string, env ...string) (string, error) {
+	ctx, cancel := p.Context()
+	defer cancel()
+	ctx, stop := context.WithCancel(ctx)
+	defer stop()
+
+	c := exec.CommandContext(ctx, args[0], args[1:]...) //nolint:gosec
+	c.Stdin = strings.NewReader(stdin)
+	c.Env = p.Environ(env...)
+	c.WaitDelay = CommandWaitDelay
+	stdout := &LimitedBuffer{Max: p.MaxOutput, Exceeded: stop}
+	stderr := &LimitedBuffer{Max: commandMaxStderr, Truncate: true}
+	c.Stdout, c.Stderr = stdout, stderr
+
+	err := c.Run()
+	switch {
+	case stdout.Over:
+		return "", fmt.Errorf("stopped after writing more than %d bytes", p.MaxOutput)
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return "", fmt.Errorf("stopped after %s", p.Timeout)
+	case err != nil:
+		if msg, _, _ := strings.Cut(strings.TrimSpace(stderr.String()), "\n"); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+	return stdout.String(), nil
+}
+
+// LimitedBuffer keeps what's written to it up to a maximum, 0 being no
+// maximum. Writing more is an error that calls Exceeded, or, if it
+// truncates, is silently dropped. Either way Over is set.
+type LimitedBuffer struct {
+	buf      bytes.Buffer
+	Max      int
+	Truncate bool
+	Exceeded func()
+	Over     bool
+}
+
+func (b *LimitedBuffer) Write(p []byte) (int, error) {
+	if b.Max > 0 && b.buf.Len()+len(p) > b.Max {
+		b.Over = true
+		if b.Truncate {
+			b.buf.Write(p[:b.Max-b.buf.Len()])
+			return len(p), nil
+		}
+		if b.Exceeded != nil {
+			b.Exceeded()
+		}
+		return 0, errCommandOutput
+	}
+	return b.buf.Write(p)
+}
+
+// String returns what has been kept.
+func (b *LimitedBuffer) String() string {
+	return b.buf.String()
+}
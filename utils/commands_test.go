@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCommandPolicyRun(t *testing.T) {
+	t.Setenv("GLOW_SECRET", "hunter2")
+	p := CommandPolicy{Timeout: time.Minute, Env: []string{"PATH"}}
+
+	out, err := p.Run([]string{"sh", "-c", "cat; echo ${GLOW_SECRET:-scrubbed} $EXTRA"}, "in\n", "EXTRA=given")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "in\nscrubbed given\n" {
+		t.Errorf("got %q", out)
+	}
+
+	if _, err := p.Run([]string{"sh", "-c", "echo oops >&2; exit 3"}, ""); err == nil || !strings.Contains(err.Error(), "oops") {
+		t.Errorf("expected an error with the command's stderr, got %v", err)
+	}
+
+	p.MaxOutput = 1000
+	if _, err := p.Run([]string{"sh", "-c", "yes"}, ""); err == nil || !strings.Contains(err.Error(), "more than 1000 bytes") {
+		t.Errorf("expected an error for too much output, got %v", err)
+	}
+
+	p.Timeout = 100 * time.Millisecond
+	if _, err := p.Run([]string{"sleep", "5"}, ""); err == nil || !strings.Contains(err.Error(), "stopped after 100ms") {
+		t.Errorf("expected a timeout, got %v", err)
+	}
+}
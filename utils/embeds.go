@@ -0,0 +1,125 @@
+package utils
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+)
+
+// Local images larger than this get a warning next to them.
+const largeImageSize = 5 << 20 // 5 MiB
+
+var (
+	// HTML elements a terminal can't display, which glamour drops silently.
+	// Objects come first as they may wrap an embed.
+	embedPatterns = []struct {
+		kind string
+		re   *regexp.Regexp
+	}{
+		{"object", embedElement("object")},
+		{"video", embedElement("video")},
+		{"audio", embedElement("audio")},
+		{"iframe", embedElement("iframe")},
+		{"embed", regexp.MustCompile(`(?is)<embed\b([^>]*?)/?>()`)},
+	}
+	srcPattern = regexp.MustCompile(`(?is)\b(?:src|data)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s>]+))`)
+	// Escapes characters of a placeholder that markdown would interpret.
+	markdownEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`, "<", `\<`)
+
+	imagePattern = regexp.MustCompile(`!\[[^\]]*\]\(<?([^)\s>]+)>?(?:\s+"[^"]*")?\)`)
+)
+
+// MarkEmbeds replaces video and audio elements, iframes and other embeds
+// outside of code blocks with a placeholder line, so they don't vanish
+// without a trace, and notes the size of large local images. Local images
+// are looked up relative to dir, unless it's empty. It returns the number
+// of placeholders and notes added.
+func MarkEmbeds(md, dir string) (string, int) {
+	var (
+		fence CodeFence
+		b     strings.Builder
+		text  strings.Builder
+		n     int
+	)
+	flush := func() {
+		s, m := markEmbeds(text.String(), dir)
+		b.WriteString(s)
+		n += m
+		text.Reset()
+	}
+	for _, l := range strings.SplitAfter(md, "\n") {
+		if fence.Scan(l) {
+			flush()
+			b.WriteString(l)
+			continue
+		}
+		text.WriteString(l)
+	}
+	flush()
+	return b.String(), n
+}
+
+// embedElement matches an element with its attributes and content.
+func embedElement(name string) *regexp.Regexp {
+	return regexp.MustCompile(`(?is)<` + name + `\b([^>]*?)(?:/>|>(.*?)</` + name + `\s*>)`)
+}
+
+func markEmbeds(s, dir string) (string, int) {
+	var n int
+	for _, p := range embedPatterns {
+		s = p.re.ReplaceAllStringFunc(s, func(tag string) string {
+			m := p.re.FindStringSubmatch(tag)
+			src := embedSource(m[1])
+			if src == "" {
+				src = embedSource(m[2]) // <source src="…"> of a video or audio
+			}
+			if src == "" {
+				src = "unknown source"
+			}
+			n++
+			return fmt.Sprintf(`*\[%s: %s — not displayable in terminal\]*`, p.kind, markdownEscaper.Replace(src))
+		})
+	}
+
+	if dir == "" {
+		return s, n
+	}
+	s = imagePattern.ReplaceAllStringFunc(s, func(img string) string {
+		p := imagePattern.FindStringSubmatch(img)[1]
+		if strings.Contains(p, "://") {
+			return img
+		}
+		if u, err := url.PathUnescape(p); err == nil {
+			p = u
+		}
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(dir, p)
+		}
+		st, err := os.Stat(p)
+		if err != nil || st.Size() <= largeImageSize {
+			return img
+		}
+		n++
+		return fmt.Sprintf(`%s *\[image: %s — %s, too large to preview\]*`, img, markdownEscaper.Replace(filepath.Base(p)), humanize.Bytes(uint64(st.Size())))
+	})
+	return s, n
+}
+
+// embedSource returns the first src or data attribute found, without the
+// scheme of URLs.
+func embedSource(s string) string {
+	m := srcPattern.FindStringSubmatch(s)
+	if m == nil {
+		return ""
+	}
+	src := m[1] + m[2] + m[3]
+	if i := strings.Index(src, "://"); i >= 0 {
+		src = src[i+3:]
+	}
+	return src
+}
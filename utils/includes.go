@@ -0,0 +1,126 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// MaxIncludeDepth is how deeply includes may be nested: a document including
+// one that includes another is two deep.
+const MaxIncludeDepth = 8
+
+// includePattern matches an include directive on a line of its own.
+var includePattern = regexp.MustCompile(`^\s{0,3}<!--\s*include:\s*(.*?)\s*-->\s*$`)
+
+// IncludeMap maps the lines of a document with its includes resolved to the
+// lines of the document itself. Lines of an included document map to the line
+// of the directive that included it.
+type IncludeMap []int
+
+// Line returns the 1-based line of the document a 1-based line of the
+// resolved document comes from.
+func (m IncludeMap) Line(l int) int {
+	if m == nil || l < 1 || l > len(m) {
+		return l
+	}
+	return m[l-1]
+}
+
+// ResolveIncludes replaces each <!-- include: path --> comment on a line of
+// its own, outside of code blocks, with the markdown file it names, less its
+// frontmatter. Paths are relative to dir, the directory of the document, and
+// includes of included files to their own directory. It fails if an included
+// file can't be read, includes itself along the way, or includes are nested
+// deeper than MaxIncludeDepth.
+func ResolveIncludes(md, dir string) (string, IncludeMap, error) {
+	if !strings.Contains(md, "<!--") {
+		return md, nil, nil
+	}
+	var r includeResolver
+	pieces, n, err := r.resolve(md, dir)
+	if err != nil || n == 0 {
+		return md, nil, err
+	}
+
+	var lines IncludeMap
+	for i, p := range pieces {
+		k := strings.Count(p, "\n")
+		if p != "" && !strings.HasSuffix(p, "\n") {
+			k++
+		}
+		for ; k > 0; k-- {
+			lines = append(lines, i+1)
+		}
+	}
+	return strings.Join(pieces, ""), lines, nil
+}
+
+// includeResolver resolves includes, keeping track of the files being
+// included, outermost first, to catch cycles.
+type includeResolver struct {
+	stack []string
+}
+
+// resolve resolves the includes of a document, returning what each of its
+// lines became, and how many includes it had.
+func (r *includeResolver) resolve(md, dir string) ([]string, int, error) {
+	var (
+		pieces []string
+		fence  CodeFence
+		n      int
+	)
+	for _, l := range strings.SplitAfter(md, "\n") {
+		m := includePattern.FindStringSubmatch(strings.TrimRight(l, "\r\n"))
+		if fence.Scan(l) || m == nil || m[1] == "" {
+			pieces = append(pieces, l)
+			continue
+		}
+
+		p := filepath.FromSlash(m[1])
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(dir, p)
+		}
+		s, err := r.include(p)
+		if err != nil {
+			return nil, 0, err
+		}
+		if strings.HasSuffix(l, "\n") && !strings.HasSuffix(s, "\n") {
+			s += "\n"
+		}
+		pieces = append(pieces, s)
+		n++
+	}
+	return pieces, n, nil
+}
+
+// include reads an included file and resolves its own includes.
+func (r *includeResolver) include(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	for i, p := range r.stack {
+		if p == abs {
+			cycle := append(append([]string{}, r.stack[i:]...), abs)
+			for j := range cycle {
+				cycle[j] = filepath.Base(cycle[j])
+			}
+			return "", fmt.Errorf("include cycle: %s", strings.Join(cycle, " -> "))
+		}
+	}
+	if len(r.stack) >= MaxIncludeDepth {
+		return "", fmt.Errorf("includes nested more than %d deep at %s", MaxIncludeDepth, path)
+	}
+
+	b, err := os.ReadFile(abs)
+	if err != nil {
+		return "", fmt.Errorf("could not include %s: %w", path, err)
+	}
+	r.stack = append(r.stack, abs)
+	defer func() { r.stack = r.stack[:len(r.stack)-1] }()
+	pieces, _, err := r.resolve(string(RemoveFrontmatter(b)), filepath.Dir(abs))
+	return strings.Join(pieces, ""), err
+}
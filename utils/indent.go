@@ -0,0 +1,137 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Tab stop lists are normalized with when no tab width is set, as in the
+// CommonMark spec.
+const defaultListTabWidth = 4
+
+// How far in a line outside of lists starts an indented code block.
+const codeIndent = 4
+
+var (
+	listItemPattern       = regexp.MustCompile(`^[ \t]*([-*+]|\d{1,9}[.)])([ \t]+|$)`)
+	tabbedListItemPattern = regexp.MustCompile(`(?m)^ *\t[ \t]*([-*+]|\d{1,9}[.)])[ \t]`)
+	thematicBreakPattern  = regexp.MustCompile(`^[ \t]*(-[ \t]*-[ \t]*-[- \t]*|\*[ \t]*\*[ \t]*\*[* \t]*|_[ \t]*_[ \t]*_[_ \t]*)$`)
+)
+
+// NormalizeListIndentation re-indents lists that are indented with tabs, or
+// a mix of tabs and spaces, with spaces. Editors disagree on how wide a tab
+// is, and markdown parsers count it as up to 4 columns, so such lists tend to
+// nest differently than intended. Here an item is nested below the closest
+// item before it that starts further left, going by tab stops of the given
+// width (4 if 0), and lines continuing an item are aligned with its text.
+// Indented code blocks outside of lists are left alone, items in them and
+// all.
+// Documents without tab-indented list items are returned as is.
+func NormalizeListIndentation(md string, tabWidth int) string {
+	if !tabbedListItemPattern.MatchString(md) {
+		return md
+	}
+	if tabWidth <= 0 {
+		tabWidth = defaultListTabWidth
+	}
+
+	// items that are open, innermost last
+	type item struct {
+		col         int // of the marker, as written
+		content     int // of the text, as written
+		normContent int // of the text, once normalized
+	}
+	var (
+		stack []item
+		fence CodeFence
+		blank bool
+
+		// shift of the code block being scanned, if it's in a list
+		codeFrom, codeTo = -1, 0
+	)
+
+	lines := strings.Split(md, "\n")
+	for i, l := range lines {
+		if fence.Open() {
+			fence.Scan(l)
+			if codeFrom >= 0 {
+				lines[i] = reindent(l, codeFrom, codeTo, tabWidth)
+			}
+			continue
+		}
+
+		rest := strings.TrimLeft(l, " \t")
+		col := columns(l[:len(l)-len(rest)], tabWidth)
+		if rest == "" {
+			blank = true
+			continue
+		}
+
+		if len(stack) == 0 && col >= codeIndent {
+			// an indented code block, or a paragraph going on: lists can't
+			// start this far in
+			blank = false
+			continue
+		}
+
+		if m := listItemPattern.FindStringSubmatch(l); m != nil && !thematicBreakPattern.MatchString(l) {
+			for len(stack) > 0 && stack[len(stack)-1].col >= col {
+				stack = stack[:len(stack)-1]
+			}
+			indent := 0
+			if len(stack) > 0 {
+				indent = stack[len(stack)-1].normContent
+			}
+			marker := m[1]
+			text := strings.TrimLeft(rest[len(marker):], " \t")
+			lines[i] = strings.Repeat(" ", indent) + marker + " " + text
+			content := columns(l[:len(l)-len(text)], tabWidth)
+			stack = append(stack, item{col, content, indent + len(marker) + 1})
+			codeFrom, codeTo = content, indent+len(marker)+1
+			fence.Scan(text)
+			blank = false
+			continue
+		}
+
+		if len(stack) > 0 && col == 0 && blank {
+			stack = nil // the list has ended
+		}
+		for len(stack) > 1 && stack[len(stack)-1].col >= col {
+			stack = stack[:len(stack)-1]
+		}
+		codeFrom = -1
+		if len(stack) > 0 && col > 0 {
+			it := stack[len(stack)-1]
+			to := it.normContent + max(0, col-it.content)
+			lines[i] = strings.Repeat(" ", to) + rest
+			codeFrom, codeTo = col, to
+		}
+		fence.Scan(l)
+		blank = false
+	}
+	return strings.Join(lines, "\n")
+}
+
+// columns returns how many columns leading whitespace takes up.
+func columns(ws string, tabWidth int) int {
+	col := 0
+	for _, r := range ws {
+		if r == '\t' {
+			col += tabWidth - col%tabWidth
+		} else {
+			col++
+		}
+	}
+	return col
+}
+
+// reindent moves a line from one indentation to another, keeping whatever
+// it's indented by beyond that.
+func reindent(l string, from, to, tabWidth int) string {
+	rest := strings.TrimLeft(l, " \t")
+	if rest == "" {
+		return l
+	}
+	col := columns(l[:len(l)-len(rest)], tabWidth)
+	return strings.Repeat(" ", to+max(0, col-from)) + rest
+}
@@ -0,0 +1,63 @@
+package utils
+
+import "testing"
+
+func TestNormalizeListIndentation(t *testing.T) {
+	for _, tc := range []struct {
+		name, md, want string
+		tabWidth       int
+	}{
+		{
+			name: "no tabs",
+			md:   "- a\n  - b\n",
+			want: "- a\n  - b\n",
+		},
+		{
+			name: "nested with tabs",
+			md:   "- a\n\t- b\n\t\t- c\n",
+			want: "- a\n  - b\n    - c\n",
+		},
+		{
+			name: "tabs after the marker",
+			md:   "-\ta\n\t-\tb\n\t\tmore\n",
+			want: "- a\n  - b\n    more\n",
+		},
+		{
+			name: "code block after a paragraph",
+			md:   "Example config:\n\n\t- name: foo\n\t  value: bar\n",
+			want: "Example config:\n\n\t- name: foo\n\t  value: bar\n",
+		},
+		{
+			name: "code block at the start",
+			md:   "\t- name: foo\n\t- name: bar\n",
+			want: "\t- name: foo\n\t- name: bar\n",
+		},
+		{
+			name: "paragraph going on",
+			md:   "Some text\n\t- not an item\n",
+			want: "Some text\n\t- not an item\n",
+		},
+		{
+			name: "code block after a list",
+			md:   "- a\n\t- b\n\nText\n\n\t- code\n",
+			want: "- a\n  - b\n\nText\n\n\t- code\n",
+		},
+		{
+			name: "fenced code in an item",
+			md:   "- a\n\t```\n\t- code\n\t```\n",
+			want: "- a\n    ```\n    - code\n    ```\n",
+		},
+		{
+			name:     "narrow tabs",
+			md:       "\t- name: foo\n",
+			want:     "- name: foo\n",
+			tabWidth: 2,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := NormalizeListIndentation(tc.md, tc.tabWidth); got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
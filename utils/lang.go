@@ -0,0 +1,123 @@
+package utils
+
+import (
+	"encoding/json"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
+)
+
+var (
+	shebangPattern  = regexp.MustCompile(`^#!\s*(?:\S*/)?(?:env\s+(?:-\S+\s+)*)?([A-Za-z]+)`)
+	filenamePattern = regexp.MustCompile("(?:^|[\\s`'\"(])((?:[\\w.-]+/)*[\\w-]*\\.[A-Za-z][\\w]*|Dockerfile|Makefile)(?:$|[\\s`'\":),])")
+
+	// Patterns that give a language away, tried in order.
+	languagePatterns = []struct {
+		lang    string
+		pattern *regexp.Regexp
+	}{
+		{"go", regexp.MustCompile(`(?m)^package \w+$|^func (\(\w+ \*?\w+\) )?\w+\(`)},
+		{"rust", regexp.MustCompile(`(?m)^\s*(pub )?fn \w+|let mut |^use \w+::`)},
+		{"python", regexp.MustCompile(`(?m)^\s*(def|class) \w+.*:\s*$|^(from \w+ )?import \w+$|^if __name__ ==`)},
+		{"java", regexp.MustCompile(`(?m)^\s*public (static )?(class|void) |System\.out\.print`)},
+		{"c", regexp.MustCompile(`(?m)^#include [<"]`)},
+		{"php", regexp.MustCompile(`^<\?php`)},
+		{"html", regexp.MustCompile(`(?i)^\s*<(!doctype|html|head|body|div|p|span|a|ul|table)\b`)},
+		{"sql", regexp.MustCompile(`(?mi)^\s*(select .+ from|insert into|create (table|index)|update \w+ set|delete from)\b`)},
+		{"javascript", regexp.MustCompile(`(?m)^\s*(const|let|var) \w+ = |^\s*(export )?(async )?function\b|=> \{|console\.log\(|require\(['"]`)},
+		{"dockerfile", regexp.MustCompile(`(?m)^FROM \S+.*\n(.*\n)*(RUN|COPY|CMD|ENTRYPOINT) `)},
+		{"bash", regexp.MustCompile(`(?m)^\s*(\$ |sudo |npm |npx |yarn |pnpm |go (get|install|run|build) |brew |apt(-get)? |pip3? |git |curl |wget |docker |make\b|cd |export \w+=|echo )`)},
+		{"yaml", regexp.MustCompile(`(?m)\A(\s*(#.*|- .+|[\w.-]+:( .*)?)\n?)+\z`)},
+	}
+)
+
+// GuessCodeLanguages labels fenced code blocks that don't name a language
+// with a guessed one, so they get syntax highlighting.
+func GuessCodeLanguages(md string) string {
+	var (
+		lines = strings.SplitAfter(md, "\n")
+		fence CodeFence
+	)
+	for i := 0; i < len(lines); i++ {
+		wasOpen := fence.Open()
+		fence.Scan(lines[i])
+		if wasOpen || !fence.Open() {
+			continue
+		}
+
+		// an opening fence: leave it be if it has an info string
+		trimmed := strings.TrimSpace(lines[i])
+		if strings.Trim(trimmed, trimmed[:1]) != "" {
+			continue
+		}
+
+		end := i + 1
+		for ; end < len(lines); end++ {
+			if fence.Scan(lines[end]); !fence.Open() {
+				break
+			}
+		}
+		code := strings.Join(lines[i+1:min(end, len(lines))], "")
+		if lang := guessLanguage(code, precedingLine(lines[:i])); lang != "" {
+			lines[i] = strings.Replace(lines[i], trimmed, trimmed+lang, 1)
+		}
+		i = end
+	}
+	return strings.Join(lines, "")
+}
+
+// precedingLine returns the last non-blank line, which often names the file
+// a code block belongs to ("Add this to `config.yml`:").
+func precedingLine(lines []string) string {
+	for i := len(lines) - 1; i >= 0; i-- {
+		if l := strings.TrimSpace(lines[i]); l != "" {
+			return l
+		}
+	}
+	return ""
+}
+
+// guessLanguage guesses the language of a piece of code, using a shebang, a
+// file name mentioned in the text before it, tell-tale patterns and finally
+// chroma's own analysers. It returns an empty string if there's no telling.
+func guessLanguage(code, before string) string {
+	if m := shebangPattern.FindStringSubmatch(code); m != nil {
+		if l := lexers.Get(strings.TrimRight(m[1], "0123456789")); l != nil {
+			return lexerName(l)
+		}
+	}
+
+	if strings.HasSuffix(before, ":") {
+		for _, m := range filenamePattern.FindAllStringSubmatch(before, -1) {
+			if l := lexers.Match(path.Base(m[1])); l != nil {
+				return lexerName(l)
+			}
+		}
+	}
+
+	if s := strings.TrimSpace(code); (strings.HasPrefix(s, "{") || strings.HasPrefix(s, "[")) && json.Valid([]byte(s)) {
+		return "json"
+	}
+	for _, p := range languagePatterns {
+		if p.pattern.MatchString(code) {
+			return p.lang
+		}
+	}
+
+	if l := lexers.Analyse(code); l != nil {
+		return lexerName(l)
+	}
+	return ""
+}
+
+// lexerName returns a name that can be used as a code block's info string
+// to get the given lexer.
+func lexerName(l chroma.Lexer) string {
+	if c := l.Config(); len(c.Aliases) > 0 {
+		return c.Aliases[0]
+	}
+	return strings.ToLower(l.Config().Name)
+}
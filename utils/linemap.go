@@ -0,0 +1,143 @@
+package utils
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/yuin/goldmark"
+	gast "github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/text"
+)
+
+var linkReferencePattern = regexp.MustCompile(`(?m)^ {0,3}\[[^\]]+\]:[ \t]*\S.*$`)
+
+// RenderLineMap renders a markdown document and maps each line of the output
+// to the 1-based source line of the block it was rendered from: a top-level
+// block, or an item of a top-level list. Blank lines between blocks belong to
+// the block that follows them.
+//
+// glamour doesn't keep track of source positions, so the map is built by
+// rendering the document up to the start of each block and counting the
+// lines that come out. That makes it a lot slower than a plain render.
+func RenderLineMap(r *glamour.TermRenderer, md string) (string, []int, error) {
+	out, err := r.Render(md)
+	if err != nil {
+		return "", nil, err
+	}
+	lines := make([]int, strings.Count(out, "\n"))
+
+	starts := blockLineStarts([]byte(md))
+	if len(starts) == 0 {
+		return out, lines, nil
+	}
+
+	// keep link references around, or partial documents would render
+	// reference links differently
+	refs := "\n\n" + strings.Join(linkReferencePattern.FindAllString(md, -1), "\n")
+
+	var prev int
+	for i, start := range starts {
+		end := len(lines)
+		if i+1 < len(starts) {
+			s, err := r.Render(md[:starts[i+1]] + refs)
+			if err != nil {
+				return "", nil, err
+			}
+			end = min(renderedLines(s), len(lines))
+		}
+
+		line := strings.Count(md[:start], "\n") + 1
+		for ; prev < end; prev++ {
+			lines[prev] = line
+		}
+	}
+	return out, lines, nil
+}
+
+// renderedLines returns the number of lines of rendered output, ignoring
+// trailing blank lines.
+func renderedLines(s string) int {
+	lines := strings.Split(s, "\n")
+	for len(lines) > 0 && strings.TrimSpace(ansi.Strip(lines[len(lines)-1])) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return len(lines)
+}
+
+// CountBlocks returns the number of top-level blocks of a document, counting
+// the items of top-level lists as blocks of their own.
+func CountBlocks(b []byte) int {
+	return len(Blocks(b))
+}
+
+// Block is a top-level block of a document, or an item of a top-level list.
+type Block struct {
+	Start int    // offset of the line the block starts on
+	Kind  string // goldmark's name for it, such as Heading or ListItem
+}
+
+// Blocks returns the top-level blocks of a document, and the items of
+// top-level lists, in order.
+func Blocks(b []byte) []Block {
+	doc := goldmark.New(goldmark.WithExtensions(extension.GFM)).Parser().Parse(text.NewReader(b))
+
+	var blocks []Block
+	add := func(n gast.Node) {
+		start := firstLine(n)
+		fc, isFenced := n.(*gast.FencedCodeBlock)
+		if isFenced && fc.Info != nil {
+			start = fc.Info.Segment.Start
+		}
+		if start < 0 {
+			return
+		}
+		// blocks start with their markers, not their text
+		start = bytes.LastIndexByte(b[:start], '\n') + 1
+		if isFenced && fc.Info == nil && start > 0 {
+			// the opening fence is on the line before the code
+			start = bytes.LastIndexByte(b[:start-1], '\n') + 1
+		}
+		if len(blocks) == 0 || start > blocks[len(blocks)-1].Start {
+			blocks = append(blocks, Block{Start: start, Kind: n.Kind().String()})
+		}
+	}
+	for n := doc.FirstChild(); n != nil; n = n.NextSibling() {
+		if n.Kind() != gast.KindList {
+			add(n)
+			continue
+		}
+		for item := n.FirstChild(); item != nil; item = item.NextSibling() {
+			add(item)
+		}
+	}
+	return blocks
+}
+
+// blockLineStarts returns the offsets of the lines the top-level blocks of a
+// document, and the items of top-level lists, start on.
+func blockLineStarts(b []byte) []int {
+	blocks := Blocks(b)
+	starts := make([]int, len(blocks))
+	for i, block := range blocks {
+		starts[i] = block.Start
+	}
+	return starts
+}
+
+// firstLine returns the offset of the first source line of a block, or -1 if
+// it has none.
+func firstLine(n gast.Node) int {
+	if n.Type() == gast.TypeBlock && n.Lines().Len() > 0 {
+		return n.Lines().At(0).Start
+	}
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		if s := firstLine(c); s >= 0 {
+			return s
+		}
+	}
+	return -1
+}
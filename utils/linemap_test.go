@@ -0,0 +1,23 @@
+package utils
+
+import "testing"
+
+func TestBlocks(t *testing.T) {
+	md := "# Title\n\ntext\n\n```go\ncode\n```\n\n- a\n- b\n"
+	want := []Block{
+		{0, "Heading"},
+		{9, "Paragraph"},
+		{15, "FencedCodeBlock"},
+		{31, "ListItem"},
+		{35, "ListItem"},
+	}
+	got := Blocks([]byte(md))
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("block %d: got %+v (%q), want %+v", i, got[i], md[got[i].Start:], want[i])
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// UpdateFile changes a file that other glow instances may be changing at the
+// same time. It holds a lock next to the file while update turns its current
+// content, nil if there's no file yet, into the new one, which is then
+// written atomically. Changes that were made by other instances since the
+// file was last read are thus seen by update rather than overwritten.
+func UpdateFile(path string, perm os.FileMode, update func(old []byte) ([]byte, error)) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil { //nolint:mnd
+		return err
+	}
+	unlock, err := lockFile(path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	old, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	data, err := update(old)
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomic(path, data, perm)
+}
@@ -0,0 +1,91 @@
+package utils
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/glow/v2/mermaid"
+	"github.com/mattn/go-runewidth"
+)
+
+// RenderMermaid replaces fenced mermaid diagrams with drawings of them in the
+// given charset. Diagrams that can't be drawn, or would be wider than width,
+// are left as they are; a width of 0 means any width fits.
+func RenderMermaid(md, charset string, width int) string {
+	var (
+		lines = strings.SplitAfter(md, "\n")
+		fence CodeFence
+		b     strings.Builder
+	)
+	for i := 0; i < len(lines); i++ {
+		wasOpen := fence.Open()
+		fence.Scan(lines[i])
+		if wasOpen || !fence.Open() || !isMermaidFence(lines[i]) {
+			b.WriteString(lines[i])
+			continue
+		}
+
+		end := i + 1
+		for ; end < len(lines); end++ {
+			if fence.Scan(lines[end]); !fence.Open() {
+				break
+			}
+		}
+		if end == len(lines) {
+			// an unclosed block: there's more to come, or it's a mistake
+			b.WriteString(strings.Join(lines[i:], ""))
+			break
+		}
+
+		indent := lines[i][:len(lines[i])-len(strings.TrimLeft(lines[i], " "))]
+		var src strings.Builder
+		for _, l := range lines[i+1 : end] {
+			src.WriteString(strings.TrimPrefix(l, indent))
+		}
+		drawing, err := mermaid.Render(src.String(), charset)
+		if err != nil || (width > 0 && drawingWidth(drawing) > width-4) {
+			b.WriteString(strings.Join(lines[i:end+1], ""))
+			i = end
+			continue
+		}
+
+		marker := strings.Repeat("`", max(3, longestRun(drawing, '`')+1)) //nolint:mnd
+		b.WriteString(indent + marker + "text\n")
+		for _, l := range strings.SplitAfter(strings.TrimSuffix(drawing, "\n"), "\n") {
+			b.WriteString(indent + l)
+		}
+		b.WriteString("\n" + indent + marker)
+		if strings.HasSuffix(lines[end], "\n") {
+			b.WriteString("\n")
+		}
+		i = end
+	}
+	return b.String()
+}
+
+// isMermaidFence reports whether an opening fence is tagged mermaid.
+func isMermaidFence(line string) bool {
+	info := strings.TrimLeft(strings.TrimSpace(line), "`~")
+	lang, _, _ := strings.Cut(strings.TrimSpace(info), " ")
+	return strings.EqualFold(strings.Trim(lang, "{}."), "mermaid")
+}
+
+func drawingWidth(s string) int {
+	w := 0
+	for _, l := range strings.Split(s, "\n") {
+		w = max(w, runewidth.StringWidth(l))
+	}
+	return w
+}
+
+func longestRun(s string, r rune) int {
+	longest, n := 0, 0
+	for _, c := range s {
+		if c != r {
+			n = 0
+			continue
+		}
+		n++
+		longest = max(longest, n)
+	}
+	return longest
+}
@@ -0,0 +1,135 @@
+package utils
+
+import (
+	"github.com/charmbracelet/glamour/ansi"
+	"github.com/charmbracelet/glamour/styles"
+)
+
+// MonoStyle is the name of a style for monochrome and e-ink displays. It
+// sets elements apart with weight, underlines and reverse video instead of
+// colors.
+const MonoStyle = "mono"
+
+// MonoStyleConfig is the mono style.
+var MonoStyleConfig = ansi.StyleConfig{
+	Document: ansi.StyleBlock{
+		StylePrimitive: ansi.StylePrimitive{
+			BlockPrefix: "\n",
+			BlockSuffix: "\n",
+		},
+		Margin: uintPtr(2), //nolint:mnd
+	},
+	BlockQuote: ansi.StyleBlock{
+		StylePrimitive: ansi.StylePrimitive{
+			Italic: boolPtr(true),
+		},
+		Indent:      uintPtr(1),
+		IndentToken: stringPtr("│ "),
+	},
+	List: ansi.StyleList{
+		LevelIndent: 2, //nolint:mnd
+	},
+	Heading: ansi.StyleBlock{
+		StylePrimitive: ansi.StylePrimitive{
+			BlockSuffix: "\n",
+			Bold:        boolPtr(true),
+		},
+	},
+	H1: ansi.StyleBlock{
+		StylePrimitive: ansi.StylePrimitive{
+			Prefix:  " ",
+			Suffix:  " ",
+			Inverse: boolPtr(true),
+		},
+	},
+	H2: ansi.StyleBlock{
+		StylePrimitive: ansi.StylePrimitive{
+			Prefix:    "## ",
+			Underline: boolPtr(true),
+		},
+	},
+	H3: ansi.StyleBlock{
+		StylePrimitive: ansi.StylePrimitive{
+			Prefix: "### ",
+		},
+	},
+	H4: ansi.StyleBlock{
+		StylePrimitive: ansi.StylePrimitive{
+			Prefix: "#### ",
+		},
+	},
+	H5: ansi.StyleBlock{
+		StylePrimitive: ansi.StylePrimitive{
+			Prefix: "##### ",
+		},
+	},
+	H6: ansi.StyleBlock{
+		StylePrimitive: ansi.StylePrimitive{
+			Prefix: "###### ",
+			Bold:   boolPtr(false),
+			Italic: boolPtr(true),
+		},
+	},
+	Strikethrough: ansi.StylePrimitive{
+		CrossedOut: boolPtr(true),
+	},
+	Emph: ansi.StylePrimitive{
+		Italic: boolPtr(true),
+	},
+	Strong: ansi.StylePrimitive{
+		Bold: boolPtr(true),
+	},
+	HorizontalRule: ansi.StylePrimitive{
+		Format: "\n--------\n",
+	},
+	Item: ansi.StylePrimitive{
+		BlockPrefix: "• ",
+	},
+	Enumeration: ansi.StylePrimitive{
+		BlockPrefix: ". ",
+	},
+	Task: ansi.StyleTask{
+		Ticked:   "[✓] ",
+		Unticked: "[ ] ",
+	},
+	Link: ansi.StylePrimitive{
+		Underline: boolPtr(true),
+	},
+	LinkText: ansi.StylePrimitive{
+		Bold: boolPtr(true),
+	},
+	Image: ansi.StylePrimitive{
+		Underline: boolPtr(true),
+	},
+	ImageText: ansi.StylePrimitive{
+		Format: "Image: {{.text}} →",
+	},
+	Code: ansi.StyleBlock{
+		StylePrimitive: ansi.StylePrimitive{
+			Prefix:  " ",
+			Suffix:  " ",
+			Inverse: boolPtr(true),
+		},
+	},
+	CodeBlock: ansi.StyleCodeBlock{
+		StyleBlock: ansi.StyleBlock{
+			Margin: uintPtr(2), //nolint:mnd
+		},
+	},
+	Table: ansi.StyleTable{},
+	DefinitionTerm: ansi.StylePrimitive{
+		Bold: boolPtr(true),
+	},
+	DefinitionDescription: ansi.StylePrimitive{
+		BlockPrefix: "\n→ ",
+	},
+}
+
+func init() {
+	// make mono available wherever a style can be named
+	styles.DefaultStyles[MonoStyle] = &MonoStyleConfig
+}
+
+func boolPtr(b bool) *bool       { return &b }
+func stringPtr(s string) *string { return &s }
+func uintPtr(u uint) *uint       { return &u }
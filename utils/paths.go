@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// hasGlobMeta reports whether a path contains any of the characters that
+// make it a glob pattern.
+func hasGlobMeta(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
+// ExpandGlobs replaces the arguments that are glob patterns with the files
+// they match, as a shell would have. Shells on Windows don't, and others
+// leave quoted patterns alone. Arguments that name a file, are URLs or
+// match nothing are kept as they are.
+func ExpandGlobs(args []string) []string {
+	var expanded []string
+	for _, arg := range args {
+		if !hasGlobMeta(arg) || strings.Contains(arg, "://") {
+			expanded = append(expanded, arg)
+			continue
+		}
+		if _, err := os.Stat(arg); err == nil {
+			expanded = append(expanded, arg)
+			continue
+		}
+		matches, err := Glob(ExpandPath(arg))
+		if err != nil || len(matches) == 0 {
+			expanded = append(expanded, arg)
+			continue
+		}
+		expanded = append(expanded, matches...)
+	}
+	return expanded
+}
+
+// Glob returns the files matching a pattern, like filepath.Glob. Where file
+// names don't depend on case, as on Windows, neither does matching the last
+// element of the pattern.
+func Glob(pattern string) ([]string, error) {
+	if !caseSensitivePaths {
+		return globFold(pattern)
+	}
+	return filepath.Glob(pattern)
+}
+
+func globFold(pattern string) ([]string, error) {
+	dir, file := filepath.Split(pattern)
+	file = strings.ToLower(file)
+	if _, err := filepath.Match(file, ""); err != nil {
+		return nil, err
+	}
+
+	dirs := []string{dir}
+	if hasGlobMeta(dir) {
+		var err error
+		if dirs, err = filepath.Glob(filepath.Clean(dir)); err != nil {
+			return nil, err
+		}
+	}
+
+	var matches []string
+	for _, d := range dirs {
+		name := d
+		if name == "" {
+			name = "."
+		}
+		entries, err := os.ReadDir(name)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			if ok, _ := filepath.Match(file, strings.ToLower(e.Name())); ok {
+				matches = append(matches, filepath.Join(d, e.Name()))
+			}
+		}
+	}
+	return matches, nil
+}
@@ -0,0 +1,19 @@
+//go:build !windows
+
+package utils
+
+import "os"
+
+// Whether file names that differ only in case are different files.
+const caseSensitivePaths = true
+
+// expandEnv expands $VAR and ${VAR} in a path.
+func expandEnv(s string) string {
+	return os.ExpandEnv(s)
+}
+
+// NativeLineEndings returns text with the line endings the platform's
+// clipboard and editors expect.
+func NativeLineEndings(s string) string {
+	return s
+}
@@ -0,0 +1,32 @@
+//go:build windows
+
+package utils
+
+import (
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Whether file names that differ only in case are different files.
+const caseSensitivePaths = false
+
+var windowsEnvPattern = regexp.MustCompile(`%[A-Za-z_][A-Za-z0-9_()]*%`)
+
+// expandEnv expands %VAR%, as well as $VAR and ${VAR}, in a path. Unknown
+// %VAR% references are kept, as cmd.exe does.
+func expandEnv(s string) string {
+	s = windowsEnvPattern.ReplaceAllStringFunc(s, func(ref string) string {
+		if v, ok := os.LookupEnv(ref[1 : len(ref)-1]); ok {
+			return v
+		}
+		return ref
+	})
+	return os.ExpandEnv(s)
+}
+
+// NativeLineEndings returns text with the line endings the platform's
+// clipboard and editors expect.
+func NativeLineEndings(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "\r\n", "\n"), "\n", "\r\n")
+}
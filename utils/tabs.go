@@ -0,0 +1,190 @@
+package utils
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// ExpandTabs replaces tabs in fenced code blocks with spaces up to the next
+// multiple of width. Left alone, tabs take up however many columns the
+// terminal decides and throw off the padding of code blocks.
+func ExpandTabs(md string, width int) string {
+	if width <= 0 || !strings.Contains(md, "\t") {
+		return md
+	}
+
+	var fence CodeFence
+	lines := strings.SplitAfter(md, "\n")
+	for i, l := range lines {
+		wasOpen := fence.Open()
+		fence.Scan(l)
+		if !wasOpen || !fence.Open() || !strings.Contains(l, "\t") {
+			continue // not a line of code, fences included
+		}
+
+		var b strings.Builder
+		col := 0
+		for _, r := range l {
+			if r == '\t' {
+				n := width - col%width
+				b.WriteString(strings.Repeat(" ", n))
+				col += n
+				continue
+			}
+			b.WriteRune(r)
+			col += runewidth.RuneWidth(r)
+		}
+		lines[i] = b.String()
+	}
+	return strings.Join(lines, "")
+}
+
+// EditorConfigTabWidth returns the tab width .editorconfig files set for a
+// file, or 0 if none do. Like editors, it looks in the file's directory and
+// up from there, until a file with root = true.
+func EditorConfigTabWidth(path string) int {
+	if path == "" {
+		return 0
+	}
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return 0
+	}
+
+	var tabWidth, indentSize string
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		props, root := readEditorConfig(filepath.Join(dir, ".editorconfig"), dir, path)
+		// files closer to the document take precedence
+		if tabWidth == "" {
+			tabWidth = props["tab_width"]
+		}
+		if indentSize == "" {
+			indentSize = props["indent_size"]
+		}
+		if root || filepath.Dir(dir) == dir {
+			break
+		}
+	}
+
+	// tab_width defaults to indent_size
+	if tabWidth == "" {
+		tabWidth = indentSize
+	}
+	n, err := strconv.Atoi(tabWidth)
+	if err != nil || n < 1 {
+		return 0
+	}
+	return n
+}
+
+// readEditorConfig returns the properties an .editorconfig file in dir sets
+// for path, and whether it's a root file.
+func readEditorConfig(name, dir, path string) (map[string]string, bool) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, false
+	}
+	defer f.Close() //nolint:errcheck
+
+	props := make(map[string]string)
+	var root, matches bool
+	preamble := true
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		l := strings.TrimSpace(sc.Text())
+		switch {
+		case l == "" || l[0] == '#' || l[0] == ';':
+			continue
+		case l[0] == '[' && strings.HasSuffix(l, "]"):
+			preamble = false
+			matches = editorConfigGlob(l[1:len(l)-1], dir, path)
+			continue
+		}
+		key, value, ok := strings.Cut(l, "=")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.ToLower(strings.TrimSpace(value))
+		if preamble && key == "root" {
+			root = value == "true"
+		}
+		if matches {
+			props[key] = value // later sections take precedence
+		}
+	}
+	return props, root
+}
+
+// editorConfigGlob returns whether a section glob matches a path. Globs
+// without a slash match file names anywhere below dir.
+func editorConfigGlob(glob, dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+	if !strings.Contains(glob, "/") {
+		glob = "**/" + glob
+	}
+	glob = strings.TrimPrefix(glob, "/")
+
+	var re strings.Builder
+	re.WriteString("^")
+	braces := 0
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			if strings.HasPrefix(glob[i:], "**/") {
+				re.WriteString("(?:.*/)?")
+				i += 2
+			} else if strings.HasPrefix(glob[i:], "**") {
+				re.WriteString(".*")
+				i++
+			} else {
+				re.WriteString("[^/]*")
+			}
+		case '?':
+			re.WriteString("[^/]")
+		case '{':
+			braces++
+			re.WriteString("(?:")
+		case '}':
+			if braces > 0 {
+				braces--
+				re.WriteString(")")
+			} else {
+				re.WriteString(`\}`)
+			}
+		case ',':
+			if braces > 0 {
+				re.WriteString("|")
+			} else {
+				re.WriteString(",")
+			}
+		case '[':
+			if j := strings.IndexByte(glob[i:], ']'); j > 0 {
+				class := glob[i+1 : i+j]
+				if strings.HasPrefix(class, "!") {
+					class = "^" + class[1:]
+				}
+				re.WriteString("[" + class + "]")
+				i += j
+			} else {
+				re.WriteString(`\[`)
+			}
+		default:
+			re.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	re.WriteString("$")
+
+	ok, err := regexp.MatchString(re.String(), rel)
+	return err == nil && ok
+}
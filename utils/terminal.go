@@ -0,0 +1,147 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"golang.org/x/term"
+)
+
+// Terminal is what glow knows about the terminal it runs in. All of glow's
+// terminal detection goes through Term, so it can be replaced, by tests or
+// by the environment variables TerminalFromEnv reads.
+type Terminal interface {
+	// IsTerminal reports whether f is a terminal.
+	IsTerminal(f *os.File) bool
+	// Size returns the size of the terminal f is.
+	Size(f *os.File) (width, height int, err error)
+	// ColorProfile returns the colors the terminal supports, whether or not
+	// f is one.
+	ColorProfile(f *os.File) termenv.Profile
+	// HasDarkBackground reports whether the terminal has a dark background.
+	HasDarkBackground() bool
+}
+
+// Term is the terminal glow runs in.
+var Term Terminal = systemTerminal{}
+
+// TerminalVariables are the environment variables TerminalFromEnv looks at.
+var TerminalVariables = []string{"GLOW_FORCE_TTY", "GLOW_COLS", "GLOW_ROWS", "GLOW_BACKGROUND"}
+
+// TerminalFromEnv returns the terminal glow runs in, with what the
+// environment fixes about it taking the place of detection, so tests and CI
+// pipelines get the same output everywhere without faking a terminal:
+//
+//   - GLOW_FORCE_TTY set to "1" or "0" makes stdout and stderr terminals or
+//     not, whatever they are.
+//   - GLOW_COLS and GLOW_ROWS are the size of the terminal. When only one of
+//     them is set and the size can't be detected, the other is 80 columns or
+//     24 rows.
+//   - GLOW_BACKGROUND is "dark" or "light".
+//
+// GLOW_COLOR_PROFILE, which DecideColor looks at, completes them.
+func TerminalFromEnv(getenv func(string) string) (Terminal, error) {
+	t := fixedTerminal{Terminal: systemTerminal{}}
+	if v := getenv("GLOW_FORCE_TTY"); v != "" {
+		tty, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid GLOW_FORCE_TTY %q: must be 1 or 0", v)
+		}
+		t.tty = &tty
+	}
+	for _, s := range []struct {
+		name string
+		n    *int
+	}{{"GLOW_COLS", &t.cols}, {"GLOW_ROWS", &t.rows}} {
+		v := getenv(s.name)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid %s %q: must be a positive number", s.name, v)
+		}
+		*s.n = n
+	}
+	switch v := strings.ToLower(getenv("GLOW_BACKGROUND")); v {
+	case "":
+	case "dark", "light":
+		dark := v == "dark"
+		t.dark = &dark
+	default:
+		return nil, fmt.Errorf("invalid GLOW_BACKGROUND %q: must be dark or light", v)
+	}
+	if v := getenv("GLOW_COLOR_PROFILE"); v != "" {
+		if _, err := ParseColorProfile(v); err != nil {
+			return nil, fmt.Errorf("invalid GLOW_COLOR_PROFILE: %w", err)
+		}
+	}
+
+	if t == (fixedTerminal{Terminal: systemTerminal{}}) {
+		return t.Terminal, nil
+	}
+	return t, nil
+}
+
+// systemTerminal detects everything from the actual terminal.
+type systemTerminal struct{}
+
+func (systemTerminal) IsTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
+
+func (systemTerminal) Size(f *os.File) (int, int, error) {
+	return term.GetSize(int(f.Fd()))
+}
+
+func (systemTerminal) ColorProfile(f *os.File) termenv.Profile {
+	return termenv.NewOutput(f, termenv.WithUnsafe()).ColorProfile()
+}
+
+func (systemTerminal) HasDarkBackground() bool {
+	return lipgloss.HasDarkBackground()
+}
+
+// fixedTerminal is a terminal with some of what's known about it fixed, and
+// the rest detected.
+type fixedTerminal struct {
+	Terminal
+	tty        *bool
+	cols, rows int
+	dark       *bool
+}
+
+func (t fixedTerminal) IsTerminal(f *os.File) bool {
+	if t.tty != nil && (f == os.Stdout || f == os.Stderr) {
+		return *t.tty
+	}
+	return t.Terminal.IsTerminal(f)
+}
+
+func (t fixedTerminal) Size(f *os.File) (int, int, error) {
+	w, h, err := t.Terminal.Size(f)
+	if t.cols == 0 && t.rows == 0 {
+		return w, h, err
+	}
+	if err != nil {
+		w, h = 80, 24 //nolint:mnd
+	}
+	if t.cols > 0 {
+		w = t.cols
+	}
+	if t.rows > 0 {
+		h = t.rows
+	}
+	return w, h, nil
+}
+
+func (t fixedTerminal) HasDarkBackground() bool {
+	if t.dark != nil {
+		return *t.dark
+	}
+	return t.Terminal.HasDarkBackground()
+}
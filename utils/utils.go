@@ -1,16 +1,24 @@
 package utils
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/charmbracelet/glamour"
 	"github.com/charmbracelet/glamour/ansi"
 	"github.com/charmbracelet/glamour/styles"
-	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/glow/v2/markup"
 	"github.com/mitchellh/go-homedir"
+	"gopkg.in/yaml.v3"
 )
 
 // RemoveFrontmatter removes the front matter header of a markdown file.
@@ -21,6 +29,54 @@ func RemoveFrontmatter(content []byte) []byte {
 	return content
 }
 
+// Frontmatter keys holding when a document was last updated, and when it
+// was written, in order of preference.
+var (
+	updatedKeys = []string{"updated", "lastmod", "modified", "last_modified"}
+	dateKeys    = []string{"date", "published", "created"}
+)
+
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// FrontmatterDates returns when a document was last updated and when it was
+// written according to its front matter, or zero times where it doesn't
+// say. Dates without a time zone are taken to be in loc.
+func FrontmatterDates(content []byte, loc *time.Location) (updated, written time.Time) {
+	body := RemoveFrontmatter(content)
+	front := content[:len(content)-len(body)]
+	if len(front) == 0 {
+		return
+	}
+	var meta map[string]yaml.Node
+	if yaml.Unmarshal(front, &meta) != nil {
+		return
+	}
+
+	find := func(keys []string) time.Time {
+		for _, k := range keys {
+			n, ok := meta[k]
+			if !ok || n.Kind != yaml.ScalarNode {
+				continue
+			}
+			v := strings.TrimSpace(n.Value)
+			for _, layout := range dateLayouts {
+				if t, err := time.ParseInLocation(layout, v, loc); err == nil {
+					return t
+				}
+			}
+		}
+		return time.Time{}
+	}
+	return find(updatedKeys), find(dateKeys)
+}
+
 var yamlPattern = regexp.MustCompile(`(?m)^---\r?\n(\s*\r?\n)?`)
 
 func detectFrontmatter(c []byte) []int {
@@ -30,13 +86,37 @@ func detectFrontmatter(c []byte) []int {
 	return []int{-1, -1}
 }
 
-// Expands tilde and all environment variables from the given path.
+// Expands tilde and all environment variables from the given path, and uses
+// the platform's path separators.
 func ExpandPath(path string) string {
 	s, err := homedir.Expand(path)
-	if err == nil {
-		return os.ExpandEnv(s)
+	if err != nil {
+		s = path
 	}
-	return os.ExpandEnv(path)
+	return filepath.FromSlash(expandEnv(s))
+}
+
+// CenteredWidth is the word-wrap width documents are centered at when no
+// width is set.
+const CenteredWidth = 88
+
+// Center indents rendered output, word-wrapped at width, so that it sits in
+// the middle of a screen of the given width. Output too wide for that is
+// returned as is.
+func Center(out string, width, screenWidth int) string {
+	// glamour's margin comes on top of the word-wrap width
+	pad := (screenWidth - width - 2) / 2 //nolint:mnd
+	if width <= 0 || pad <= 0 {
+		return out
+	}
+	prefix := strings.Repeat(" ", pad)
+	lines := strings.Split(out, "\n")
+	for i, l := range lines {
+		if l != "" {
+			lines[i] = prefix + l
+		}
+	}
+	return strings.Join(lines, "\n")
 }
 
 // WrapCodeBlock wraps a string in a code block with the given language.
@@ -48,7 +128,8 @@ var markdownExtensions = []string{
 	".md", ".mdown", ".mkdn", ".mkd", ".markdown",
 }
 
-// IsMarkdownFile returns whether the filename has a markdown extension.
+// IsMarkdownFile returns whether the filename has a markdown extension, or
+// that of a markup language converted to markdown.
 func IsMarkdownFile(filename string) bool {
 	ext := filepath.Ext(filename)
 
@@ -63,50 +144,429 @@ func IsMarkdownFile(filename string) bool {
 		}
 	}
 
+	// Other markup languages are converted to markdown.
+	if markup.Supported(filename) {
+		return true
+	}
+
 	// Has an extension but not markdown
 	// so assume this is a code file.
 	return false
 }
 
-func GlamourStyle(style string, isCode bool) glamour.TermRendererOption {
-	if !isCode {
-		if style == styles.AutoStyle {
-			return glamour.WithAutoStyle()
-		} else {
-			return glamour.WithStylePath(style)
+// Decorations overrides the decorative glyphs of a glamour style. Nil fields
+// keep whatever the style defines; an empty string removes the decoration.
+type Decorations struct {
+	HeadingPrefix *string // replaces the "## " markers of all headings
+	HeadingSuffix *string
+	Rule          *string // horizontal rule
+	BlockQuote    *string // blockquote gutter
+}
+
+func (d Decorations) empty() bool {
+	return d == Decorations{}
+}
+
+func (d Decorations) apply(c *ansi.StyleConfig) {
+	for _, h := range []*ansi.StyleBlock{&c.H1, &c.H2, &c.H3, &c.H4, &c.H5, &c.H6} {
+		if d.HeadingPrefix != nil {
+			h.Prefix = *d.HeadingPrefix
 		}
+		if d.HeadingSuffix != nil {
+			h.Suffix = *d.HeadingSuffix
+		}
+	}
+	if d.Rule != nil {
+		c.HorizontalRule.Format = ""
+		if *d.Rule != "" {
+			c.HorizontalRule.Format = "\n" + *d.Rule + "\n"
+		}
+	}
+	if d.BlockQuote != nil {
+		token := *d.BlockQuote
+		c.BlockQuote.IndentToken = &token
+	}
+}
+
+// GlamourStyle returns the glamour option for the given style name or JSON
+// path, with any decorations layered on top.
+func GlamourStyle(style string, isCode bool, deco Decorations) glamour.TermRendererOption {
+	// auto is decided by Term rather than by glamour, which would look at
+	// the terminal itself
+	if !isCode && deco.empty() && style != styles.AutoStyle {
+		return glamour.WithStylePath(style)
+	}
+	return LoadGlamourStyle(style, isCode, deco)
+}
+
+// LoadGlamourStyle is GlamourStyle, except that the style is read right away
+// rather than by each renderer it's used for, so the option can be reused.
+func LoadGlamourStyle(style string, isCode bool, deco Decorations) glamour.TermRendererOption {
+	styleConfig, err := LoadStyleConfig(style)
+	if err != nil {
+		return func(*glamour.TermRenderer) error { return err }
 	}
 
 	// If we are rendering a pure code block, we need to modify the style to
 	// remove the indentation.
+	if isCode {
+		var margin uint
+		styleConfig.CodeBlock.Margin = &margin
+	}
+	deco.apply(&styleConfig)
 
-	var styleConfig ansi.StyleConfig
+	return glamour.WithStyles(styleConfig)
+}
 
+// LoadStyleConfig reads a glamour style by name or JSON path.
+func LoadStyleConfig(style string) (ansi.StyleConfig, error) {
+	var styleConfig ansi.StyleConfig
 	switch style {
 	case styles.AutoStyle:
-		if lipgloss.HasDarkBackground() {
-			styleConfig = styles.DarkStyleConfig
-		} else {
-			styleConfig = styles.LightStyleConfig
-		}
-	case styles.DarkStyle:
-		styleConfig = styles.DarkStyleConfig
-	case styles.LightStyle:
-		styleConfig = styles.LightStyleConfig
-	case styles.PinkStyle:
-		styleConfig = styles.PinkStyleConfig
-	case styles.NoTTYStyle:
-		styleConfig = styles.NoTTYStyleConfig
-	case styles.DraculaStyle:
-		styleConfig = styles.DraculaStyleConfig
-	case styles.TokyoNightStyle:
-		styleConfig = styles.DraculaStyleConfig
+		if Term.HasDarkBackground() {
+			return styles.DarkStyleConfig, nil
+		}
+		return styles.LightStyleConfig, nil
 	default:
-		return glamour.WithStylesFromJSONFile(style)
+		if s, ok := styles.DefaultStyles[style]; ok {
+			return *s, nil
+		}
+		b, err := os.ReadFile(style)
+		if err == nil {
+			err = json.Unmarshal(b, &styleConfig)
+		}
+		return styleConfig, err
 	}
+}
 
-	var margin uint
-	styleConfig.CodeBlock.Margin = &margin
+// BlockQuoteToken returns the gutter blockquotes are rendered with in the
+// given style and decorations.
+func BlockQuoteToken(style string, deco Decorations) string {
+	if deco.BlockQuote != nil {
+		return *deco.BlockQuote
+	}
+	if c, err := LoadStyleConfig(style); err == nil && c.BlockQuote.IndentToken != nil {
+		return *c.BlockQuote.IndentToken
+	}
+	// glamour's default
+	return " "
+}
 
-	return glamour.WithStyles(styleConfig)
+// ParseTarget splits a document reference such as "README.md:42" or
+// "README.md#installation" into its path, line and anchor parts. References
+// without a valid suffix are returned as-is.
+func ParseTarget(s string) (path string, line int, anchor string) {
+	if i := strings.LastIndex(s, "#"); i > 0 && i < len(s)-1 {
+		return s[:i], 0, s[i+1:]
+	}
+	if i := strings.LastIndex(s, ":"); i > 0 {
+		if n, err := strconv.Atoi(s[i+1:]); err == nil && n > 0 {
+			return s[:i], n, ""
+		}
+	}
+	return s, 0, ""
+}
+
+var headingPattern = regexp.MustCompile(`^ {0,3}(#{1,6})\s+(.*?)(\s+#+)?\s*$`)
+
+// HeadingSlug returns the GitHub-style anchor for a heading's text.
+func HeadingSlug(heading string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(heading)) {
+		switch {
+		case unicode.IsLetter(r), unicode.IsDigit(r), r == '-', r == '_':
+			b.WriteRune(r)
+		case r == ' ':
+			b.WriteRune('-')
+		}
+	}
+	return b.String()
+}
+
+// AnchorLine returns the 1-based line number of the heading matching the
+// given anchor, or 0 if there's no such heading.
+func AnchorLine(content []byte, anchor string) int {
+	anchor = strings.ToLower(strings.TrimPrefix(anchor, "#"))
+	for _, h := range Headings(content) {
+		if HeadingSlug(h.Text) == anchor {
+			return h.Line
+		}
+	}
+	return 0
+}
+
+// Heading is a heading of a markdown document.
+type Heading struct {
+	Text  string
+	Level int
+	Line  int // 1-based
+}
+
+// Headings returns the headings of a markdown document, leaving out lines
+// in code blocks that merely look like headings.
+func Headings(content []byte) []Heading {
+	var (
+		headings []Heading
+		fence    CodeFence
+	)
+	for i, l := range strings.Split(string(content), "\n") {
+		if fence.Scan(l) {
+			continue
+		}
+		if m := headingPattern.FindStringSubmatch(l); m != nil {
+			headings = append(headings, Heading{Text: m[2], Level: len(m[1]), Line: i + 1})
+		}
+	}
+	return headings
+}
+
+// CodeFence tracks fenced code blocks while scanning markdown line by line.
+type CodeFence struct {
+	marker string
+}
+
+// Scan updates the fence state for the given line and reports whether the
+// line belongs to a fenced code block, fences included.
+func (f *CodeFence) Scan(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	if f.marker != "" {
+		if strings.HasPrefix(trimmed, f.marker) && strings.Trim(trimmed, f.marker[:1]) == "" {
+			f.marker = ""
+		}
+		return true
+	}
+	if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+		f.marker = trimmed[:len(trimmed)-len(strings.TrimLeft(trimmed, trimmed[:1]))]
+		return true
+	}
+	return false
+}
+
+// Open reports whether the scanned lines ended inside a fenced code block.
+func (f CodeFence) Open() bool {
+	return f.marker != ""
+}
+
+// TruncateCodeBlocks shortens fenced code blocks longer than max lines and
+// notes how many lines were left out below each block. The hint, if any, is
+// appended to the note.
+func TruncateCodeBlocks(md string, max int, hint string) string {
+	if max <= 0 {
+		return md
+	}
+
+	var (
+		b       strings.Builder
+		fence   CodeFence
+		lines   int
+		omitted int
+	)
+	note := func() {
+		if omitted == 0 {
+			return
+		}
+		if !strings.HasSuffix(b.String(), "\n") {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "\n*… %d more lines%s*\n\n", omitted, hint)
+		omitted = 0
+	}
+
+	for _, l := range strings.SplitAfter(md, "\n") {
+		wasOpen := fence.Open()
+		fence.Scan(l)
+		switch {
+		case !wasOpen && fence.Open(): // opening fence
+			lines = 0
+			b.WriteString(l)
+		case wasOpen && !fence.Open(): // closing fence
+			b.WriteString(l)
+			note()
+		case wasOpen:
+			lines++
+			if lines > max {
+				omitted++
+				continue
+			}
+			b.WriteString(l)
+		default:
+			b.WriteString(l)
+		}
+	}
+	note()
+	return b.String()
+}
+
+// TruncateLongLines cuts lines longer than max characters and notes how many
+// characters were left out at the end of each. The hint, if any, is appended
+// to the note.
+func TruncateLongLines(md string, max int, hint string) string {
+	if max <= 0 {
+		return md
+	}
+
+	var fence CodeFence
+	lines := strings.SplitAfter(md, "\n")
+	for i, l := range lines {
+		inCode := fence.Scan(l)
+		if len(l) <= max {
+			continue // fewer bytes than max can't be more characters
+		}
+
+		line, nl := strings.CutSuffix(l, "\n")
+		n := utf8.RuneCountInString(line)
+		if n <= max {
+			continue
+		}
+		cut := 0
+		for j := 0; j < max; j++ {
+			_, size := utf8.DecodeRuneInString(line[cut:])
+			cut += size
+		}
+
+		note := fmt.Sprintf("… %d more characters%s", n-max, hint)
+		if !inCode {
+			note = "*" + note + "*"
+		}
+		lines[i] = line[:cut] + " " + note
+		if nl {
+			lines[i] += "\n"
+		}
+	}
+	return strings.Join(lines, "")
+}
+
+// BreakLongWords breaks up runs of more than max non-space characters outside
+// of code blocks, such as minified content, into chunks of the given size.
+// Word-wrapping can't break such runs and slows to a crawl on them.
+func BreakLongWords(md string, max, chunk int) string {
+	if max <= 0 || chunk <= 0 {
+		return md
+	}
+
+	var fence CodeFence
+	lines := strings.SplitAfter(md, "\n")
+	for i, l := range lines {
+		if fence.Scan(l) || len(l) <= max {
+			continue
+		}
+
+		var (
+			b   strings.Builder
+			run []rune
+		)
+		flush := func() {
+			if len(run) > max {
+				for len(run) > chunk {
+					b.WriteString(string(run[:chunk]))
+					b.WriteByte(' ')
+					run = run[chunk:]
+				}
+			}
+			b.WriteString(string(run))
+			run = run[:0]
+		}
+		for _, r := range l {
+			if unicode.IsSpace(r) {
+				flush()
+				b.WriteRune(r)
+				continue
+			}
+			run = append(run, r)
+		}
+		flush()
+		lines[i] = b.String()
+	}
+	return strings.Join(lines, "")
+}
+
+// SplitDocuments splits concatenated markdown documents at lines that equal
+// the given delimiter, other than those in fenced code blocks. An empty
+// delimiter returns the content as a single document.
+func SplitDocuments(content []byte, delimiter string) [][]byte {
+	if delimiter == "" {
+		return [][]byte{content}
+	}
+
+	var (
+		docs  [][]byte
+		start int
+		fence CodeFence
+	)
+	for i := 0; i < len(content); {
+		end := bytes.IndexByte(content[i:], '\n')
+		if end < 0 {
+			end = len(content)
+		} else {
+			end += i
+		}
+		line := string(bytes.TrimRight(content[i:end], "\r"))
+		if !fence.Open() && line == delimiter {
+			docs = append(docs, content[start:i])
+			start = min(end+1, len(content))
+		} else {
+			fence.Scan(line)
+		}
+		i = end + 1
+	}
+	return append(docs, content[start:])
+}
+
+// SkipLines returns content starting at the given 0-based line.
+func SkipLines(content []byte, n int) []byte {
+	for ; n > 0; n-- {
+		i := bytes.IndexByte(content, '\n')
+		if i < 0 {
+			return nil
+		}
+		content = content[i+1:]
+	}
+	return content
+}
+
+// FenceStart returns the 0-based line a document should start at to show
+// the given 0-based line: the opening fence of the code block it's in, so
+// the rest of the document isn't taken for code, or the line itself.
+func FenceStart(content []byte, n int) int {
+	var (
+		fence CodeFence
+		start int
+	)
+	for i, l := range strings.SplitAfter(string(content), "\n") {
+		if i >= n {
+			break
+		}
+		wasOpen := fence.Open()
+		fence.Scan(l)
+		if !wasOpen && fence.Open() {
+			start = i
+		}
+	}
+	if fence.Open() {
+		return start
+	}
+	return n
+}
+
+// WriteFileAtomic writes data to a temporary file next to path and renames it
+// into place, so readers never see a partially written file.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name()) //nolint:errcheck
+
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Chmod(perm); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
 }
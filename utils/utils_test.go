@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSplitDocuments(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		content   string
+		delimiter string
+		want      []string
+	}{
+		{"no delimiter", "# A\n---\n# B\n", "", []string{"# A\n---\n# B\n"}},
+		{"split", "# A\n%%\n# B\n", "%%", []string{"# A\n", "# B\n"}},
+		{"crlf", "# A\r\n%%\r\n# B\r\n", "%%", []string{"# A\r\n", "# B\r\n"}},
+		{"trailing", "# A\n%%\n", "%%", []string{"# A\n", ""}},
+		{"in code", "```\n%%\n```\n%%\n# B\n", "%%", []string{"```\n%%\n```\n", "# B\n"}},
+		{"in tilde code", "~~~~\n```\n%%\n~~~~\n%%\n", "%%", []string{"~~~~\n```\n%%\n~~~~\n", ""}},
+		{"fence-like delimiter", "# A\n~~~\n# B\n", "~~~", []string{"# A\n", "# B\n"}},
+	} {
+		var got []string
+		for _, doc := range SplitDocuments([]byte(tc.content), tc.delimiter) {
+			got = append(got, string(doc))
+		}
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestParseTarget(t *testing.T) {
+	for _, tc := range []struct {
+		in, path, anchor string
+		line             int
+	}{
+		{"README.md", "README.md", "", 0},
+		{"README.md:12", "README.md", "", 12},
+		{"README.md#install", "README.md", "install", 0},
+		{"docs/a:b.md:3", "docs/a:b.md", "", 3},
+		{"notes#1.md#usage", "notes#1.md", "usage", 0},
+		{`C:\docs\README.md`, `C:\docs\README.md`, "", 0},
+		{`C:\docs\README.md:7`, `C:\docs\README.md`, "", 7},
+		{`C:\docs\README.md#setup`, `C:\docs\README.md`, "setup", 0},
+		// not line numbers or anchors
+		{"README.md:0", "README.md:0", "", 0},
+		{"README.md:-3", "README.md:-3", "", 0},
+		{"README.md:", "README.md:", "", 0},
+		{"README.md#", "README.md#", "", 0},
+		{"#install", "#install", "", 0},
+		{":12", ":12", "", 0},
+	} {
+		path, line, anchor := ParseTarget(tc.in)
+		if path != tc.path || line != tc.line || anchor != tc.anchor {
+			t.Errorf("ParseTarget(%q) = %q, %d, %q, want %q, %d, %q", tc.in, path, line, anchor, tc.path, tc.line, tc.anchor)
+		}
+	}
+}
+
+func TestAnchorLine(t *testing.T) {
+	md := []byte("# Project\n\n## Getting Started\n\n```\n## Not a heading\n```\n\n### API_v2 ###\n")
+	for anchor, want := range map[string]int{
+		"project":          1,
+		"#getting-started": 3,
+		"Getting-Started":  3,
+		"api_v2":           9,
+		"not-a-heading":    0,
+		"missing":          0,
+	} {
+		if got := AnchorLine(md, anchor); got != want {
+			t.Errorf("AnchorLine(%q) = %d, want %d", anchor, got, want)
+		}
+	}
+}
+
+func TestSkipLines(t *testing.T) {
+	content := []byte("one\ntwo\nthree\n")
+	for _, tc := range []struct {
+		n    int
+		want string
+	}{
+		{0, "one\ntwo\nthree\n"},
+		{1, "two\nthree\n"},
+		{2, "three\n"},
+		{3, ""},
+		{4, ""}, // out of range
+		{100, ""},
+		{-1, "one\ntwo\nthree\n"},
+	} {
+		if got := SkipLines(content, tc.n); string(got) != tc.want {
+			t.Errorf("SkipLines(%d) = %q, want %q", tc.n, got, tc.want)
+		}
+	}
+	if got := SkipLines([]byte("one\ntwo"), 1); string(got) != "two" {
+		t.Errorf("SkipLines without a trailing newline = %q, want %q", got, "two")
+	}
+}
+
+func TestFenceStart(t *testing.T) {
+	md := []byte("# Title\n\n```go\nfunc main() {\n}\n```\n\ntext\n~~~\n```\n~~~\n")
+	for n, want := range map[int]int{
+		0:  0,
+		2:  2, // the opening fence
+		3:  2,
+		4:  2,
+		5:  2, // the closing fence
+		6:  6,
+		7:  7,
+		9:  8, // a backtick fence inside a tilde one doesn't count
+		10: 8,
+		11: 11,
+	} {
+		if got := FenceStart(md, n); got != want {
+			t.Errorf("FenceStart(%d) = %d, want %d", n, got, want)
+		}
+	}
+}
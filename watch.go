@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/glow/v2/utils"
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/cobra"
+)
+
+// How long to wait for more changes after a file changed, as editors tend to
+// save in several steps.
+const watchSettle = 100 * time.Millisecond
+
+// clearScreen moves the cursor home and clears the screen.
+const clearScreen = "\x1b[H\x1b[2J"
+
+// watchCLI renders the sources, and again whenever one of their files
+// changes, until interrupted. On a terminal the screen is cleared before each
+// rendering; errors are shown in place of the output rather than ending the
+// watch, since documents are often broken halfway through an edit.
+func watchCLI(cmd *cobra.Command, args []string, w io.Writer) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close() //nolint:errcheck
+
+	// editors often replace files rather than write to them, which would end
+	// a watch on the file itself, so their directories are watched instead
+	files := map[string]bool{}
+	for _, arg := range args {
+		p, err := filepath.Abs(arg)
+		if err != nil {
+			return err
+		}
+		if info, err := os.Stat(p); err != nil || !info.Mode().IsRegular() {
+			return fmt.Errorf("--watch only works with local files: %s", arg)
+		}
+		files[p] = true
+		if err := watcher.Add(filepath.Dir(p)); err != nil {
+			return err
+		}
+	}
+
+	clear := utils.Term.IsTerminal(os.Stdout)
+	pager = pagerOff
+	for {
+		var out strings.Builder
+		if clear {
+			out.WriteString(clearScreen)
+		}
+		if err := executeArgs(cmd, args, &out); err != nil {
+			fmt.Fprintf(&out, "\n  %s\n", errorStyle.Render(err.Error()))
+		}
+		if _, err := io.WriteString(w, out.String()); err != nil {
+			return err
+		}
+
+		if err := waitForChange(watcher, files); err != nil {
+			return err
+		}
+	}
+}
+
+// waitForChange waits until one of the files changes, and then until no more
+// changes come in for a moment.
+func waitForChange(w *fsnotify.Watcher, files map[string]bool) error {
+	var settle <-chan time.Time
+	for {
+		select {
+		case e, ok := <-w.Events:
+			if !ok {
+				return errors.New("stopped watching")
+			}
+			if files[filepath.Clean(e.Name)] && !e.Has(fsnotify.Chmod) {
+				settle = time.After(watchSettle)
+			}
+		case err, ok := <-w.Errors:
+			if !ok {
+				return errors.New("stopped watching")
+			}
+			return err
+		case <-settle:
+			return nil
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+func TestWaitForChange(t *testing.T) {
+	dir := t.TempDir()
+	doc := filepath.Join(dir, "doc.md")
+	if err := os.WriteFile(doc, []byte("# one"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close() //nolint:errcheck
+	if err := w.Add(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- waitForChange(w, map[string]bool{doc: true}) }()
+
+	// other files in the directory don't count
+	if err := os.WriteFile(filepath.Join(dir, "other.md"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-done:
+		t.Fatal("returned for a change to another file")
+	case <-time.After(3 * watchSettle):
+	}
+
+	// replacing the file, as editors do, does
+	tmp := filepath.Join(dir, "doc.md.tmp")
+	if err := os.WriteFile(tmp, []byte("# two"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, doc); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("didn't return after the file changed")
+	}
+}
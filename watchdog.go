@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"runtime/metrics"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/glow/v2/source"
+	"github.com/dustin/go-humanize"
+)
+
+// memoryExitCode is the exit code after the watchdog aborts rendering. It's
+// EX_OSERR from sysexits.h, set apart from crashes and regular errors so
+// wrappers can tell the input was too much for the ceiling.
+const memoryExitCode = 71
+
+// How often the watchdog samples memory use.
+const watchdogInterval = 50 * time.Millisecond
+
+// watchdog aborts CLI rendering when memory use crosses --max-memory, rather
+// than having the system kill glow. All of its methods are safe to call on a
+// nil watchdog.
+var watchdog *memoryWatchdog
+
+// memorySample is the memory use of the process at some point: the resident
+// set size and the bytes taken by live objects on the heap.
+type memorySample struct {
+	rss  uint64
+	heap uint64
+}
+
+type memoryWatchdog struct {
+	limit uint64 // 0 only records the peak
+
+	// Where the diagnostic goes, and how memory is sampled and the process
+	// exited, swapped out in tests.
+	w      io.Writer
+	sample func() memorySample
+	exit   func(int)
+
+	mu   sync.Mutex
+	peak memorySample
+	src  string // the source being rendered
+	doc  int    // 1-based, or 0 while the source is read
+
+	once sync.Once
+	done chan struct{}
+}
+
+func newMemoryWatchdog(limit uint64) *memoryWatchdog {
+	return &memoryWatchdog{
+		limit:  limit,
+		w:      os.Stderr,
+		sample: sampleMemory,
+		exit:   os.Exit,
+		done:   make(chan struct{}),
+	}
+}
+
+// parseMemoryLimit parses a ceiling such as "512MiB" or "2GB". Empty means no
+// ceiling.
+func parseMemoryLimit(s string) (uint64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	n, err := humanize.ParseBytes(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid memory ceiling %q: use a size such as 512MiB or 2GB", s)
+	}
+	return n, nil
+}
+
+// start samples memory in the background until stop is called. It's only
+// started once, and only by the CLI renderer: aborting would leave the TUI's
+// terminal in a mess.
+func (d *memoryWatchdog) start() {
+	if d == nil {
+		return
+	}
+	d.once.Do(func() {
+		if d.limit > 0 {
+			// make the garbage collector work harder before it comes to
+			// aborting
+			debug.SetMemoryLimit(int64(d.limit / 10 * 9)) //nolint:gosec,mnd
+		}
+		go func() {
+			t := time.NewTicker(watchdogInterval)
+			defer t.Stop()
+			for {
+				select {
+				case <-d.done:
+					return
+				case <-t.C:
+					d.check()
+				}
+			}
+		}()
+	})
+}
+
+// stop ends sampling.
+func (d *memoryWatchdog) stop() {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	select {
+	case <-d.done:
+	default:
+		close(d.done)
+	}
+}
+
+// rendering records what's being rendered, for the diagnostic, and starts
+// the watchdog if it isn't running yet. A document of 0 means the source is
+// being read.
+func (d *memoryWatchdog) rendering(src *source.Source, doc int) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	d.src, d.doc = sourceName(src), doc
+	d.mu.Unlock()
+	d.start()
+}
+
+// check takes a sample, and aborts if it's over the ceiling.
+func (d *memoryWatchdog) check() {
+	s := d.sample()
+	d.mu.Lock()
+	d.peak.rss = max(d.peak.rss, s.rss)
+	d.peak.heap = max(d.peak.heap, s.heap)
+	over := d.limit > 0 && s.rss > d.limit
+	d.mu.Unlock()
+	if over {
+		d.abort(s)
+	}
+}
+
+// peakUsage returns the highest memory use sampled so far.
+func (d *memoryWatchdog) peakUsage() memorySample {
+	if d == nil {
+		return memorySample{}
+	}
+	d.check()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.peak
+}
+
+// abort explains what was being rendered when memory ran over, and exits.
+func (d *memoryWatchdog) abort(s memorySample) {
+	d.mu.Lock()
+	what := fmt.Sprintf("reading %s", d.src)
+	if d.doc > 0 {
+		what = fmt.Sprintf("rendering document %d of %s", d.doc, d.src)
+	}
+	d.mu.Unlock()
+
+	err := fmt.Errorf("memory use of %s crossed the ceiling of %s while %s (heap %s)",
+		humanize.IBytes(s.rss), humanize.IBytes(d.limit), what, humanize.IBytes(s.heap))
+	fmt.Fprintf(d.w, "Error: %v\n", err)
+	fmt.Fprintln(d.w, "The input may be too large or too deeply nested to render. Split it with --delimiter, or raise the ceiling with --max-memory.")
+	events.finish(err)
+	d.exit(memoryExitCode)
+}
+
+// sampleMemory measures the memory use of the process. The resident set
+// size is read from /proc where there is one; elsewhere, what the Go runtime
+// has mapped stands in for it.
+func sampleMemory() memorySample {
+	m := []metrics.Sample{
+		{Name: "/memory/classes/heap/objects:bytes"},
+		{Name: "/memory/classes/total:bytes"},
+	}
+	metrics.Read(m)
+	s := memorySample{heap: m[0].Value.Uint64(), rss: m[1].Value.Uint64()}
+	if runtime.GOOS == "linux" {
+		if rss, err := readRSS(); err == nil {
+			s.rss = rss
+		}
+	}
+	return s
+}
+
+// readRSS reads the resident set size of the process from /proc/self/statm,
+// where it's the second field, in pages.
+func readRSS() (uint64, error) {
+	b, err := os.ReadFile("/proc/self/statm")
+	if err != nil {
+		return 0, err
+	}
+	fields := bytes.Fields(b)
+	if len(fields) < 2 { //nolint:mnd
+		return 0, errors.New("unexpected format of /proc/self/statm")
+	}
+	pages, err := strconv.ParseUint(string(fields[1]), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return pages * uint64(os.Getpagesize()), nil //nolint:gosec
+}
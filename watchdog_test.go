@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/glow/v2/source"
+)
+
+func TestParseMemoryLimit(t *testing.T) {
+	for in, want := range map[string]uint64{
+		"":       0,
+		"512MiB": 512 << 20,
+		"2GB":    2_000_000_000,
+		"1 gib":  1 << 30,
+	} {
+		got, err := parseMemoryLimit(in)
+		if err != nil || got != want {
+			t.Errorf("parseMemoryLimit(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+	if _, err := parseMemoryLimit("lots"); err == nil {
+		t.Error("expected an error for an invalid ceiling")
+	}
+}
+
+func TestMemoryWatchdog(t *testing.T) {
+	var (
+		out  bytes.Buffer
+		code = -1
+		rss  uint64
+	)
+	d := newMemoryWatchdog(100 << 20)
+	d.w = &out
+	d.sample = func() memorySample { return memorySample{rss: rss, heap: rss / 2} }
+	d.exit = func(c int) { code = c }
+	d.mu.Lock()
+	d.src, d.doc = sourceName(&source.Source{URL: "big.md"}), 3
+	d.mu.Unlock()
+
+	rss = 80 << 20
+	d.check()
+	if code != -1 {
+		t.Fatalf("aborted under the ceiling: %s", out.String())
+	}
+
+	rss = 120 << 20
+	d.check()
+	if code != memoryExitCode {
+		t.Fatalf("exit code = %d, want %d", code, memoryExitCode)
+	}
+	for _, want := range []string{"120 MiB", "100 MiB", "document 3 of big.md", "--max-memory"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("diagnostic %q doesn't mention %q", out.String(), want)
+		}
+	}
+	if p := d.peakUsage(); p.rss != 120<<20 || p.heap != 60<<20 {
+		t.Errorf("peak = %+v, want 120 MiB resident and 60 MiB of heap", p)
+	}
+}
+
+func TestSoak(t *testing.T) {
+	style, width = "notty", 80
+	var cases []soakCase
+	for _, c := range soakCases {
+		if c.name == "documents" || c.name == "deep-list" {
+			cases = append(cases, c)
+		}
+	}
+	var out bytes.Buffer
+	if err := soak(&out, cases, 1); err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(out.String(), "\n"); n != 3 {
+		t.Errorf("expected a header and two cases, got:\n%s", out.String())
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// widthSetting is what --width is set to: a number of columns to word-wrap
+// at, or one of the named widths below.
+type widthSetting string
+
+const (
+	widthMax     widthSetting = "max"     // the full width of the terminal
+	widthContent widthSetting = "content" // the longest line, up to the full width
+)
+
+func (w *widthSetting) String() string { return string(*w) }
+
+func (w *widthSetting) Type() string { return "width" }
+
+func (w *widthSetting) Set(s string) error {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch widthSetting(s) {
+	case widthMax, widthContent:
+	case "":
+		s = "0"
+	default:
+		if _, err := strconv.ParseUint(s, 10, 0); err != nil {
+			return fmt.Errorf("invalid width %q: use a number of columns, max or content", s)
+		}
+	}
+	*w = widthSetting(s)
+	return nil
+}
+
+// columns returns the number of columns of a numeric width, or 0 for the
+// named ones.
+func (w widthSetting) columns() uint {
+	n, _ := strconv.ParseUint(string(w), 10, 0)
+	return uint(n)
+}
+
+// contentWidth returns the width to word-wrap a document at with --width
+// content: that of its longest line when it isn't wrapped at all, so tables
+// and code don't get wrapped or cut off, but no wider than limit.
+func contentWidth(r *glamour.TermRenderer, md string, limit int) (int, error) {
+	out, err := r.Render(md)
+	if err != nil {
+		return 0, err
+	}
+	var w int
+	for _, l := range strings.Split(out, "\n") {
+		w = max(w, ansi.StringWidth(strings.TrimRight(ansi.Strip(l), " ")))
+	}
+	// glamour's margin comes on top of the word-wrap width
+	return min(max(0, w-cliMargin), limit), nil
+}